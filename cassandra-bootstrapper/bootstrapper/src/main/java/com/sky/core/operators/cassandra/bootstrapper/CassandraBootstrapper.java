@@ -25,6 +25,7 @@ public class CassandraBootstrapper {
                 new ClusterProperties(),
                 new JavaAgents(),
                 new JvmMemoryDefaults(),
+                new NodeReplacement(),
                 new RackDC()
         };
 
@@ -0,0 +1,26 @@
+package com.sky.core.operators.cassandra.bootstrapper.configurations;
+
+import java.util.Arrays;
+import java.util.Collections;
+import java.util.Optional;
+
+import static java.lang.String.format;
+
+public class NodeReplacement extends ConfigurationAction {
+    @Override
+    public void apply(final Context context) {
+        // NODE_REPLACEMENTS lists the addresses of the dead nodes being replaced as comma-separated <pod name>=<address> entries
+        final Optional<String> replacements = context.getEnvironmentReader().read("NODE_REPLACEMENTS");
+        if (!replacements.isPresent()) {
+            return;
+        }
+
+        final String podEntryPrefix = context.getEnvironmentReader().readMandatory("POD_NAME") + "=";
+        Arrays.stream(replacements.get().split(","))
+                .map(String::trim)
+                .filter(entry -> entry.startsWith(podEntryPrefix))
+                .map(entry -> entry.substring(podEntryPrefix.length()))
+                .findFirst()
+                .ifPresent(address -> appendLines(context.getJvmOptions(), Collections.singletonList(format("-Dcassandra.replace_address_first_boot=%s", address))));
+    }
+}
@@ -174,6 +174,28 @@ public class CassandraBootstrapperTest {
         new CassandraBootstrapper(environmentReader).configure(cassandraConfigFolder.getRoot(), targetConfDir, targetLibDir);
     }
 
+    @Test
+    public void addsReplaceAddressToJvmOptionsWhenThePodReplacesADeadNode() throws IOException {
+        environmentReader.addEnvironmentVariable("POD_NAME", "mycluster-a-1");
+        environmentReader.addEnvironmentVariable("NODE_REPLACEMENTS", "mycluster-a-0=10.0.0.1,mycluster-a-1=10.0.0.2");
+
+        new CassandraBootstrapper(environmentReader).configure(cassandraConfigFolder.getRoot(), targetConfDir, targetLibDir);
+
+        List<String> lines = Files.readAllLines(jvmOptions.toPath());
+        assertThat(lines).contains("-Dcassandra.replace_address_first_boot=10.0.0.2");
+    }
+
+    @Test
+    public void doesNotAddReplaceAddressToJvmOptionsWhenAnotherPodReplacesADeadNode() throws IOException {
+        environmentReader.addEnvironmentVariable("POD_NAME", "mycluster-a-10");
+        environmentReader.addEnvironmentVariable("NODE_REPLACEMENTS", "mycluster-a-1=10.0.0.2");
+
+        new CassandraBootstrapper(environmentReader).configure(cassandraConfigFolder.getRoot(), targetConfDir, targetLibDir);
+
+        List<String> lines = Files.readAllLines(jvmOptions.toPath());
+        assertThat(lines).doesNotContain("-Dcassandra.replace_address_first_boot=10.0.0.2");
+    }
+
     @Test
     public void setsClusterNameInCassandraYaml() throws Exception {
         new CassandraBootstrapper(environmentReader).configure(cassandraConfigFolder.getRoot(), targetConfDir, targetLibDir);
@@ -10,9 +10,12 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/client/clientset/versioned"
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/operator"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/operator/webhook"
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
 )
 
 var (
@@ -20,6 +23,21 @@ var (
 	metricRequestTimeout time.Duration
 	logLevel             string
 	allowEmptyDir        bool
+	pauseAllClusters     bool
+	servePlans           bool
+	webhookBindAddress   string
+	webhookTLSCertFile   string
+	webhookTLSKeyFile    string
+	leaderElect          bool
+	leaderElectNamespace string
+	leaderElectLockName  string
+	leaderElectLease     time.Duration
+	leaderElectRenew     time.Duration
+	leaderElectRetry     time.Duration
+	kubeconfig           string
+	kubeContext          string
+	namespaces           []string
+	namespaceSelector    string
 )
 
 var rootCmd = &cobra.Command{
@@ -40,6 +58,21 @@ func init() {
 	rootCmd.PersistentFlags().DurationVar(&metricRequestTimeout, "metric-request-timeout", 2*time.Second, "Time limit for cassandra node metrics requests")
 	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", log.InfoLevel.String(), "should be one of: debug, info, warn, error, fatal, panic")
 	rootCmd.PersistentFlags().BoolVar(&allowEmptyDir, "allow-empty-dir", false, "Set to true in order to allow creation of clusters which use emptyDir storage")
+	rootCmd.PersistentFlags().BoolVar(&pauseAllClusters, "pause-all-clusters", false, "Set to true in order to hold changes to the resources of all clusters, as though each of them had spec.paused set")
+	rootCmd.PersistentFlags().BoolVar(&servePlans, "serve-plans", false, "Set to true in order to serve the plans of proposed cluster changes on /plan of port 9090. Requests to it are not authenticated, so any client able to reach the port can read the stored definition of each cluster")
+	rootCmd.PersistentFlags().StringVar(&webhookBindAddress, "webhook-bind-address", "", "Address on which to serve the HTTPS admission webhooks, e.g. :8443. Admission webhooks are disabled when empty")
+	rootCmd.PersistentFlags().StringVar(&webhookTLSCertFile, "webhook-tls-cert-file", "", "File containing the x509 certificate used to serve the admission webhooks")
+	rootCmd.PersistentFlags().StringVar(&webhookTLSKeyFile, "webhook-tls-key-file", "", "File containing the x509 private key matching --webhook-tls-cert-file")
+	rootCmd.PersistentFlags().StringVar(&kubeconfig, "kubeconfig", "", "Path to the kubeconfig file used to run the operator outside of a Kubernetes cluster. Defaults to the standard loading rules, then the in-cluster config")
+	rootCmd.PersistentFlags().StringVar(&kubeContext, "context", "", "Name of the kubeconfig context to use. Defaults to the current context")
+	rootCmd.PersistentFlags().StringSliceVar(&namespaces, "namespaces", []string{}, "Namespaces in which clusters are managed. Comma-separated list. Defaults to the OPERATOR_NAMESPACE environment variable, or all namespaces when it is not set")
+	rootCmd.PersistentFlags().StringVar(&namespaceSelector, "namespace-selector", "", "Label selector of the namespaces in which clusters are managed, e.g. cassandra=enabled. Namespaces are watched as they start or stop matching it")
+	rootCmd.PersistentFlags().BoolVar(&leaderElect, "leader-elect", false, "Set to true in order to run several replicas of the operator, of which only the elected leader manages clusters")
+	rootCmd.PersistentFlags().StringVar(&leaderElectNamespace, "leader-elect-namespace", "", "Namespace of the config map used as a leader election lock. Defaults to the OPERATOR_NAMESPACE environment variable")
+	rootCmd.PersistentFlags().StringVar(&leaderElectLockName, "leader-elect-lock-name", "cassandra-operator-leader", "Name of the config map used as a leader election lock")
+	rootCmd.PersistentFlags().DurationVar(&leaderElectLease, "leader-elect-lease-duration", 15*time.Second, "Time the other replicas wait before trying to become the leader once the leader stops renewing its leadership")
+	rootCmd.PersistentFlags().DurationVar(&leaderElectRenew, "leader-elect-renew-deadline", 10*time.Second, "Time during which the leader tries to renew its leadership before giving it up")
+	rootCmd.PersistentFlags().DurationVar(&leaderElectRetry, "leader-elect-retry-period", 2*time.Second, "Time between attempts to become or remain the leader")
 }
 
 func handleArgs(_ *cobra.Command, _ []string) error {
@@ -52,6 +85,20 @@ func handleArgs(_ *cobra.Command, _ []string) error {
 		return fmt.Errorf("invalid metric-poll-interval, it must be a positive integer")
 	}
 
+	if webhookBindAddress != "" && (webhookTLSCertFile == "" || webhookTLSKeyFile == "") {
+		return fmt.Errorf("webhook-tls-cert-file and webhook-tls-key-file must be supplied when webhook-bind-address is set")
+	}
+
+	if err := validateNamespaceArgs(); err != nil {
+		return err
+	}
+
+	if leaderElect {
+		if err := validateLeaderElectionArgs(isPositive); err != nil {
+			return err
+		}
+	}
+
 	level, err := log.ParseLevel(logLevel)
 	if err != nil {
 		return fmt.Errorf("invalid log-level")
@@ -61,11 +108,70 @@ func handleArgs(_ *cobra.Command, _ []string) error {
 	return nil
 }
 
+func validateNamespaceArgs() error {
+	if namespaceSelector != "" {
+		if len(namespaces) > 0 {
+			return fmt.Errorf("namespaces and namespace-selector cannot be supplied together")
+		}
+		if _, err := labels.Parse(namespaceSelector); err != nil {
+			return fmt.Errorf("invalid namespace-selector: %v", err)
+		}
+		return nil
+	}
+
+	if len(namespaces) == 0 {
+		if ns := os.Getenv("OPERATOR_NAMESPACE"); ns != "" {
+			namespaces = []string{ns}
+		}
+	}
+	return nil
+}
+
+func validateLeaderElectionArgs(isPositive func(time.Duration) bool) error {
+	if leaderElectNamespace == "" {
+		leaderElectNamespace = os.Getenv("OPERATOR_NAMESPACE")
+	}
+	if leaderElectNamespace == "" {
+		return fmt.Errorf("leader-elect-namespace must be supplied when the OPERATOR_NAMESPACE environment variable is not set")
+	}
+
+	if !isPositive(leaderElectRetry) {
+		return fmt.Errorf("invalid leader-elect-retry-period, it must be a positive integer")
+	}
+	if leaderElectRenew <= leaderElectRetry {
+		return fmt.Errorf("leader-elect-renew-deadline must be greater than leader-elect-retry-period")
+	}
+	if leaderElectLease <= leaderElectRenew {
+		return fmt.Errorf("leader-elect-lease-duration must be greater than leader-elect-renew-deadline")
+	}
+	return nil
+}
+
 func startOperator(_ *cobra.Command, _ []string) error {
 	operatorConfig := &operator.Config{
 		MetricRequestDuration: metricPollInterval,
 		MetricPollInterval:    metricPollInterval,
 		AllowEmptyDir:         allowEmptyDir,
+		PauseAllClusters:      pauseAllClusters,
+		ServePlans:            servePlans,
+		PodNamespace:          os.Getenv("OPERATOR_NAMESPACE"),
+		PodName:               os.Getenv("OPERATOR_POD_NAME"),
+		Namespaces:            namespaces,
+		NamespaceSelector:     namespaceSelector,
+		Webhook: webhook.Config{
+			BindAddress: webhookBindAddress,
+			CertFile:    webhookTLSCertFile,
+			KeyFile:     webhookTLSKeyFile,
+		},
+		LeaderElection: operator.LeaderElectionConfig{
+			Enabled:       leaderElect,
+			LockNamespace: leaderElectNamespace,
+			LockName:      leaderElectLockName,
+			Identity:      operatorIdentity(),
+			LeaseDuration: leaderElectLease,
+			RenewDeadline: leaderElectRenew,
+			RetryPeriod:   leaderElectRetry,
+		},
 	}
 	log.Infof("Starting Cassandra operator with config: %v", operatorConfig)
 
@@ -74,16 +180,36 @@ func startOperator(_ *cobra.Command, _ []string) error {
 	}
 
 	kubernetesConfig := kubernetesConfig()
-	op := operator.New(kubernetesClient(kubernetesConfig), cassandraClient(kubernetesConfig), operatorConfig)
+	op := operator.New(kubernetesClient(kubernetesConfig), cassandraClient(kubernetesConfig), kubernetesConfig, operatorConfig)
 	op.Run()
 
 	return nil
 }
 
+// operatorIdentity distinguishes the replicas of the operator during leader election
+func operatorIdentity() string {
+	if podName := os.Getenv("OPERATOR_POD_NAME"); podName != "" {
+		return podName
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		log.Fatalf("Unable to determine the identity of the operator: %v", err)
+	}
+	return hostname
+}
+
+// kubernetesConfig follows the standard kubeconfig loading rules, falling back to the in-cluster config
+// when no kubeconfig can be found, as is the case when the operator runs in a pod
 func kubernetesConfig() *rest.Config {
-	config, err := rest.InClusterConfig()
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = kubeconfig
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		loadingRules,
+		&clientcmd.ConfigOverrides{CurrentContext: kubeContext},
+	).ClientConfig()
 	if err != nil {
-		log.Fatalf("Unable to obtain in-cluster config: %v", err)
+		log.Fatalf("Unable to obtain Kubernetes config: %v", err)
 	}
 	return config
 }
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/apis/cassandra/v1alpha1"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/operator/plan"
+	"github.com/spf13/cobra"
+	"io/ioutil"
+)
+
+var (
+	proposedDefinitionFile string
+	currentDefinitionFile  string
+)
+
+var planCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Shows the changes the operator would make to a cluster in order to apply a proposed Cassandra resource, without applying anything",
+	Long: "Shows the rack changes, the stateful set patches, the forbidden changes and the estimated number of pod restarts " +
+		"which would result from applying the proposed Cassandra resource. The proposed resource is compared with the one " +
+		"stored in Kubernetes, or with the one given by --current. Exits with an error when the proposed resource would be rejected.",
+	SilenceUsage: true,
+	RunE:         planChange,
+}
+
+func init() {
+	planCmd.Flags().StringVarP(&proposedDefinitionFile, "filename", "f", "", "File containing the proposed Cassandra resource, as YAML or JSON")
+	planCmd.Flags().StringVar(&currentDefinitionFile, "current", "", "File containing the current Cassandra resource, in place of the one stored in Kubernetes. An empty file, such as /dev/null, stands for a cluster which doesn't exist yet")
+	rootCmd.AddCommand(planCmd)
+}
+
+func planChange(_ *cobra.Command, _ []string) error {
+	if proposedDefinitionFile == "" {
+		return fmt.Errorf("filename must be supplied")
+	}
+	proposed, err := readCassandra(proposedDefinitionFile)
+	if err != nil {
+		return err
+	}
+	if proposed == nil {
+		return fmt.Errorf("%s contains no Cassandra resource", proposedDefinitionFile)
+	}
+
+	var current *v1alpha1.Cassandra
+	if currentDefinitionFile != "" {
+		current, err = readCassandra(currentDefinitionFile)
+	} else {
+		current, err = plan.CurrentDefinition(cassandraClient(kubernetesConfig()), proposed.Namespace, proposed.Name)
+	}
+	if err != nil {
+		return err
+	}
+
+	planner, err := plan.New()
+	if err != nil {
+		return err
+	}
+	changePlan := planner.PlanChange(current, proposed)
+
+	content, err := json.MarshalIndent(changePlan, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to encode plan: %v", err)
+	}
+	fmt.Println(string(content))
+
+	if changePlan.InvalidDefinition != "" || len(changePlan.ForbiddenChanges) > 0 {
+		return fmt.Errorf("the proposed Cassandra resource would be rejected")
+	}
+	return nil
+}
+
+// readCassandra reads the Cassandra resource held in the file, which is nil when the file is empty
+func readCassandra(file string) (*v1alpha1.Cassandra, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %s: %v", file, err)
+	}
+	if len(bytes.TrimSpace(content)) == 0 {
+		return nil, nil
+	}
+	return plan.DecodeCassandra(content)
+}
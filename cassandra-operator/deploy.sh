@@ -30,16 +30,36 @@ function waitForDeployment {
     echo "Deployment is ready"
 }
 
+function createWebhookCertificate {
+    local context=$1
+    local namespace=$2
+    local tmpDir=$3
+    local secret=cassandra-operator-webhook-tls
+    local service=cassandra-operator-webhook.${namespace}.svc
+
+    if kubectl --context ${context} -n ${namespace} get secret ${secret} > /dev/null 2>&1; then
+        echo "Using the existing webhook certificate in ${namespace}.${secret}"
+        return
+    fi
+
+    echo "Creating a self-signed webhook certificate for ${service}"
+    openssl req -x509 -newkey rsa:2048 -nodes -days 365 -subj "/CN=${service}" -addext "subjectAltName=DNS:${service}" \
+        -keyout ${tmpDir}/tls.key -out ${tmpDir}/tls.crt
+    kubectl --context ${context} -n ${namespace} create secret tls ${secret} --cert=${tmpDir}/tls.crt --key=${tmpDir}/tls.key
+}
+
 function deployOperator() {
     local operatorImage=$1
     local context=$2
     local namespace=$3
     local ingressHost=$4
     local deployment=cassandra-operator
-    local operatorArgs='["--allow-empty-dir=true", "--log-level=debug"]'
+    local operatorArgs='"--allow-empty-dir=true", "--log-level=debug"'
     local tmpDir=$(mktemp -d)
     trap '{ CODE=$?; rm -rf ${tmpDir} ; exit ${CODE}; }' EXIT
 
+    createWebhookCertificate ${context} ${namespace} ${tmpDir}
+
     k8Resources="cassandra-operator-rbac.yml cassandra-node-rbac.yml cassandra-operator-deployment.yml cassandra-snapshot.yml cassandra-operator-crd.yml"
     for k8Resource in ${k8Resources}
     do
@@ -2,6 +2,7 @@ package v1alpha1
 
 import (
 	"fmt"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"reflect"
 
@@ -37,8 +38,40 @@ type CassandraSpec struct {
 	Pod         Pod  `json:"pod"`
 	// +optional
 	Snapshot *Snapshot `json:"snapshot,omitempty"`
+	// +optional
+	Repair *Repair `json:"repair,omitempty"`
+	// +optional
+	AutoCleanup *AutoCleanup `json:"autoCleanup,omitempty"`
+	// +optional
+	Storage *Storage `json:"storage,omitempty"`
+	// ConfigMapRef names the config map in the namespace of the cluster which holds custom Cassandra configuration.
+	// Defaults to the config map named after the cluster with a `-config` suffix.
+	// +optional
+	ConfigMapRef *corev1.LocalObjectReference `json:"configMapRef,omitempty"`
+	// Paused stops the operator from changing the resources of the cluster, and suspends its cron jobs.
+	// Changes made to the cluster definition in the meantime are applied once it is unpaused.
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+}
+
+// Storage describes how the persistent storage of the Cassandra nodes is managed
+type Storage struct {
+	// ReclaimPolicy determines what happens to the persistent volume claim of a node removed from the cluster,
+	// and to the persistent volume claims of all nodes when the cluster is deleted. Defaults to Retain.
+	// +optional
+	ReclaimPolicy StorageReclaimPolicy `json:"reclaimPolicy,omitempty"`
 }
 
+// StorageReclaimPolicy describes what happens to the persistent volume claims of nodes removed from the cluster
+type StorageReclaimPolicy string
+
+const (
+	// StorageReclaimRetain means the persistent volume claim is left behind, so its data can be recovered
+	StorageReclaimRetain StorageReclaimPolicy = "Retain"
+	// StorageReclaimDelete means the persistent volume claim is deleted
+	StorageReclaimDelete StorageReclaimPolicy = "Delete"
+)
+
 type Probe struct {
 	// +optional
 	FailureThreshold int32 `json:"failureThreshold"`
@@ -60,14 +93,150 @@ type Pod struct {
 	StorageSize resource.Quantity `json:"storageSize"`
 	Memory      resource.Quantity `json:"memory"`
 	CPU         resource.Quantity `json:"cpu"`
+	// CPULimit is the cpu limit of the cassandra container, which has no cpu limit when it is not given
+	// +optional
+	CPULimit *resource.Quantity `json:"cpuLimit,omitempty"`
+	// EphemeralStorage is the ephemeral storage requested by the cassandra container
+	// +optional
+	EphemeralStorage *resource.Quantity `json:"ephemeralStorage,omitempty"`
+	// EphemeralStorageLimit is the ephemeral storage limit of the cassandra container
+	// +optional
+	EphemeralStorageLimit *resource.Quantity `json:"ephemeralStorageLimit,omitempty"`
+	// BootstrapperResources are the resources of the cassandra-bootstrapper init container.
+	// Defaults to the cpu and memory of the cassandra container.
+	// +optional
+	BootstrapperResources *corev1.ResourceRequirements `json:"bootstrapperResources,omitempty"`
+	// InitConfigResources are the resources of the init-config init container.
+	// Defaults to the cpu and memory of the cassandra container.
+	// +optional
+	InitConfigResources *corev1.ResourceRequirements `json:"initConfigResources,omitempty"`
 	// +optional
 	LivenessProbe *Probe `json:"livenessProbe"`
 	// +optional
 	ReadinessProbe *Probe `json:"readinessProbe"`
+	// Labels are added to the pods, alongside the ones set by the operator which cannot be overridden
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+	// Annotations are added to the pods
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// Env is added to the environment of the cassandra container
+	// +optional
+	Env []corev1.EnvVar `json:"env,omitempty"`
+	// InitContainers are run after the init containers of the operator, once the configuration of the node is prepared
+	// +optional
+	InitContainers []corev1.Container `json:"initContainers,omitempty"`
+	// Sidecars are run alongside the cassandra container. They can share the Cassandra data
+	// by mounting the volume named cassandra-storage-<cluster name>.
+	// +optional
+	Sidecars []corev1.Container `json:"sidecars,omitempty"`
+	// ExtraVolumes are added to the volumes of the pods, to be mounted by the cassandra container or the sidecars
+	// +optional
+	ExtraVolumes []corev1.Volume `json:"extraVolumes,omitempty"`
+	// ExtraVolumeMounts are added to the volume mounts of the cassandra container
+	// +optional
+	ExtraVolumeMounts []corev1.VolumeMount `json:"extraVolumeMounts,omitempty"`
+	// Scheduling applies to the pods of all racks
+	Scheduling `json:",inline"`
 }
 
 // CassandraStatus is the status for the Cassandra resource
 type CassandraStatus struct {
+	// ObservedGeneration is the most recent generation of the Cassandra spec applied by the operator
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// CurrentOperation describes the operation the operator is executing for the cluster, if any
+	// +optional
+	CurrentOperation string `json:"currentOperation,omitempty"`
+	// Replicas is the total number of pods across all racks
+	// +optional
+	Replicas int32 `json:"replicas"`
+	// ReadyReplicas is the total number of ready pods across all racks
+	// +optional
+	ReadyReplicas int32 `json:"readyReplicas"`
+	// +optional
+	Racks []RackStatus `json:"racks,omitempty"`
+	// +optional
+	Nodes []NodeStatus `json:"nodes,omitempty"`
+	// +optional
+	Conditions []CassandraCondition `json:"conditions,omitempty"`
+	// NodeMaintenance are the nodetool commands which remain to be run on the nodes of the cluster, apart from the
+	// changes made to the cluster
+	// +optional
+	NodeMaintenance []NodeMaintenance `json:"nodeMaintenance,omitempty"`
+}
+
+// CassandraConditionType is the type of a condition reported on the Cassandra resource
+type CassandraConditionType string
+
+const (
+	// ClusterAvailable means all the pods of the cluster are ready
+	ClusterAvailable CassandraConditionType = "Available"
+	// ClusterProgressing means the operator is applying a change to the cluster
+	ClusterProgressing CassandraConditionType = "Progressing"
+	// ClusterDegraded means one or more nodes of the cluster are down
+	ClusterDegraded CassandraConditionType = "Degraded"
+	// SnapshotScheduled means snapshots of the cluster data are taken on the schedule given in the spec
+	SnapshotScheduled CassandraConditionType = "SnapshotScheduled"
+	// RepairScheduled means the cluster data is repaired on the schedule given in the spec
+	RepairScheduled CassandraConditionType = "RepairScheduled"
+	// ConfigInvalid means the latest spec could not be applied, because it is invalid or contains a forbidden change
+	ConfigInvalid CassandraConditionType = "ConfigInvalid"
+	// ClusterPaused means the changes to the resources of the cluster are held until it is unpaused
+	ClusterPaused CassandraConditionType = "Paused"
+	// NodeMaintenanceFailed means a nodetool command run on each node once the cluster has changed failed on some nodes
+	NodeMaintenanceFailed CassandraConditionType = "NodeMaintenanceFailed"
+)
+
+// CassandraCondition describes the state of an aspect of the cluster at a certain point
+type CassandraCondition struct {
+	Type   CassandraConditionType `json:"type"`
+	Status corev1.ConditionStatus `json:"status"`
+	// LastTransitionTime is the last time the condition changed status
+	// +optional
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// RackStatus is the status of the stateful set backing a rack
+type RackStatus struct {
+	Name          string `json:"name"`
+	Replicas      int32  `json:"replicas"`
+	ReadyReplicas int32  `json:"readyReplicas"`
+}
+
+// NodeStatus is the status of a Cassandra node as last reported by the cluster
+type NodeStatus struct {
+	// Name is the name of the pod running the node
+	Name string `json:"name"`
+	Rack string `json:"rack"`
+	// Liveness is either 'up' or 'down'
+	Liveness string `json:"liveness"`
+	// State is one of 'normal', 'joining', 'leaving' or 'moving'
+	State string `json:"state"`
+}
+
+// NodeMaintenanceCommand is a nodetool command run on nodes of the cluster one at a time once the cluster has changed
+type NodeMaintenanceCommand string
+
+const (
+	// UpgradeSSTablesCommand rewrites the SSTables of a node in the format of the Cassandra version it runs
+	UpgradeSSTablesCommand NodeMaintenanceCommand = "upgradesstables"
+	// CleanupCommand removes the data a node no longer owns once new nodes have joined the cluster
+	CleanupCommand NodeMaintenanceCommand = "cleanup"
+)
+
+// NodeMaintenance tracks the progress of a nodetool command run on the nodes of the cluster one at a time
+type NodeMaintenance struct {
+	Command NodeMaintenanceCommand `json:"command"`
+	// Pods are the pods on which the command remains to be run, in order
+	Pods []string `json:"pods"`
+	// FailedPods are the pods on which the command has failed
+	// +optional
+	FailedPods []string `json:"failedPods,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -85,8 +254,53 @@ type Rack struct {
 	Zone         string `json:"zone"`
 	StorageClass string `json:"storageClass"`
 	Replicas     int32  `json:"replicas"`
+	// Scheduling applies to the pods of the rack, in addition to or in place of the one of the cluster
+	Scheduling `json:",inline"`
+}
+
+// Scheduling controls the nodes on which Cassandra pods are scheduled
+type Scheduling struct {
+	// Tolerations of the pods. The tolerations of a rack are added to the ones of the cluster.
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+	// NodeSelector restricts the nodes on which the pods are scheduled. The node selector of a rack is merged with the one
+	// of the cluster, taking precedence for the labels they both define.
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// PriorityClassName of the pods. The priority class of a rack takes precedence over the one of the cluster.
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+	// AntiAffinity determines how the pods of a cluster are spread across nodes.
+	// The anti-affinity of a rack takes precedence over the one of the cluster.
+	// +optional
+	AntiAffinity *AntiAffinity `json:"antiAffinity,omitempty"`
+	// ZoneTopologyKey is the node label whose value is the zone of a rack.
+	// Defaults to failure-domain.beta.kubernetes.io/zone. The key of a rack takes precedence over the one of the cluster.
+	// +optional
+	ZoneTopologyKey string `json:"zoneTopologyKey,omitempty"`
+}
+
+// AntiAffinity determines how the pods of a cluster are spread across nodes
+type AntiAffinity struct {
+	// Mode is either Required, so that no two pods of the cluster are scheduled in the same topology domain,
+	// or Preferred, so that they are only scheduled in the same domain when no other one is available. Defaults to Required.
+	// +optional
+	Mode AntiAffinityMode `json:"mode,omitempty"`
+	// TopologyKey is the node label whose value identifies the topology domain. Defaults to kubernetes.io/hostname.
+	// +optional
+	TopologyKey string `json:"topologyKey,omitempty"`
 }
 
+// AntiAffinityMode describes whether the anti-affinity between the pods of a cluster is enforced
+type AntiAffinityMode string
+
+const (
+	// AntiAffinityRequired means pods are never scheduled in the same topology domain
+	AntiAffinityRequired AntiAffinityMode = "Required"
+	// AntiAffinityPreferred means pods are scheduled in the same topology domain only when no other one is available
+	AntiAffinityPreferred AntiAffinityMode = "Preferred"
+)
+
 // Snapshot defines the snapshot creation and deletion configuration
 type Snapshot struct {
 	// +optional
@@ -117,6 +331,106 @@ type RetentionPolicy struct {
 	CleanupTimeoutSeconds *int32 `json:"cleanupTimeoutSeconds,omitempty"`
 }
 
+// Repair defines the anti-entropy repair of the cluster data, run node by node on a schedule
+type Repair struct {
+	// +optional
+	Image string `json:"image"`
+	// Schedule follows the cron format, see https://en.wikipedia.org/wiki/Cron
+	Schedule string `json:"schedule"`
+	// Keyspaces to repair. Defaults to all keyspaces.
+	// +optional
+	Keyspaces []string `json:"keyspaces"`
+	// Parallelism is one of sequential, parallel or dc-parallel. Defaults to parallel.
+	// +optional
+	Parallelism RepairParallelism `json:"parallelism,omitempty"`
+	// Subranges is the number of subranges into which each primary token range of a node is split,
+	// each subrange being repaired on its own. The token ranges of each node are read with `nodetool describering`,
+	// which requires the keyspaces to be given. Only supported with the Murmur3Partitioner.
+	// Defaults to repairing the primary range of each node at once.
+	// +optional
+	Subranges *int32 `json:"subranges,omitempty"`
+	// TimeoutSeconds is the max time allowed to repair a single node, or a single subrange when subranges are used
+	// +optional
+	TimeoutSeconds *int32 `json:"timeoutSeconds,omitempty"`
+}
+
+// AutoCleanup describes the removal of the data which the existing nodes no longer own once new nodes have joined the cluster
+type AutoCleanup struct {
+	// Enabled runs `nodetool cleanup` on each of the existing nodes in turn once the racks have been scaled up
+	Enabled bool `json:"enabled"`
+	// Jobs is the number of tables cleaned up at the same time on a node, which throttles the cleanup.
+	// Defaults to the Cassandra default.
+	// +optional
+	Jobs *int32 `json:"jobs,omitempty"`
+}
+
+// RepairParallelism describes how the replicas of a range are repaired
+type RepairParallelism string
+
+const (
+	// RepairSequential repairs one replica at a time
+	RepairSequential RepairParallelism = "sequential"
+	// RepairParallel repairs all replicas at the same time
+	RepairParallel RepairParallelism = "parallel"
+	// RepairDCParallel repairs one replica at a time in each data centre
+	RepairDCParallel RepairParallelism = "dc-parallel"
+)
+
+// GetCondition returns the condition of the supplied type, or nil when it has never been reported
+func (s *CassandraStatus) GetCondition(conditionType CassandraConditionType) *CassandraCondition {
+	for i := range s.Conditions {
+		if s.Conditions[i].Type == conditionType {
+			return &s.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// SetCondition adds or replaces the condition of the supplied type.
+// The last transition time is only changed when the status of the condition changes.
+func (s *CassandraStatus) SetCondition(conditionType CassandraConditionType, status corev1.ConditionStatus, reason, message string) {
+	condition := s.GetCondition(conditionType)
+	if condition == nil {
+		s.Conditions = append(s.Conditions, CassandraCondition{Type: conditionType})
+		condition = &s.Conditions[len(s.Conditions)-1]
+	}
+
+	if condition.Status != status {
+		condition.Status = status
+		condition.LastTransitionTime = metav1.Now()
+	}
+	condition.Reason = reason
+	condition.Message = message
+}
+
+// AddNodeMaintenance records that the supplied command remains to be run on the supplied pods, after the pods on which
+// it is already pending
+func (s *CassandraStatus) AddNodeMaintenance(command NodeMaintenanceCommand, pods []string) {
+	if len(pods) == 0 {
+		return
+	}
+	for i := range s.NodeMaintenance {
+		if s.NodeMaintenance[i].Command == command {
+			for _, pod := range pods {
+				if !containsPod(s.NodeMaintenance[i].Pods, pod) {
+					s.NodeMaintenance[i].Pods = append(s.NodeMaintenance[i].Pods, pod)
+				}
+			}
+			return
+		}
+	}
+	s.NodeMaintenance = append(s.NodeMaintenance, NodeMaintenance{Command: command, Pods: pods})
+}
+
+func containsPod(pods []string, pod string) bool {
+	for _, p := range pods {
+		if p == pod {
+			return true
+		}
+	}
+	return false
+}
+
 // QualifiedName is the cluster fully qualified name which follows the format <namespace>.<name>
 func (c *Cassandra) QualifiedName() string {
 	return fmt.Sprintf("%s.%s", c.Namespace, c.Name)
@@ -132,6 +446,11 @@ func (c *Cassandra) SnapshotCleanupJobName() string {
 	return fmt.Sprintf("%s-snapshot-cleanup", c.Name)
 }
 
+// RepairJobName is the name of the repair job for the cluster
+func (c *Cassandra) RepairJobName() string {
+	return fmt.Sprintf("%s-repair", c.Name)
+}
+
 // ServiceName is the cluster service name
 func (c *Cassandra) ServiceName() string {
 	return fmt.Sprintf("%s.%s", c.Name, c.Namespace)
@@ -147,8 +466,35 @@ func (c *Cassandra) RackName(rack *Rack) string {
 	return fmt.Sprintf("%s-%s", c.Name, rack.Name)
 }
 
-// CustomConfigMapName returns the expected config map name for this cluster. This will return a value even if the config map does not exist.
+// PodName is the name of the pod with the supplied ordinal in the stateful set of the supplied rack
+func (c *Cassandra) PodName(rack *Rack, ordinal int32) string {
+	return fmt.Sprintf("%s-%d", c.RackName(rack), ordinal)
+}
+
+// StorageVolumeClaimName is the name of the persistent volume claim holding the data of the pod with the supplied ordinal in the supplied rack
+func (c *Cassandra) StorageVolumeClaimName(rack *Rack, ordinal int32) string {
+	return fmt.Sprintf("%s-%s", c.StorageVolumeName(), c.PodName(rack, ordinal))
+}
+
+// NodeReplacementsConfigMapName is the name of the config map holding the addresses of the dead nodes being replaced
+func (c *Cassandra) NodeReplacementsConfigMapName() string {
+	return fmt.Sprintf("%s-node-replacements", c.Name)
+}
+
+// StorageReclaimPolicy returns the reclaim policy of the persistent volume claims of the cluster, defaulting to Retain
+func (c *Cassandra) StorageReclaimPolicy() StorageReclaimPolicy {
+	if c.Spec.Storage == nil || c.Spec.Storage.ReclaimPolicy == "" {
+		return StorageReclaimRetain
+	}
+	return c.Spec.Storage.ReclaimPolicy
+}
+
+// CustomConfigMapName returns the expected config map name for this cluster, either the referenced config map
+// or the one following the naming convention. This will return a value even if the config map does not exist.
 func (c *Cassandra) CustomConfigMapName() string {
+	if c.Spec.ConfigMapRef != nil && c.Spec.ConfigMapRef.Name != "" {
+		return c.Spec.ConfigMapRef.Name
+	}
 	return fmt.Sprintf("%s-config", c.Name)
 }
 
@@ -166,3 +512,8 @@ func SnapshotCleanupPropertiesUpdated(snapshot1 *Snapshot, snapshot2 *Snapshot)
 			*snapshot1.RetentionPolicy.CleanupTimeoutSeconds != *snapshot2.RetentionPolicy.CleanupTimeoutSeconds ||
 			*snapshot1.RetentionPolicy.RetentionPeriodDays != *snapshot2.RetentionPolicy.RetentionPeriodDays)
 }
+
+// RepairPropertiesUpdated returns false when repair1 and repair2 have the same properties
+func RepairPropertiesUpdated(repair1 *Repair, repair2 *Repair) bool {
+	return !reflect.DeepEqual(repair1, repair2)
+}
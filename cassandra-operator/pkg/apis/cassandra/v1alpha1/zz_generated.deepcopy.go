@@ -5,16 +5,54 @@
 package v1alpha1
 
 import (
+	v1 "k8s.io/api/core/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AntiAffinity) DeepCopyInto(out *AntiAffinity) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AntiAffinity.
+func (in *AntiAffinity) DeepCopy() *AntiAffinity {
+	if in == nil {
+		return nil
+	}
+	out := new(AntiAffinity)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AutoCleanup) DeepCopyInto(out *AutoCleanup) {
+	*out = *in
+	if in.Jobs != nil {
+		in, out := &in.Jobs, &out.Jobs
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AutoCleanup.
+func (in *AutoCleanup) DeepCopy() *AutoCleanup {
+	if in == nil {
+		return nil
+	}
+	out := new(AutoCleanup)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Cassandra) DeepCopyInto(out *Cassandra) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -36,6 +74,23 @@ func (in *Cassandra) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CassandraCondition) DeepCopyInto(out *CassandraCondition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CassandraCondition.
+func (in *CassandraCondition) DeepCopy() *CassandraCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(CassandraCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CassandraList) DeepCopyInto(out *CassandraList) {
 	*out = *in
@@ -75,7 +130,9 @@ func (in *CassandraSpec) DeepCopyInto(out *CassandraSpec) {
 	if in.Racks != nil {
 		in, out := &in.Racks, &out.Racks
 		*out = make([]Rack, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	in.Pod.DeepCopyInto(&out.Pod)
 	if in.Snapshot != nil {
@@ -83,6 +140,26 @@ func (in *CassandraSpec) DeepCopyInto(out *CassandraSpec) {
 		*out = new(Snapshot)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Repair != nil {
+		in, out := &in.Repair, &out.Repair
+		*out = new(Repair)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AutoCleanup != nil {
+		in, out := &in.AutoCleanup, &out.AutoCleanup
+		*out = new(AutoCleanup)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		*out = new(Storage)
+		**out = **in
+	}
+	if in.ConfigMapRef != nil {
+		in, out := &in.ConfigMapRef, &out.ConfigMapRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
 	return
 }
 
@@ -99,6 +176,30 @@ func (in *CassandraSpec) DeepCopy() *CassandraSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CassandraStatus) DeepCopyInto(out *CassandraStatus) {
 	*out = *in
+	if in.Racks != nil {
+		in, out := &in.Racks, &out.Racks
+		*out = make([]RackStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Nodes != nil {
+		in, out := &in.Nodes, &out.Nodes
+		*out = make([]NodeStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]CassandraCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.NodeMaintenance != nil {
+		in, out := &in.NodeMaintenance, &out.NodeMaintenance
+		*out = make([]NodeMaintenance, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -112,12 +213,79 @@ func (in *CassandraStatus) DeepCopy() *CassandraStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeMaintenance) DeepCopyInto(out *NodeMaintenance) {
+	*out = *in
+	if in.Pods != nil {
+		in, out := &in.Pods, &out.Pods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.FailedPods != nil {
+		in, out := &in.FailedPods, &out.FailedPods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeMaintenance.
+func (in *NodeMaintenance) DeepCopy() *NodeMaintenance {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeMaintenance)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeStatus) DeepCopyInto(out *NodeStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeStatus.
+func (in *NodeStatus) DeepCopy() *NodeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Pod) DeepCopyInto(out *Pod) {
 	*out = *in
 	out.StorageSize = in.StorageSize.DeepCopy()
 	out.Memory = in.Memory.DeepCopy()
 	out.CPU = in.CPU.DeepCopy()
+	if in.CPULimit != nil {
+		in, out := &in.CPULimit, &out.CPULimit
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.EphemeralStorage != nil {
+		in, out := &in.EphemeralStorage, &out.EphemeralStorage
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.EphemeralStorageLimit != nil {
+		in, out := &in.EphemeralStorageLimit, &out.EphemeralStorageLimit
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.BootstrapperResources != nil {
+		in, out := &in.BootstrapperResources, &out.BootstrapperResources
+		*out = new(v1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.InitConfigResources != nil {
+		in, out := &in.InitConfigResources, &out.InitConfigResources
+		*out = new(v1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.LivenessProbe != nil {
 		in, out := &in.LivenessProbe, &out.LivenessProbe
 		*out = new(Probe)
@@ -128,6 +296,54 @@ func (in *Pod) DeepCopyInto(out *Pod) {
 		*out = new(Probe)
 		**out = **in
 	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]v1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.InitContainers != nil {
+		in, out := &in.InitContainers, &out.InitContainers
+		*out = make([]v1.Container, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Sidecars != nil {
+		in, out := &in.Sidecars, &out.Sidecars
+		*out = make([]v1.Container, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ExtraVolumes != nil {
+		in, out := &in.ExtraVolumes, &out.ExtraVolumes
+		*out = make([]v1.Volume, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ExtraVolumeMounts != nil {
+		in, out := &in.ExtraVolumeMounts, &out.ExtraVolumeMounts
+		*out = make([]v1.VolumeMount, len(*in))
+		copy(*out, *in)
+	}
+	in.Scheduling.DeepCopyInto(&out.Scheduling)
 	return
 }
 
@@ -160,6 +376,7 @@ func (in *Probe) DeepCopy() *Probe {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Rack) DeepCopyInto(out *Rack) {
 	*out = *in
+	in.Scheduling.DeepCopyInto(&out.Scheduling)
 	return
 }
 
@@ -173,6 +390,53 @@ func (in *Rack) DeepCopy() *Rack {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RackStatus) DeepCopyInto(out *RackStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RackStatus.
+func (in *RackStatus) DeepCopy() *RackStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RackStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Repair) DeepCopyInto(out *Repair) {
+	*out = *in
+	if in.Keyspaces != nil {
+		in, out := &in.Keyspaces, &out.Keyspaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Subranges != nil {
+		in, out := &in.Subranges, &out.Subranges
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TimeoutSeconds != nil {
+		in, out := &in.TimeoutSeconds, &out.TimeoutSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Repair.
+func (in *Repair) DeepCopy() *Repair {
+	if in == nil {
+		return nil
+	}
+	out := new(Repair)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RetentionPolicy) DeepCopyInto(out *RetentionPolicy) {
 	*out = *in
@@ -199,6 +463,41 @@ func (in *RetentionPolicy) DeepCopy() *RetentionPolicy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Scheduling) DeepCopyInto(out *Scheduling) {
+	*out = *in
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]v1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.AntiAffinity != nil {
+		in, out := &in.AntiAffinity, &out.AntiAffinity
+		*out = new(AntiAffinity)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Scheduling.
+func (in *Scheduling) DeepCopy() *Scheduling {
+	if in == nil {
+		return nil
+	}
+	out := new(Scheduling)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Snapshot) DeepCopyInto(out *Snapshot) {
 	*out = *in
@@ -229,3 +528,19 @@ func (in *Snapshot) DeepCopy() *Snapshot {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Storage) DeepCopyInto(out *Storage) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Storage.
+func (in *Storage) DeepCopy() *Storage {
+	if in == nil {
+		return nil
+	}
+	out := new(Storage)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -4,14 +4,21 @@ import (
 	"fmt"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	"reflect"
+	"sort"
 	"time"
 
 	"github.com/prometheus/common/log"
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/apis/cassandra/v1alpha1"
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/client/clientset/versioned"
 	"k8s.io/api/apps/v1beta2"
+	batchv1 "k8s.io/api/batch/v1"
 	"k8s.io/api/batch/v1beta1"
 	"k8s.io/api/core/v1"
+	storageV1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
@@ -19,17 +26,27 @@ import (
 
 var (
 	statefulSetCascadingPolicy = metaV1.DeletePropagationForeground
+	statefulSetOrphanPolicy    = metaV1.DeletePropagationOrphan
+)
+
+const (
+	// fileSystemResizePending is reported on a persistent volume claim whose volume has been expanded,
+	// but whose file system will only be resized once the volume is next mounted
+	fileSystemResizePending       v1.PersistentVolumeClaimConditionType = "FileSystemResizePending"
+	persistentVolumeClaimPollTime                                       = 5 * time.Second
+	statefulSetDeletionPollTime                                         = 2 * time.Second
+	statefulSetDeletionTimeout                                          = 2 * time.Minute
 )
 
 // Accessor exposes operations to access various kubernetes resources belonging to a Cluster
 type Accessor struct {
-	kubeClientset      *kubernetes.Clientset
-	cassandraClientset *versioned.Clientset
+	kubeClientset      kubernetes.Interface
+	cassandraClientset versioned.Interface
 	eventRecorder      record.EventRecorder
 }
 
 // NewAccessor creates a new Accessor
-func NewAccessor(kubeClientset *kubernetes.Clientset, cassandraClientset *versioned.Clientset, eventRecorder record.EventRecorder) *Accessor {
+func NewAccessor(kubeClientset kubernetes.Interface, cassandraClientset versioned.Interface, eventRecorder record.EventRecorder) *Accessor {
 	return &Accessor{
 		kubeClientset:      kubeClientset,
 		cassandraClientset: cassandraClientset,
@@ -42,11 +59,61 @@ func (h *Accessor) GetCassandraForCluster(c *Cluster) (*v1alpha1.Cassandra, erro
 	return h.cassandraClientset.CoreV1alpha1().Cassandras(c.Namespace()).Get(c.Name(), metaV1.GetOptions{})
 }
 
+// UpdateCassandraStatus applies the supplied change to the latest status of the Cassandra resource
+// and writes it through the status subresource. The update is skipped when the status is unchanged.
+func (h *Accessor) UpdateCassandraStatus(cassandra *v1alpha1.Cassandra, changeStatus func(*v1alpha1.CassandraStatus)) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		current, err := h.cassandraClientset.CoreV1alpha1().Cassandras(cassandra.Namespace).Get(cassandra.Name, metaV1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		updated := current.DeepCopy()
+		changeStatus(&updated.Status)
+		if reflect.DeepEqual(current.Status, updated.Status) {
+			return nil
+		}
+
+		_, err = h.cassandraClientset.CoreV1alpha1().Cassandras(cassandra.Namespace).UpdateStatus(updated)
+		return err
+	})
+}
+
+// FindRackStatusesFor reports the replicas and ready replicas of the stateful sets backing each rack in the supplied cluster
+func (h *Accessor) FindRackStatusesFor(c *Cluster) ([]v1alpha1.RackStatus, error) {
+	statefulSets, err := h.FindStatefulSetsForCluster(c)
+	if err != nil {
+		return nil, err
+	}
+	return c.rackStatusesFor(statefulSets), nil
+}
+
+// FindStatefulSetsForCluster returns the stateful sets of all racks in the supplied cluster,
+// including the racks which are no longer part of the cluster definition
+func (h *Accessor) FindStatefulSetsForCluster(c *Cluster) ([]v1beta2.StatefulSet, error) {
+	statefulSets, err := h.kubeClientset.AppsV1beta2().StatefulSets(c.Namespace()).List(metaV1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s", OperatorLabel, c.Name())})
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve stateful sets for cluster %s: %v", c.QualifiedName(), err)
+	}
+	return statefulSets.Items, nil
+}
+
 // CreateServiceForCluster creates a Kubernetes service from the supplied cluster definition
 func (h *Accessor) CreateServiceForCluster(c *Cluster) (*v1.Service, error) {
 	return h.kubeClientset.CoreV1().Services(c.Namespace()).Create(c.CreateService())
 }
 
+// GetServiceForCluster returns the headless service of the supplied cluster
+func (h *Accessor) GetServiceForCluster(c *Cluster) (*v1.Service, error) {
+	return h.kubeClientset.CoreV1().Services(c.Namespace()).Get(c.Name(), metaV1.GetOptions{})
+}
+
+// UpdateService updates the given service
+func (h *Accessor) UpdateService(service *v1.Service) error {
+	_, err := h.kubeClientset.CoreV1().Services(service.Namespace).Update(service)
+	return err
+}
+
 // DeleteServiceForCluster deletes the Kubernetes service for the supplied cluster definition
 func (h *Accessor) DeleteServiceForCluster(c *Cluster) error {
 	return h.kubeClientset.CoreV1().Services(c.Namespace()).Delete(c.Name(), metaV1.NewDeleteOptions(0))
@@ -59,20 +126,35 @@ func (h *Accessor) DeleteStatefulSetsForCluster(c *Cluster) error {
 		metaV1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s", OperatorLabel, c.Name())})
 }
 
-// FindCustomConfigMap looks for a custom config map which is associated with a given named cluster in a given
-// Kubernetes namespace.
-func (h *Accessor) FindCustomConfigMap(namespace, clusterName string) *v1.ConfigMap {
-	customConfigMapName := fmt.Sprintf("%s-config", clusterName)
-	configMap, err := h.kubeClientset.CoreV1().ConfigMaps(namespace).Get(customConfigMapName, metaV1.GetOptions{})
-	if err == nil {
-		return configMap
+// DeleteStatefulSetForRack deletes the stateful set associated with the supplied rack in the supplied cluster
+func (h *Accessor) DeleteStatefulSetForRack(c *Cluster, rack *v1alpha1.Rack) error {
+	return h.kubeClientset.AppsV1beta2().StatefulSets(c.Namespace()).Delete(fmt.Sprintf("%s-%s", c.Name(), rack.Name), &metaV1.DeleteOptions{PropagationPolicy: &statefulSetCascadingPolicy})
+}
+
+// FindCustomConfigMap looks for the custom config map of the given cluster, referenced by its definition or following
+// the naming convention, which is nil when the cluster has none
+func (h *Accessor) FindCustomConfigMap(clusterDefinition *v1alpha1.Cassandra) (*v1.ConfigMap, error) {
+	configMap, err := h.kubeClientset.CoreV1().ConfigMaps(clusterDefinition.Namespace).Get(clusterDefinition.CustomConfigMapName(), metaV1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, nil
 	}
-	return nil
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve custom config map %s.%s: %v", clusterDefinition.Namespace, clusterDefinition.CustomConfigMapName(), err)
+	}
+	return configMap, nil
+}
+
+// LabelCustomConfigMap labels the custom config map with the name of the supplied cluster, as only labelled config maps
+// are watched by the operator
+func (h *Accessor) LabelCustomConfigMap(clusterDefinition *v1alpha1.Cassandra, configMap *v1.ConfigMap) error {
+	patch := fmt.Sprintf(`{"metadata": {"labels": {"%s": "%s"}}}`, OperatorLabel, clusterDefinition.Name)
+	_, err := h.kubeClientset.CoreV1().ConfigMaps(configMap.Namespace).Patch(configMap.Name, types.StrategicMergePatchType, []byte(patch))
+	return err
 }
 
 // CreateStatefulSetForRack creates a StatefulSet for a given within the supplied cluster definition
 func (h *Accessor) CreateStatefulSetForRack(c *Cluster, rack *v1alpha1.Rack, customConfigMap *v1.ConfigMap) (*v1beta2.StatefulSet, error) {
-	return h.kubeClientset.AppsV1beta2().StatefulSets(c.Namespace()).Create(c.createStatefulSetForRack(rack, customConfigMap))
+	return h.kubeClientset.AppsV1beta2().StatefulSets(c.Namespace()).Create(c.CreateStatefulSetForRack(rack, customConfigMap))
 }
 
 // PatchStatefulSet applies a patch to a stateful set corresponding to the supplied rack in the supplied cluster
@@ -85,12 +167,194 @@ func (h *Accessor) GetStatefulSetForRack(c *Cluster, rack *v1alpha1.Rack) (*v1be
 	return h.kubeClientset.AppsV1beta2().StatefulSets(c.Namespace()).Get(fmt.Sprintf("%s-%s", c.Name(), rack.Name), metaV1.GetOptions{})
 }
 
+// FindPodsForRack returns the pods of the supplied rack in the supplied cluster, ordered by name
+func (h *Accessor) FindPodsForRack(c *Cluster, rack *v1alpha1.Rack) ([]v1.Pod, error) {
+	labelSelector := fmt.Sprintf("%s=%s,%s=%s", OperatorLabel, c.Name(), RackLabel, rack.Name)
+	pods, err := h.kubeClientset.CoreV1().Pods(c.Namespace()).List(metaV1.ListOptions{LabelSelector: labelSelector})
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve pods for rack %s in cluster %s: %v", rack.Name, c.QualifiedName(), err)
+	}
+
+	sort.Slice(pods.Items, func(i, j int) bool { return pods.Items[i].Name < pods.Items[j].Name })
+	return pods.Items, nil
+}
+
+// GetPodForRack returns the pod with the supplied ordinal in the stateful set of the supplied rack
+func (h *Accessor) GetPodForRack(c *Cluster, rack *v1alpha1.Rack, ordinal int32) (*v1.Pod, error) {
+	return h.kubeClientset.CoreV1().Pods(c.Namespace()).Get(c.definition.PodName(rack, ordinal), metaV1.GetOptions{})
+}
+
+// GetPodForCluster returns the pod of the supplied cluster with the supplied name
+func (h *Accessor) GetPodForCluster(c *Cluster, name string) (*v1.Pod, error) {
+	return h.kubeClientset.CoreV1().Pods(c.Namespace()).Get(name, metaV1.GetOptions{})
+}
+
+// DeletePersistentVolumeClaimForRack deletes the persistent volume claim holding the data of the pod with the supplied ordinal in the supplied rack
+func (h *Accessor) DeletePersistentVolumeClaimForRack(c *Cluster, rack *v1alpha1.Rack, ordinal int32) error {
+	return h.kubeClientset.CoreV1().PersistentVolumeClaims(c.Namespace()).Delete(c.definition.StorageVolumeClaimName(rack, ordinal), &metaV1.DeleteOptions{})
+}
+
+// FindPersistentVolumeClaimsForCluster returns the persistent volume claims holding the data of the nodes of the supplied cluster
+func (h *Accessor) FindPersistentVolumeClaimsForCluster(c *Cluster) ([]v1.PersistentVolumeClaim, error) {
+	labelSelector := fmt.Sprintf("%s=%s", OperatorLabel, c.Name())
+	claims, err := h.kubeClientset.CoreV1().PersistentVolumeClaims(c.Namespace()).List(metaV1.ListOptions{LabelSelector: labelSelector})
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve persistent volume claims for cluster %s: %v", c.QualifiedName(), err)
+	}
+	return claims.Items, nil
+}
+
+// DeletePersistentVolumeClaim deletes the supplied persistent volume claim
+func (h *Accessor) DeletePersistentVolumeClaim(claim *v1.PersistentVolumeClaim) error {
+	return h.kubeClientset.CoreV1().PersistentVolumeClaims(claim.Namespace).Delete(claim.Name, &metaV1.DeleteOptions{})
+}
+
+// GetPersistentVolumeClaimForRack returns the persistent volume claim holding the data of the pod with the supplied ordinal in the supplied rack
+func (h *Accessor) GetPersistentVolumeClaimForRack(c *Cluster, rack *v1alpha1.Rack, ordinal int32) (*v1.PersistentVolumeClaim, error) {
+	return h.kubeClientset.CoreV1().PersistentVolumeClaims(c.Namespace()).Get(c.definition.StorageVolumeClaimName(rack, ordinal), metaV1.GetOptions{})
+}
+
+// ResizePersistentVolumeClaim requests the supplied storage size for the persistent volume claim
+func (h *Accessor) ResizePersistentVolumeClaim(claim *v1.PersistentVolumeClaim, storageSize resource.Quantity) (*v1.PersistentVolumeClaim, error) {
+	patch := fmt.Sprintf(`{"spec": {"resources": {"requests": {"storage": "%s"}}}}`, storageSize.String())
+	return h.kubeClientset.CoreV1().PersistentVolumeClaims(claim.Namespace).Patch(claim.Name, types.StrategicMergePatchType, []byte(patch))
+}
+
+// WaitUntilPersistentVolumeClaimResized waits until the capacity of the persistent volume claim has reached the supplied
+// storage size and the claim no longer reports that its volume or file system is being resized.
+// When the file system of the claim will only be resized once its volume is next mounted, it doesn't wait any longer
+// and returns the claim, which reports FileSystemResizePending, so that its pod can be restarted.
+func (h *Accessor) WaitUntilPersistentVolumeClaimResized(claim *v1.PersistentVolumeClaim, storageSize resource.Quantity, timeout time.Duration) (*v1.PersistentVolumeClaim, error) {
+	var currentClaim *v1.PersistentVolumeClaim
+	err := wait.PollImmediate(persistentVolumeClaimPollTime, timeout, func() (bool, error) {
+		var err error
+		currentClaim, err = h.kubeClientset.CoreV1().PersistentVolumeClaims(claim.Namespace).Get(claim.Name, metaV1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		return FileSystemResizePending(currentClaim) || persistentVolumeClaimResized(currentClaim, storageSize), nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error while waiting for persistent volume claim %s.%s to be resized to %s: %v", claim.Namespace, claim.Name, storageSize.String(), err)
+	}
+	return currentClaim, nil
+}
+
+// FileSystemResizePending reports whether the volume of the persistent volume claim has been expanded,
+// but its file system will only be resized once the pod using it is restarted
+func FileSystemResizePending(claim *v1.PersistentVolumeClaim) bool {
+	return claimHasCondition(claim, fileSystemResizePending)
+}
+
+func persistentVolumeClaimResized(claim *v1.PersistentVolumeClaim, storageSize resource.Quantity) bool {
+	capacity, ok := claim.Status.Capacity[v1.ResourceStorage]
+	if !ok || capacity.Cmp(storageSize) < 0 {
+		return false
+	}
+	return !claimHasCondition(claim, v1.PersistentVolumeClaimResizing) && !claimHasCondition(claim, fileSystemResizePending)
+}
+
+func claimHasCondition(claim *v1.PersistentVolumeClaim, conditionType v1.PersistentVolumeClaimConditionType) bool {
+	for _, condition := range claim.Status.Conditions {
+		if condition.Type == conditionType && condition.Status == v1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// GetStorageClass returns the storage class with the supplied name
+func (h *Accessor) GetStorageClass(name string) (*storageV1.StorageClass, error) {
+	return h.kubeClientset.StorageV1().StorageClasses().Get(name, metaV1.GetOptions{})
+}
+
+// OrphanPodsOfStatefulSetForRack deletes the stateful set of the supplied rack while leaving its pods and persistent volume claims
+// in place, and waits until the stateful set is gone so that it can be recreated to adopt them
+func (h *Accessor) OrphanPodsOfStatefulSetForRack(c *Cluster, rack *v1alpha1.Rack) error {
+	statefulSets := h.kubeClientset.AppsV1beta2().StatefulSets(c.Namespace())
+	name := fmt.Sprintf("%s-%s", c.Name(), rack.Name)
+	if err := statefulSets.Delete(name, &metaV1.DeleteOptions{PropagationPolicy: &statefulSetOrphanPolicy}); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	err := wait.PollImmediate(statefulSetDeletionPollTime, statefulSetDeletionTimeout, func() (bool, error) {
+		_, err := statefulSets.Get(name, metaV1.GetOptions{})
+		if errors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	})
+	if err != nil {
+		return fmt.Errorf("error while waiting for stateful set %s.%s to be deleted: %v", c.Namespace(), name, err)
+	}
+	return nil
+}
+
+// DeletePod deletes the supplied pod, provided it hasn't been replaced by another pod with the same name
+func (h *Accessor) DeletePod(pod *v1.Pod) error {
+	return h.kubeClientset.CoreV1().Pods(pod.Namespace).Delete(pod.Name, metaV1.NewPreconditionDeleteOptions(string(pod.UID)))
+}
+
+// RemovePodAnnotation removes the annotation with the supplied name from the pod
+func (h *Accessor) RemovePodAnnotation(pod *v1.Pod, name string) error {
+	patch := fmt.Sprintf(`{"metadata": {"annotations": {"%s": null}}}`, name)
+	_, err := h.kubeClientset.CoreV1().Pods(pod.Namespace).Patch(pod.Name, types.StrategicMergePatchType, []byte(patch))
+	return err
+}
+
+// FindNodeReplacements returns the addresses of the dead nodes being replaced by the nodes of the supplied cluster,
+// keyed by the name of the pod of the replacing node
+func (h *Accessor) FindNodeReplacements(c *Cluster) (map[string]string, error) {
+	configMap, err := h.kubeClientset.CoreV1().ConfigMaps(c.Namespace()).Get(c.definition.NodeReplacementsConfigMapName(), metaV1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve node replacements for cluster %s: %v", c.QualifiedName(), err)
+	}
+	return NodeReplacements(configMap), nil
+}
+
+// SetNodeReplacement records that the node on the supplied pod replaces the dead node with the supplied address,
+// which the bootstrapper of the pod passes on to Cassandra. An empty address removes the replacement.
+func (h *Accessor) SetNodeReplacement(c *Cluster, podName, address string) error {
+	configMaps := h.kubeClientset.CoreV1().ConfigMaps(c.Namespace())
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configMap, err := configMaps.Get(c.definition.NodeReplacementsConfigMapName(), metaV1.GetOptions{})
+		if errors.IsNotFound(err) {
+			if address == "" {
+				return nil
+			}
+			_, err = configMaps.Create(c.CreateNodeReplacementsConfigMap(map[string]string{podName: address}))
+			return err
+		}
+		if err != nil {
+			return err
+		}
+
+		replacements := NodeReplacements(configMap)
+		if address == "" {
+			delete(replacements, podName)
+		} else {
+			replacements[podName] = address
+		}
+		configMap.Data = c.CreateNodeReplacementsConfigMap(replacements).Data
+		_, err = configMaps.Update(configMap)
+		return err
+	})
+}
+
+// DeleteNodeReplacementsForCluster deletes the config map holding the addresses of the dead nodes replaced by the nodes of the supplied cluster
+func (h *Accessor) DeleteNodeReplacementsForCluster(c *Cluster) error {
+	return h.kubeClientset.CoreV1().ConfigMaps(c.Namespace()).Delete(c.definition.NodeReplacementsConfigMapName(), &metaV1.DeleteOptions{})
+}
+
 // UpdateStatefulSet updates the stateful set associated with the supplied cluster
 func (h *Accessor) UpdateStatefulSet(c *Cluster, statefulSet *v1beta2.StatefulSet) (*v1beta2.StatefulSet, error) {
 	return h.kubeClientset.AppsV1beta2().StatefulSets(c.Namespace()).Update(statefulSet)
 }
 
-// FindExistingResourcesFor finds Kubernetes services, stateful sets and pods associated with the supplied cluster
+// FindExistingResourcesFor finds Kubernetes services, stateful sets, pods and persistent volume claims associated with the supplied cluster
 func (h *Accessor) FindExistingResourcesFor(c *Cluster) []string {
 	labelSelector := fmt.Sprintf("%s=%s", OperatorLabel, c.Name())
 	log.Infof("Searching for resources with label %s", labelSelector)
@@ -112,6 +376,11 @@ func (h *Accessor) FindExistingResourcesFor(c *Cluster) []string {
 		foundResources = append(foundResources, fmt.Sprintf("pod:%s", pod))
 	}
 
+	claims := h.persistentVolumeClaimsForCluster(c, listOptions)
+	for _, claim := range claims {
+		foundResources = append(foundResources, fmt.Sprintf("persistentvolumeclaim:%s", claim))
+	}
+
 	return foundResources
 }
 
@@ -163,6 +432,11 @@ func (h *Accessor) FindCronJobForCluster(cassandra *v1alpha1.Cassandra, label st
 	return &cronJobList.Items[0], nil
 }
 
+// CreateJob creates the given job
+func (h *Accessor) CreateJob(job *batchv1.Job) (*batchv1.Job, error) {
+	return h.kubeClientset.BatchV1().Jobs(job.Namespace).Create(job)
+}
+
 // DeleteCronJob deletes the given job
 func (h *Accessor) DeleteCronJob(job *v1beta1.CronJob) error {
 	deletePropagation := metaV1.DeletePropagationBackground
@@ -217,6 +491,20 @@ func (h *Accessor) podsForCluster(c *Cluster, listOptions metaV1.ListOptions) []
 	return podNames
 }
 
+func (h *Accessor) persistentVolumeClaimsForCluster(c *Cluster, listOptions metaV1.ListOptions) []string {
+	claims, err := h.kubeClientset.CoreV1().PersistentVolumeClaims(c.Namespace()).List(listOptions)
+	var claimNames []string
+	if err != nil {
+		log.Warnf("Unable to determine if persistent volume claims exist for cluster %s, assuming they don't: %v", c.QualifiedName(), err)
+	} else {
+		for _, claim := range claims.Items {
+			claimNames = append(claimNames, claim.Name)
+		}
+	}
+
+	return claimNames
+}
+
 func (h *Accessor) statefulSetChangeApplied(cluster *Cluster, appliedStatefulSet *v1beta2.StatefulSet) func() (bool, error) {
 	return func() (bool, error) {
 		currentStatefulSet, err := h.kubeClientset.AppsV1beta1().StatefulSets(appliedStatefulSet.Namespace).Get(appliedStatefulSet.Name, metaV1.GetOptions{})
@@ -1,6 +1,7 @@
 package cluster
 
 import (
+	"crypto/sha256"
 	"fmt"
 	"github.com/robfig/cron"
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/apis/cassandra/v1alpha1"
@@ -9,8 +10,13 @@ import (
 	batchv1 "k8s.io/api/batch/v1"
 	"k8s.io/api/batch/v1beta1"
 	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -23,6 +29,22 @@ const (
 	// an associated custom config map.
 	ConfigHashAnnotation = "clusterConfigHash"
 
+	// ReplaceAddressAnnotation is set on a Cassandra pod to have the dead node with the given address replaced
+	// by a new node on that pod
+	ReplaceAddressAnnotation = "cassandra.core.sky.uk/replaceAddress"
+
+	// RestartedAtAnnotation is set on a Cassandra resource to have its pods restarted rack by rack.
+	// The operator copies it to the pod template of each rack whenever its value changes.
+	RestartedAtAnnotation = "cassandra.core.sky.uk/restartedAt"
+
+	// RepairRequestedAtAnnotation is set on a Cassandra resource to have its cluster repaired at once, outside of the repair schedule.
+	// The operator runs a job from the template of the repair cron job whenever its value changes.
+	RepairRequestedAtAnnotation = "cassandra.core.sky.uk/repairRequestedAt"
+
+	// SuspendedByPauseAnnotation is set on the cron jobs which the operator suspends when their cluster is paused,
+	// so that only those are resumed once the cluster is unpaused, and not the ones suspended by hand
+	SuspendedByPauseAnnotation = "cassandra.core.sky.uk/suspendedByPause"
+
 	// RackLabel is a label used to identify the rack name in a cluster
 	RackLabel       = "rack"
 	customConfigDir = "/custom-config"
@@ -37,6 +59,7 @@ const (
 	DefaultDCName                      = "dc1"
 	cassandraContainerName             = "cassandra"
 	cassandraBootstrapperContainerName = "cassandra-bootstrapper"
+	initConfigContainerName            = "init-config"
 
 	// DefaultCassandraBootstrapperImage is the name of the Docker image used to prepare the configuration for the Cassandra node before it can be started
 	DefaultCassandraBootstrapperImage = "skyuk/cassandra-bootstrapper:latest"
@@ -46,8 +69,24 @@ const (
 	extraLibVolumeMountPath      = "/extra-lib"
 	configurationVolumeName      = "configuration"
 	extraLibVolumeName           = "extra-lib"
+	zoneLabel                    = "failure-domain.beta.kubernetes.io/zone"
+	hostnameLabel                = "kubernetes.io/hostname"
+	preferredAntiAffinityWeight  = 100
+	appLabel                     = "app"
+	extraClasspathEnvVar         = "EXTRA_CLASSPATH"
+	nodeReplacementsKey          = "replacements"
 )
 
+// operatorPodLabels are the pod labels set by the operator, which select the pods of a rack
+var operatorPodLabels = []string{OperatorLabel, RackLabel, appLabel}
+
+// nodeReplacementEnvVars are the environment variables through which the bootstrapper finds the dead node its pod replaces.
+// They are missing from the pod templates created by previous versions of the operator.
+var nodeReplacementEnvVars = []string{"POD_NAME", "NODE_REPLACEMENTS"}
+
+// operatorMountPaths are the paths at which the operator mounts volumes in the cassandra container
+var operatorMountPaths = []string{storageVolumeMountPath, configurationVolumeMountPath, extraLibVolumeMountPath}
+
 var defaultLivenessProbe = v1alpha1.Probe{
 	FailureThreshold:    int32(3),
 	InitialDelaySeconds: int32(30),
@@ -79,6 +118,11 @@ func New(clusterDefinition *v1alpha1.Cassandra) (*Cluster, error) {
 	return cluster, nil
 }
 
+// Validate checks that the supplied Cassandra definition is valid. The definition is left unmodified.
+func Validate(clusterDefinition *v1alpha1.Cassandra) error {
+	return CopyInto(&Cluster{}, clusterDefinition.DeepCopy())
+}
+
 // CopyInto copies a Cassandra cluster definition into the internal cluster data structure supplied.
 func CopyInto(cluster *Cluster, clusterDefinition *v1alpha1.Cassandra) error {
 	if err := validateRacks(clusterDefinition); err != nil {
@@ -93,14 +137,28 @@ func CopyInto(cluster *Cluster, clusterDefinition *v1alpha1.Cassandra) error {
 		return err
 	}
 
-	cassandraImage := clusterDefinition.Spec.Pod.Image
-	if cassandraImage == "" {
-		cassandraImage = DefaultCassandraImage
+	if err := validateRepair(clusterDefinition); err != nil {
+		return err
+	}
+
+	if err := validateAutoCleanup(clusterDefinition); err != nil {
+		return err
+	}
+
+	if err := validateStorage(clusterDefinition); err != nil {
+		return err
+	}
+
+	if err := validateScheduling(clusterDefinition); err != nil {
+		return err
+	}
+
+	if err := validatePodMetadata(clusterDefinition); err != nil {
+		return err
 	}
 
-	bootstrapperImage := clusterDefinition.Spec.Pod.BootstrapperImage
-	if bootstrapperImage == "" {
-		bootstrapperImage = DefaultCassandraBootstrapperImage
+	if err := validatePodContainers(clusterDefinition); err != nil {
+		return err
 	}
 
 	if clusterDefinition.Spec.Snapshot != nil {
@@ -109,11 +167,6 @@ func CopyInto(cluster *Cluster, clusterDefinition *v1alpha1.Cassandra) error {
 		}
 	}
 
-	dc := clusterDefinition.Spec.DC
-	if dc == "" {
-		dc = DefaultDCName
-	}
-
 	if clusterDefinition.Spec.Pod.LivenessProbe == nil {
 		clusterDefinition.Spec.Pod.LivenessProbe = defaultLivenessProbe.DeepCopy()
 	} else {
@@ -137,12 +190,45 @@ func CopyInto(cluster *Cluster, clusterDefinition *v1alpha1.Cassandra) error {
 	}
 
 	cluster.definition = clusterDefinition.DeepCopy()
-	cluster.definition.Spec.DC = dc
-	cluster.definition.Spec.Pod.BootstrapperImage = bootstrapperImage
-	cluster.definition.Spec.Pod.Image = cassandraImage
+	ApplyDefaults(cluster.definition)
 	return nil
 }
 
+// ApplyDefaults sets the default value of each optional property which is not set in the supplied Cassandra definition
+func ApplyDefaults(clusterDefinition *v1alpha1.Cassandra) {
+	if clusterDefinition.Spec.DC == "" {
+		clusterDefinition.Spec.DC = DefaultDCName
+	}
+
+	if clusterDefinition.Spec.Pod.Image == "" {
+		clusterDefinition.Spec.Pod.Image = DefaultCassandraImage
+	}
+
+	if clusterDefinition.Spec.Pod.BootstrapperImage == "" {
+		clusterDefinition.Spec.Pod.BootstrapperImage = DefaultCassandraBootstrapperImage
+	}
+
+	if clusterDefinition.Spec.Snapshot != nil && clusterDefinition.Spec.Snapshot.Image == "" {
+		clusterDefinition.Spec.Snapshot.Image = DefaultCassandraSnapshotImage
+	}
+
+	if clusterDefinition.Spec.Repair != nil && clusterDefinition.Spec.Repair.Image == "" {
+		clusterDefinition.Spec.Repair.Image = DefaultCassandraSnapshotImage
+	}
+
+	if clusterDefinition.Spec.Pod.LivenessProbe == nil {
+		clusterDefinition.Spec.Pod.LivenessProbe = defaultLivenessProbe.DeepCopy()
+	} else {
+		mergeProbeDefaults(clusterDefinition.Spec.Pod.LivenessProbe, &defaultLivenessProbe)
+	}
+
+	if clusterDefinition.Spec.Pod.ReadinessProbe == nil {
+		clusterDefinition.Spec.Pod.ReadinessProbe = defaultReadinessProbe.DeepCopy()
+	} else {
+		mergeProbeDefaults(clusterDefinition.Spec.Pod.ReadinessProbe, &defaultReadinessProbe)
+	}
+}
+
 // Definition returns a copy of the definition of the cluster. Any modifications made to this will be ignored.
 func (c *Cluster) Definition() *v1alpha1.Cassandra {
 	return c.definition.DeepCopy()
@@ -177,6 +263,15 @@ func validatePodResources(clusterDefinition *v1alpha1.Cassandra) error {
 	if !clusterDefinition.Spec.UseEmptyDir && clusterDefinition.Spec.Pod.StorageSize.IsZero() {
 		return fmt.Errorf("no podStorageSize property provided and useEmptyDir false for Cassandra cluster definition: %s.%s", clusterDefinition.Namespace, clusterDefinition.Name)
 	}
+
+	pod := clusterDefinition.Spec.Pod
+	if pod.CPULimit != nil && pod.CPULimit.Cmp(pod.CPU) < 0 {
+		return fmt.Errorf("invalid cpuLimit %s, must not be less than cpu %s for Cassandra cluster definition: %s", pod.CPULimit.String(), pod.CPU.String(), clusterDefinition.QualifiedName())
+	}
+
+	if pod.EphemeralStorage != nil && pod.EphemeralStorageLimit != nil && pod.EphemeralStorageLimit.Cmp(*pod.EphemeralStorage) < 0 {
+		return fmt.Errorf("invalid ephemeralStorageLimit %s, must not be less than ephemeralStorage %s for Cassandra cluster definition: %s", pod.EphemeralStorageLimit.String(), pod.EphemeralStorage.String(), clusterDefinition.QualifiedName())
+	}
 	return nil
 }
 
@@ -220,6 +315,153 @@ func validateSnapshot(clusterDefinition *v1alpha1.Cassandra) error {
 	return nil
 }
 
+func validateRepair(clusterDefinition *v1alpha1.Cassandra) error {
+	repair := clusterDefinition.Spec.Repair
+	if repair == nil {
+		return nil
+	}
+
+	if repair.Schedule == "" {
+		return fmt.Errorf("no repair schedule property provided for Cassandra cluster definition: %s", clusterDefinition.QualifiedName())
+	}
+
+	if _, err := cron.Parse(repair.Schedule); err != nil {
+		return fmt.Errorf("invalid repair schedule, must be a cron expression but got '%s' for Cassandra cluster definition: %s", repair.Schedule, clusterDefinition.QualifiedName())
+	}
+
+	switch repair.Parallelism {
+	case "", v1alpha1.RepairSequential, v1alpha1.RepairParallel, v1alpha1.RepairDCParallel:
+	default:
+		return fmt.Errorf("invalid repair parallelism '%s', must be one of %s, %s or %s for Cassandra cluster definition: %s", repair.Parallelism, v1alpha1.RepairSequential, v1alpha1.RepairParallel, v1alpha1.RepairDCParallel, clusterDefinition.QualifiedName())
+	}
+
+	if repair.Subranges != nil {
+		if *repair.Subranges < 1 {
+			return fmt.Errorf("invalid repair subranges value %d, must be greater than zero for Cassandra cluster definition: %s", *repair.Subranges, clusterDefinition.QualifiedName())
+		}
+		if len(repair.Keyspaces) == 0 {
+			return fmt.Errorf("repair subranges require the keyspaces to repair for Cassandra cluster definition: %s", clusterDefinition.QualifiedName())
+		}
+	}
+
+	if repair.TimeoutSeconds != nil && *repair.TimeoutSeconds < 0 {
+		return fmt.Errorf("invalid repair timeoutSeconds value %d, must be non-negative for Cassandra cluster definition: %s", *repair.TimeoutSeconds, clusterDefinition.QualifiedName())
+	}
+	return nil
+}
+
+func validateAutoCleanup(clusterDefinition *v1alpha1.Cassandra) error {
+	autoCleanup := clusterDefinition.Spec.AutoCleanup
+	if autoCleanup != nil && autoCleanup.Jobs != nil && *autoCleanup.Jobs < 1 {
+		return fmt.Errorf("invalid autoCleanup jobs value %d, must be greater than zero for Cassandra cluster definition: %s", *autoCleanup.Jobs, clusterDefinition.QualifiedName())
+	}
+	return nil
+}
+
+func validateStorage(clusterDefinition *v1alpha1.Cassandra) error {
+	if clusterDefinition.Spec.Storage == nil {
+		return nil
+	}
+
+	switch clusterDefinition.Spec.Storage.ReclaimPolicy {
+	case "", v1alpha1.StorageReclaimRetain, v1alpha1.StorageReclaimDelete:
+		return nil
+	default:
+		return fmt.Errorf("invalid storage reclaimPolicy '%s', must be one of %s or %s for Cassandra cluster definition: %s", clusterDefinition.Spec.Storage.ReclaimPolicy, v1alpha1.StorageReclaimRetain, v1alpha1.StorageReclaimDelete, clusterDefinition.QualifiedName())
+	}
+}
+
+func validateScheduling(clusterDefinition *v1alpha1.Cassandra) error {
+	if err := validateAntiAffinity(clusterDefinition.Spec.Pod.AntiAffinity, "pod", clusterDefinition); err != nil {
+		return err
+	}
+	for _, rack := range clusterDefinition.Spec.Racks {
+		if err := validateAntiAffinity(rack.AntiAffinity, fmt.Sprintf("rack '%s'", rack.Name), clusterDefinition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateAntiAffinity(antiAffinity *v1alpha1.AntiAffinity, owner string, clusterDefinition *v1alpha1.Cassandra) error {
+	if antiAffinity == nil {
+		return nil
+	}
+
+	switch antiAffinity.Mode {
+	case "", v1alpha1.AntiAffinityRequired, v1alpha1.AntiAffinityPreferred:
+		return nil
+	default:
+		return fmt.Errorf("invalid antiAffinity mode '%s' for %s, must be one of %s or %s for Cassandra cluster definition: %s", antiAffinity.Mode, owner, v1alpha1.AntiAffinityRequired, v1alpha1.AntiAffinityPreferred, clusterDefinition.QualifiedName())
+	}
+}
+
+func validatePodMetadata(clusterDefinition *v1alpha1.Cassandra) error {
+	for _, label := range operatorPodLabels {
+		if _, ok := clusterDefinition.Spec.Pod.Labels[label]; ok {
+			return fmt.Errorf("pod label '%s' is set by the operator and cannot be overridden for Cassandra cluster definition: %s", label, clusterDefinition.QualifiedName())
+		}
+	}
+
+	for _, annotation := range []string{ConfigHashAnnotation, RestartedAtAnnotation} {
+		if _, ok := clusterDefinition.Spec.Pod.Annotations[annotation]; ok {
+			return fmt.Errorf("pod annotation '%s' is set by the operator and cannot be overridden for Cassandra cluster definition: %s", annotation, clusterDefinition.QualifiedName())
+		}
+	}
+
+	envNames := map[string]bool{extraClasspathEnvVar: true}
+	for _, env := range clusterDefinition.Spec.Pod.Env {
+		if env.Name == "" {
+			return fmt.Errorf("pod environment variable with no name specified for Cassandra cluster definition: %s", clusterDefinition.QualifiedName())
+		}
+		if envNames[env.Name] {
+			return fmt.Errorf("pod environment variable '%s' is defined more than once or set by the operator for Cassandra cluster definition: %s", env.Name, clusterDefinition.QualifiedName())
+		}
+		envNames[env.Name] = true
+	}
+	return nil
+}
+
+func validatePodContainers(clusterDefinition *v1alpha1.Cassandra) error {
+	containerNames := map[string]bool{initConfigContainerName: true, cassandraBootstrapperContainerName: true, cassandraContainerName: true}
+	containers := append(append([]v1.Container{}, clusterDefinition.Spec.Pod.InitContainers...), clusterDefinition.Spec.Pod.Sidecars...)
+	for _, container := range containers {
+		if container.Name == "" {
+			return fmt.Errorf("pod container with no name specified for Cassandra cluster definition: %s", clusterDefinition.QualifiedName())
+		}
+		if containerNames[container.Name] {
+			return fmt.Errorf("pod container '%s' is defined more than once or by the operator for Cassandra cluster definition: %s", container.Name, clusterDefinition.QualifiedName())
+		}
+		containerNames[container.Name] = true
+	}
+
+	volumeNames := map[string]bool{}
+	for _, name := range operatorVolumeNames(clusterDefinition) {
+		volumeNames[name] = true
+	}
+	for _, volume := range clusterDefinition.Spec.Pod.ExtraVolumes {
+		if volume.Name == "" {
+			return fmt.Errorf("pod volume with no name specified for Cassandra cluster definition: %s", clusterDefinition.QualifiedName())
+		}
+		if volumeNames[volume.Name] {
+			return fmt.Errorf("pod volume '%s' is defined more than once or by the operator for Cassandra cluster definition: %s", volume.Name, clusterDefinition.QualifiedName())
+		}
+		volumeNames[volume.Name] = true
+	}
+
+	for _, mount := range clusterDefinition.Spec.Pod.ExtraVolumeMounts {
+		if containsString(operatorMountPaths, mount.MountPath) {
+			return fmt.Errorf("pod volume mount path '%s' is used by the operator for Cassandra cluster definition: %s", mount.MountPath, clusterDefinition.QualifiedName())
+		}
+	}
+	return nil
+}
+
+// operatorVolumeNames are the names of the pod volumes the operator may define
+func operatorVolumeNames(clusterDefinition *v1alpha1.Cassandra) []string {
+	return []string{configurationVolumeName, extraLibVolumeName, clusterDefinition.StorageVolumeName(), customConfigMapVolumeName(clusterDefinition)}
+}
+
 func validateLivenessProbe(probe *v1alpha1.Probe, clusterDefinition *v1alpha1.Cassandra) error {
 	if probe.SuccessThreshold != 1 {
 		return fmt.Errorf("invalid success threshold for liveness probe, must be set to 1 for Cassandra cluster definition: %s.%s", clusterDefinition.Namespace, clusterDefinition.Name)
@@ -292,7 +534,290 @@ func (c *Cluster) Racks() []v1alpha1.Rack {
 	return c.definition.Spec.Racks
 }
 
-func (c *Cluster) createStatefulSetForRack(rack *v1alpha1.Rack, customConfigMap *v1.ConfigMap) *appsv1.StatefulSet {
+func (c *Cluster) rackStatusesFor(statefulSets []appsv1.StatefulSet) []v1alpha1.RackStatus {
+	statefulSetsByName := map[string]appsv1.StatefulSet{}
+	for _, statefulSet := range statefulSets {
+		statefulSetsByName[statefulSet.Name] = statefulSet
+	}
+
+	var rackStatuses []v1alpha1.RackStatus
+	for _, rack := range c.Racks() {
+		rackStatus := v1alpha1.RackStatus{Name: rack.Name}
+		if statefulSet, ok := statefulSetsByName[c.definition.RackName(&rack)]; ok {
+			rackStatus.Replicas = statefulSet.Status.Replicas
+			rackStatus.ReadyReplicas = statefulSet.Status.ReadyReplicas
+		}
+		rackStatuses = append(rackStatuses, rackStatus)
+	}
+	return rackStatuses
+}
+
+// SpecForStatefulSets describes the cluster which is actually run by the supplied stateful sets, so that it can be
+// compared with the cluster definition. Properties which are not reflected in the stateful sets are reported as defined.
+// Racks are listed in the order of the cluster definition, followed by the racks which are no longer defined, and the pod
+// properties are those of the first stateful set which doesn't match the cluster definition.
+func (c *Cluster) SpecForStatefulSets(statefulSets []appsv1.StatefulSet) *v1alpha1.CassandraSpec {
+	spec := c.definition.Spec.DeepCopy()
+	spec.Racks = nil
+
+	statefulSetsByRack := map[string]*appsv1.StatefulSet{}
+	var undefinedRacks []string
+	for i := range statefulSets {
+		rackName, ok := statefulSets[i].Labels[RackLabel]
+		if !ok {
+			continue
+		}
+		statefulSetsByRack[rackName] = &statefulSets[i]
+		if !isDefinedRack(rackName, c.definition.Spec.Racks) {
+			undefinedRacks = append(undefinedRacks, rackName)
+		}
+	}
+	sort.Strings(undefinedRacks)
+
+	var orderedStatefulSets []*appsv1.StatefulSet
+	for _, rack := range c.definition.Spec.Racks {
+		if statefulSet, ok := statefulSetsByRack[rack.Name]; ok {
+			spec.Racks = append(spec.Racks, rackForStatefulSet(rack, statefulSet))
+			orderedStatefulSets = append(orderedStatefulSets, statefulSet)
+		}
+	}
+	for _, rackName := range undefinedRacks {
+		spec.Racks = append(spec.Racks, rackForStatefulSet(v1alpha1.Rack{Name: rackName}, statefulSetsByRack[rackName]))
+		orderedStatefulSets = append(orderedStatefulSets, statefulSetsByRack[rackName])
+	}
+
+	for _, statefulSet := range orderedStatefulSets {
+		if !c.podSpecMatches(statefulSet) {
+			c.copyPodSpecFrom(statefulSet, spec)
+			break
+		}
+	}
+	return spec
+}
+
+func rackForStatefulSet(rack v1alpha1.Rack, statefulSet *appsv1.StatefulSet) v1alpha1.Rack {
+	if statefulSet.Spec.Replicas != nil {
+		rack.Replicas = *statefulSet.Spec.Replicas
+	}
+
+	if affinity := statefulSet.Spec.Template.Spec.Affinity; affinity != nil && affinity.NodeAffinity != nil && affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution != nil {
+		for _, term := range affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms {
+			for _, expression := range term.MatchExpressions {
+				// the zone is the only node requirement of a rack, whichever label holds it
+				if expression.Operator == v1.NodeSelectorOpIn && len(expression.Values) > 0 {
+					rack.Zone = expression.Values[0]
+				}
+			}
+		}
+	}
+
+	if len(statefulSet.Spec.VolumeClaimTemplates) > 0 && statefulSet.Spec.VolumeClaimTemplates[0].Spec.StorageClassName != nil {
+		rack.StorageClass = *statefulSet.Spec.VolumeClaimTemplates[0].Spec.StorageClassName
+	}
+	return rack
+}
+
+func (c *Cluster) podSpecMatches(statefulSet *appsv1.StatefulSet) bool {
+	spec := c.definition.Spec.DeepCopy()
+	c.copyPodSpecFrom(statefulSet, spec)
+	return reflect.DeepEqual(spec.Pod, c.definition.Spec.Pod) && spec.DC == c.definition.Spec.DC && spec.UseEmptyDir == c.definition.Spec.UseEmptyDir
+}
+
+// copyPodSpecFrom overwrites the pod properties of the supplied spec with the ones the stateful set runs with.
+// Quantities are only overwritten when their values differ, as their representation may have been changed by Kubernetes.
+func (c *Cluster) copyPodSpecFrom(statefulSet *appsv1.StatefulSet, spec *v1alpha1.CassandraSpec) {
+	podSpec := statefulSet.Spec.Template.Spec
+	if container := findContainer(cassandraContainerName, podSpec.Containers); container != nil {
+		spec.Pod.Image = container.Image
+		copyQuantity(container.Resources.Requests, v1.ResourceCPU, &spec.Pod.CPU)
+		copyQuantity(container.Resources.Requests, v1.ResourceMemory, &spec.Pod.Memory)
+		copyOptionalQuantity(container.Resources.Limits, v1.ResourceCPU, &spec.Pod.CPULimit)
+		copyOptionalQuantity(container.Resources.Requests, v1.ResourceEphemeralStorage, &spec.Pod.EphemeralStorage)
+		copyOptionalQuantity(container.Resources.Limits, v1.ResourceEphemeralStorage, &spec.Pod.EphemeralStorageLimit)
+		if container.LivenessProbe != nil {
+			spec.Pod.LivenessProbe = probeFor(container.LivenessProbe)
+		}
+		if container.ReadinessProbe != nil {
+			spec.Pod.ReadinessProbe = probeFor(container.ReadinessProbe)
+		}
+		copyEnv(container.Env, &spec.Pod.Env)
+		copyVolumeMounts(container.VolumeMounts, &spec.Pod.ExtraVolumeMounts, operatorMountPaths...)
+	}
+
+	copyContainers(podSpec.Containers, &spec.Pod.Sidecars, cassandraContainerName)
+	copyContainers(podSpec.InitContainers, &spec.Pod.InitContainers, initConfigContainerName, cassandraBootstrapperContainerName)
+	copyVolumes(podSpec.Volumes, &spec.Pod.ExtraVolumes, operatorVolumeNames(c.definition)...)
+
+	copyEntries(statefulSet.Spec.Template.Labels, &spec.Pod.Labels, operatorPodLabels...)
+	copyEntries(statefulSet.Spec.Template.Annotations, &spec.Pod.Annotations, ConfigHashAnnotation, RestartedAtAnnotation)
+
+	if container := findContainer(initConfigContainerName, podSpec.InitContainers); container != nil {
+		copyResources(container.Resources, InitConfigResources(&spec.Pod), &spec.Pod.InitConfigResources)
+	}
+
+	if container := findContainer(cassandraBootstrapperContainerName, podSpec.InitContainers); container != nil {
+		spec.Pod.BootstrapperImage = container.Image
+		copyResources(container.Resources, BootstrapperResources(&spec.Pod), &spec.Pod.BootstrapperResources)
+		for _, env := range container.Env {
+			if env.Name == "CLUSTER_DATA_CENTER" {
+				spec.DC = env.Value
+			}
+		}
+	}
+
+	spec.UseEmptyDir = len(statefulSet.Spec.VolumeClaimTemplates) == 0
+	if !spec.UseEmptyDir {
+		copyQuantity(statefulSet.Spec.VolumeClaimTemplates[0].Spec.Resources.Requests, v1.ResourceStorage, &spec.Pod.StorageSize)
+	}
+}
+
+func copyQuantity(resources v1.ResourceList, name v1.ResourceName, quantity *resource.Quantity) {
+	if value, ok := resources[name]; ok && value.Cmp(*quantity) != 0 {
+		*quantity = value
+	}
+}
+
+// copyOptionalQuantity is like copyQuantity, but also unsets the quantity when it isn't in the resources
+func copyOptionalQuantity(resources v1.ResourceList, name v1.ResourceName, quantity **resource.Quantity) {
+	value, ok := resources[name]
+	switch {
+	case !ok:
+		*quantity = nil
+	case *quantity == nil || value.Cmp(**quantity) != 0:
+		*quantity = &value
+	}
+}
+
+// copyResources overwrites the resources of an init container in the spec with the live ones, when they differ
+// from the resources the spec gives to the container
+func copyResources(liveResources, resources v1.ResourceRequirements, specResources **v1.ResourceRequirements) {
+	if !equality.Semantic.DeepEqual(liveResources, resources) {
+		*specResources = liveResources.DeepCopy()
+	}
+}
+
+// copyEntries overwrites the labels or annotations of the spec with the ones of the stateful set, leaving aside the
+// entries set by the operator. They are only overwritten when they differ, so that no entries and an empty map are the same.
+func copyEntries(liveEntries map[string]string, entries *map[string]string, operatorKeys ...string) {
+	customEntries := map[string]string{}
+	for key, value := range liveEntries {
+		customEntries[key] = value
+	}
+	for _, key := range operatorKeys {
+		delete(customEntries, key)
+	}
+
+	if len(customEntries) == 0 && len(*entries) == 0 {
+		return
+	}
+	if !reflect.DeepEqual(customEntries, *entries) {
+		*entries = customEntries
+	}
+}
+
+// copyEnv overwrites the environment variables of the spec with the ones of the cassandra container, leaving aside the ones
+// set by the operator. Fields defaulted by Kubernetes, such as the api version of a field reference, are ignored.
+func copyEnv(liveEnv []v1.EnvVar, env *[]v1.EnvVar) {
+	var customEnv []v1.EnvVar
+	for _, envVar := range liveEnv {
+		if envVar.Name != extraClasspathEnvVar {
+			customEnv = append(customEnv, envVar)
+		}
+	}
+
+	if !sameItems(*env, customEnv, len(*env), len(customEnv)) {
+		*env = customEnv
+	}
+}
+
+// copyContainers overwrites the containers of the spec with the ones of the pod template, leaving aside the ones of the operator
+func copyContainers(liveContainers []v1.Container, containers *[]v1.Container, operatorContainerNames ...string) {
+	var customContainers []v1.Container
+	for _, container := range liveContainers {
+		if !containsString(operatorContainerNames, container.Name) {
+			customContainers = append(customContainers, container)
+		}
+	}
+
+	if !sameItems(*containers, customContainers, len(*containers), len(customContainers)) {
+		*containers = customContainers
+	}
+}
+
+// copyVolumes overwrites the extra volumes of the spec with the ones of the pod template, leaving aside the ones of the operator
+func copyVolumes(liveVolumes []v1.Volume, volumes *[]v1.Volume, operatorVolumeNames ...string) {
+	var customVolumes []v1.Volume
+	for _, volume := range liveVolumes {
+		if !containsString(operatorVolumeNames, volume.Name) {
+			customVolumes = append(customVolumes, volume)
+		}
+	}
+
+	if !sameItems(*volumes, customVolumes, len(*volumes), len(customVolumes)) {
+		*volumes = customVolumes
+	}
+}
+
+// copyVolumeMounts overwrites the extra volume mounts of the spec with the ones of the cassandra container,
+// leaving aside the ones of the operator
+func copyVolumeMounts(liveMounts []v1.VolumeMount, mounts *[]v1.VolumeMount, operatorMountPaths ...string) {
+	var customMounts []v1.VolumeMount
+	for _, mount := range liveMounts {
+		if !containsString(operatorMountPaths, mount.MountPath) {
+			customMounts = append(customMounts, mount)
+		}
+	}
+
+	if !sameItems(*mounts, customMounts, len(*mounts), len(customMounts)) {
+		*mounts = customMounts
+	}
+}
+
+// sameItems reports whether the live items are the defined ones, ignoring the fields defaulted by Kubernetes
+func sameItems(definedItems, liveItems interface{}, definedCount, liveCount int) bool {
+	return definedCount == liveCount && (definedCount == 0 || equality.Semantic.DeepDerivative(definedItems, liveItems))
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func probeFor(probe *v1.Probe) *v1alpha1.Probe {
+	return &v1alpha1.Probe{
+		FailureThreshold:    probe.FailureThreshold,
+		InitialDelaySeconds: probe.InitialDelaySeconds,
+		PeriodSeconds:       probe.PeriodSeconds,
+		SuccessThreshold:    probe.SuccessThreshold,
+		TimeoutSeconds:      probe.TimeoutSeconds,
+	}
+}
+
+func findContainer(name string, containers []v1.Container) *v1.Container {
+	for i := range containers {
+		if containers[i].Name == name {
+			return &containers[i]
+		}
+	}
+	return nil
+}
+
+func isDefinedRack(name string, racks []v1alpha1.Rack) bool {
+	for _, rack := range racks {
+		if rack.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateStatefulSetForRack creates the stateful set which runs the nodes of the supplied rack
+func (c *Cluster) CreateStatefulSetForRack(rack *v1alpha1.Rack, customConfigMap *v1.ConfigMap) *appsv1.StatefulSet {
+	scheduling := SchedulingForRack(&c.definition.Spec.Pod, rack)
 	sts := &appsv1.StatefulSet{
 		ObjectMeta: c.objectMetadata(c.definition.RackName(rack), RackLabel, rack.Name),
 		Spec: appsv1.StatefulSetSpec{
@@ -307,62 +832,161 @@ func (c *Cluster) createStatefulSetForRack(rack *v1alpha1.Rack, customConfigMap
 			ServiceName: c.definition.Name,
 			Template: v1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: map[string]string{
-						OperatorLabel: c.definition.Name,
-						RackLabel:     rack.Name,
-						"app":         c.definition.Name,
-					},
+					Labels:      c.createPodLabels(rack),
+					Annotations: c.createPodAnnotations(customConfigMap),
 				},
 				Spec: v1.PodSpec{
 					ServiceAccountName: v1alpha1.NodeServiceAccountName,
-					InitContainers: []v1.Container{
+					InitContainers: append([]v1.Container{
 						c.createInitConfigContainer(),
 						c.createCassandraBootstrapperContainer(rack, customConfigMap),
-					},
-					Containers: []v1.Container{
+					}, c.definition.Spec.Pod.InitContainers...),
+					Containers: append([]v1.Container{
 						c.createCassandraContainer(rack, customConfigMap),
-					},
-					Volumes: c.createPodVolumes(customConfigMap),
-					Affinity: &v1.Affinity{
-						PodAntiAffinity: &v1.PodAntiAffinity{
-							RequiredDuringSchedulingIgnoredDuringExecution: []v1.PodAffinityTerm{
-								{
-									LabelSelector: &metav1.LabelSelector{
-										MatchLabels: map[string]string{
-											OperatorLabel: c.definition.Name,
-										},
-									},
-									TopologyKey: "kubernetes.io/hostname",
-								},
-							},
-						},
-						NodeAffinity: &v1.NodeAffinity{
-							RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{
-								NodeSelectorTerms: []v1.NodeSelectorTerm{
-									{
-										MatchExpressions: []v1.NodeSelectorRequirement{
-											{
-												Key:      "failure-domain.beta.kubernetes.io/zone",
-												Operator: v1.NodeSelectorOpIn,
-												Values:   []string{rack.Zone},
-											},
-										},
-									},
-								},
-							},
-						},
-					},
+					}, c.definition.Spec.Pod.Sidecars...),
+					Volumes:           c.createPodVolumes(customConfigMap),
+					Affinity:          Affinity(c.definition.Name, rack, scheduling),
+					Tolerations:       scheduling.Tolerations,
+					NodeSelector:      scheduling.NodeSelector,
+					PriorityClassName: scheduling.PriorityClassName,
 				},
 			},
 			VolumeClaimTemplates: c.createCassandraDataPersistentVolumeClaimForRack(rack),
 		},
 	}
 
+	return sts
+}
+
+func (c *Cluster) createPodLabels(rack *v1alpha1.Rack) map[string]string {
+	labels := map[string]string{}
+	for key, value := range c.definition.Spec.Pod.Labels {
+		labels[key] = value
+	}
+	labels[OperatorLabel] = c.definition.Name
+	labels[RackLabel] = rack.Name
+	labels[appLabel] = c.definition.Name
+	return labels
+}
+
+// createPodAnnotations returns no annotations unless some are defined for the pods, a custom config map is used
+// or a restart of the cluster has been requested
+func (c *Cluster) createPodAnnotations(customConfigMap *v1.ConfigMap) map[string]string {
+	restartedAt := c.RestartedAt()
+	if len(c.definition.Spec.Pod.Annotations) == 0 && customConfigMap == nil && restartedAt == "" {
+		return nil
+	}
+
+	annotations := map[string]string{}
+	for key, value := range c.definition.Spec.Pod.Annotations {
+		annotations[key] = value
+	}
 	if customConfigMap != nil {
-		sts.Spec.Template.Annotations = map[string]string{ConfigHashAnnotation: hash.ConfigMapHash(customConfigMap)}
+		annotations[ConfigHashAnnotation] = hash.ConfigMapHash(customConfigMap)
+	}
+	if restartedAt != "" {
+		annotations[RestartedAtAnnotation] = restartedAt
+	}
+	return annotations
+}
+
+// RestartedAt returns the value of the annotation requesting a restart of the cluster, or an empty string when none was requested
+func (c *Cluster) RestartedAt() string {
+	return c.definition.Annotations[RestartedAtAnnotation]
+}
+
+// RepairRequestedAt returns the value of the annotation requesting a repair of the cluster, or an empty string when none was requested
+func (c *Cluster) RepairRequestedAt() string {
+	return c.definition.Annotations[RepairRequestedAtAnnotation]
+}
+
+// SchedulingForRack combines the scheduling of the supplied pod with the one of the supplied rack, with defaults applied
+func SchedulingForRack(pod *v1alpha1.Pod, rack *v1alpha1.Rack) *v1alpha1.Scheduling {
+	clusterScheduling := &pod.Scheduling
+	scheduling := &v1alpha1.Scheduling{
+		PriorityClassName: clusterScheduling.PriorityClassName,
+		AntiAffinity:      &v1alpha1.AntiAffinity{Mode: v1alpha1.AntiAffinityRequired, TopologyKey: hostnameLabel},
+		ZoneTopologyKey:   zoneLabel,
 	}
 
-	return sts
+	for _, tolerations := range [][]v1.Toleration{clusterScheduling.Tolerations, rack.Tolerations} {
+		for _, toleration := range tolerations {
+			scheduling.Tolerations = append(scheduling.Tolerations, *toleration.DeepCopy())
+		}
+	}
+
+	for _, nodeSelector := range []map[string]string{clusterScheduling.NodeSelector, rack.NodeSelector} {
+		for label, value := range nodeSelector {
+			if scheduling.NodeSelector == nil {
+				scheduling.NodeSelector = map[string]string{}
+			}
+			scheduling.NodeSelector[label] = value
+		}
+	}
+
+	if rack.PriorityClassName != "" {
+		scheduling.PriorityClassName = rack.PriorityClassName
+	}
+
+	for _, antiAffinity := range []*v1alpha1.AntiAffinity{clusterScheduling.AntiAffinity, rack.AntiAffinity} {
+		if antiAffinity == nil {
+			continue
+		}
+		if antiAffinity.Mode != "" {
+			scheduling.AntiAffinity.Mode = antiAffinity.Mode
+		}
+		if antiAffinity.TopologyKey != "" {
+			scheduling.AntiAffinity.TopologyKey = antiAffinity.TopologyKey
+		}
+	}
+
+	for _, zoneTopologyKey := range []string{clusterScheduling.ZoneTopologyKey, rack.ZoneTopologyKey} {
+		if zoneTopologyKey != "" {
+			scheduling.ZoneTopologyKey = zoneTopologyKey
+		}
+	}
+	return scheduling
+}
+
+// Affinity returns the affinity of the pods of the supplied rack, which keeps them in the zone of the rack
+// and apart from the other pods of the cluster as the scheduling requires
+func Affinity(clusterName string, rack *v1alpha1.Rack, scheduling *v1alpha1.Scheduling) *v1.Affinity {
+	antiAffinityTerm := v1.PodAffinityTerm{
+		LabelSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{
+				OperatorLabel: clusterName,
+			},
+		},
+		TopologyKey: scheduling.AntiAffinity.TopologyKey,
+	}
+
+	podAntiAffinity := &v1.PodAntiAffinity{}
+	if scheduling.AntiAffinity.Mode == v1alpha1.AntiAffinityPreferred {
+		podAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution = []v1.WeightedPodAffinityTerm{
+			{Weight: preferredAntiAffinityWeight, PodAffinityTerm: antiAffinityTerm},
+		}
+	} else {
+		podAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution = []v1.PodAffinityTerm{antiAffinityTerm}
+	}
+
+	return &v1.Affinity{
+		PodAntiAffinity: podAntiAffinity,
+		NodeAffinity: &v1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{
+				NodeSelectorTerms: []v1.NodeSelectorTerm{
+					{
+						MatchExpressions: []v1.NodeSelectorRequirement{
+							{
+								Key:      scheduling.ZoneTopologyKey,
+								Operator: v1.NodeSelectorOpIn,
+								Values:   []string{rack.Zone},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
 }
 
 // CreateService creates a headless service for the supplied cluster definition.
@@ -463,6 +1087,75 @@ func (c *Cluster) CreateSnapshotCleanupContainer(snapshot *v1alpha1.Snapshot) *v
 	}
 }
 
+// CreateRepairJob creates a cronjob to trigger the repair of the cluster data
+func (c *Cluster) CreateRepairJob() *v1beta1.CronJob {
+	if c.definition.Spec.Repair == nil {
+		return nil
+	}
+
+	return c.createCronJob(
+		c.definition.RepairJobName(),
+		v1alpha1.SnapshotServiceAccountName,
+		c.definition.Spec.Repair.Schedule,
+		c.CreateRepairContainer(c.definition.Spec.Repair),
+	)
+}
+
+// CreateRepairContainer creates the container that will execute the repair command
+func (c *Cluster) CreateRepairContainer(repair *v1alpha1.Repair) *v1.Container {
+	repairCommand := []string{"/cassandra-snapshot", "repair",
+		"-n", c.Namespace(),
+		"-l", fmt.Sprintf("%s=%s,%s=%s", OperatorLabel, c.Name(), "app", c.Name()),
+	}
+	if repair.TimeoutSeconds != nil {
+		timeoutDuration := durationSeconds(repair.TimeoutSeconds)
+		repairCommand = append(repairCommand, "-t", timeoutDuration.String())
+	}
+	if repair.Parallelism != "" {
+		repairCommand = append(repairCommand, "-p", string(repair.Parallelism))
+	}
+	if repair.Subranges != nil {
+		repairCommand = append(repairCommand, "-s", strconv.Itoa(int(*repair.Subranges)))
+	}
+	if len(repair.Keyspaces) > 0 {
+		repairCommand = append(repairCommand, "-k")
+		repairCommand = append(repairCommand, strings.Join(repair.Keyspaces, ","))
+	}
+
+	return &v1.Container{
+		Name:    c.definition.RepairJobName(),
+		Image:   repair.Image,
+		Command: repairCommand,
+	}
+}
+
+// CreateOnDemandJob creates a job from the template of the given cron job, as requested at the given time.
+// The job is named after the request so that it is only run once, and is removed along with the cron job.
+func CreateOnDemandJob(cronJob *v1beta1.CronJob, requestedAt string) *batchv1.Job {
+	template := cronJob.Spec.JobTemplate.DeepCopy()
+	annotations := map[string]string{"cronjob.kubernetes.io/instantiate": "manual", RepairRequestedAtAnnotation: requestedAt}
+	for name, value := range template.Annotations {
+		annotations[name] = value
+	}
+	isController := true
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        fmt.Sprintf("%s-%s", cronJob.Name, fmt.Sprintf("%x", sha256.Sum256([]byte(requestedAt)))[:10]),
+			Namespace:   cronJob.Namespace,
+			Labels:      template.Labels,
+			Annotations: annotations,
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: "batch/v1beta1",
+				Kind:       "CronJob",
+				Name:       cronJob.Name,
+				UID:        cronJob.UID,
+				Controller: &isController,
+			}},
+		},
+		Spec: template.Spec,
+	}
+}
+
 func (c *Cluster) createCronJob(objectName, serviceAccountName, schedule string, container *v1.Container) *v1beta1.CronJob {
 	return &v1beta1.CronJob{
 		ObjectMeta: c.objectMetadata(objectName, "app", objectName),
@@ -531,7 +1224,7 @@ func (c *Cluster) createCassandraContainer(rack *v1alpha1.Rack, customConfigMap
 				ContainerPort: 9042,
 			},
 		},
-		Resources:      c.createResourceRequirements(),
+		Resources:      CassandraResources(&c.definition.Spec.Pod),
 		LivenessProbe:  createProbe(c.definition.Spec.Pod.LivenessProbe, "/bin/sh", "-c", "nodetool info"),
 		ReadinessProbe: createProbe(c.definition.Spec.Pod.ReadinessProbe, "/bin/sh", "-c", "nodetool status | grep -E \"^UN\\s+${NODE_LISTEN_ADDRESS}\""),
 		Lifecycle: &v1.Lifecycle{
@@ -541,12 +1234,14 @@ func (c *Cluster) createCassandraContainer(rack *v1alpha1.Rack, customConfigMap
 				},
 			},
 		},
-		Env:          []v1.EnvVar{{Name: "EXTRA_CLASSPATH", Value: "/extra-lib/cassandra-seed-provider.jar"}},
+		Env:          append([]v1.EnvVar{{Name: extraClasspathEnvVar, Value: "/extra-lib/cassandra-seed-provider.jar"}}, c.definition.Spec.Pod.Env...),
 		VolumeMounts: c.createVolumeMounts(customConfigMap),
 	}
 }
 
 func (c *Cluster) createEnvironmentVariableDefinition(rack *v1alpha1.Rack) []v1.EnvVar {
+	// the node replacements config map only exists while a node is being replaced
+	optional := true
 	envVariables := []v1.EnvVar{
 		{
 			Name:  "CLUSTER_NAMESPACE",
@@ -580,11 +1275,93 @@ func (c *Cluster) createEnvironmentVariableDefinition(rack *v1alpha1.Rack) []v1.
 			Name:  "POD_MEMORY_BYTES",
 			Value: fmt.Sprintf("%d", c.definition.Spec.Pod.Memory.Value()),
 		},
+		{
+			Name: "POD_NAME",
+			ValueFrom: &v1.EnvVarSource{
+				FieldRef: &v1.ObjectFieldSelector{
+					FieldPath: "metadata.name",
+				},
+			},
+		},
+		{
+			Name: "NODE_REPLACEMENTS",
+			ValueFrom: &v1.EnvVarSource{
+				ConfigMapKeyRef: &v1.ConfigMapKeySelector{
+					LocalObjectReference: v1.LocalObjectReference{Name: c.definition.NodeReplacementsConfigMapName()},
+					Key:                  nodeReplacementsKey,
+					Optional:             &optional,
+				},
+			},
+		},
 	}
 
 	return envVariables
 }
 
+// SupportsNodeReplacement reports whether the bootstrapper of the pods created from the supplied template is given
+// the node replacements, which is not the case of pod templates created by previous versions of the operator
+func SupportsNodeReplacement(template *v1.PodTemplateSpec) bool {
+	for _, container := range template.Spec.InitContainers {
+		if container.Name == cassandraBootstrapperContainerName {
+			for _, env := range container.Env {
+				if env.Name == "NODE_REPLACEMENTS" {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// RemoveNodeReplacementEnv removes the environment variables which give the node replacements to the bootstrapper
+// from the supplied pod spec
+func RemoveNodeReplacementEnv(podSpec *v1.PodSpec) {
+	for i := range podSpec.InitContainers {
+		container := &podSpec.InitContainers[i]
+		if container.Name != cassandraBootstrapperContainerName {
+			continue
+		}
+		var env []v1.EnvVar
+		for _, envVar := range container.Env {
+			if !containsString(nodeReplacementEnvVars, envVar.Name) {
+				env = append(env, envVar)
+			}
+		}
+		container.Env = env
+	}
+}
+
+// CreateNodeReplacementsConfigMap creates the config map holding the addresses of the dead nodes replaced by the nodes
+// of the cluster, keyed by the name of the pod of the replacing node. The config map is not labelled with the name of the
+// cluster, as the operator only watches the labelled config maps which hold a custom configuration.
+func (c *Cluster) CreateNodeReplacementsConfigMap(replacements map[string]string) *v1.ConfigMap {
+	var entries []string
+	for podName, address := range replacements {
+		entries = append(entries, fmt.Sprintf("%s=%s", podName, address))
+	}
+	sort.Strings(entries)
+
+	return &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      c.definition.NodeReplacementsConfigMapName(),
+			Namespace: c.Namespace(),
+		},
+		Data: map[string]string{nodeReplacementsKey: strings.Join(entries, ",")},
+	}
+}
+
+// NodeReplacements returns the addresses of the dead nodes held in the supplied node replacements config map,
+// keyed by the name of the pod of the replacing node
+func NodeReplacements(configMap *v1.ConfigMap) map[string]string {
+	replacements := map[string]string{}
+	for _, entry := range strings.Split(configMap.Data[nodeReplacementsKey], ",") {
+		if parts := strings.SplitN(entry, "=", 2); len(parts) == 2 {
+			replacements[parts[0]] = parts[1]
+		}
+	}
+	return replacements
+}
+
 func (c *Cluster) createCassandraDataPersistentVolumeClaimForRack(rack *v1alpha1.Rack) []v1.PersistentVolumeClaim {
 	var persistentVolumeClaim []v1.PersistentVolumeClaim
 
@@ -613,7 +1390,7 @@ func (c *Cluster) createVolumeMounts(customConfigMap *v1.ConfigMap) []v1.VolumeM
 		{Name: extraLibVolumeName, MountPath: extraLibVolumeMountPath},
 	}
 
-	return mounts
+	return append(mounts, c.definition.Spec.Pod.ExtraVolumeMounts...)
 }
 
 func (c *Cluster) createCustomConfigVolumeMount() v1.VolumeMount {
@@ -637,7 +1414,7 @@ func (c *Cluster) createPodVolumes(customConfigMap *v1.ConfigMap) []v1.Volume {
 		volumes = append(volumes, emptyDir(c.definition.StorageVolumeName()))
 	}
 
-	return volumes
+	return append(volumes, c.definition.Spec.Pod.ExtraVolumes...)
 }
 
 func emptyDir(name string) v1.Volume {
@@ -675,8 +1452,13 @@ func createProbe(probe *v1alpha1.Probe, command ...string) *v1.Probe {
 	}
 }
 
-// AddCustomConfigVolumeToStatefulSet updates the provided statefulset to mount the configmap as a volume
+// AddCustomConfigVolumeToStatefulSet updates the provided statefulset to mount the configmap as a volume.
+// A configmap volume already present in the statefulset is replaced.
 func (c *Cluster) AddCustomConfigVolumeToStatefulSet(statefulSet *appsv1.StatefulSet, customConfigMap *v1.ConfigMap) error {
+	if err := c.RemoveCustomConfigVolumeFromStatefulSet(statefulSet, customConfigMap); err != nil {
+		return err
+	}
+
 	if statefulSet.Spec.Template.Annotations == nil {
 		statefulSet.Spec.Template.Annotations = map[string]string{}
 	}
@@ -718,17 +1500,21 @@ func (c *Cluster) RemoveCustomConfigVolumeFromStatefulSet(statefulSet *appsv1.St
 }
 
 func (c *Cluster) customConfigMapVolumeName() string {
-	return fmt.Sprintf("cassandra-custom-config-%s", c.definition.Name)
+	return customConfigMapVolumeName(c.definition)
+}
+
+func customConfigMapVolumeName(clusterDefinition *v1alpha1.Cassandra) string {
+	return fmt.Sprintf("cassandra-custom-config-%s", clusterDefinition.Name)
 }
 func (c *Cluster) createInitConfigContainer() v1.Container {
 	return v1.Container{
-		Name:    "init-config",
+		Name:    initConfigContainerName,
 		Image:   c.definition.Spec.Pod.Image,
 		Command: []string{"sh", "-c", "cp -vr /etc/cassandra/* /configuration"},
 		VolumeMounts: []v1.VolumeMount{
 			{Name: "configuration", MountPath: "/configuration"},
 		},
-		Resources: c.createResourceRequirements(),
+		Resources: InitConfigResources(&c.definition.Spec.Pod),
 	}
 }
 func (c *Cluster) createCassandraBootstrapperContainer(rack *v1alpha1.Rack, customConfigMap *v1.ConfigMap) v1.Container {
@@ -745,19 +1531,50 @@ func (c *Cluster) createCassandraBootstrapperContainer(rack *v1alpha1.Rack, cust
 		Name:         cassandraBootstrapperContainerName,
 		Env:          c.createEnvironmentVariableDefinition(rack),
 		Image:        c.definition.Spec.Pod.BootstrapperImage,
-		Resources:    c.createResourceRequirements(),
+		Resources:    BootstrapperResources(&c.definition.Spec.Pod),
 		VolumeMounts: mounts,
 	}
 }
 
-func (c *Cluster) createResourceRequirements() v1.ResourceRequirements {
+// CassandraResources returns the resources of the cassandra container of the supplied pod
+func CassandraResources(pod *v1alpha1.Pod) v1.ResourceRequirements {
+	resources := defaultResources(pod)
+	if pod.CPULimit != nil {
+		resources.Limits[v1.ResourceCPU] = *pod.CPULimit
+	}
+	if pod.EphemeralStorage != nil {
+		resources.Requests[v1.ResourceEphemeralStorage] = *pod.EphemeralStorage
+	}
+	if pod.EphemeralStorageLimit != nil {
+		resources.Limits[v1.ResourceEphemeralStorage] = *pod.EphemeralStorageLimit
+	}
+	return resources
+}
+
+// BootstrapperResources returns the resources of the cassandra-bootstrapper init container of the supplied pod
+func BootstrapperResources(pod *v1alpha1.Pod) v1.ResourceRequirements {
+	if pod.BootstrapperResources != nil {
+		return *pod.BootstrapperResources.DeepCopy()
+	}
+	return defaultResources(pod)
+}
+
+// InitConfigResources returns the resources of the init-config init container of the supplied pod
+func InitConfigResources(pod *v1alpha1.Pod) v1.ResourceRequirements {
+	if pod.InitConfigResources != nil {
+		return *pod.InitConfigResources.DeepCopy()
+	}
+	return defaultResources(pod)
+}
+
+func defaultResources(pod *v1alpha1.Pod) v1.ResourceRequirements {
 	return v1.ResourceRequirements{
 		Requests: v1.ResourceList{
-			v1.ResourceCPU:    c.definition.Spec.Pod.CPU,
-			v1.ResourceMemory: c.definition.Spec.Pod.Memory,
+			v1.ResourceCPU:    pod.CPU,
+			v1.ResourceMemory: pod.Memory,
 		},
 		Limits: v1.ResourceList{
-			v1.ResourceMemory: c.definition.Spec.Pod.Memory,
+			v1.ResourceMemory: pod.Memory,
 		},
 	}
 }
@@ -765,24 +1582,34 @@ func (c *Cluster) createResourceRequirements() v1.ResourceRequirements {
 // ConfigMapBelongsToAManagedCluster determines whether the supplied ConfigMap belongs to a managed cluster
 func ConfigMapBelongsToAManagedCluster(managedClusters map[string]*Cluster, configMap *v1.ConfigMap) bool {
 	for _, mc := range managedClusters {
-		if configMap.Name == mc.definition.CustomConfigMapName() {
+		if mc.IsCustomConfigMap(configMap) {
 			return true
 		}
 	}
 	return false
 }
 
-// LooksLikeACassandraConfigMap determines whether the supplied ConfigMap could belong to a managed cluster
+// IsCustomConfigMap determines whether the supplied ConfigMap is the custom config map of the cluster
+func (c *Cluster) IsCustomConfigMap(configMap *v1.ConfigMap) bool {
+	return configMap.Namespace == c.Namespace() && configMap.Name == c.definition.CustomConfigMapName()
+}
+
+// LooksLikeACassandraConfigMap determines whether the supplied ConfigMap could belong to a managed cluster,
+// either because it is labelled with the name of a cluster or because it follows the naming convention
 func LooksLikeACassandraConfigMap(configMap *v1.ConfigMap) bool {
-	return strings.HasSuffix(configMap.Name, "-config")
+	return configMap.Labels[OperatorLabel] != "" || strings.HasSuffix(configMap.Name, "-config")
 }
 
-// QualifiedClusterNameFor returns the fully qualified name of the cluster that should be associated to the supplied configMap
+// QualifiedClusterNameFor returns the fully qualified name of the cluster that should be associated to the supplied configMap,
+// taken from its label when it has one and from its name otherwise
 func QualifiedClusterNameFor(configMap *v1.ConfigMap) (string, error) {
+	if clusterName := configMap.Labels[OperatorLabel]; clusterName != "" {
+		return fmt.Sprintf("%s.%s", configMap.Namespace, clusterName), nil
+	}
 	if !LooksLikeACassandraConfigMap(configMap) {
 		return "", fmt.Errorf("configMap name %s does not follow the naming convention for a cluster", configMap.Name)
 	}
-	return fmt.Sprintf("%s.%s", configMap.Namespace, strings.Replace(configMap.Name, "-config", "", -1)), nil
+	return fmt.Sprintf("%s.%s", configMap.Namespace, strings.TrimSuffix(configMap.Name, "-config")), nil
 }
 
 func durationDays(days *int32) time.Duration {
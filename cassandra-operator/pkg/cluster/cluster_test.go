@@ -7,6 +7,7 @@ import (
 	"github.com/onsi/gomega/types"
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/apis/cassandra/v1alpha1"
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/test"
+	appsv1 "k8s.io/api/apps/v1beta2"
 	"k8s.io/api/batch/v1beta1"
 	"k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -70,6 +71,23 @@ var _ = Describe("cluster construction", func() {
 			Expect(err).ToNot(HaveOccurred())
 		})
 
+		It("should reject a configuration with a cpu limit less than the cpu", func() {
+			clusterDef.Spec.Pod.CPU = resource.MustParse("1")
+			cpuLimit := resource.MustParse("500m")
+			clusterDef.Spec.Pod.CPULimit = &cpuLimit
+			_, err := ACluster(clusterDef)
+			Expect(err).To(MatchError("invalid cpuLimit 500m, must not be less than cpu 1 for Cassandra cluster definition: mynamespace.mycluster"))
+		})
+
+		It("should reject a configuration with an ephemeral storage limit less than the ephemeral storage", func() {
+			ephemeralStorage := resource.MustParse("2Gi")
+			ephemeralStorageLimit := resource.MustParse("1Gi")
+			clusterDef.Spec.Pod.EphemeralStorage = &ephemeralStorage
+			clusterDef.Spec.Pod.EphemeralStorageLimit = &ephemeralStorageLimit
+			_, err := ACluster(clusterDef)
+			Expect(err).To(MatchError("invalid ephemeralStorageLimit 1Gi, must not be less than ephemeralStorage 2Gi for Cassandra cluster definition: mynamespace.mycluster"))
+		})
+
 		It("should use the 3.11 version of the apache cassandra image if one is not supplied for the cluster", func() {
 			cluster, err := ACluster(clusterDef)
 			Expect(err).ToNot(HaveOccurred())
@@ -372,6 +390,177 @@ var _ = Describe("cluster construction", func() {
 			})
 
 		})
+
+		Context("repair config", func() {
+			BeforeEach(func() {
+				clusterDef.Spec.Repair = &v1alpha1.Repair{Schedule: "0 2 * * *"}
+			})
+
+			It("should be rejected when schedule is not provided", func() {
+				clusterDef.Spec.Repair.Schedule = ""
+				_, err := ACluster(clusterDef)
+				Expect(err).To(MatchError("no repair schedule property provided for Cassandra cluster definition: mynamespace.mycluster"))
+			})
+
+			It("should be rejected when schedule is not a cron expression", func() {
+				clusterDef.Spec.Repair.Schedule = "1 2 3 x"
+				_, err := ACluster(clusterDef)
+				Expect(err).To(MatchError("invalid repair schedule, must be a cron expression but got '1 2 3 x' for Cassandra cluster definition: mynamespace.mycluster"))
+			})
+
+			It("should be rejected when parallelism is unknown", func() {
+				clusterDef.Spec.Repair.Parallelism = "all-at-once"
+				_, err := ACluster(clusterDef)
+				Expect(err).To(MatchError("invalid repair parallelism 'all-at-once', must be one of sequential, parallel or dc-parallel for Cassandra cluster definition: mynamespace.mycluster"))
+			})
+
+			It("should be rejected when subranges is not greater than zero", func() {
+				subranges := int32(0)
+				clusterDef.Spec.Repair.Keyspaces = []string{"keyspace1"}
+				clusterDef.Spec.Repair.Subranges = &subranges
+				_, err := ACluster(clusterDef)
+				Expect(err).To(MatchError("invalid repair subranges value 0, must be greater than zero for Cassandra cluster definition: mynamespace.mycluster"))
+			})
+
+			It("should be rejected when subranges are given without keyspaces", func() {
+				subranges := int32(4)
+				clusterDef.Spec.Repair.Subranges = &subranges
+				_, err := ACluster(clusterDef)
+				Expect(err).To(MatchError("repair subranges require the keyspaces to repair for Cassandra cluster definition: mynamespace.mycluster"))
+			})
+
+			It("should be rejected when repair timeout value is negative", func() {
+				timeoutSeconds := int32(-1)
+				clusterDef.Spec.Repair.TimeoutSeconds = &timeoutSeconds
+				_, err := ACluster(clusterDef)
+				Expect(err).To(MatchError("invalid repair timeoutSeconds value -1, must be non-negative for Cassandra cluster definition: mynamespace.mycluster"))
+			})
+
+			It("should use the latest version of the cassandra snapshot image if one is not supplied for the cluster", func() {
+				cluster, err := ACluster(clusterDef)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cluster.definition.Spec.Repair.Image).To(Equal("skyuk/cassandra-snapshot:latest"))
+			})
+		})
+
+		Context("autoCleanup config", func() {
+			It("should be rejected when the number of jobs is not positive", func() {
+				jobs := int32(0)
+				clusterDef.Spec.AutoCleanup = &v1alpha1.AutoCleanup{Enabled: true, Jobs: &jobs}
+				_, err := ACluster(clusterDef)
+				Expect(err).To(MatchError("invalid autoCleanup jobs value 0, must be greater than zero for Cassandra cluster definition: mynamespace.mycluster"))
+			})
+		})
+
+		Context("storage config", func() {
+			It("should accept a Delete reclaim policy", func() {
+				clusterDef.Spec.Storage = &v1alpha1.Storage{ReclaimPolicy: v1alpha1.StorageReclaimDelete}
+				_, err := ACluster(clusterDef)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("should be rejected when the reclaim policy is unknown", func() {
+				clusterDef.Spec.Storage = &v1alpha1.Storage{ReclaimPolicy: "Recycle"}
+				_, err := ACluster(clusterDef)
+				Expect(err).To(MatchError("invalid storage reclaimPolicy 'Recycle', must be one of Retain or Delete for Cassandra cluster definition: mynamespace.mycluster"))
+			})
+		})
+
+		Context("scheduling config", func() {
+			It("should be rejected when the anti-affinity mode of a rack is unknown", func() {
+				clusterDef.Spec.Racks[1].AntiAffinity = &v1alpha1.AntiAffinity{Mode: "Sometimes"}
+				_, err := ACluster(clusterDef)
+				Expect(err).To(MatchError("invalid antiAffinity mode 'Sometimes' for rack 'b', must be one of Required or Preferred for Cassandra cluster definition: mynamespace.mycluster"))
+			})
+		})
+
+		Context("pod metadata config", func() {
+			It("should be rejected when a pod label set by the operator is overridden", func() {
+				clusterDef.Spec.Pod.Labels = map[string]string{"rack": "other-rack"}
+				_, err := ACluster(clusterDef)
+				Expect(err).To(MatchError("pod label 'rack' is set by the operator and cannot be overridden for Cassandra cluster definition: mynamespace.mycluster"))
+			})
+
+			It("should be rejected when the config hash annotation is overridden", func() {
+				clusterDef.Spec.Pod.Annotations = map[string]string{ConfigHashAnnotation: "some-hash"}
+				_, err := ACluster(clusterDef)
+				Expect(err).To(MatchError("pod annotation 'clusterConfigHash' is set by the operator and cannot be overridden for Cassandra cluster definition: mynamespace.mycluster"))
+			})
+
+			It("should be rejected when the restartedAt annotation is set on the pods", func() {
+				clusterDef.Spec.Pod.Annotations = map[string]string{RestartedAtAnnotation: "2019-01-01T00:00:00Z"}
+				_, err := ACluster(clusterDef)
+				Expect(err).To(MatchError("pod annotation 'cassandra.core.sky.uk/restartedAt' is set by the operator and cannot be overridden for Cassandra cluster definition: mynamespace.mycluster"))
+			})
+
+			It("should be rejected when an environment variable set by the operator is overridden", func() {
+				clusterDef.Spec.Pod.Env = []v1.EnvVar{{Name: "EXTRA_CLASSPATH", Value: "/some/jar"}}
+				_, err := ACluster(clusterDef)
+				Expect(err).To(MatchError("pod environment variable 'EXTRA_CLASSPATH' is defined more than once or set by the operator for Cassandra cluster definition: mynamespace.mycluster"))
+			})
+
+			It("should be rejected when an environment variable is defined twice", func() {
+				clusterDef.Spec.Pod.Env = []v1.EnvVar{{Name: "JVM_OPTS", Value: "-Xss256k"}, {Name: "JVM_OPTS", Value: "-Xss512k"}}
+				_, err := ACluster(clusterDef)
+				Expect(err).To(MatchError("pod environment variable 'JVM_OPTS' is defined more than once or set by the operator for Cassandra cluster definition: mynamespace.mycluster"))
+			})
+		})
+
+		Context("pod containers config", func() {
+			It("should be rejected when a sidecar has the name of a container of the operator", func() {
+				clusterDef.Spec.Pod.Sidecars = []v1.Container{{Name: "cassandra", Image: "some-image"}}
+				_, err := ACluster(clusterDef)
+				Expect(err).To(MatchError("pod container 'cassandra' is defined more than once or by the operator for Cassandra cluster definition: mynamespace.mycluster"))
+			})
+
+			It("should be rejected when an init container and a sidecar have the same name", func() {
+				clusterDef.Spec.Pod.InitContainers = []v1.Container{{Name: "backup-agent", Image: "some-image"}}
+				clusterDef.Spec.Pod.Sidecars = []v1.Container{{Name: "backup-agent", Image: "some-image"}}
+				_, err := ACluster(clusterDef)
+				Expect(err).To(MatchError("pod container 'backup-agent' is defined more than once or by the operator for Cassandra cluster definition: mynamespace.mycluster"))
+			})
+
+			It("should be rejected when an extra volume has the name of a volume of the operator", func() {
+				clusterDef.Spec.Pod.ExtraVolumes = []v1.Volume{{Name: "cassandra-storage-mycluster"}}
+				_, err := ACluster(clusterDef)
+				Expect(err).To(MatchError("pod volume 'cassandra-storage-mycluster' is defined more than once or by the operator for Cassandra cluster definition: mynamespace.mycluster"))
+			})
+
+			It("should be rejected when an extra volume is mounted at a path used by the operator", func() {
+				clusterDef.Spec.Pod.ExtraVolumeMounts = []v1.VolumeMount{{Name: "logs", MountPath: "/etc/cassandra"}}
+				_, err := ACluster(clusterDef)
+				Expect(err).To(MatchError("pod volume mount path '/etc/cassandra' is used by the operator for Cassandra cluster definition: mynamespace.mycluster"))
+			})
+		})
+	})
+
+	Context("defaults", func() {
+		It("should set the defaults of all unset properties on the supplied definition", func() {
+			ApplyDefaults(clusterDef)
+			Expect(clusterDef.Spec.DC).To(Equal("dc1"))
+			Expect(clusterDef.Spec.Pod.Image).To(Equal("cassandra:3.11"))
+			Expect(clusterDef.Spec.Pod.BootstrapperImage).To(Equal("skyuk/cassandra-bootstrapper:latest"))
+			Expect(clusterDef.Spec.Snapshot.Image).To(Equal("skyuk/cassandra-snapshot:latest"))
+			Expect(*clusterDef.Spec.Pod.LivenessProbe).To(Equal(defaultLivenessProbe))
+			Expect(*clusterDef.Spec.Pod.ReadinessProbe).To(Equal(defaultReadinessProbe))
+		})
+
+		It("should leave the properties already set unchanged", func() {
+			clusterDef.Spec.DC = "some-dc"
+			clusterDef.Spec.Pod.Image = "somerepo/cassandra:3.11.3"
+			clusterDef.Spec.Pod.ReadinessProbe = &v1alpha1.Probe{TimeoutSeconds: 20}
+			ApplyDefaults(clusterDef)
+			Expect(clusterDef.Spec.DC).To(Equal("some-dc"))
+			Expect(clusterDef.Spec.Pod.Image).To(Equal("somerepo/cassandra:3.11.3"))
+			Expect(clusterDef.Spec.Pod.ReadinessProbe.TimeoutSeconds).To(Equal(int32(20)))
+			Expect(clusterDef.Spec.Pod.ReadinessProbe.PeriodSeconds).To(Equal(defaultReadinessProbe.PeriodSeconds))
+		})
+
+		It("should not modify the definition when validating it", func() {
+			original := clusterDef.DeepCopy()
+			Expect(Validate(clusterDef)).To(Succeed())
+			Expect(clusterDef).To(Equal(original))
+		})
 	})
 })
 
@@ -401,6 +590,36 @@ var _ = Describe("identification of custom config maps", func() {
 		Expect(clusterName).To(Equal("the-namespace.cluster1"))
 	})
 
+	It("should look like a custom configmap when it is labelled with the name of a cluster", func() {
+		configMap := v1.ConfigMap{ObjectMeta: metaV1.ObjectMeta{Name: "custom-cassandra-yaml", Labels: map[string]string{OperatorLabel: "cluster1"}}}
+		Expect(LooksLikeACassandraConfigMap(&configMap)).To(BeTrue())
+	})
+
+	It("should derive the name of a cluster from the label of a config map", func() {
+		configMap := v1.ConfigMap{ObjectMeta: metaV1.ObjectMeta{Name: "custom-cassandra-yaml", Namespace: "the-namespace", Labels: map[string]string{OperatorLabel: "cluster1"}}}
+		clusterName, err := QualifiedClusterNameFor(&configMap)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(clusterName).To(Equal("the-namespace.cluster1"))
+	})
+
+	It("should identify the config map referenced by the cluster definition", func() {
+		c := &Cluster{definition: &v1alpha1.Cassandra{
+			ObjectMeta: metaV1.ObjectMeta{Name: "cluster1", Namespace: "the-namespace"},
+			Spec:       v1alpha1.CassandraSpec{ConfigMapRef: &v1.LocalObjectReference{Name: "custom-cassandra-yaml"}},
+		}}
+
+		Expect(c.IsCustomConfigMap(&v1.ConfigMap{ObjectMeta: metaV1.ObjectMeta{Name: "custom-cassandra-yaml", Namespace: "the-namespace"}})).To(BeTrue())
+		Expect(c.IsCustomConfigMap(&v1.ConfigMap{ObjectMeta: metaV1.ObjectMeta{Name: "cluster1-config", Namespace: "the-namespace"}})).To(BeFalse())
+	})
+
+	It("should not identify a config map in another namespace as the custom config map of a cluster", func() {
+		c := &Cluster{definition: &v1alpha1.Cassandra{ObjectMeta: metaV1.ObjectMeta{Name: "cluster1", Namespace: "the-namespace"}}}
+
+		Expect(c.IsCustomConfigMap(&v1.ConfigMap{ObjectMeta: metaV1.ObjectMeta{Name: "cluster1-config", Namespace: "the-namespace"}})).To(BeTrue())
+		Expect(c.IsCustomConfigMap(&v1.ConfigMap{ObjectMeta: metaV1.ObjectMeta{Name: "cluster1-config", Namespace: "another-namespace"}})).To(BeFalse())
+	})
+
 	It("should fail to derive the name of a cluster from a config map which does not fit the naming convention", func() {
 		configMap := v1.ConfigMap{ObjectMeta: metaV1.ObjectMeta{Name: "cluster1-something-else", Namespace: "the-namespace"}}
 		_, err := QualifiedClusterNameFor(&configMap)
@@ -440,7 +659,7 @@ var _ = Describe("creation of stateful sets", func() {
 		Expect(err).NotTo(HaveOccurred())
 
 		// when
-		statefulSet := cluster.createStatefulSetForRack(&clusterDef.Spec.Racks[0], nil)
+		statefulSet := cluster.CreateStatefulSetForRack(&clusterDef.Spec.Racks[0], nil)
 
 		// then
 		Expect(statefulSet.Spec.Template.Spec.InitContainers).To(HaveLen(2))
@@ -464,7 +683,7 @@ var _ = Describe("creation of stateful sets", func() {
 		cluster, err := ACluster(clusterDef)
 		Expect(err).NotTo(HaveOccurred())
 
-		statefulSet := cluster.createStatefulSetForRack(&clusterDef.Spec.Racks[0], nil)
+		statefulSet := cluster.CreateStatefulSetForRack(&clusterDef.Spec.Racks[0], nil)
 		Expect(statefulSet.Spec.Template.Spec.InitContainers[1].Name).To(Equal("cassandra-bootstrapper"))
 		Expect(statefulSet.Spec.Template.Spec.InitContainers[1].Image).To(Equal("somerepo/abootstapperimage:v1"))
 	})
@@ -473,28 +692,152 @@ var _ = Describe("creation of stateful sets", func() {
 		cluster, err := ACluster(clusterDef)
 		Expect(err).NotTo(HaveOccurred())
 
-		statefulSet := cluster.createStatefulSetForRack(&clusterDef.Spec.Racks[0], nil)
+		statefulSet := cluster.CreateStatefulSetForRack(&clusterDef.Spec.Racks[0], nil)
 		Expect(statefulSet.Spec.Template.Spec.InitContainers).To(HaveLen(2))
 		Expect(statefulSet.Spec.Template.Spec.InitContainers[1].Env).To(ContainElement(v1.EnvVar{Name: "POD_CPU_MILLICORES", Value: "100"}))
 		Expect(statefulSet.Spec.Template.Spec.InitContainers[1].Env).To(ContainElement(v1.EnvVar{Name: "POD_MEMORY_BYTES", Value: strconv.Itoa(1024 * 1024 * 1024)}))
 	})
 
+	It("should pass the node replacements to the bootstrapper init-container", func() {
+		cluster, err := ACluster(clusterDef)
+		Expect(err).NotTo(HaveOccurred())
+
+		statefulSet := cluster.CreateStatefulSetForRack(&clusterDef.Spec.Racks[0], nil)
+		optional := true
+		Expect(statefulSet.Spec.Template.Spec.InitContainers[1].Env).To(ContainElement(v1.EnvVar{
+			Name:      "POD_NAME",
+			ValueFrom: &v1.EnvVarSource{FieldRef: &v1.ObjectFieldSelector{FieldPath: "metadata.name"}},
+		}))
+		Expect(statefulSet.Spec.Template.Spec.InitContainers[1].Env).To(ContainElement(v1.EnvVar{
+			Name: "NODE_REPLACEMENTS",
+			ValueFrom: &v1.EnvVarSource{ConfigMapKeyRef: &v1.ConfigMapKeySelector{
+				LocalObjectReference: v1.LocalObjectReference{Name: "mycluster-node-replacements"},
+				Key:                  "replacements",
+				Optional:             &optional,
+			}},
+		}))
+	})
+
+	It("should read back the node replacements held in the node replacements config map", func() {
+		cluster, err := ACluster(clusterDef)
+		Expect(err).NotTo(HaveOccurred())
+
+		replacements := map[string]string{"mycluster-a-0": "10.0.0.1", "mycluster-b-1": "10.0.0.2"}
+		configMap := cluster.CreateNodeReplacementsConfigMap(replacements)
+
+		Expect(configMap.Name).To(Equal("mycluster-node-replacements"))
+		Expect(configMap.Data).To(Equal(map[string]string{"replacements": "mycluster-a-0=10.0.0.1,mycluster-b-1=10.0.0.2"}))
+		Expect(NodeReplacements(configMap)).To(Equal(replacements))
+		Expect(NodeReplacements(cluster.CreateNodeReplacementsConfigMap(nil))).To(BeEmpty())
+	})
+
 	It("should define environment variable for extra classpath in main container", func() {
 		cluster, err := ACluster(clusterDef)
 		Expect(err).NotTo(HaveOccurred())
 
-		statefulSet := cluster.createStatefulSetForRack(&clusterDef.Spec.Racks[0], nil)
+		statefulSet := cluster.CreateStatefulSetForRack(&clusterDef.Spec.Racks[0], nil)
 		Expect(statefulSet.Spec.Template.Spec.Containers).To(HaveLen(1))
 		Expect(statefulSet.Spec.Template.Spec.Containers[0].Env).To(ContainElement(v1.EnvVar{Name: "EXTRA_CLASSPATH", Value: "/extra-lib/cassandra-seed-provider.jar"}))
 	})
 
+	It("should set the cpu limit and ephemeral storage of the cassandra container only", func() {
+		cpuLimit := resource.MustParse("2")
+		ephemeralStorage := resource.MustParse("1Gi")
+		ephemeralStorageLimit := resource.MustParse("2Gi")
+		clusterDef.Spec.Pod.CPULimit = &cpuLimit
+		clusterDef.Spec.Pod.EphemeralStorage = &ephemeralStorage
+		clusterDef.Spec.Pod.EphemeralStorageLimit = &ephemeralStorageLimit
+		cluster, err := ACluster(clusterDef)
+		Expect(err).NotTo(HaveOccurred())
+
+		statefulSet := cluster.CreateStatefulSetForRack(&clusterDef.Spec.Racks[0], nil)
+		resources := statefulSet.Spec.Template.Spec.Containers[0].Resources
+		Expect(resources.Limits[v1.ResourceCPU]).To(Equal(cpuLimit))
+		Expect(resources.Requests[v1.ResourceEphemeralStorage]).To(Equal(ephemeralStorage))
+		Expect(resources.Limits[v1.ResourceEphemeralStorage]).To(Equal(ephemeralStorageLimit))
+		for _, initContainer := range statefulSet.Spec.Template.Spec.InitContainers {
+			Expect(initContainer.Resources.Limits).NotTo(HaveKey(v1.ResourceCPU))
+			Expect(initContainer.Resources.Requests).NotTo(HaveKey(v1.ResourceEphemeralStorage))
+		}
+	})
+
+	It("should use the resources given to the init containers in place of the ones of the cassandra container", func() {
+		bootstrapperResources := v1.ResourceRequirements{
+			Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("10m"), v1.ResourceMemory: resource.MustParse("64Mi")},
+			Limits:   v1.ResourceList{v1.ResourceMemory: resource.MustParse("64Mi")},
+		}
+		initConfigResources := v1.ResourceRequirements{
+			Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("5m"), v1.ResourceMemory: resource.MustParse("16Mi")},
+		}
+		clusterDef.Spec.Pod.BootstrapperResources = &bootstrapperResources
+		clusterDef.Spec.Pod.InitConfigResources = &initConfigResources
+		cluster, err := ACluster(clusterDef)
+		Expect(err).NotTo(HaveOccurred())
+
+		statefulSet := cluster.CreateStatefulSetForRack(&clusterDef.Spec.Racks[0], nil)
+		Expect(statefulSet.Spec.Template.Spec.InitContainers[0].Resources).To(Equal(initConfigResources))
+		Expect(statefulSet.Spec.Template.Spec.InitContainers[1].Resources).To(Equal(bootstrapperResources))
+		Expect(statefulSet.Spec.Template.Spec.InitContainers[1].Env).To(ContainElement(v1.EnvVar{Name: "POD_MEMORY_BYTES", Value: strconv.Itoa(1024 * 1024 * 1024)}))
+		Expect(*statefulSet.Spec.Template.Spec.Containers[0].Resources.Requests.Memory()).To(Equal(clusterDef.Spec.Pod.Memory))
+	})
+
+	It("should add the restartedAt annotation of the cluster to the pod template", func() {
+		clusterDef.Annotations = map[string]string{RestartedAtAnnotation: "2019-01-01T00:00:00Z"}
+		cluster, err := ACluster(clusterDef)
+		Expect(err).NotTo(HaveOccurred())
+
+		statefulSet := cluster.CreateStatefulSetForRack(&clusterDef.Spec.Racks[0], nil)
+		Expect(statefulSet.Spec.Template.Annotations).To(Equal(map[string]string{RestartedAtAnnotation: "2019-01-01T00:00:00Z"}))
+		Expect(cluster.SpecForStatefulSets([]appsv1.StatefulSet{*statefulSet}).Pod.Annotations).To(BeEmpty())
+	})
+
+	It("should add the pod labels, annotations and environment variables of the cluster definition to the pod template", func() {
+		clusterDef.Spec.Pod.Labels = map[string]string{"team": "data"}
+		clusterDef.Spec.Pod.Annotations = map[string]string{"prometheus.io/scrape": "true"}
+		clusterDef.Spec.Pod.Env = []v1.EnvVar{{Name: "JVM_OPTS", Value: "-Xss256k"}}
+		cluster, err := ACluster(clusterDef)
+		Expect(err).NotTo(HaveOccurred())
+
+		statefulSet := cluster.CreateStatefulSetForRack(&clusterDef.Spec.Racks[0], configMap)
+		Expect(statefulSet.Spec.Template.Labels).To(Equal(map[string]string{"team": "data", OperatorLabel: CLUSTER, RackLabel: "a", "app": CLUSTER}))
+		Expect(statefulSet.Spec.Template.Annotations).To(HaveKeyWithValue("prometheus.io/scrape", "true"))
+		Expect(statefulSet.Spec.Template.Annotations).To(HaveKey(ConfigHashAnnotation))
+		Expect(statefulSet.Spec.Template.Spec.Containers[0].Env).To(Equal([]v1.EnvVar{
+			{Name: "EXTRA_CLASSPATH", Value: "/extra-lib/cassandra-seed-provider.jar"},
+			{Name: "JVM_OPTS", Value: "-Xss256k"},
+		}))
+		Expect(statefulSet.Spec.Selector.MatchLabels).NotTo(HaveKey("team"))
+	})
+
+	It("should add the init containers, sidecars and extra volumes of the cluster definition to the pod template", func() {
+		clusterDef.Spec.Pod.InitContainers = []v1.Container{{Name: "fetch-agent", Image: "agent-fetcher"}}
+		clusterDef.Spec.Pod.Sidecars = []v1.Container{{
+			Name:         "log-shipper",
+			Image:        "log-shipper",
+			VolumeMounts: []v1.VolumeMount{{Name: "logs", MountPath: "/logs"}, {Name: "cassandra-storage-mycluster", MountPath: "/var/lib/cassandra"}},
+		}}
+		clusterDef.Spec.Pod.ExtraVolumes = []v1.Volume{{Name: "logs", VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}}}}
+		clusterDef.Spec.Pod.ExtraVolumeMounts = []v1.VolumeMount{{Name: "logs", MountPath: "/var/log/cassandra"}}
+		cluster, err := ACluster(clusterDef)
+		Expect(err).NotTo(HaveOccurred())
+
+		statefulSet := cluster.CreateStatefulSetForRack(&clusterDef.Spec.Racks[0], nil)
+		Expect(statefulSet.Spec.Template.Spec.InitContainers).To(HaveLen(3))
+		Expect(statefulSet.Spec.Template.Spec.InitContainers[2]).To(Equal(clusterDef.Spec.Pod.InitContainers[0]))
+		Expect(statefulSet.Spec.Template.Spec.Containers).To(HaveLen(2))
+		Expect(statefulSet.Spec.Template.Spec.Containers[0].Name).To(Equal("cassandra"))
+		Expect(statefulSet.Spec.Template.Spec.Containers[1]).To(Equal(clusterDef.Spec.Pod.Sidecars[0]))
+		Expect(statefulSet.Spec.Template.Spec.Volumes).To(ContainElement(clusterDef.Spec.Pod.ExtraVolumes[0]))
+		Expect(statefulSet.Spec.Template.Spec.Containers[0].VolumeMounts).To(ContainElement(clusterDef.Spec.Pod.ExtraVolumeMounts[0]))
+	})
+
 	It("should define emptyDir volumes for configuration and extra libraries", func() {
 		// given
 		cluster, err := ACluster(clusterDef)
 		Expect(err).ToNot(HaveOccurred())
 
 		// when
-		statefulSet := cluster.createStatefulSetForRack(&cluster.Racks()[0], nil)
+		statefulSet := cluster.CreateStatefulSetForRack(&cluster.Racks()[0], nil)
 
 		// then
 		volumes := statefulSet.Spec.Template.Spec.Volumes
@@ -509,7 +852,7 @@ var _ = Describe("creation of stateful sets", func() {
 		Expect(err).ToNot(HaveOccurred())
 
 		// when
-		statefulSet := cluster.createStatefulSetForRack(&cluster.Racks()[0], nil)
+		statefulSet := cluster.CreateStatefulSetForRack(&cluster.Racks()[0], nil)
 
 		// then
 		Expect(statefulSet.Spec.Template.Spec.Volumes).To(haveExactly(0, matchingEmptyDir(fmt.Sprintf("cassandra-storage-%s", clusterDef.Name))))
@@ -527,7 +870,7 @@ var _ = Describe("creation of stateful sets", func() {
 		Expect(err).ToNot(HaveOccurred())
 
 		// when
-		statefulSet := cluster.createStatefulSetForRack(&cluster.Racks()[0], nil)
+		statefulSet := cluster.CreateStatefulSetForRack(&cluster.Racks()[0], nil)
 
 		// then
 		volumes := statefulSet.Spec.Template.Spec.Volumes
@@ -545,7 +888,7 @@ var _ = Describe("creation of stateful sets", func() {
 		Expect(err).ToNot(HaveOccurred())
 
 		// when
-		statefulSet := cluster.createStatefulSetForRack(&cluster.Racks()[0], configMap)
+		statefulSet := cluster.CreateStatefulSetForRack(&cluster.Racks()[0], configMap)
 
 		// then
 		mainContainerVolumeMounts := statefulSet.Spec.Template.Spec.Containers[0].VolumeMounts
@@ -561,7 +904,7 @@ var _ = Describe("creation of stateful sets", func() {
 			Expect(err).ToNot(HaveOccurred())
 
 			// when
-			statefulSet := cluster.createStatefulSetForRack(&cluster.Racks()[0], configMap)
+			statefulSet := cluster.CreateStatefulSetForRack(&cluster.Racks()[0], configMap)
 
 			// then
 			initConfigContainerVolumeMounts := statefulSet.Spec.Template.Spec.InitContainers[0].VolumeMounts
@@ -575,7 +918,7 @@ var _ = Describe("creation of stateful sets", func() {
 			Expect(err).ToNot(HaveOccurred())
 
 			// when
-			statefulSet := cluster.createStatefulSetForRack(&cluster.Racks()[0], configMap)
+			statefulSet := cluster.CreateStatefulSetForRack(&cluster.Racks()[0], configMap)
 
 			// then
 			volumes := statefulSet.Spec.Template.Spec.Volumes
@@ -597,7 +940,7 @@ var _ = Describe("creation of stateful sets", func() {
 			Expect(err).ToNot(HaveOccurred())
 
 			// when
-			statefulSet := cluster.createStatefulSetForRack(&cluster.Racks()[0], nil)
+			statefulSet := cluster.CreateStatefulSetForRack(&cluster.Racks()[0], nil)
 
 			Expect(statefulSet.Spec.Template.Spec.Volumes).To(HaveLen(2))
 
@@ -610,6 +953,90 @@ var _ = Describe("creation of stateful sets", func() {
 	})
 })
 
+var _ = Describe("scheduling of the pods of a rack", func() {
+	var clusterDef *v1alpha1.Cassandra
+	BeforeEach(func() {
+		clusterDef = &v1alpha1.Cassandra{
+			ObjectMeta: metaV1.ObjectMeta{Name: CLUSTER, Namespace: NAMESPACE},
+			Spec: v1alpha1.CassandraSpec{
+				Racks: []v1alpha1.Rack{{Name: "a", Replicas: 1, StorageClass: "some-storage", Zone: "some-zone"}},
+				Pod: v1alpha1.Pod{
+					Memory:      resource.MustParse("1Gi"),
+					CPU:         resource.MustParse("100m"),
+					StorageSize: resource.MustParse("1Gi"),
+				},
+			},
+		}
+	})
+
+	It("should require pods of the cluster to run on different hosts of the zone of the rack by default", func() {
+		cluster, err := ACluster(clusterDef)
+		Expect(err).NotTo(HaveOccurred())
+
+		podSpec := cluster.CreateStatefulSetForRack(&clusterDef.Spec.Racks[0], nil).Spec.Template.Spec
+		Expect(podSpec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution).To(HaveLen(1))
+		Expect(podSpec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution[0].TopologyKey).To(Equal("kubernetes.io/hostname"))
+		Expect(podSpec.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution).To(BeEmpty())
+		Expect(podSpec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions).To(Equal([]v1.NodeSelectorRequirement{
+			{Key: "failure-domain.beta.kubernetes.io/zone", Operator: v1.NodeSelectorOpIn, Values: []string{"some-zone"}},
+		}))
+		Expect(podSpec.Tolerations).To(BeEmpty())
+		Expect(podSpec.NodeSelector).To(BeEmpty())
+		Expect(podSpec.PriorityClassName).To(BeEmpty())
+	})
+
+	It("should only prefer pods of the cluster to run in different topology domains when the anti-affinity is preferred", func() {
+		clusterDef.Spec.Pod.AntiAffinity = &v1alpha1.AntiAffinity{Mode: v1alpha1.AntiAffinityPreferred, TopologyKey: "topology.kubernetes.io/rack"}
+		clusterDef.Spec.Pod.ZoneTopologyKey = "topology.kubernetes.io/zone"
+		cluster, err := ACluster(clusterDef)
+		Expect(err).NotTo(HaveOccurred())
+
+		podSpec := cluster.CreateStatefulSetForRack(&clusterDef.Spec.Racks[0], nil).Spec.Template.Spec
+		Expect(podSpec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution).To(BeEmpty())
+		Expect(podSpec.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution).To(HaveLen(1))
+		Expect(podSpec.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution[0].Weight).To(Equal(int32(100)))
+		Expect(podSpec.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution[0].PodAffinityTerm.TopologyKey).To(Equal("topology.kubernetes.io/rack"))
+		Expect(podSpec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions[0].Key).To(Equal("topology.kubernetes.io/zone"))
+	})
+
+	It("should combine the scheduling of the cluster with the one of the rack", func() {
+		clusterDef.Spec.Pod.Scheduling = v1alpha1.Scheduling{
+			Tolerations:       []v1.Toleration{{Key: "dedicated", Operator: v1.TolerationOpEqual, Value: "cassandra", Effect: v1.TaintEffectNoSchedule}},
+			NodeSelector:      map[string]string{"pool": "cassandra", "disk": "ssd"},
+			PriorityClassName: "databases",
+			AntiAffinity:      &v1alpha1.AntiAffinity{TopologyKey: "topology.kubernetes.io/rack"},
+		}
+		clusterDef.Spec.Racks[0].Scheduling = v1alpha1.Scheduling{
+			Tolerations:       []v1.Toleration{{Key: "zone-a", Operator: v1.TolerationOpExists}},
+			NodeSelector:      map[string]string{"disk": "nvme"},
+			PriorityClassName: "critical-databases",
+			AntiAffinity:      &v1alpha1.AntiAffinity{Mode: v1alpha1.AntiAffinityPreferred},
+		}
+		cluster, err := ACluster(clusterDef)
+		Expect(err).NotTo(HaveOccurred())
+
+		podSpec := cluster.CreateStatefulSetForRack(&clusterDef.Spec.Racks[0], nil).Spec.Template.Spec
+		Expect(podSpec.Tolerations).To(Equal([]v1.Toleration{
+			{Key: "dedicated", Operator: v1.TolerationOpEqual, Value: "cassandra", Effect: v1.TaintEffectNoSchedule},
+			{Key: "zone-a", Operator: v1.TolerationOpExists},
+		}))
+		Expect(podSpec.NodeSelector).To(Equal(map[string]string{"pool": "cassandra", "disk": "nvme"}))
+		Expect(podSpec.PriorityClassName).To(Equal("critical-databases"))
+		Expect(podSpec.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution).To(HaveLen(1))
+		Expect(podSpec.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution[0].PodAffinityTerm.TopologyKey).To(Equal("topology.kubernetes.io/rack"))
+	})
+
+	It("should find the zone of a rack whichever node label holds it", func() {
+		clusterDef.Spec.Pod.ZoneTopologyKey = "topology.kubernetes.io/zone"
+		cluster, err := ACluster(clusterDef)
+		Expect(err).NotTo(HaveOccurred())
+
+		statefulSet := cluster.CreateStatefulSetForRack(&clusterDef.Spec.Racks[0], nil)
+		spec := cluster.SpecForStatefulSets([]appsv1.StatefulSet{*statefulSet})
+		Expect(spec.Racks[0].Zone).To(Equal("some-zone"))
+	})
+})
+
 var _ = Describe("modification of stateful sets", func() {
 	var clusterDef *v1alpha1.Cassandra
 	var configMap = &v1.ConfigMap{
@@ -640,7 +1067,7 @@ var _ = Describe("modification of stateful sets", func() {
 			// given
 			cluster, err := ACluster(clusterDef)
 			Expect(err).ToNot(HaveOccurred())
-			statefulSet := cluster.createStatefulSetForRack(&cluster.Racks()[0], nil)
+			statefulSet := cluster.CreateStatefulSetForRack(&cluster.Racks()[0], nil)
 
 			// when
 			err = cluster.AddCustomConfigVolumeToStatefulSet(statefulSet, configMap)
@@ -658,7 +1085,7 @@ var _ = Describe("modification of stateful sets", func() {
 			// given
 			cluster, err := ACluster(clusterDef)
 			Expect(err).ToNot(HaveOccurred())
-			statefulSet := cluster.createStatefulSetForRack(&cluster.Racks()[0], nil)
+			statefulSet := cluster.CreateStatefulSetForRack(&cluster.Racks()[0], nil)
 
 			// when
 			err = cluster.AddCustomConfigVolumeToStatefulSet(statefulSet, configMap)
@@ -667,6 +1094,21 @@ var _ = Describe("modification of stateful sets", func() {
 			// then
 			Expect(statefulSet.Spec.Template.Annotations[ConfigHashAnnotation]).ToNot(BeEmpty())
 		})
+
+		It("should not add the configMap volume twice when the stateful set already mounts it", func() {
+			// given
+			cluster, err := ACluster(clusterDef)
+			Expect(err).ToNot(HaveOccurred())
+			statefulSet := cluster.CreateStatefulSetForRack(&cluster.Racks()[0], configMap)
+
+			// when
+			err = cluster.AddCustomConfigVolumeToStatefulSet(statefulSet, configMap)
+			Expect(err).ToNot(HaveOccurred())
+
+			// then
+			Expect(statefulSet.Spec.Template.Spec.Volumes).To(haveExactly(1, matchingConfigMap("cassandra-custom-config-mycluster", "mycluster-config")))
+			Expect(statefulSet.Spec.Template.Spec.InitContainers[1].VolumeMounts).To(haveExactly(1, matchingVolumeMount("cassandra-custom-config-mycluster", "/custom-config")))
+		})
 	})
 
 	Context("the custom configMap is removed", func() {
@@ -674,7 +1116,7 @@ var _ = Describe("modification of stateful sets", func() {
 			// given
 			cluster, err := ACluster(clusterDef)
 			Expect(err).ToNot(HaveOccurred())
-			statefulSet := cluster.createStatefulSetForRack(&cluster.Racks()[0], configMap)
+			statefulSet := cluster.CreateStatefulSetForRack(&cluster.Racks()[0], configMap)
 
 			// when
 			err = cluster.RemoveCustomConfigVolumeFromStatefulSet(statefulSet, nil)
@@ -691,7 +1133,7 @@ var _ = Describe("modification of stateful sets", func() {
 			// given
 			cluster, err := ACluster(clusterDef)
 			Expect(err).ToNot(HaveOccurred())
-			statefulSet := cluster.createStatefulSetForRack(&cluster.Racks()[0], configMap)
+			statefulSet := cluster.CreateStatefulSetForRack(&cluster.Racks()[0], configMap)
 
 			// when
 			err = cluster.RemoveCustomConfigVolumeFromStatefulSet(statefulSet, nil)
@@ -703,6 +1145,265 @@ var _ = Describe("modification of stateful sets", func() {
 	})
 })
 
+var _ = Describe("reporting of rack statuses", func() {
+	var clusterDef *v1alpha1.Cassandra
+	BeforeEach(func() {
+		clusterDef = &v1alpha1.Cassandra{
+			ObjectMeta: metaV1.ObjectMeta{Name: CLUSTER, Namespace: NAMESPACE},
+			Spec: v1alpha1.CassandraSpec{
+				Racks: []v1alpha1.Rack{{Name: "a", Replicas: 2, StorageClass: "some-storage", Zone: "some-zone"}, {Name: "b", Replicas: 1, StorageClass: "some-storage", Zone: "some-zone"}},
+				Pod: v1alpha1.Pod{
+					Memory:      resource.MustParse("1Gi"),
+					CPU:         resource.MustParse("100m"),
+					StorageSize: resource.MustParse("1Gi"),
+				},
+			},
+		}
+	})
+
+	It("should report the replicas and ready replicas of the stateful set for each rack", func() {
+		// given
+		cluster, err := ACluster(clusterDef)
+		Expect(err).ToNot(HaveOccurred())
+		statefulSetA := cluster.CreateStatefulSetForRack(&cluster.Racks()[0], nil)
+		statefulSetA.Status.Replicas = 2
+		statefulSetA.Status.ReadyReplicas = 1
+		statefulSetB := cluster.CreateStatefulSetForRack(&cluster.Racks()[1], nil)
+		statefulSetB.Status.Replicas = 1
+		statefulSetB.Status.ReadyReplicas = 1
+
+		// when
+		rackStatuses := cluster.rackStatusesFor([]appsv1.StatefulSet{*statefulSetB, *statefulSetA})
+
+		// then
+		Expect(rackStatuses).To(Equal([]v1alpha1.RackStatus{
+			{Name: "a", Replicas: 2, ReadyReplicas: 1},
+			{Name: "b", Replicas: 1, ReadyReplicas: 1},
+		}))
+	})
+
+	It("should report no replicas for a rack without a stateful set", func() {
+		// given
+		cluster, err := ACluster(clusterDef)
+		Expect(err).ToNot(HaveOccurred())
+		statefulSetA := cluster.CreateStatefulSetForRack(&cluster.Racks()[0], nil)
+		statefulSetA.Status.Replicas = 2
+		statefulSetA.Status.ReadyReplicas = 2
+
+		// when
+		rackStatuses := cluster.rackStatusesFor([]appsv1.StatefulSet{*statefulSetA})
+
+		// then
+		Expect(rackStatuses).To(Equal([]v1alpha1.RackStatus{
+			{Name: "a", Replicas: 2, ReadyReplicas: 2},
+			{Name: "b", Replicas: 0, ReadyReplicas: 0},
+		}))
+	})
+})
+
+var _ = Describe("description of the cluster run by stateful sets", func() {
+	var clusterDef *v1alpha1.Cassandra
+	BeforeEach(func() {
+		clusterDef = &v1alpha1.Cassandra{
+			ObjectMeta: metaV1.ObjectMeta{Name: CLUSTER, Namespace: NAMESPACE},
+			Spec: v1alpha1.CassandraSpec{
+				Racks: []v1alpha1.Rack{{Name: "a", Replicas: 2, StorageClass: "some-storage", Zone: "some-zone"}, {Name: "b", Replicas: 1, StorageClass: "another-storage", Zone: "another-zone"}},
+				Pod: v1alpha1.Pod{
+					Memory:      resource.MustParse("1Gi"),
+					CPU:         resource.MustParse("100m"),
+					StorageSize: resource.MustParse("1Gi"),
+				},
+			},
+		}
+	})
+
+	It("should match the cluster definition when the stateful sets were created from it", func() {
+		// given
+		cluster, err := ACluster(clusterDef)
+		Expect(err).ToNot(HaveOccurred())
+		statefulSetA := cluster.CreateStatefulSetForRack(&cluster.Racks()[0], nil)
+		statefulSetB := cluster.CreateStatefulSetForRack(&cluster.Racks()[1], nil)
+		statefulSetA.Spec.Template.Spec.Containers[0].Resources.Requests[v1.ResourceMemory] = resource.MustParse("1024Mi")
+
+		// when
+		spec := cluster.SpecForStatefulSets([]appsv1.StatefulSet{*statefulSetB, *statefulSetA})
+
+		// then
+		Expect(spec).To(Equal(&cluster.Definition().Spec))
+	})
+
+	It("should report the replicas, zone and storage class of each rack", func() {
+		// given
+		cluster, err := ACluster(clusterDef)
+		Expect(err).ToNot(HaveOccurred())
+		statefulSetA := cluster.CreateStatefulSetForRack(&cluster.Racks()[0], nil)
+		replicas := int32(3)
+		statefulSetA.Spec.Replicas = &replicas
+		statefulSetB := cluster.CreateStatefulSetForRack(&cluster.Racks()[1], nil)
+		statefulSetB.Spec.Template.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions[0].Values = []string{"other-zone"}
+		otherStorage := "other-storage"
+		statefulSetB.Spec.VolumeClaimTemplates[0].Spec.StorageClassName = &otherStorage
+
+		// when
+		spec := cluster.SpecForStatefulSets([]appsv1.StatefulSet{*statefulSetA, *statefulSetB})
+
+		// then
+		Expect(spec.Racks).To(Equal([]v1alpha1.Rack{
+			{Name: "a", Replicas: 3, StorageClass: "some-storage", Zone: "some-zone"},
+			{Name: "b", Replicas: 1, StorageClass: "other-storage", Zone: "other-zone"},
+		}))
+	})
+
+	It("should omit the racks without a stateful set and report the racks which are no longer defined", func() {
+		// given
+		cluster, err := ACluster(clusterDef)
+		Expect(err).ToNot(HaveOccurred())
+		statefulSetA := cluster.CreateStatefulSetForRack(&cluster.Racks()[0], nil)
+		removedRack := v1alpha1.Rack{Name: "c", Replicas: 1, StorageClass: "some-storage", Zone: "some-zone"}
+		statefulSetC := cluster.CreateStatefulSetForRack(&removedRack, nil)
+
+		// when
+		spec := cluster.SpecForStatefulSets([]appsv1.StatefulSet{*statefulSetC, *statefulSetA})
+
+		// then
+		Expect(spec.Racks).To(Equal([]v1alpha1.Rack{
+			{Name: "a", Replicas: 2, StorageClass: "some-storage", Zone: "some-zone"},
+			removedRack,
+		}))
+	})
+
+	It("should report the pod properties of the first stateful set which doesn't match the cluster definition", func() {
+		// given
+		cluster, err := ACluster(clusterDef)
+		Expect(err).ToNot(HaveOccurred())
+		statefulSetA := cluster.CreateStatefulSetForRack(&cluster.Racks()[0], nil)
+		statefulSetB := cluster.CreateStatefulSetForRack(&cluster.Racks()[1], nil)
+		cassandraContainer := &statefulSetB.Spec.Template.Spec.Containers[0]
+		cassandraContainer.Image = "cassandra:3.11.1"
+		cassandraContainer.Resources.Requests[v1.ResourceCPU] = resource.MustParse("200m")
+		cassandraContainer.LivenessProbe.TimeoutSeconds = 42
+		statefulSetB.Spec.Template.Spec.InitContainers[1].Image = "some-bootstrapper"
+
+		// when
+		spec := cluster.SpecForStatefulSets([]appsv1.StatefulSet{*statefulSetA, *statefulSetB})
+
+		// then
+		Expect(spec.Pod.Image).To(Equal("cassandra:3.11.1"))
+		Expect(spec.Pod.CPU).To(Equal(resource.MustParse("200m")))
+		Expect(spec.Pod.LivenessProbe.TimeoutSeconds).To(Equal(int32(42)))
+		Expect(spec.Pod.BootstrapperImage).To(Equal("some-bootstrapper"))
+		Expect(spec.Pod.Memory).To(Equal(cluster.Definition().Spec.Pod.Memory))
+	})
+
+	It("should report the pod labels, annotations and environment variables the stateful set runs with", func() {
+		// given
+		clusterDef.Spec.Pod.Labels = map[string]string{"team": "data"}
+		clusterDef.Spec.Pod.Env = []v1.EnvVar{{Name: "JVM_OPTS", Value: "-Xss256k"}}
+		cluster, err := ACluster(clusterDef)
+		Expect(err).ToNot(HaveOccurred())
+		statefulSetA := cluster.CreateStatefulSetForRack(&cluster.Racks()[0], nil)
+		statefulSetA.Spec.Template.Labels["team"] = "platform"
+		statefulSetA.Spec.Template.Annotations = map[string]string{"prometheus.io/scrape": "true"}
+		statefulSetA.Spec.Template.Spec.Containers[0].Env = statefulSetA.Spec.Template.Spec.Containers[0].Env[:1]
+
+		// when
+		spec := cluster.SpecForStatefulSets([]appsv1.StatefulSet{*statefulSetA})
+
+		// then
+		Expect(spec.Pod.Labels).To(Equal(map[string]string{"team": "platform"}))
+		Expect(spec.Pod.Annotations).To(Equal(map[string]string{"prometheus.io/scrape": "true"}))
+		Expect(spec.Pod.Env).To(BeEmpty())
+	})
+
+	It("should report the init containers, sidecars and extra volumes the stateful set runs with", func() {
+		// given
+		clusterDef.Spec.Pod.Sidecars = []v1.Container{{Name: "log-shipper", Image: "log-shipper"}}
+		cluster, err := ACluster(clusterDef)
+		Expect(err).ToNot(HaveOccurred())
+		statefulSetA := cluster.CreateStatefulSetForRack(&cluster.Racks()[0], nil)
+		statefulSetA.Spec.Template.Spec.Containers = statefulSetA.Spec.Template.Spec.Containers[:1]
+		statefulSetA.Spec.Template.Spec.InitContainers = append(statefulSetA.Spec.Template.Spec.InitContainers, v1.Container{Name: "fetch-agent", Image: "agent-fetcher"})
+		logsVolume := v1.Volume{Name: "logs", VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}}}
+		statefulSetA.Spec.Template.Spec.Volumes = append(statefulSetA.Spec.Template.Spec.Volumes, logsVolume)
+		logsMount := v1.VolumeMount{Name: "logs", MountPath: "/var/log/cassandra"}
+		statefulSetA.Spec.Template.Spec.Containers[0].VolumeMounts = append(statefulSetA.Spec.Template.Spec.Containers[0].VolumeMounts, logsMount)
+
+		// when
+		spec := cluster.SpecForStatefulSets([]appsv1.StatefulSet{*statefulSetA})
+
+		// then
+		Expect(spec.Pod.Sidecars).To(BeEmpty())
+		Expect(spec.Pod.InitContainers).To(Equal([]v1.Container{{Name: "fetch-agent", Image: "agent-fetcher"}}))
+		Expect(spec.Pod.ExtraVolumes).To(Equal([]v1.Volume{logsVolume}))
+		Expect(spec.Pod.ExtraVolumeMounts).To(Equal([]v1.VolumeMount{logsMount}))
+	})
+
+	It("should report the cpu limit and init container resources the stateful set runs with", func() {
+		// given
+		cpuLimit := resource.MustParse("2")
+		clusterDef.Spec.Pod.CPULimit = &cpuLimit
+		cluster, err := ACluster(clusterDef)
+		Expect(err).ToNot(HaveOccurred())
+		statefulSetA := cluster.CreateStatefulSetForRack(&cluster.Racks()[0], nil)
+		delete(statefulSetA.Spec.Template.Spec.Containers[0].Resources.Limits, v1.ResourceCPU)
+		bootstrapperResources := v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("10m")}}
+		statefulSetA.Spec.Template.Spec.InitContainers[1].Resources = bootstrapperResources
+
+		// when
+		spec := cluster.SpecForStatefulSets([]appsv1.StatefulSet{*statefulSetA})
+
+		// then
+		Expect(spec.Pod.CPULimit).To(BeNil())
+		Expect(spec.Pod.BootstrapperResources).To(Equal(&bootstrapperResources))
+		Expect(spec.Pod.InitConfigResources).To(BeNil())
+	})
+
+	It("should ignore the fields of sidecars defaulted by Kubernetes", func() {
+		// given
+		clusterDef.Spec.Pod.Sidecars = []v1.Container{{Name: "log-shipper", Image: "log-shipper"}}
+		cluster, err := ACluster(clusterDef)
+		Expect(err).ToNot(HaveOccurred())
+		statefulSetA := cluster.CreateStatefulSetForRack(&cluster.Racks()[0], nil)
+		statefulSetA.Spec.Template.Spec.Containers[1].ImagePullPolicy = v1.PullIfNotPresent
+		statefulSetA.Spec.Template.Spec.Containers[1].TerminationMessagePath = v1.TerminationMessagePathDefault
+
+		// when
+		spec := cluster.SpecForStatefulSets([]appsv1.StatefulSet{*statefulSetA})
+
+		// then
+		Expect(spec.Pod).To(Equal(cluster.Definition().Spec.Pod))
+	})
+
+	It("should ignore the fields of environment variables defaulted by Kubernetes", func() {
+		// given
+		clusterDef.Spec.Pod.Env = []v1.EnvVar{{Name: "POD_NAME", ValueFrom: &v1.EnvVarSource{FieldRef: &v1.ObjectFieldSelector{FieldPath: "metadata.name"}}}}
+		cluster, err := ACluster(clusterDef)
+		Expect(err).ToNot(HaveOccurred())
+		statefulSetA := cluster.CreateStatefulSetForRack(&cluster.Racks()[0], nil)
+		statefulSetA.Spec.Template.Spec.Containers[0].Env[1].ValueFrom.FieldRef.APIVersion = "v1"
+
+		// when
+		spec := cluster.SpecForStatefulSets([]appsv1.StatefulSet{*statefulSetA})
+
+		// then
+		Expect(spec.Pod).To(Equal(cluster.Definition().Spec.Pod))
+	})
+
+	It("should report the use of emptyDir when the stateful sets have no volume claim templates", func() {
+		// given
+		cluster, err := ACluster(clusterDef)
+		Expect(err).ToNot(HaveOccurred())
+		statefulSetA := cluster.CreateStatefulSetForRack(&cluster.Racks()[0], nil)
+		statefulSetA.Spec.VolumeClaimTemplates = nil
+
+		// when
+		spec := cluster.SpecForStatefulSets([]appsv1.StatefulSet{*statefulSetA})
+
+		// then
+		Expect(spec.UseEmptyDir).To(BeTrue())
+	})
+})
+
 var _ = Describe("creation of snapshot job", func() {
 	var (
 		clusterDef      *v1alpha1.Cassandra
@@ -851,6 +1552,95 @@ var _ = Describe("creation of snapshot job", func() {
 
 })
 
+var _ = Describe("creation of repair job", func() {
+	var (
+		clusterDef    *v1alpha1.Cassandra
+		repairTimeout = int32(3600)
+		subranges     = int32(8)
+	)
+
+	BeforeEach(func() {
+		clusterDef = &v1alpha1.Cassandra{
+			ObjectMeta: metaV1.ObjectMeta{Name: CLUSTER, Namespace: NAMESPACE},
+			Spec: v1alpha1.CassandraSpec{
+				Racks: []v1alpha1.Rack{{Name: "a", Replicas: 1, StorageClass: "some-storage", Zone: "some-zone"}},
+				Pod: v1alpha1.Pod{
+					Memory:      resource.MustParse("1Gi"),
+					CPU:         resource.MustParse("100m"),
+					StorageSize: resource.MustParse("1Gi"),
+				},
+				Repair: &v1alpha1.Repair{
+					Schedule: "0 2 * * *",
+				},
+			},
+		}
+	})
+
+	It("should create a cronjob named after the cluster that will trigger at the specified schedule", func() {
+		cluster, err := ACluster(clusterDef)
+		Expect(err).NotTo(HaveOccurred())
+
+		cronJob := cluster.CreateRepairJob()
+		Expect(cronJob.Name).To(Equal(fmt.Sprintf("%s-repair", clusterDef.Name)))
+		Expect(cronJob.Namespace).To(Equal(clusterDef.Namespace))
+		Expect(cronJob.Labels).To(And(
+			HaveKeyWithValue(OperatorLabel, clusterDef.Name),
+			HaveKeyWithValue("app", fmt.Sprintf("%s-repair", clusterDef.Name)),
+		))
+		Expect(cronJob.Spec.Schedule).To(Equal("0 2 * * *"))
+		Expect(cronJob.Spec.ConcurrencyPolicy).To(Equal(v1beta1.ForbidConcurrent))
+	})
+
+	It("should create a cronjob that will trigger a primary range repair of all keyspaces when no option is specified", func() {
+		cluster, err := ACluster(clusterDef)
+		Expect(err).NotTo(HaveOccurred())
+
+		cronJob := cluster.CreateRepairJob()
+		Expect(cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers).To(HaveLen(1))
+
+		repairContainer := cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers[0]
+		Expect(repairContainer.Name).To(Equal(fmt.Sprintf("%s-repair", clusterDef.Name)))
+		Expect(repairContainer.Command).To(Equal([]string{
+			"/cassandra-snapshot", "repair",
+			"-n", cluster.Namespace(),
+			"-l", fmt.Sprintf("%s=%s,%s=%s", OperatorLabel, clusterDef.Name, "app", clusterDef.Name),
+		}))
+		Expect(repairContainer.Image).To(Equal("skyuk/cassandra-snapshot:latest"))
+	})
+
+	It("should create a cronjob that will trigger a repair with the specified options", func() {
+		clusterDef.Spec.Repair.Image = "somerepo/snapshot:v1"
+		clusterDef.Spec.Repair.Keyspaces = []string{"keyspace1", "keyspace50"}
+		clusterDef.Spec.Repair.Parallelism = v1alpha1.RepairDCParallel
+		clusterDef.Spec.Repair.TimeoutSeconds = &repairTimeout
+		clusterDef.Spec.Repair.Subranges = &subranges
+		cluster, err := ACluster(clusterDef)
+		Expect(err).NotTo(HaveOccurred())
+
+		cronJob := cluster.CreateRepairJob()
+
+		repairContainer := cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers[0]
+		Expect(repairContainer.Command).To(Equal([]string{
+			"/cassandra-snapshot", "repair",
+			"-n", cluster.Namespace(),
+			"-l", fmt.Sprintf("%s=%s,%s=%s", OperatorLabel, clusterDef.Name, "app", clusterDef.Name),
+			"-t", durationSeconds(&repairTimeout).String(),
+			"-p", "dc-parallel",
+			"-s", "8",
+			"-k", "keyspace1,keyspace50",
+		}))
+		Expect(repairContainer.Image).To(Equal("somerepo/snapshot:v1"))
+	})
+
+	It("should not create a repair job if none is specified in the cluster spec", func() {
+		clusterDef.Spec.Repair = nil
+		cluster, err := ACluster(clusterDef)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(cluster.CreateRepairJob()).To(BeNil())
+	})
+})
+
 var _ = Describe("creation of snapshot cleanup job", func() {
 	var (
 		clusterDef      *v1alpha1.Cassandra
@@ -15,6 +15,62 @@ const (
 	ClusterUpdateEvent = "ClusterUpdate"
 	// WaitingForStatefulSetChange is an event created when waiting for a stateful set change to complete
 	WaitingForStatefulSetChange = "WaitingForStatefulSetChange"
+	// UpgradeSSTablesStartedEvent is an event created when the SSTables of a node are being upgraded after an image change
+	UpgradeSSTablesStartedEvent = "UpgradeSSTablesStarted"
+	// UpgradeSSTablesCompletedEvent is an event created when the SSTables of a node have been upgraded
+	UpgradeSSTablesCompletedEvent = "UpgradeSSTablesCompleted"
+	// UpgradeSSTablesFailedEvent is an event created when the SSTables of a node could not be upgraded
+	UpgradeSSTablesFailedEvent = "UpgradeSSTablesFailed"
+	// RackRestartStartedEvent is an event created when the pods of a rack are being restarted as requested by the restartedAt annotation
+	RackRestartStartedEvent = "RackRestartStarted"
+	// RackRestartedEvent is an event created when all the pods of a rack have been restarted
+	RackRestartedEvent = "RackRestarted"
+	// CleanupStartedEvent is an event created when the data a node no longer owns is being removed after a scale-up
+	CleanupStartedEvent = "CleanupStarted"
+	// CleanupCompletedEvent is an event created when the data a node no longer owns has been removed
+	CleanupCompletedEvent = "CleanupCompleted"
+	// CleanupFailedEvent is an event created when the data a node no longer owns could not be removed
+	CleanupFailedEvent = "CleanupFailed"
+	// NodeDecommissionStartedEvent is an event created when a node is being decommissioned before its rack is scaled down
+	NodeDecommissionStartedEvent = "NodeDecommissionStarted"
+	// NodeDecommissionedEvent is an event created when a node has left the ring and its pod has been removed
+	NodeDecommissionedEvent = "NodeDecommissioned"
+	// NodeDecommissionFailedEvent is an event created when a node could not be decommissioned
+	NodeDecommissionFailedEvent = "NodeDecommissionFailed"
+	// PersistentVolumeClaimDeletedEvent is an event created when the persistent volume claim of a removed node is deleted
+	PersistentVolumeClaimDeletedEvent = "PersistentVolumeClaimDeleted"
+	// RackDeletionRefusedEvent is an event created when a rack is not deleted because the remaining racks could not hold all replicas of a keyspace
+	RackDeletionRefusedEvent = "RackDeletionRefused"
+	// RackDeletedEvent is an event created when all nodes of a rack have been decommissioned and its stateful set removed
+	RackDeletedEvent = "RackDeleted"
+	// PersistentVolumeClaimResizeStartedEvent is an event created when the persistent volume claim of a node is being expanded
+	PersistentVolumeClaimResizeStartedEvent = "PersistentVolumeClaimResizeStarted"
+	// PersistentVolumeClaimFileSystemResizeStartedEvent is an event created when the pod of a node is restarted so that the file system of its expanded volume is resized
+	PersistentVolumeClaimFileSystemResizeStartedEvent = "PersistentVolumeClaimFileSystemResizeStarted"
+	// PersistentVolumeClaimResizedEvent is an event created when the persistent volume claim of a node has reached its new size
+	PersistentVolumeClaimResizedEvent = "PersistentVolumeClaimResized"
+	// PersistentVolumeClaimResizeFailedEvent is an event created when the persistent volume claim of a node could not be expanded
+	PersistentVolumeClaimResizeFailedEvent = "PersistentVolumeClaimResizeFailed"
+	// StorageResizeRefusedEvent is an event created when the storage of a rack is not resized because its storage class doesn't allow volume expansion
+	StorageResizeRefusedEvent = "StorageResizeRefused"
+	// NodeReplacementStartedEvent is an event created when a dead node is being replaced by a new node on the same pod
+	NodeReplacementStartedEvent = "NodeReplacementStarted"
+	// NodeReplacedEvent is an event created when the replacement node has joined the ring
+	NodeReplacedEvent = "NodeReplaced"
+	// NodeReplacementFailedEvent is an event created when a dead node could not be replaced
+	NodeReplacementFailedEvent = "NodeReplacementFailed"
+	// NodeReplacementRefusedEvent is an event created when the replacement of a node is not attempted because the node is still live
+	// or its address is invalid
+	NodeReplacementRefusedEvent = "NodeReplacementRefused"
+	// CustomConfigMapLabelRefusedEvent is an event created when the custom config map of a cluster is not labelled for it
+	// because it is already labelled for another cluster
+	CustomConfigMapLabelRefusedEvent = "CustomConfigMapLabelRefused"
+	// DriftCorrectedEvent is an event created when a resource of the cluster has been changed to match the cluster definition again
+	DriftCorrectedEvent = "DriftCorrected"
+	// ClusterPausedEvent is an event created when the changes to the resources of a cluster are held because it is paused
+	ClusterPausedEvent = "ClusterPaused"
+	// ClusterResumedEvent is an event created when the changes held while a cluster was paused have been applied
+	ClusterResumedEvent = "ClusterResumed"
 	// ClusterSnapshotCreationScheduleEvent is an event triggered on creation of a scheduled snapshot
 	ClusterSnapshotCreationScheduleEvent = "ClusterSnapshotCreationScheduleEvent"
 	// ClusterSnapshotCreationUnscheduleEvent is an event triggered on removal of a scheduled snapshot
@@ -27,6 +83,16 @@ const (
 	ClusterSnapshotCleanupUnscheduleEvent = "ClusterSnapshotCleanupUnscheduleEvent"
 	// ClusterSnapshotCleanupModificationEvent is an event triggered when the snapshot cleanup job is modified
 	ClusterSnapshotCleanupModificationEvent = "ClusterSnapshotCleanupModificationEvent"
+	// ClusterRepairScheduleEvent is an event triggered on creation of a scheduled repair
+	ClusterRepairScheduleEvent = "ClusterRepairScheduleEvent"
+	// ClusterRepairUnscheduleEvent is an event triggered on removal of a scheduled repair
+	ClusterRepairUnscheduleEvent = "ClusterRepairUnscheduleEvent"
+	// ClusterRepairModificationEvent is an event triggered when the scheduled repair is modified
+	ClusterRepairModificationEvent = "ClusterRepairModificationEvent"
+	// RepairStartedEvent is an event created when a repair job is started as requested by the repairRequestedAt annotation
+	RepairStartedEvent = "RepairStarted"
+	// RepairRefusedEvent is an event created when a repair is requested for a cluster which has no repair configured
+	RepairRefusedEvent = "RepairRefused"
 
 	operatorNamespace = ""
 )
@@ -1,16 +1,23 @@
 package dispatcher
 
 import (
-	"github.com/prometheus/common/log"
 	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+	"k8s.io/client-go/util/workqueue"
 )
 
 // Event describes an event which can happen to a particular entity. Events have a kind (e.g. "created", "modified",
 // "deleted"), a key which uniquely identifies the entity the event applies to, and event-specific data.
+// Progress is left to the handler, which can record in it how far it got with the event, so that an event which failed
+// is resumed from where it stopped when it is retried.
 type Event struct {
-	Kind string
-	Key  string
-	Data interface{}
+	Kind     string
+	Key      string
+	Data     interface{}
+	Progress interface{}
 }
 
 // Dispatcher is a component which takes events bound for a particular entity, and routes them to a worker which
@@ -21,66 +28,162 @@ type Event struct {
 type Dispatcher interface {
 	// Dispatch the supplied Event to an appropriate worker
 	Dispatch(e *Event)
+	// Retries returns the number of times the handling of the oldest pending event for the supplied key has been retried
+	Retries(key string) int
+}
+
+// RetryPolicy describes how the handling of an event is retried when the handler returns an error.
+// The delay between two attempts doubles after each failure, starting from BaseDelay up to MaxDelay.
+type RetryPolicy struct {
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+	MaxRetries int
+}
+
+// DefaultRetryPolicy retries a failed event for about half an hour before giving up on it
+var DefaultRetryPolicy = RetryPolicy{BaseDelay: 1 * time.Second, MaxDelay: 5 * time.Minute, MaxRetries: 15}
+
+var eventRetries = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "cassandra_operator_event_retries_total",
+		Help: "Number of times the handling of an event has been retried after a failure",
+	},
+	[]string{"key", "kind"},
+)
+
+func init() {
+	prometheus.MustRegister(eventRetries)
 }
 
-// New Dispatcher. The handlerFunc will be invoked to handle a single Event after dispatch,
-// once stopCh is closed, no more events would be handled
-func New(handlerFunc func(*Event), stopCh <-chan struct{}) Dispatcher {
+// New Dispatcher. The handlerFunc will be invoked to handle a single Event after dispatch and the Event will be handled
+// again according to the retryPolicy when it returns an error. Once stopCh is closed, no more events would be handled
+func New(handlerFunc func(*Event) error, retryPolicy RetryPolicy, stopCh <-chan struct{}) Dispatcher {
 	dispatcher := &dispatcher{
-		handlerFunc:             handlerFunc,
-		eventProcessingChannels: make(map[string]chan Event),
-		stopCh:                  stopCh,
-		dispatchLock:            sync.Mutex{},
+		handlerFunc:   handlerFunc,
+		retryPolicy:   retryPolicy,
+		queue:         workqueue.NewRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(retryPolicy.BaseDelay, retryPolicy.MaxDelay)),
+		pendingEvents: make(map[string][]*Event),
+		stopCh:        stopCh,
 	}
 	go dispatcher.watchStopChannel()
 
 	return dispatcher
 }
 
+// dispatcher keeps the events of each key in the order they were dispatched, while the rate limited queue holds the
+// keys with pending events. As the queue never hands out a key which is being processed, the events of a key are handled
+// one at a time, and an event which failed is retried before any later event for the same key is handled.
+// A worker is started for each key with pending events and stops once a key no longer has any, when the key is forgotten,
+// so that the keys of deleted clusters don't pile up.
 type dispatcher struct {
-	eventProcessingChannels map[string]chan Event
-	handlerFunc             func(*Event)
-	stopCh                  <-chan struct{}
-	stopped                 bool
-	dispatchLock            sync.Mutex
+	handlerFunc   func(*Event) error
+	retryPolicy   RetryPolicy
+	queue         workqueue.RateLimitingInterface
+	pendingEvents map[string][]*Event
+	stopCh        <-chan struct{}
+	stopped       bool
+	workers       int
+	dispatchLock  sync.Mutex
 }
 
-const clusterEventBufferSize = 100
-
 func (d *dispatcher) Dispatch(e *Event) {
-	if !d.stopped {
-		d.dispatchLock.Lock()
-		defer d.dispatchLock.Unlock()
-		eventProcessingChannel, ok := d.eventProcessingChannels[e.Key]
-		if !ok {
-			eventProcessingChannel = make(chan Event, clusterEventBufferSize)
-			d.eventProcessingChannels[e.Key] = eventProcessingChannel
-			log.Infof("Starting event worker for key: %s", e.Key)
-			go d.start(eventProcessingChannel, d.handlerFunc, d.stopCh)
-		}
-		eventProcessingChannel <- *e
-	} else {
+	d.dispatchLock.Lock()
+	defer d.dispatchLock.Unlock()
+
+	if d.stopped {
 		log.Warnf("Ignoring event with kind: %s and key: %s, as the event dispatching was stopped", e.Kind, e.Key)
+		return
+	}
+
+	pendingEvents, known := d.pendingEvents[e.Key]
+	if !known {
+		log.Infof("Starting event worker for key: %s", e.Key)
+		d.workers++
+		go d.start()
 	}
+	d.pendingEvents[e.Key] = append(pendingEvents, e)
+
+	// a key with pending events is already queued, being processed or waiting to be retried,
+	// and will be queued again once its oldest event has been handled
+	if len(pendingEvents) == 0 {
+		d.queue.Add(e.Key)
+	}
+}
+
+func (d *dispatcher) Retries(key string) int {
+	return d.queue.NumRequeues(key)
 }
 
 func (d *dispatcher) watchStopChannel() {
-	for {
-		select {
-		case <-d.stopCh:
-			d.stopped = true
-			return
-		}
+	<-d.stopCh
+	d.dispatchLock.Lock()
+	defer d.dispatchLock.Unlock()
+	d.stopped = true
+	d.queue.ShutDown()
+}
+
+func (d *dispatcher) start() {
+	for d.processNextKey() {
 	}
 }
 
-func (d *dispatcher) start(eventsIn <-chan Event, handler func(*Event), stopCh <-chan struct{}) {
-	for {
-		select {
-		case e := <-eventsIn:
-			handler(&e)
-		case <-stopCh:
-			return
+func (d *dispatcher) processNextKey() bool {
+	item, shutdown := d.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer d.queue.Done(item)
+
+	key := item.(string)
+	e := d.oldestPendingEvent(key)
+	if e == nil {
+		return !d.isStopped()
+	}
+
+	if err := d.handlerFunc(e); err != nil {
+		retries := d.queue.NumRequeues(key)
+		if retries < d.retryPolicy.MaxRetries {
+			log.Warnf("Error while handling event with kind: %s and key: %s, retry %d of %d will follow: %v", e.Kind, e.Key, retries+1, d.retryPolicy.MaxRetries, err)
+			eventRetries.WithLabelValues(e.Key, e.Kind).Inc()
+			d.queue.AddRateLimited(key)
+			return true
 		}
+		log.Errorf("Giving up on event with kind: %s and key: %s after %d retries: %v", e.Kind, e.Key, retries, err)
 	}
+
+	d.queue.Forget(key)
+	if d.removeOldestPendingEvent(key) > 0 {
+		d.queue.Add(key)
+		return true
+	}
+	return false
+}
+
+func (d *dispatcher) oldestPendingEvent(key string) *Event {
+	d.dispatchLock.Lock()
+	defer d.dispatchLock.Unlock()
+
+	if d.stopped || len(d.pendingEvents[key]) == 0 {
+		return nil
+	}
+	return d.pendingEvents[key][0]
+}
+
+func (d *dispatcher) removeOldestPendingEvent(key string) int {
+	d.dispatchLock.Lock()
+	defer d.dispatchLock.Unlock()
+
+	d.pendingEvents[key] = d.pendingEvents[key][1:]
+	if len(d.pendingEvents[key]) == 0 {
+		log.Infof("Stopping event worker for key: %s, which has no pending events", key)
+		delete(d.pendingEvents, key)
+		d.workers--
+	}
+	return len(d.pendingEvents[key])
+}
+
+func (d *dispatcher) isStopped() bool {
+	d.dispatchLock.Lock()
+	defer d.dispatchLock.Unlock()
+	return d.stopped
 }
@@ -1,6 +1,7 @@
 package dispatcher
 
 import (
+	"errors"
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/test"
 	"sync"
 	"testing"
@@ -11,6 +12,8 @@ import (
 	. "github.com/onsi/gomega"
 )
 
+var testRetryPolicy = RetryPolicy{BaseDelay: 10 * time.Millisecond, MaxDelay: 100 * time.Millisecond, MaxRetries: 3}
+
 func TestDispatcher(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecsWithDefaultAndCustomReporters(t, "Dispatcher Suite", test.CreateParallelReporters("dispatcher"))
@@ -21,7 +24,7 @@ var _ = Describe("cluster Event dispatcher", func() {
 		It("should successfully dispatch a single Event correctly", func() {
 			// given
 			handler := &counterHandler{}
-			dispatcher := New(handler.handle, make(chan struct{}))
+			dispatcher := New(handler.handle, testRetryPolicy, make(chan struct{}))
 
 			// when
 			dispatcher.Dispatch(&Event{Kind: "test", Key: "cluster1", Data: "test"})
@@ -32,7 +35,7 @@ var _ = Describe("cluster Event dispatcher", func() {
 
 		It("should successfully dispatch two events for different clusters", func() {
 			handler := multiEventHandler{test1Handler: &counterHandler{}, test2Handler: &counterHandler{}}
-			dispatcher := New(handler.handle, make(chan struct{}))
+			dispatcher := New(handler.handle, testRetryPolicy, make(chan struct{}))
 
 			// when
 			dispatcher.Dispatch(&Event{Kind: "test1", Key: "cluster1", Data: "test"})
@@ -45,9 +48,25 @@ var _ = Describe("cluster Event dispatcher", func() {
 	})
 
 	Context("Event handling", func() {
+		It("should forget the keys without pending events and stop their workers", func() {
+			// given
+			handler := &failingHandler{failuresBeforeSuccess: 1}
+			d := New(handler.handle, testRetryPolicy, make(chan struct{})).(*dispatcher)
+
+			// when
+			d.Dispatch(&Event{Kind: "failing", Key: "cluster1", Data: "test"})
+			d.Dispatch(&Event{Kind: "next", Key: "cluster1", Data: "test"})
+			d.Dispatch(&Event{Kind: "other", Key: "cluster2", Data: "test"})
+
+			// then
+			Eventually(handler.eventsHandled).Should(HaveLen(4))
+			Eventually(d.knownKeys).Should(BeEmpty())
+			Eventually(d.workerCount).Should(Equal(0))
+		})
+
 		Specify("two events bound for the same cluster are handled sequentially", func() {
 			handler := &timeRecordingHandler{processedEvents: make(map[string]*timeRecord)}
-			dispatcher := New(handler.handle, make(chan struct{}))
+			dispatcher := New(handler.handle, testRetryPolicy, make(chan struct{}))
 
 			dispatcher.Dispatch(&Event{Kind: "test1", Key: "cluster1", Data: "test"})
 			dispatcher.Dispatch(&Event{Kind: "test2", Key: "cluster1", Data: "test"})
@@ -57,12 +76,67 @@ var _ = Describe("cluster Event dispatcher", func() {
 		})
 	})
 
+	Context("Event retries", func() {
+		It("should handle a failed event again until it succeeds", func() {
+			// given
+			handler := &failingHandler{failuresBeforeSuccess: 2}
+			dispatcher := New(handler.handle, testRetryPolicy, make(chan struct{}))
+
+			// when
+			dispatcher.Dispatch(&Event{Kind: "failing", Key: "cluster1", Data: "test"})
+
+			// then
+			Eventually(handler.eventsHandled).Should(Equal([]string{"failing", "failing", "failing"}))
+			Consistently(handler.eventsHandled, 200*time.Millisecond).Should(HaveLen(3))
+		})
+
+		It("should report how many times the oldest event of a key has been retried", func() {
+			// given
+			handler := &failingHandler{failuresBeforeSuccess: 2}
+			dispatcher := New(handler.handle, RetryPolicy{BaseDelay: 1 * time.Minute, MaxDelay: 1 * time.Minute, MaxRetries: 3}, make(chan struct{}))
+
+			// when
+			dispatcher.Dispatch(&Event{Kind: "failing", Key: "cluster1", Data: "test"})
+
+			// then
+			Eventually(func() int { return dispatcher.Retries("cluster1") }).Should(Equal(1))
+			Expect(dispatcher.Retries("cluster2")).To(Equal(0))
+		})
+
+		It("should not handle later events for the same key before the failed event has succeeded", func() {
+			// given
+			handler := &failingHandler{failuresBeforeSuccess: 2}
+			dispatcher := New(handler.handle, testRetryPolicy, make(chan struct{}))
+
+			// when
+			dispatcher.Dispatch(&Event{Kind: "failing", Key: "cluster1", Data: "test"})
+			dispatcher.Dispatch(&Event{Kind: "next", Key: "cluster1", Data: "test"})
+
+			// then
+			Eventually(handler.eventsHandled).Should(Equal([]string{"failing", "failing", "failing", "next"}))
+			Expect(dispatcher.Retries("cluster1")).To(Equal(0))
+		})
+
+		It("should give up on an event once the maximum number of retries is reached", func() {
+			// given
+			handler := &failingHandler{failuresBeforeSuccess: 100}
+			dispatcher := New(handler.handle, testRetryPolicy, make(chan struct{}))
+
+			// when
+			dispatcher.Dispatch(&Event{Kind: "failing", Key: "cluster1", Data: "test"})
+			dispatcher.Dispatch(&Event{Kind: "next", Key: "cluster1", Data: "test"})
+
+			// then
+			Eventually(handler.eventsHandled).Should(Equal([]string{"failing", "failing", "failing", "failing", "next"}))
+		})
+	})
+
 	Context("shutting down", func() {
 		Specify("should no longer accept events for any consumer when stop channel is closed", func() {
 			// given
 			stopCh := make(chan struct{})
 			handler := &multiEventHandler{test1Handler: &counterHandler{}, test2Handler: &counterHandler{}}
-			dispatcher := New(handler.handle, stopCh)
+			dispatcher := New(handler.handle, testRetryPolicy, stopCh)
 
 			// when
 			dispatcher.Dispatch(&Event{Kind: "test1", Key: "cluster1", Data: "test"})
@@ -83,6 +157,22 @@ var _ = Describe("cluster Event dispatcher", func() {
 	})
 })
 
+func (d *dispatcher) knownKeys() []string {
+	d.dispatchLock.Lock()
+	defer d.dispatchLock.Unlock()
+	var keys []string
+	for key := range d.pendingEvents {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func (d *dispatcher) workerCount() int {
+	d.dispatchLock.Lock()
+	defer d.dispatchLock.Unlock()
+	return d.workers
+}
+
 func eventProcessedCountFor(handler *counterHandler) func() int {
 	return func() int {
 		return handler.eventProcessedCount
@@ -99,7 +189,7 @@ type timeRecordingHandler struct {
 	sync.Mutex
 }
 
-func (t *timeRecordingHandler) handle(e *Event) {
+func (t *timeRecordingHandler) handle(e *Event) error {
 	t.Lock()
 	defer t.Unlock()
 
@@ -107,6 +197,7 @@ func (t *timeRecordingHandler) handle(e *Event) {
 	time.Sleep(1 * time.Second)
 	stopTime := time.Now()
 	t.processedEvents[e.Kind] = &timeRecord{startTime: startTime, stopTime: stopTime}
+	return nil
 }
 
 func (t *timeRecordingHandler) eventProcessedCount() int {
@@ -125,8 +216,9 @@ type counterHandler struct {
 	eventProcessedCount int
 }
 
-func (b *counterHandler) handle(e *Event) {
+func (b *counterHandler) handle(e *Event) error {
 	b.eventProcessedCount++
+	return nil
 }
 
 type multiEventHandler struct {
@@ -134,10 +226,35 @@ type multiEventHandler struct {
 	test2Handler *counterHandler
 }
 
-func (m *multiEventHandler) handle(e *Event) {
+func (m *multiEventHandler) handle(e *Event) error {
 	if e.Key == "cluster1" {
-		m.test1Handler.handle(e)
+		return m.test1Handler.handle(e)
 	} else if e.Key == "cluster2" {
-		m.test2Handler.handle(e)
+		return m.test2Handler.handle(e)
+	}
+	return nil
+}
+
+type failingHandler struct {
+	failuresBeforeSuccess int
+	handledEvents         []string
+	sync.Mutex
+}
+
+func (f *failingHandler) handle(e *Event) error {
+	f.Lock()
+	defer f.Unlock()
+
+	f.handledEvents = append(f.handledEvents, e.Kind)
+	if e.Kind == "failing" && f.failuresBeforeSuccess > 0 {
+		f.failuresBeforeSuccess--
+		return errors.New("transient failure")
 	}
+	return nil
+}
+
+func (f *failingHandler) eventsHandled() []string {
+	f.Lock()
+	defer f.Unlock()
+	return append([]string{}, f.handledEvents...)
 }
@@ -123,6 +123,36 @@ var _ = Describe("Cluster Metrics", func() {
 			Expect(clusterStatus.movingNodes).To(ConsistOf("172.16.46.58", "172.16.101.30"))
 		})
 
+		It("reports the nodes which are leaving the ring", func() {
+			// given
+			jolokia.returns2LeavingNodes()
+			metrics := &PrometheusMetrics{gatherer: metricsGatherer}
+
+			// when
+			leavingNodes, err := metrics.LeavingNodes(cluster)
+
+			// then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(leavingNodes).To(ConsistOf("172.16.46.58", "172.16.101.30"))
+		})
+
+		It("reports the nodes which the ring places in a rack", func() {
+			// given
+			jolokia.returnsLiveNodes("172.0.0.1", "172.0.0.2")
+			jolokia.returnsUnreachableNodes("172.0.0.3")
+			jolokia.returnsRackForNode("racka", "172.0.0.1")
+			jolokia.returnsRackForNode("rackb", "172.0.0.2")
+			jolokia.returnsRackForNode("rackb", "172.0.0.3")
+			metrics := &PrometheusMetrics{gatherer: metricsGatherer}
+
+			// when
+			nodes, err := metrics.NodesInRack(cluster, "rackb")
+
+			// then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(nodes).To(Equal([]string{"172.0.0.2", "172.0.0.3"}))
+		})
+
 		It("returns an error when jolokia is not available", func() {
 			// given
 			jolokiaURLProvider.jolokiaIsUnavailable()
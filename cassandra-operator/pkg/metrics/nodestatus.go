@@ -1,5 +1,7 @@
 package metrics
 
+import "github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/apis/cassandra/v1alpha1"
+
 type nodeStatus struct {
 	up      bool
 	down    bool
@@ -35,6 +37,15 @@ func (n *nodeStatus) stateLabel() string {
 	return "normal"
 }
 
+func (n *nodeStatus) toNodeStatus(podName, rack string) v1alpha1.NodeStatus {
+	return v1alpha1.NodeStatus{
+		Name:     podName,
+		Rack:     rack,
+		Liveness: n.livenessLabel(),
+		State:    n.stateLabel(),
+	}
+}
+
 var allLabelPairs = []labelPair{
 	{"up", "normal"},
 	{"up", "joining"},
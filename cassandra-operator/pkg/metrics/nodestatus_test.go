@@ -3,6 +3,7 @@ package metrics
 import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/apis/cassandra/v1alpha1"
 )
 
 var _ = Describe("Node status derivation", func() {
@@ -101,5 +102,19 @@ var _ = Describe("Node status derivation", func() {
 				{"down", "moving"},
 			}))
 		})
+
+		It("converts the node status into the status reported on the Cassandra resource", func() {
+			nodeStatuses := transformClusterStatus(&clusterStatus{
+				unreachableNodes: []string{"10.0.0.1"},
+				leavingNodes:     []string{"10.0.0.1"},
+			})
+
+			Expect(nodeStatuses["10.0.0.1"].toNodeStatus("mycluster-a-0", "a")).To(Equal(v1alpha1.NodeStatus{
+				Name:     "mycluster-a-0",
+				Rack:     "a",
+				Liveness: "down",
+				State:    "leaving",
+			}))
+		})
 	})
 })
@@ -7,10 +7,12 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus"
 	log "github.com/sirupsen/logrus"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/apis/cassandra/v1alpha1"
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/cluster"
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	coreV1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"math/rand"
+	"sort"
 	"time"
 )
 
@@ -107,22 +109,65 @@ func (m *PrometheusMetrics) RemoveNodeFromMetrics(cluster *cluster.Cluster, podN
 	}
 }
 
-// UpdateMetrics updates metrics for the given cluster
-func (m *PrometheusMetrics) UpdateMetrics(cluster *cluster.Cluster) {
+// LeavingNodes returns the addresses of the nodes of the given cluster which are leaving the ring
+func (m *PrometheusMetrics) LeavingNodes(cluster *cluster.Cluster) ([]string, error) {
+	clusterStatus, err := m.gatherer.GatherMetricsFor(cluster)
+	if err != nil {
+		return nil, err
+	}
+	return clusterStatus.leavingNodes, nil
+}
+
+// LiveNodes returns the addresses of the nodes of the given cluster which are live
+func (m *PrometheusMetrics) LiveNodes(cluster *cluster.Cluster) ([]string, error) {
+	clusterStatus, err := m.gatherer.GatherMetricsFor(cluster)
+	if err != nil {
+		return nil, err
+	}
+	return clusterStatus.liveNodes, nil
+}
+
+// JoiningNodes returns the addresses of the nodes of the given cluster which are joining the ring
+func (m *PrometheusMetrics) JoiningNodes(cluster *cluster.Cluster) ([]string, error) {
+	clusterStatus, err := m.gatherer.GatherMetricsFor(cluster)
+	if err != nil {
+		return nil, err
+	}
+	return clusterStatus.joiningNodes, nil
+}
+
+// NodesInRack returns the addresses of the nodes of the given cluster which the ring reports as belonging to the given rack
+func (m *PrometheusMetrics) NodesInRack(cluster *cluster.Cluster, rackName string) ([]string, error) {
+	clusterStatus, err := m.gatherer.GatherMetricsFor(cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	var nodes []string
+	for podIP, rack := range clusterStatus.nodeRacks {
+		if rack == rackName {
+			nodes = append(nodes, podIP)
+		}
+	}
+	sort.Strings(nodes)
+	return nodes, nil
+}
+
+// UpdateMetrics updates metrics for the given cluster and returns the status of each of its nodes, ordered by pod name
+func (m *PrometheusMetrics) UpdateMetrics(cluster *cluster.Cluster) ([]v1alpha1.NodeStatus, error) {
 	podIPMapper, err := m.podsInCluster(cluster)
 	if err != nil {
-		log.Errorf("Unable to retrieve pod list for cluster %s: %v", cluster.QualifiedName(), err)
-		return
+		return nil, fmt.Errorf("unable to retrieve pod list for cluster %s: %v", cluster.QualifiedName(), err)
 	}
 
 	clusterStatus, err := m.gatherer.GatherMetricsFor(cluster)
 	if err != nil {
-		log.Errorf("Unable to gather metrics for cluster %s: %v", cluster.QualifiedName(), err)
-		return
+		return nil, fmt.Errorf("unable to gather metrics for cluster %s: %v", cluster.QualifiedName(), err)
 	}
 
 	podIPToNodeStatus := transformClusterStatus(clusterStatus)
 
+	var nodeStatuses []v1alpha1.NodeStatus
 	clusterLastKnownTopology := &clusterTopology{nodesToRack: make(map[string]string)}
 	for podIP, nodeStatus := range podIPToNodeStatus {
 		podIPMapper.withPodNameDoOrError(podIP, func(podName string) {
@@ -133,11 +178,15 @@ func (m *PrometheusMetrics) UpdateMetrics(cluster *cluster.Cluster) {
 			}
 			clusterLastKnownTopology.nodesToRack[podName] = rack
 			m.updateNodeStatus(cluster, rack, podName, nodeStatus)
+			nodeStatuses = append(nodeStatuses, nodeStatus.toNodeStatus(podName, rack))
 		})
 	}
 
 	m.lastKnownClustersTopology.Set(cluster.QualifiedName(), clusterLastKnownTopology)
 	m.clustersMetrics.clusterSizeGauge.WithLabelValues(cluster.Name(), cluster.Namespace()).Set(clusterLastKnownTopology.nodeCount())
+
+	sort.Slice(nodeStatuses, func(i, j int) bool { return nodeStatuses[i].Name < nodeStatuses[j].Name })
+	return nodeStatuses, nil
 }
 
 func (m *PrometheusMetrics) updateNodeStatus(cluster *cluster.Cluster, rack string, podName string, nodeStatus *nodeStatus) {
@@ -0,0 +1,136 @@
+package nodetool
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/client-go/util/exec"
+)
+
+const cassandraContainerName = "cassandra"
+
+var replicationOptionPattern = regexp.MustCompile(`'([^']+)': '([^']*)'`)
+
+// Nodetool provides an interface to nodetool functions running on Cassandra pods within a Kubernetes cluster.
+type Nodetool struct {
+	kubeClientset *kubernetes.Clientset
+	restConfig    *rest.Config
+}
+
+// New creates a new Nodetool using the supplied client and REST configuration to connect to Kubernetes.
+func New(kubeClientset *kubernetes.Clientset, restConfig *rest.Config) *Nodetool {
+	return &Nodetool{kubeClientset: kubeClientset, restConfig: restConfig}
+}
+
+// UpgradeSSTables rewrites the SSTables on the given Pod which are not in the current SSTable format, so that the
+// node no longer depends on the format used by the previous Cassandra version.
+func (n *Nodetool) UpgradeSSTables(pod *v1.Pod, timeout time.Duration) error {
+	log.Infof("Upgrading SSTables on pod %s.%s", pod.Namespace, pod.Name)
+	_, err := n.runCommand(pod, timeout, []string{"nodetool", "upgradesstables"})
+	return err
+}
+
+// Cleanup removes the data on the given Pod which belongs to token ranges the node no longer owns.
+// The number of tables cleaned up at the same time is left to Cassandra when jobs is nil.
+func (n *Nodetool) Cleanup(pod *v1.Pod, jobs *int32, timeout time.Duration) error {
+	log.Infof("Cleaning up node on pod %s.%s", pod.Namespace, pod.Name)
+	args := []string{"nodetool", "cleanup"}
+	if jobs != nil {
+		args = append(args, "-j", strconv.Itoa(int(*jobs)))
+	}
+	_, err := n.runCommand(pod, timeout, args)
+	return err
+}
+
+// Decommission streams the data owned by the node running on the given Pod to the remaining nodes and removes it from the ring.
+// It returns once the node has finished streaming its data.
+func (n *Nodetool) Decommission(pod *v1.Pod, timeout time.Duration) error {
+	log.Infof("Decommissioning node on pod %s.%s", pod.Namespace, pod.Name)
+	_, err := n.runCommand(pod, timeout, []string{"nodetool", "decommission"})
+	return err
+}
+
+// ReplicationFactors returns the number of replicas of each keyspace held in the supplied data centre, as defined in
+// the schema read with cqlsh on the given Pod. Keyspaces which are not replicated to the data centre are omitted.
+func (n *Nodetool) ReplicationFactors(pod *v1.Pod, dc string, timeout time.Duration) (map[string]int, error) {
+	output, err := n.runCommand(pod, timeout, []string{"cqlsh", pod.Status.PodIP, "-e", "SELECT keyspace_name, replication FROM system_schema.keyspaces"})
+	if err != nil {
+		return nil, fmt.Errorf("error while reading the keyspace replication on pod %s: %v", pod.Name, err)
+	}
+	return parseReplicationFactors(output, dc), nil
+}
+
+func parseReplicationFactors(cqlshOutput, dc string) map[string]int {
+	replicationFactors := map[string]int{}
+	for _, line := range strings.Split(cqlshOutput, "\n") {
+		columns := strings.SplitN(line, "|", 2)
+		if len(columns) != 2 || !strings.Contains(columns[1], "'class'") {
+			continue
+		}
+
+		replication := map[string]string{}
+		for _, option := range replicationOptionPattern.FindAllStringSubmatch(columns[1], -1) {
+			replication[option[1]] = option[2]
+		}
+
+		replicationFactor := replication[dc]
+		if strings.HasSuffix(replication["class"], "SimpleStrategy") {
+			replicationFactor = replication["replication_factor"]
+		}
+
+		if rf, err := strconv.Atoi(replicationFactor); err == nil && rf > 0 {
+			replicationFactors[strings.TrimSpace(columns[0])] = rf
+		}
+	}
+	return replicationFactors
+}
+
+func (n *Nodetool) runCommand(pod *v1.Pod, timeout time.Duration, args []string) (string, error) {
+	execRequest := n.kubeClientset.CoreV1().RESTClient().Post().
+		Timeout(timeout).
+		Resource("pods").
+		Name(pod.Name).
+		Namespace(pod.Namespace).
+		SubResource("exec").
+		Param("stdout", "true").
+		Param("stderr", "true").
+		Param("container", cassandraContainerName)
+
+	for _, arg := range args {
+		execRequest = execRequest.Param("command", arg)
+	}
+
+	executor, err := remotecommand.NewSPDYExecutor(n.restConfig, "POST", execRequest.URL())
+	if err != nil {
+		return "", err
+	}
+
+	stdOut := new(bytes.Buffer)
+	stdErr := new(bytes.Buffer)
+	err = executor.Stream(remotecommand.StreamOptions{
+		Stdout: stdOut,
+		Stderr: stdErr,
+	})
+
+	if err != nil {
+		if exitErr, ok := err.(exec.ExitError); ok && exitErr.Exited() {
+			return "", fmt.Errorf("`%s` failed with exit code %d: %v. stdout: %s. stderr: %s", strings.Join(args, " "), exitErr.ExitStatus(), err, stdOut.String(), stdErr.String())
+		}
+		return "", fmt.Errorf("`%s` failed with unknown exit code: %v. stdout: %s. stderr: %s", strings.Join(args, " "), err, stdOut.String(), stdErr.String())
+	}
+
+	if stdErr.String() != "" {
+		return "", fmt.Errorf("`%s` failed with stdout: %s. stderr: %s", strings.Join(args, " "), stdOut.String(), stdErr.String())
+	}
+
+	return stdOut.String(), nil
+}
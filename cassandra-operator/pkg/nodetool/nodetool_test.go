@@ -0,0 +1,52 @@
+package nodetool
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/test"
+)
+
+func TestNodetool(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecsWithDefaultAndCustomReporters(t, "Nodetool Suite", test.CreateParallelReporters("nodetool"))
+}
+
+const keyspacesOutput = `
+ keyspace_name      | replication
+--------------------+-----------------------------------------------------------------------------------------------------
+        system_auth |         {'class': 'org.apache.cassandra.locator.SimpleStrategy', 'replication_factor': '1'}
+      system_schema |                                     {'class': 'org.apache.cassandra.locator.LocalStrategy'}
+ system_distributed |         {'class': 'org.apache.cassandra.locator.SimpleStrategy', 'replication_factor': '3'}
+             system |                                     {'class': 'org.apache.cassandra.locator.LocalStrategy'}
+         keyspace_a | {'class': 'org.apache.cassandra.locator.NetworkTopologyStrategy', 'dc1': '3', 'dc2': '2'}
+         keyspace_b |              {'class': 'org.apache.cassandra.locator.NetworkTopologyStrategy', 'dc2': '1'}
+
+(6 rows)
+`
+
+var _ = Describe("replication factors", func() {
+	It("should report the replication factor of keyspaces using the SimpleStrategy", func() {
+		replicationFactors := parseReplicationFactors(keyspacesOutput, "dc1")
+		Expect(replicationFactors).To(HaveKeyWithValue("system_auth", 1))
+		Expect(replicationFactors).To(HaveKeyWithValue("system_distributed", 3))
+	})
+
+	It("should report the replication factor within the data centre of keyspaces using the NetworkTopologyStrategy", func() {
+		Expect(parseReplicationFactors(keyspacesOutput, "dc1")).To(HaveKeyWithValue("keyspace_a", 3))
+		Expect(parseReplicationFactors(keyspacesOutput, "dc2")).To(HaveKeyWithValue("keyspace_a", 2))
+	})
+
+	It("should omit local keyspaces and keyspaces not replicated to the data centre", func() {
+		replicationFactors := parseReplicationFactors(keyspacesOutput, "dc1")
+		Expect(replicationFactors).To(HaveLen(3))
+		Expect(replicationFactors).NotTo(HaveKey("system"))
+		Expect(replicationFactors).NotTo(HaveKey("system_schema"))
+		Expect(replicationFactors).NotTo(HaveKey("keyspace_b"))
+	})
+
+	It("should report no keyspaces when the output contains no keyspace", func() {
+		Expect(parseReplicationFactors("\n(0 rows)\n", "dc1")).To(BeEmpty())
+	})
+})
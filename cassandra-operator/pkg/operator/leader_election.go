@@ -0,0 +1,92 @@
+package operator
+
+import (
+	"encoding/json"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"time"
+)
+
+// LeaderLabel is set on the pod of the replica which manages the clusters, so that the operator Service only selects
+// the leader while the webhook Service selects every ready replica
+const LeaderLabel = "sky.uk/cassandra-operator-leader"
+
+// LeaderElectionConfig describes how replicas of the Operator elect the one which manages the clusters
+type LeaderElectionConfig struct {
+	// Enabled must be true when more than one replica of the Operator is running
+	Enabled bool
+	// LockNamespace and LockName identify the config map used as a lock by the replicas
+	LockNamespace string
+	LockName      string
+	// Identity distinguishes this replica from the others, e.g. its pod name
+	Identity string
+	// LeaseDuration is how long the other replicas wait before trying to acquire the lock once the leader stops renewing it
+	LeaseDuration time.Duration
+	// RenewDeadline is how long the leader keeps trying to renew the lock before giving up its leadership
+	RenewDeadline time.Duration
+	// RetryPeriod is how long replicas wait between attempts to acquire or renew the lock
+	RetryPeriod time.Duration
+}
+
+// newLeaderElector creates the leader elector which calls manageClusters once this replica has become the leader.
+// The process exits when the leadership is lost, as the state of the clusters held by this replica cannot be handed over
+// to the new leader, nor can the operations in progress be interrupted safely.
+func (o *Operator) newLeaderElector(manageClusters func()) (*leaderelection.LeaderElector, error) {
+	config := o.config.LeaderElection
+	lock, err := resourcelock.New(
+		resourcelock.ConfigMapsResourceLock,
+		config.LockNamespace,
+		config.LockName,
+		o.kubeClientset.CoreV1(),
+		resourcelock.ResourceLockConfig{Identity: config.Identity, EventRecorder: o.eventRecorder},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create lock %s.%s: %v", config.LockNamespace, config.LockName, err)
+	}
+
+	return leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:          lock,
+		LeaseDuration: config.LeaseDuration,
+		RenewDeadline: config.RenewDeadline,
+		RetryPeriod:   config.RetryPeriod,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(_ <-chan struct{}) {
+				log.Infof("Operator %s has been elected as leader and will manage clusters", config.Identity)
+				manageClusters()
+			},
+			OnStoppedLeading: func() {
+				log.Fatalf("Operator %s is no longer the leader, exiting so that clusters are only managed by the new leader", config.Identity)
+			},
+			OnNewLeader: func(identity string) {
+				log.Infof("Operator %s is the leader", identity)
+			},
+		},
+	})
+}
+
+// labelAsLeader sets LeaderLabel on the pod of this replica, or removes it when the replica is not the leader.
+// Nothing is labelled when the pod is unknown, e.g. when the operator runs outside of Kubernetes.
+func (o *Operator) labelAsLeader(leader bool) error {
+	if o.config.PodName == "" {
+		return nil
+	}
+
+	var value interface{}
+	if leader {
+		value = "true"
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"labels": map[string]interface{}{LeaderLabel: value}},
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := o.kubeClientset.CoreV1().Pods(o.config.PodNamespace).Patch(o.config.PodName, types.MergePatchType, patch); err != nil {
+		return fmt.Errorf("unable to label pod %s.%s as leader=%t: %v", o.config.PodNamespace, o.config.PodName, leader, err)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package operator
+
+import (
+	log "github.com/sirupsen/logrus"
+	informers "github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/client/informers/externalversions"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/dispatcher"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/operator/operations"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+	"sync"
+)
+
+// allNamespaces is watched when neither namespaces nor a namespace selector are configured
+const allNamespaces = ""
+
+// namespaceWatches holds the informers started for each watched namespace, so they can be stopped
+// when the namespace no longer matches the namespace selector
+type namespaceWatches struct {
+	sync.Mutex
+	watches map[string]*namespaceWatch
+}
+
+type namespaceWatch struct {
+	cassandraInformer informers.SharedInformerFactory
+	stopCh            chan struct{}
+}
+
+// watchNamespaces watches the configured namespaces, or the namespaces matching the namespace selector
+// as they come and go
+func (o *Operator) watchNamespaces() {
+	switch {
+	case o.config.NamespaceSelector != "":
+		log.Infof("Operator listening for changes in namespaces matching %s", o.config.NamespaceSelector)
+		go registerNamespaceInformer(o, o.config.NamespaceSelector).Run(o.stopCh)
+	case len(o.config.Namespaces) == 0:
+		log.Info("Operator listening for changes in any namespace")
+		o.watchNamespace(allNamespaces)
+	default:
+		log.Infof("Operator listening for changes in namespaces %v", o.config.Namespaces)
+		for _, ns := range o.config.Namespaces {
+			o.watchNamespace(ns)
+		}
+	}
+}
+
+func registerNamespaceInformer(o *Operator, selector string) cache.Controller {
+	listWatch := cache.NewFilteredListWatchFromClient(o.kubeClientset.CoreV1().RESTClient(), "namespaces", metav1.NamespaceAll, func(options *metav1.ListOptions) {
+		options.LabelSelector = selector
+	})
+	// namespaces whose labels stop matching the selector are notified as deleted
+	_, informer := cache.NewInformer(listWatch, &v1.Namespace{}, resourceResyncInterval, cache.ResourceEventHandlerFuncs{
+		AddFunc:    o.namespaceAdded,
+		DeleteFunc: o.namespaceDeleted,
+	})
+	return informer
+}
+
+func (o *Operator) namespaceAdded(obj interface{}) {
+	o.watchNamespace(obj.(*v1.Namespace).Name)
+}
+
+func (o *Operator) namespaceDeleted(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	if namespace, ok := obj.(*v1.Namespace); ok {
+		o.unwatchNamespace(namespace.Name)
+	}
+}
+
+// watchNamespace starts the Cassandra and config map informers of the namespace, unless it is already watched
+func (o *Operator) watchNamespace(ns string) {
+	o.namespaceWatches.Lock()
+	defer o.namespaceWatches.Unlock()
+
+	if _, ok := o.namespaceWatches.watches[ns]; ok {
+		return
+	}
+
+	if ns != allNamespaces {
+		log.Infof("Watching namespace %s", ns)
+	}
+	watch := &namespaceWatch{
+		cassandraInformer: registerCassandraInformer(o, ns),
+		stopCh:            make(chan struct{}),
+	}
+	o.namespaceWatches.watches[ns] = watch
+
+	watch.cassandraInformer.Start(watch.stopCh)
+	go registerConfigMapInformer(o, ns).Run(watch.stopCh)
+	go func() {
+		select {
+		case <-o.stopCh:
+			o.stopWatchingNamespace(ns)
+		case <-watch.stopCh:
+		}
+	}()
+}
+
+// unwatchNamespace stops the informers of the namespace and forgets about its clusters,
+// whose resources are left in place
+func (o *Operator) unwatchNamespace(ns string) {
+	watch := o.stopWatchingNamespace(ns)
+	if watch == nil {
+		return
+	}
+
+	log.Infof("Namespace %s is no longer watched", ns)
+	clusterDefinitions, err := watch.cassandraInformer.Core().V1alpha1().Cassandras().Lister().Cassandras(ns).List(labels.Everything())
+	if err != nil {
+		log.Errorf("Unable to list clusters in namespace %s: %v", ns, err)
+		return
+	}
+	for _, clusterDefinition := range clusterDefinitions {
+		o.eventDispatcher.Dispatch(&dispatcher.Event{Kind: operations.UnwatchCluster, Key: clusterDefinition.QualifiedName(), Data: clusterDefinition.DeepCopy()})
+	}
+}
+
+func (o *Operator) stopWatchingNamespace(ns string) *namespaceWatch {
+	o.namespaceWatches.Lock()
+	defer o.namespaceWatches.Unlock()
+
+	watch, ok := o.namespaceWatches.watches[ns]
+	if !ok {
+		return nil
+	}
+	close(watch.stopCh)
+	delete(o.namespaceWatches.watches, ns)
+	return watch
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/apis/cassandra/v1alpha1"
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/cluster"
 	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/client-go/tools/record"
 )
 
@@ -17,23 +18,25 @@ type AddSnapshotCleanupOperation struct {
 }
 
 // Execute performs the operation
-func (o *AddSnapshotCleanupOperation) Execute() {
+func (o *AddSnapshotCleanupOperation) Execute() error {
 	c, err := cluster.New(o.clusterDefinition)
 	if err != nil {
 		log.Errorf("Unable to create cluster object %s.%s: %v", o.clusterDefinition.Namespace, o.clusterDefinition.Name, err)
-		return
+		return nil
 	}
 
-	o.addSnapshotCleanupJob(c)
+	return o.addSnapshotCleanupJob(c)
 }
 
-func (o *AddSnapshotCleanupOperation) addSnapshotCleanupJob(c *cluster.Cluster) {
+func (o *AddSnapshotCleanupOperation) addSnapshotCleanupJob(c *cluster.Cluster) error {
 	_, err := o.clusterAccessor.CreateCronJobForCluster(c, c.CreateSnapshotCleanupJob())
-	if err != nil {
-		log.Errorf("Error while creating snapshot cleanup job for cluster %s: %v", c.QualifiedName(), err)
-		return
+	if errors.IsAlreadyExists(err) {
+		log.Infof("Snapshot cleanup job already exists for cluster %s", c.QualifiedName())
+	} else if err != nil {
+		return fmt.Errorf("error while creating snapshot cleanup job for cluster %s: %v", c.QualifiedName(), err)
 	}
 	o.eventRecorder.Eventf(c.Definition(), v1.EventTypeNormal, cluster.ClusterSnapshotCleanupScheduleEvent, "Snapshot cleanup scheduled for cluster %s", c.QualifiedName())
+	return nil
 }
 
 func (o *AddSnapshotCleanupOperation) String() string {
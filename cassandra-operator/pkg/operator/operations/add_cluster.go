@@ -5,6 +5,9 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/apis/cassandra/v1alpha1"
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/cluster"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/record"
 	"strings"
 )
 
@@ -14,12 +17,16 @@ type AddClusterOperation struct {
 	clusters            map[string]*cluster.Cluster
 	statefulSetAccessor *statefulSetAccessor
 	clusterDefinition   *v1alpha1.Cassandra
+	eventRecorder       record.EventRecorder
 }
 
 // Execute performs the operation
-func (o *AddClusterOperation) Execute() {
+func (o *AddClusterOperation) Execute() error {
 	log.Infof("New Cassandra cluster definition added: %s.%s", o.clusterDefinition.Namespace, o.clusterDefinition.Name)
-	configMap := o.clusterAccessor.FindCustomConfigMap(o.clusterDefinition.Namespace, o.clusterDefinition.Name)
+	configMap, err := o.clusterAccessor.FindCustomConfigMap(o.clusterDefinition)
+	if err != nil {
+		return err
+	}
 	if configMap != nil {
 		log.Infof("Found custom config map for cluster %s.%s", o.clusterDefinition.Namespace, o.clusterDefinition.Name)
 	}
@@ -27,29 +34,43 @@ func (o *AddClusterOperation) Execute() {
 	c, err := cluster.New(o.clusterDefinition)
 	if err != nil {
 		log.Errorf("Unable to create cluster %s.%s: %v", o.clusterDefinition.Namespace, o.clusterDefinition.Name, err)
-		return
+		updateCondition(o.clusterAccessor, o.clusterDefinition, v1alpha1.ConfigInvalid, v1.ConditionTrue, reasonInvalidSpec, err.Error())
+		return nil
 	}
 	o.clusters[c.QualifiedName()] = c
+	if configMap != nil {
+		labelCustomConfigMap(o.clusterAccessor, o.eventRecorder, o.clusterDefinition, configMap)
+	}
 
 	foundResources := o.clusterAccessor.FindExistingResourcesFor(c)
 	if len(foundResources) > 0 {
-		log.Infof("Resources already found for cluster %s, not attempting to recreate: %s", c.QualifiedName(), strings.Join(foundResources, ","))
+		log.Infof("Resources already found for cluster %s, only missing resources will be created: %s", c.QualifiedName(), strings.Join(foundResources, ","))
+	}
+
+	_, err = o.clusterAccessor.CreateServiceForCluster(c)
+	if errors.IsAlreadyExists(err) {
+		log.Debugf("Headless service already exists for cluster : %s", c.QualifiedName())
+	} else if err != nil {
+		return fmt.Errorf("error while creating headless service for cluster %s: %v", c.QualifiedName(), err)
 	} else {
-		_, err = o.clusterAccessor.CreateServiceForCluster(c)
-		if err != nil {
-			log.Errorf("Error while creating headless service for cluster %s: %v", c.QualifiedName(), err)
-			return
-		}
 		log.Infof("Headless service created for cluster : %s", c.QualifiedName())
+	}
 
-		err = o.statefulSetAccessor.registerStatefulSets(c, configMap)
-		if err != nil {
-			log.Errorf("Error while creating stateful sets for cluster %s: %v", c.QualifiedName(), err)
-			return
-		}
+	err = o.statefulSetAccessor.registerStatefulSets(c, configMap)
+	if err != nil {
+		return fmt.Errorf("error while creating stateful sets for cluster %s: %v", c.QualifiedName(), err)
 	}
 
 	c.Online = true
+
+	err = o.clusterAccessor.UpdateCassandraStatus(o.clusterDefinition, func(status *v1alpha1.CassandraStatus) {
+		status.ObservedGeneration = o.clusterDefinition.Generation
+		status.SetCondition(v1alpha1.ConfigInvalid, v1.ConditionFalse, reasonSpecApplied, "")
+	})
+	if err != nil {
+		return fmt.Errorf("unable to update status for cluster %s: %v", c.QualifiedName(), err)
+	}
+	return nil
 }
 
 func (o *AddClusterOperation) String() string {
@@ -3,6 +3,7 @@ package operations
 import (
 	"fmt"
 	log "github.com/sirupsen/logrus"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/apis/cassandra/v1alpha1"
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/cluster"
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/operator/operations/adjuster"
 	"k8s.io/api/core/v1"
@@ -19,18 +20,38 @@ type AddCustomConfigOperation struct {
 }
 
 // Execute performs the operation
-func (o *AddCustomConfigOperation) Execute() {
+func (o *AddCustomConfigOperation) Execute() error {
 	cassandra := o.cluster.Definition()
 	o.eventRecorder.Eventf(cassandra, v1.EventTypeNormal, cluster.ClusterUpdateEvent, "Custom config created for cluster %s", cassandra.QualifiedName())
 	for _, rack := range o.cluster.Racks() {
 		err := o.statefulSetAccessor.updateStatefulSet(o.cluster, o.configMap, &rack, o.cluster.AddCustomConfigVolumeToStatefulSet)
 		if err != nil {
-			log.Errorf("unable to add custom configMap to statefulSet for rack %s in cluster %s: %v. Other racks will not be updated", rack.Name, cassandra.QualifiedName(), err)
-			return
+			return fmt.Errorf("unable to add custom configMap to statefulSet for rack %s in cluster %s: %v. Other racks will not be updated", rack.Name, cassandra.QualifiedName(), err)
 		}
 	}
+	return nil
 }
 
 func (o *AddCustomConfigOperation) String() string {
 	return fmt.Sprintf("add custom config for cluster %s", o.cluster.QualifiedName())
 }
+
+// labelCustomConfigMap labels the custom config map with the name of the cluster when it isn't already, so that its changes
+// are watched. A config map labelled for another cluster is left untouched, as that cluster would no longer see its changes.
+// The config map is used by the cluster either way, so failing to label it is only reported.
+func labelCustomConfigMap(clusterAccessor *cluster.Accessor, eventRecorder record.EventRecorder, cassandra *v1alpha1.Cassandra, configMap *v1.ConfigMap) {
+	switch labelledCluster := configMap.Labels[cluster.OperatorLabel]; labelledCluster {
+	case cassandra.Name:
+		return
+	case "":
+		if err := clusterAccessor.LabelCustomConfigMap(cassandra, configMap); err != nil {
+			log.Warnf("Unable to label custom config map %s.%s, its changes may not be applied until cluster %s is next reconciled: %v", configMap.Namespace, configMap.Name, cassandra.QualifiedName(), err)
+			return
+		}
+		log.Infof("Custom config map %s.%s has been labelled for cluster %s", configMap.Namespace, configMap.Name, cassandra.QualifiedName())
+	default:
+		log.Warnf("Custom config map %s.%s is labelled for cluster %s, so it will not be labelled for cluster %s", configMap.Namespace, configMap.Name, labelledCluster, cassandra.QualifiedName())
+		eventRecorder.Eventf(cassandra, v1.EventTypeWarning, cluster.CustomConfigMapLabelRefusedEvent,
+			"custom config map %s is labelled for cluster %s, so its changes will only be applied to this cluster when it is reconciled", configMap.Name, labelledCluster)
+	}
+}
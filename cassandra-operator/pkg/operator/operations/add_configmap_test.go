@@ -0,0 +1,83 @@
+package operations
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/client/clientset/versioned/fake"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/cluster"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+)
+
+var _ = Describe("labelling of the custom config map of a cluster", func() {
+	var (
+		c             *cluster.Cluster
+		configMap     *corev1.ConfigMap
+		kubeClientset *kubefake.Clientset
+		accessor      *cluster.Accessor
+		eventRecorder *record.FakeRecorder
+	)
+
+	BeforeEach(func() {
+		c = aClusterWithRack("a", 1)
+		configMap = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: c.Definition().CustomConfigMapName(), Namespace: c.Namespace()}}
+		eventRecorder = record.NewFakeRecorder(10)
+	})
+
+	JustBeforeEach(func() {
+		kubeClientset = kubefake.NewSimpleClientset(configMap)
+		accessor = cluster.NewAccessor(kubeClientset, fake.NewSimpleClientset(), eventRecorder)
+	})
+
+	It("should not change the config map when looking it up", func() {
+		found, err := accessor.FindCustomConfigMap(c.Definition())
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found.Name).To(Equal(configMap.Name))
+		Expect(kubeClientset.Actions()).To(HaveLen(1))
+		Expect(kubeClientset.Actions()[0].GetVerb()).To(Equal("get"))
+	})
+
+	It("should report no config map when the cluster has none", func() {
+		definition := c.Definition()
+		definition.Spec.ConfigMapRef = &corev1.LocalObjectReference{Name: "other-config"}
+
+		found, err := accessor.FindCustomConfigMap(definition)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeNil())
+	})
+
+	It("should label a config map which isn't labelled", func() {
+		labelCustomConfigMap(accessor, eventRecorder, c.Definition(), configMap)
+
+		Expect(kubeClientset.Actions()).To(HaveLen(1))
+		patch, ok := kubeClientset.Actions()[0].(k8stesting.PatchAction)
+		Expect(ok).To(BeTrue())
+		Expect(patch.GetName()).To(Equal(configMap.Name))
+		Expect(string(patch.GetPatch())).To(Equal(`{"metadata": {"labels": {"sky.uk/cassandra-operator": "mycluster"}}}`))
+	})
+
+	Context("the config map is already labelled", func() {
+		It("should leave a config map labelled for the cluster untouched", func() {
+			configMap.Labels = map[string]string{cluster.OperatorLabel: c.Name()}
+
+			labelCustomConfigMap(accessor, eventRecorder, c.Definition(), configMap)
+
+			Expect(kubeClientset.Actions()).To(BeEmpty())
+			Expect(eventRecorder.Events).To(BeEmpty())
+		})
+
+		It("should refuse to relabel a config map labelled for another cluster", func() {
+			configMap.Labels = map[string]string{cluster.OperatorLabel: "othercluster"}
+
+			labelCustomConfigMap(accessor, eventRecorder, c.Definition(), configMap)
+
+			Expect(kubeClientset.Actions()).To(BeEmpty())
+			Expect(eventRecorder.Events).To(Receive(HavePrefix("Warning %s", cluster.CustomConfigMapLabelRefusedEvent)))
+		})
+	})
+})
@@ -0,0 +1,46 @@
+package operations
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/apis/cassandra/v1alpha1"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/cluster"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/record"
+)
+
+// AddRepairOperation describes what the operator does when a repair schedule is added for a cluster
+type AddRepairOperation struct {
+	clusterDefinition *v1alpha1.Cassandra
+	clusterAccessor   *cluster.Accessor
+	eventRecorder     record.EventRecorder
+}
+
+// Execute performs the operation
+func (o *AddRepairOperation) Execute() error {
+	c, err := cluster.New(o.clusterDefinition)
+	if err != nil {
+		log.Errorf("Unable to create cluster object %s.%s: %v", o.clusterDefinition.Namespace, o.clusterDefinition.Name, err)
+		return nil
+	}
+
+	return o.addRepairJob(c)
+}
+
+func (o *AddRepairOperation) addRepairJob(c *cluster.Cluster) error {
+	_, err := o.clusterAccessor.CreateCronJobForCluster(c, c.CreateRepairJob())
+	if errors.IsAlreadyExists(err) {
+		log.Infof("Repair job already exists for cluster %s", c.QualifiedName())
+	} else if err != nil {
+		updateCondition(o.clusterAccessor, o.clusterDefinition, v1alpha1.RepairScheduled, v1.ConditionFalse, reasonRepairJobFailed, err.Error())
+		return fmt.Errorf("error while creating repair job for cluster %s: %v", c.QualifiedName(), err)
+	}
+	o.eventRecorder.Eventf(c.Definition(), v1.EventTypeNormal, cluster.ClusterRepairScheduleEvent, "Repair scheduled for cluster %s", c.QualifiedName())
+	updateCondition(o.clusterAccessor, o.clusterDefinition, v1alpha1.RepairScheduled, v1.ConditionTrue, reasonRepairJobScheduled, fmt.Sprintf("repair job %s scheduled", c.Definition().RepairJobName()))
+	return nil
+}
+
+func (o *AddRepairOperation) String() string {
+	return fmt.Sprintf("add repair schedule for cluster %s", o.clusterDefinition.QualifiedName())
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/apis/cassandra/v1alpha1"
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/cluster"
 	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/client-go/tools/record"
 )
 
@@ -17,23 +18,27 @@ type AddSnapshotOperation struct {
 }
 
 // Execute performs the operation
-func (o *AddSnapshotOperation) Execute() {
+func (o *AddSnapshotOperation) Execute() error {
 	c, err := cluster.New(o.clusterDefinition)
 	if err != nil {
 		log.Errorf("Unable to create cluster object %s.%s: %v", o.clusterDefinition.Namespace, o.clusterDefinition.Name, err)
-		return
+		return nil
 	}
 
-	o.addSnapshotJob(c)
+	return o.addSnapshotJob(c)
 }
 
-func (o *AddSnapshotOperation) addSnapshotJob(c *cluster.Cluster) {
+func (o *AddSnapshotOperation) addSnapshotJob(c *cluster.Cluster) error {
 	_, err := o.clusterAccessor.CreateCronJobForCluster(c, c.CreateSnapshotJob())
-	if err != nil {
-		log.Errorf("Error while creating snapshot creation job for cluster %s: %v", c.QualifiedName(), err)
-		return
+	if errors.IsAlreadyExists(err) {
+		log.Infof("Snapshot creation job already exists for cluster %s", c.QualifiedName())
+	} else if err != nil {
+		updateCondition(o.clusterAccessor, o.clusterDefinition, v1alpha1.SnapshotScheduled, v1.ConditionFalse, reasonSnapshotJobFailed, err.Error())
+		return fmt.Errorf("error while creating snapshot creation job for cluster %s: %v", c.QualifiedName(), err)
 	}
 	o.eventRecorder.Eventf(c.Definition(), v1.EventTypeNormal, cluster.ClusterSnapshotCreationScheduleEvent, "Snapshot creation scheduled for cluster %s", c.QualifiedName())
+	updateCondition(o.clusterAccessor, o.clusterDefinition, v1alpha1.SnapshotScheduled, v1.ConditionTrue, reasonSnapshotJobScheduled, fmt.Sprintf("snapshot job %s scheduled", c.Definition().SnapshotJobName()))
+	return nil
 }
 
 func (o *AddSnapshotOperation) String() string {
@@ -2,12 +2,16 @@ package adjuster
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/apis/cassandra/v1alpha1"
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/cluster"
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/operator/hash"
 	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"reflect"
+	"strings"
 	"text/template"
 	"time"
 )
@@ -16,13 +20,30 @@ const statefulSetPatchTemplate = `{
   "spec": {
     "replicas": {{ .Replicas }},
     "template": {
+      "metadata": {
+        "labels": {{ .PodLabels }},
+        "annotations": {{ .PodAnnotations }}
+      },
       "spec": {
 		"initContainers": [{
            "name": "cassandra-bootstrapper",	
-           "image": "{{ .PodBootstrapperImage }}"	
-		}],
+           "image": "{{ .PodBootstrapperImage }}",
+           "env": [{
+             "name": "POD_CPU_MILLICORES",
+             "value": "{{ .PodCPUMillicores }}"
+           }, {
+             "name": "POD_MEMORY_BYTES",
+             "value": "{{ .PodMemoryBytes }}"
+           }],
+           "resources": {{ .PodBootstrapperResources }}
+		}, {
+           "name": "init-config",
+           "image": "{{ .PodImage }}",
+           "resources": {{ .PodInitConfigResources }}
+		}{{ range .PodInitContainers }}, {{ . }}{{ end }}],
         "containers": [{
            "name": "cassandra",
+           "image": "{{ .PodImage }}",
            "livenessProbe": {
              "failureThreshold": {{ .PodLivenessProbe.FailureThreshold }},
              "initialDelaySeconds": {{ .PodLivenessProbe.InitialDelaySeconds }},
@@ -37,16 +58,26 @@ const statefulSetPatchTemplate = `{
              "successThreshold": {{ .PodReadinessProbe.SuccessThreshold }},
              "timeoutSeconds": {{ .PodReadinessProbe.TimeoutSeconds }}
            },
+           "env": {{ .PodEnv }},
+           "volumeMounts": {{ .PodExtraVolumeMounts }},
            "resources": {
              "requests": {
                "cpu": "{{ .PodCPU }}",
-               "memory": "{{ .PodMemory }}"
+               "memory": "{{ .PodMemory }}",
+               "ephemeral-storage": {{ .PodEphemeralStorage }}
              },
 			 "limits": {
-               "memory": "{{ .PodMemory }}"
+               "cpu": {{ .PodCPULimit }},
+               "memory": "{{ .PodMemory }}",
+               "ephemeral-storage": {{ .PodEphemeralStorageLimit }}
              }
 	       }
-        }]
+        }{{ range .PodSidecars }}, {{ . }}{{ end }}],
+        "volumes": {{ .PodExtraVolumes }},
+        "tolerations": {{ .PodTolerations }},
+        "nodeSelector": {{ .PodNodeSelector }},
+        "priorityClassName": {{ .PodPriorityClassName }},
+        "affinity": {{ .PodAffinity }}
       }
     }
   }
@@ -70,12 +101,20 @@ const scaleDownPatchTemplate = `{"spec": {"replicas": %d}}`
 type ClusterChangeType string
 
 const (
-	deleteRack ClusterChangeType = "delete rack"
+	// DeleteRack means that all nodes of an existing rack need to be decommissioned before its stateful set is removed.
+	DeleteRack ClusterChangeType = "delete rack"
 	// AddRack means that a new rack should be added to a cluster.
 	AddRack ClusterChangeType = "add rack"
 	// UpdateRack means that an existing rack in the cluster needs to be updated.
-	UpdateRack    ClusterChangeType = "update rack"
-	scaleDownRack ClusterChangeType = "scale down rack"
+	UpdateRack ClusterChangeType = "update rack"
+	// UpgradeRack means that an existing rack in the cluster needs to be updated to a new Cassandra image,
+	// after which the SSTables of its nodes need to be upgraded.
+	UpgradeRack ClusterChangeType = "upgrade rack"
+	// ScaleDownRack means that nodes need to be decommissioned and removed from an existing rack in the cluster.
+	ScaleDownRack ClusterChangeType = "scale down rack"
+	// ResizeRackStorage means that the persistent volume claims of an existing rack need to be expanded,
+	// after which its stateful set needs to be recreated with the new volume claim template.
+	ResizeRackStorage ClusterChangeType = "resize rack storage"
 )
 
 // ClusterChange describes a single change which needs to be applied to Kubernetes in order for the running cluster to
@@ -85,7 +124,8 @@ type ClusterChange struct {
 	Rack             v1alpha1.Rack
 	ChangeType       ClusterChangeType
 	Patch            string
-	nodesToScaleDown int
+	NodesToScaleDown int
+	NodesToScaleUp   int
 }
 
 // Adjuster calculates the set of changes which need to be applied to Kubernetes in order for the running
@@ -95,12 +135,31 @@ type Adjuster struct {
 }
 
 type patchProperties struct {
-	Replicas             int32
-	PodBootstrapperImage string
-	PodCPU               string
-	PodMemory            string
-	PodLivenessProbe     *v1alpha1.Probe
-	PodReadinessProbe    *v1alpha1.Probe
+	Replicas                 int32
+	PodImage                 string
+	PodBootstrapperImage     string
+	PodCPU                   string
+	PodMemory                string
+	PodCPULimit              string
+	PodEphemeralStorage      string
+	PodEphemeralStorageLimit string
+	PodBootstrapperResources string
+	PodInitConfigResources   string
+	PodCPUMillicores         int64
+	PodMemoryBytes           int64
+	PodLivenessProbe         *v1alpha1.Probe
+	PodReadinessProbe        *v1alpha1.Probe
+	PodLabels                string
+	PodAnnotations           string
+	PodEnv                   string
+	PodInitContainers        []string
+	PodSidecars              []string
+	PodExtraVolumes          string
+	PodExtraVolumeMounts     string
+	PodTolerations           string
+	PodNodeSelector          string
+	PodPriorityClassName     string
+	PodAffinity              string
 }
 
 // New creates a new Adjuster.
@@ -114,8 +173,8 @@ func New() (*Adjuster, error) {
 }
 
 // ChangesForCluster compares oldCluster with newCluster, and produces an ordered list of ClusterChanges which need to
-// be applied in order for the running cluster to be in the state matching newCluster.
-func (r *Adjuster) ChangesForCluster(oldCluster *v1alpha1.CassandraSpec, newCluster *v1alpha1.CassandraSpec) ([]ClusterChange, error) {
+// be applied in order for the running cluster with the given name to be in the state matching newCluster.
+func (r *Adjuster) ChangesForCluster(clusterName string, oldCluster *v1alpha1.CassandraSpec, newCluster *v1alpha1.CassandraSpec) ([]ClusterChange, error) {
 	addedRacks, matchedRacks, deletedRacks := r.matchRacks(oldCluster, newCluster)
 	if err := r.ensureChangeIsAllowed(oldCluster, newCluster, matchedRacks); err != nil {
 		return nil, err
@@ -129,28 +188,65 @@ func (r *Adjuster) ChangesForCluster(oldCluster *v1alpha1.CassandraSpec, newClus
 	}
 
 	for _, deletedRack := range deletedRacks {
-		clusterChanges = append(clusterChanges, ClusterChange{Rack: deletedRack, ChangeType: deleteRack})
+		clusterChanges = append(clusterChanges, ClusterChange{Rack: deletedRack, ChangeType: DeleteRack})
 	}
 
 	scaledDownRacks := r.scaledDownRacks(matchedRacks)
 	for _, scaledDownRack := range scaledDownRacks {
 		nodesToScaleDown := scaledDownRack.old.Replicas - scaledDownRack.new.Replicas
-		clusterChanges = append(clusterChanges, ClusterChange{Rack: scaledDownRack.new, ChangeType: scaleDownRack, Patch: r.scaleDownPatchForRack(int(nodesToScaleDown)), nodesToScaleDown: int(nodesToScaleDown)})
+		clusterChanges = append(clusterChanges, ClusterChange{Rack: scaledDownRack.new, ChangeType: ScaleDownRack, Patch: r.ScaleDownPatch(scaledDownRack.new.Replicas), NodesToScaleDown: int(nodesToScaleDown)})
 	}
 
-	if r.podSpecHasChanged(oldCluster, newCluster) {
+	if r.imageHasChanged(oldCluster, newCluster) {
+		for _, matchedRack := range matchedRacks {
+			clusterChanges = append(clusterChanges, ClusterChange{Rack: matchedRack.new, ChangeType: UpgradeRack, Patch: r.patchForRack(clusterName, matchedRack, oldCluster, newCluster, changeTime), NodesToScaleUp: matchedRack.nodesToScaleUp()})
+		}
+	} else if r.podSpecHasChanged(oldCluster, newCluster) {
 		for _, matchedRack := range matchedRacks {
-			clusterChanges = append(clusterChanges, ClusterChange{Rack: matchedRack.new, ChangeType: UpdateRack, Patch: r.patchForRack(&matchedRack.new, newCluster, changeTime)})
+			clusterChanges = append(clusterChanges, ClusterChange{Rack: matchedRack.new, ChangeType: UpdateRack, Patch: r.patchForRack(clusterName, matchedRack, oldCluster, newCluster, changeTime), NodesToScaleUp: matchedRack.nodesToScaleUp()})
 		}
 	} else {
-		for _, matchedRack := range r.scaledUpRacks(matchedRacks) {
-			clusterChanges = append(clusterChanges, ClusterChange{Rack: matchedRack, ChangeType: UpdateRack, Patch: r.patchForRack(&matchedRack, newCluster, changeTime)})
+		for _, matchedRack := range r.updatedRacks(oldCluster, newCluster, matchedRacks) {
+			clusterChanges = append(clusterChanges, ClusterChange{Rack: matchedRack.new, ChangeType: UpdateRack, Patch: r.patchForRack(clusterName, matchedRack, oldCluster, newCluster, changeTime), NodesToScaleUp: matchedRack.nodesToScaleUp()})
+		}
+	}
+
+	if r.storageSizeHasIncreased(oldCluster, newCluster) {
+		for _, matchedRack := range matchedRacks {
+			clusterChanges = append(clusterChanges, ClusterChange{Rack: matchedRack.new, ChangeType: ResizeRackStorage})
 		}
 	}
 
 	return clusterChanges, nil
 }
 
+// ValidateChange checks that the change from oldCluster to newCluster only modifies properties which are allowed to change.
+func (r *Adjuster) ValidateChange(oldCluster *v1alpha1.CassandraSpec, newCluster *v1alpha1.CassandraSpec) error {
+	_, matchedRacks, _ := r.matchRacks(oldCluster, newCluster)
+	return r.ensureChangeIsAllowed(oldCluster, newCluster, matchedRacks)
+}
+
+// ForbiddenChanges lists all the changes from oldCluster to newCluster which are not allowed, rather than only the first one.
+func (r *Adjuster) ForbiddenChanges(oldCluster *v1alpha1.CassandraSpec, newCluster *v1alpha1.CassandraSpec) []string {
+	_, matchedRacks, _ := r.matchRacks(oldCluster, newCluster)
+	var forbiddenChanges []string
+	for _, err := range r.forbiddenChanges(oldCluster, newCluster, matchedRacks) {
+		forbiddenChanges = append(forbiddenChanges, err.Error())
+	}
+	return forbiddenChanges
+}
+
+// RequiresRollingRestart reports whether the change from oldCluster to newCluster modifies the pod template of the given rack,
+// in which case the pods of the rack are restarted one at a time
+func (r *Adjuster) RequiresRollingRestart(oldCluster *v1alpha1.CassandraSpec, newCluster *v1alpha1.CassandraSpec, rackName string) bool {
+	if r.imageHasChanged(oldCluster, newCluster) || r.podSpecHasChanged(oldCluster, newCluster) {
+		return true
+	}
+	oldRack, inOldCluster := findRack(v1alpha1.Rack{Name: rackName}, oldCluster.Racks)
+	newRack, inNewCluster := findRack(v1alpha1.Rack{Name: rackName}, newCluster.Racks)
+	return inOldCluster && inNewCluster && r.schedulingHasChanged(oldCluster, newCluster, matchedRack{old: *oldRack, new: *newRack})
+}
+
 // CreateConfigMapHashPatchForRack produces a ClusterChange which need to be applied for the given rack
 func (r *Adjuster) CreateConfigMapHashPatchForRack(rack *v1alpha1.Rack, configMap *v1.ConfigMap) *ClusterChange {
 	configMapHash := hash.ConfigMapHash(configMap)
@@ -158,14 +254,35 @@ func (r *Adjuster) CreateConfigMapHashPatchForRack(rack *v1alpha1.Rack, configMa
 	return &ClusterChange{Rack: *rack, ChangeType: UpdateRack, Patch: patch}
 }
 
-func (r *Adjuster) patchForRack(rack *v1alpha1.Rack, newCluster *v1alpha1.CassandraSpec, changeTime time.Time) string {
+func (r *Adjuster) patchForRack(clusterName string, rack matchedRack, oldCluster, newCluster *v1alpha1.CassandraSpec, changeTime time.Time) string {
+	oldScheduling := cluster.SchedulingForRack(&oldCluster.Pod, &rack.old)
+	newScheduling := cluster.SchedulingForRack(&newCluster.Pod, &rack.new)
 	props := patchProperties{
-		Replicas:             rack.Replicas,
-		PodBootstrapperImage: newCluster.Pod.BootstrapperImage,
-		PodCPU:               newCluster.Pod.CPU.String(),
-		PodMemory:            newCluster.Pod.Memory.String(),
-		PodLivenessProbe:     newCluster.Pod.LivenessProbe,
-		PodReadinessProbe:    newCluster.Pod.ReadinessProbe,
+		Replicas:                 rack.new.Replicas,
+		PodImage:                 imageOrDefault(newCluster),
+		PodBootstrapperImage:     newCluster.Pod.BootstrapperImage,
+		PodCPU:                   newCluster.Pod.CPU.String(),
+		PodMemory:                newCluster.Pod.Memory.String(),
+		PodCPULimit:              toJSON(newCluster.Pod.CPULimit),
+		PodEphemeralStorage:      toJSON(newCluster.Pod.EphemeralStorage),
+		PodEphemeralStorageLimit: toJSON(newCluster.Pod.EphemeralStorageLimit),
+		PodBootstrapperResources: resourcesPatch(cluster.BootstrapperResources(&oldCluster.Pod), cluster.BootstrapperResources(&newCluster.Pod)),
+		PodInitConfigResources:   resourcesPatch(cluster.InitConfigResources(&oldCluster.Pod), cluster.InitConfigResources(&newCluster.Pod)),
+		PodCPUMillicores:         newCluster.Pod.CPU.MilliValue(),
+		PodMemoryBytes:           newCluster.Pod.Memory.Value(),
+		PodLivenessProbe:         newCluster.Pod.LivenessProbe,
+		PodReadinessProbe:        newCluster.Pod.ReadinessProbe,
+		PodLabels:                mapPatch(oldCluster.Pod.Labels, newCluster.Pod.Labels),
+		PodAnnotations:           mapPatch(oldCluster.Pod.Annotations, newCluster.Pod.Annotations),
+		PodEnv:                   jsonArray(listPatch("name", envItems(oldCluster.Pod.Env), envItems(newCluster.Pod.Env))),
+		PodInitContainers:        listPatch("name", containerItems(oldCluster.Pod.InitContainers), containerItems(newCluster.Pod.InitContainers)),
+		PodSidecars:              listPatch("name", containerItems(oldCluster.Pod.Sidecars), containerItems(newCluster.Pod.Sidecars)),
+		PodExtraVolumes:          jsonArray(listPatch("name", volumeItems(oldCluster.Pod.ExtraVolumes), volumeItems(newCluster.Pod.ExtraVolumes))),
+		PodExtraVolumeMounts:     jsonArray(listPatch("mountPath", volumeMountItems(oldCluster.Pod.ExtraVolumeMounts), volumeMountItems(newCluster.Pod.ExtraVolumeMounts))),
+		PodTolerations:           toJSON(newScheduling.Tolerations),
+		PodNodeSelector:          mapPatch(oldScheduling.NodeSelector, newScheduling.NodeSelector),
+		PodPriorityClassName:     toJSON(newScheduling.PriorityClassName),
+		PodAffinity:              affinityPatch(cluster.Affinity(clusterName, &rack.new, newScheduling)),
 	}
 	var patch bytes.Buffer
 	r.patchTemplate.Execute(&patch, props)
@@ -173,58 +290,230 @@ func (r *Adjuster) patchForRack(rack *v1alpha1.Rack, newCluster *v1alpha1.Cassan
 	return patchString
 }
 
-func (r *Adjuster) scaleDownPatchForRack(nodesToScaleDown int) string {
-	return fmt.Sprintf(scaleDownPatchTemplate, nodesToScaleDown)
+// mapPatch produces the JSON patch of a map of labels or annotations, in which the entries no longer defined are removed
+func mapPatch(oldEntries, newEntries map[string]string) string {
+	patch := map[string]*string{}
+	for key := range oldEntries {
+		patch[key] = nil
+	}
+	for key, value := range newEntries {
+		value := value
+		patch[key] = &value
+	}
+	return toJSON(patch)
+}
+
+// affinityPatch produces the JSON patch of the affinity of a pod, in which the anti-affinity term of the mode
+// no longer used is removed
+func affinityPatch(affinity *v1.Affinity) string {
+	return toJSON(map[string]interface{}{
+		"nodeAffinity": affinity.NodeAffinity,
+		"podAntiAffinity": map[string]interface{}{
+			"requiredDuringSchedulingIgnoredDuringExecution":  affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution,
+			"preferredDuringSchedulingIgnoredDuringExecution": affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution,
+		},
+	})
+}
+
+// resourcesPatch produces the JSON patch of the resources of a container, in which the quantities no longer defined are removed
+func resourcesPatch(oldResources, newResources v1.ResourceRequirements) string {
+	return toJSON(map[string]map[v1.ResourceName]*resource.Quantity{
+		"requests": resourceListPatch(oldResources.Requests, newResources.Requests),
+		"limits":   resourceListPatch(oldResources.Limits, newResources.Limits),
+	})
+}
+
+func resourceListPatch(oldResources, newResources v1.ResourceList) map[v1.ResourceName]*resource.Quantity {
+	patch := map[v1.ResourceName]*resource.Quantity{}
+	for name := range oldResources {
+		patch[name] = nil
+	}
+	for name, quantity := range newResources {
+		quantity := quantity
+		patch[name] = &quantity
+	}
+	return patch
+}
+
+// listItem is an item of a list patched with a strategic merge patch, identified by the value of its merge key
+type listItem struct {
+	key   string
+	value interface{}
+}
+
+// listPatch produces the JSON items of the strategic merge patch of a list, in which the items no longer defined are deleted
+func listPatch(mergeKey string, oldItems, newItems []listItem) []string {
+	var patch []string
+	for _, oldItem := range oldItems {
+		if !hasItem(oldItem.key, newItems) {
+			patch = append(patch, toJSON(map[string]string{mergeKey: oldItem.key, "$patch": "delete"}))
+		}
+	}
+	for _, newItem := range newItems {
+		patch = append(patch, toJSON(newItem.value))
+	}
+	return patch
+}
+
+func hasItem(key string, items []listItem) bool {
+	for _, item := range items {
+		if item.key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func envItems(env []v1.EnvVar) []listItem {
+	var items []listItem
+	for _, envVar := range env {
+		items = append(items, listItem{envVar.Name, envVar})
+	}
+	return items
+}
+
+func containerItems(containers []v1.Container) []listItem {
+	var items []listItem
+	for _, container := range containers {
+		items = append(items, listItem{container.Name, container})
+	}
+	return items
+}
+
+func volumeItems(volumes []v1.Volume) []listItem {
+	var items []listItem
+	for _, volume := range volumes {
+		items = append(items, listItem{volume.Name, volume})
+	}
+	return items
+}
+
+func volumeMountItems(mounts []v1.VolumeMount) []listItem {
+	var items []listItem
+	for _, mount := range mounts {
+		items = append(items, listItem{mount.MountPath, mount})
+	}
+	return items
+}
+
+func jsonArray(items []string) string {
+	return fmt.Sprintf("[%s]", strings.Join(items, ", "))
+}
+
+func toJSON(value interface{}) string {
+	// the properties of a pod can always be marshalled
+	content, _ := json.Marshal(value)
+	return string(content)
+}
+
+// ScaleDownPatch produces the patch which reduces the number of replicas of a rack to the supplied value
+func (r *Adjuster) ScaleDownPatch(replicas int32) string {
+	return fmt.Sprintf(scaleDownPatchTemplate, replicas)
 }
 
 func (r *Adjuster) ensureChangeIsAllowed(oldCluster, newCluster *v1alpha1.CassandraSpec, matchedRacks []matchedRack) error {
+	if forbiddenChanges := r.forbiddenChanges(oldCluster, newCluster, matchedRacks); len(forbiddenChanges) > 0 {
+		return forbiddenChanges[0]
+	}
+	return nil
+}
+
+func (r *Adjuster) forbiddenChanges(oldCluster, newCluster *v1alpha1.CassandraSpec, matchedRacks []matchedRack) []error {
+	var forbiddenChanges []error
 	if !reflect.DeepEqual(oldCluster.DC, newCluster.DC) {
 		currentDC := oldCluster.DC
 		if currentDC == "" {
 			currentDC = cluster.DefaultDCName
 		}
-		return fmt.Errorf("changing dc is forbidden. The dc used will continue to be '%v'", currentDC)
+		forbiddenChanges = append(forbiddenChanges, fmt.Errorf("changing dc is forbidden. The dc used will continue to be '%v'", currentDC))
 	}
 
-	if !reflect.DeepEqual(oldCluster.Pod.Image, newCluster.Pod.Image) {
-		currentImage := oldCluster.Pod.Image
-		if currentImage == "" {
-			currentImage = cluster.DefaultCassandraImage
+	if r.imageHasChanged(oldCluster, newCluster) {
+		if err := validateImageChange(imageOrDefault(oldCluster), imageOrDefault(newCluster)); err != nil {
+			forbiddenChanges = append(forbiddenChanges, err)
 		}
-		return fmt.Errorf("changing image is forbidden. The image used will continue to be '%v'", currentImage)
 	}
+
 	if !reflect.DeepEqual(oldCluster.UseEmptyDir, newCluster.UseEmptyDir) {
-		return fmt.Errorf("changing useEmptyDir is forbidden. The useEmptyDir used will continue to be '%v'", oldCluster.UseEmptyDir)
+		forbiddenChanges = append(forbiddenChanges, fmt.Errorf("changing useEmptyDir is forbidden. The useEmptyDir used will continue to be '%v'", oldCluster.UseEmptyDir))
+	}
+
+	if newCluster.Pod.StorageSize.Cmp(oldCluster.Pod.StorageSize) < 0 {
+		forbiddenChanges = append(forbiddenChanges, fmt.Errorf("reducing storageSize is forbidden. The storageSize used will continue to be '%s'", oldCluster.Pod.StorageSize.String()))
 	}
 
 	for _, matchedRack := range matchedRacks {
 		if matchedRack.new.StorageClass != matchedRack.old.StorageClass {
-			return fmt.Errorf("changing storageClass for rack '%s' is forbidden. The storageClass used will continue to be '%s'", matchedRack.old.Name, matchedRack.old.StorageClass)
+			forbiddenChanges = append(forbiddenChanges, fmt.Errorf("changing storageClass for rack '%s' is forbidden. The storageClass used will continue to be '%s'", matchedRack.old.Name, matchedRack.old.StorageClass))
 		}
 
 		if matchedRack.new.Zone != matchedRack.old.Zone {
-			return fmt.Errorf("changing zone for rack '%s' is forbidden. The zone used will continue to be '%s'", matchedRack.old.Name, matchedRack.old.Zone)
+			forbiddenChanges = append(forbiddenChanges, fmt.Errorf("changing zone for rack '%s' is forbidden. The zone used will continue to be '%s'", matchedRack.old.Name, matchedRack.old.Zone))
 		}
 	}
-	return nil
+	return forbiddenChanges
+}
+
+// storageSizeHasIncreased reports whether the persistent volumes of the cluster need to be expanded.
+// Clusters using emptyDir have no storage size to compare.
+func (r *Adjuster) storageSizeHasIncreased(oldCluster, newCluster *v1alpha1.CassandraSpec) bool {
+	return !newCluster.UseEmptyDir && newCluster.Pod.StorageSize.Cmp(oldCluster.Pod.StorageSize) > 0
+}
+
+func (r *Adjuster) imageHasChanged(oldCluster, newCluster *v1alpha1.CassandraSpec) bool {
+	return imageOrDefault(oldCluster) != imageOrDefault(newCluster)
+}
+
+func imageOrDefault(clusterSpec *v1alpha1.CassandraSpec) string {
+	if clusterSpec.Pod.Image == "" {
+		return cluster.DefaultCassandraImage
+	}
+	return clusterSpec.Pod.Image
 }
 
 func (r *Adjuster) podSpecHasChanged(oldCluster, newCluster *v1alpha1.CassandraSpec) bool {
 	return !reflect.DeepEqual(oldCluster.Pod.CPU, newCluster.Pod.CPU) ||
 		!reflect.DeepEqual(oldCluster.Pod.Memory, newCluster.Pod.Memory) ||
+		!equality.Semantic.DeepEqual(cluster.CassandraResources(&oldCluster.Pod), cluster.CassandraResources(&newCluster.Pod)) ||
+		!equality.Semantic.DeepEqual(cluster.BootstrapperResources(&oldCluster.Pod), cluster.BootstrapperResources(&newCluster.Pod)) ||
+		!equality.Semantic.DeepEqual(cluster.InitConfigResources(&oldCluster.Pod), cluster.InitConfigResources(&newCluster.Pod)) ||
 		!reflect.DeepEqual(oldCluster.Pod.LivenessProbe, newCluster.Pod.LivenessProbe) ||
 		!reflect.DeepEqual(oldCluster.Pod.ReadinessProbe, newCluster.Pod.ReadinessProbe) ||
-		!reflect.DeepEqual(oldCluster.Pod.BootstrapperImage, newCluster.Pod.BootstrapperImage)
+		!reflect.DeepEqual(oldCluster.Pod.BootstrapperImage, newCluster.Pod.BootstrapperImage) ||
+		entriesHaveChanged(oldCluster.Pod.Labels, newCluster.Pod.Labels) ||
+		entriesHaveChanged(oldCluster.Pod.Annotations, newCluster.Pod.Annotations) ||
+		listHasChanged(oldCluster.Pod.Env, newCluster.Pod.Env, len(oldCluster.Pod.Env), len(newCluster.Pod.Env)) ||
+		listHasChanged(oldCluster.Pod.InitContainers, newCluster.Pod.InitContainers, len(oldCluster.Pod.InitContainers), len(newCluster.Pod.InitContainers)) ||
+		listHasChanged(oldCluster.Pod.Sidecars, newCluster.Pod.Sidecars, len(oldCluster.Pod.Sidecars), len(newCluster.Pod.Sidecars)) ||
+		listHasChanged(oldCluster.Pod.ExtraVolumes, newCluster.Pod.ExtraVolumes, len(oldCluster.Pod.ExtraVolumes), len(newCluster.Pod.ExtraVolumes)) ||
+		listHasChanged(oldCluster.Pod.ExtraVolumeMounts, newCluster.Pod.ExtraVolumeMounts, len(oldCluster.Pod.ExtraVolumeMounts), len(newCluster.Pod.ExtraVolumeMounts))
 }
 
-func (r *Adjuster) scaledUpRacks(matchedRacks []matchedRack) []v1alpha1.Rack {
-	var scaledUpRacks []v1alpha1.Rack
+// entriesHaveChanged reports whether the labels or annotations differ, an empty map being the same as no map at all
+func entriesHaveChanged(oldEntries, newEntries map[string]string) bool {
+	return (len(oldEntries) > 0 || len(newEntries) > 0) && !reflect.DeepEqual(oldEntries, newEntries)
+}
+
+// listHasChanged reports whether the items of a list differ, an empty list being the same as no list at all
+func listHasChanged(oldItems, newItems interface{}, oldCount, newCount int) bool {
+	return (oldCount > 0 || newCount > 0) && !equality.Semantic.DeepEqual(oldItems, newItems)
+}
+
+// schedulingHasChanged reports whether the pods of the rack are scheduled differently,
+// once the scheduling of the cluster and the one of the rack are combined
+func (r *Adjuster) schedulingHasChanged(oldCluster, newCluster *v1alpha1.CassandraSpec, matchedRack matchedRack) bool {
+	return !equality.Semantic.DeepEqual(cluster.SchedulingForRack(&oldCluster.Pod, &matchedRack.old), cluster.SchedulingForRack(&newCluster.Pod, &matchedRack.new))
+}
+
+// updatedRacks returns the racks which are scaled up or whose pods are scheduled differently, when the pod spec is unchanged
+func (r *Adjuster) updatedRacks(oldCluster, newCluster *v1alpha1.CassandraSpec, matchedRacks []matchedRack) []matchedRack {
+	var updatedRacks []matchedRack
 	for _, matchedRack := range matchedRacks {
-		if matchedRack.new.Replicas > matchedRack.old.Replicas {
-			scaledUpRacks = append(scaledUpRacks, matchedRack.new)
+		if matchedRack.nodesToScaleUp() > 0 || r.schedulingHasChanged(oldCluster, newCluster, matchedRack) {
+			updatedRacks = append(updatedRacks, matchedRack)
 		}
 	}
-	return scaledUpRacks
+	return updatedRacks
 }
 
 func (r *Adjuster) scaledDownRacks(matchedRacks []matchedRack) []matchedRack {
@@ -242,6 +531,13 @@ type matchedRack struct {
 	new v1alpha1.Rack
 }
 
+func (m matchedRack) nodesToScaleUp() int {
+	if m.new.Replicas > m.old.Replicas {
+		return int(m.new.Replicas - m.old.Replicas)
+	}
+	return 0
+}
+
 func (r *Adjuster) matchRacks(oldCluster, newCluster *v1alpha1.CassandraSpec) ([]v1alpha1.Rack, []matchedRack, []v1alpha1.Rack) {
 	var removedRacks []v1alpha1.Rack
 	var matchedRacks []matchedRack
@@ -14,9 +14,11 @@ import (
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/apis/cassandra/v1alpha1"
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/cluster"
 	"k8s.io/apimachinery/pkg/api/resource"
+	"reflect"
 )
 
 const (
+	clusterName                       = "mycluster"
 	containerCPU                      = "$.spec.template.spec.containers[0].resources.requests.cpu"
 	containerMemoryRequest            = "$.spec.template.spec.containers[0].resources.requests.memory"
 	containerMemoryLimit              = "$.spec.template.spec.containers[0].resources.limits.memory"
@@ -32,6 +34,17 @@ const (
 	rackReplicas                      = "$.spec.replicas"
 	clusterConfigHash                 = "$.spec.template.metadata.annotations.clusterConfigHash"
 	bootstrapperImage                 = "$.spec.template.spec.initContainers[0].image"
+	initConfigImage                   = "$.spec.template.spec.initContainers[1].image"
+	bootstrapperCPU                   = "$.spec.template.spec.initContainers[0].resources.requests.cpu"
+	bootstrapperMemoryLimit           = "$.spec.template.spec.initContainers[0].resources.limits.memory"
+	bootstrapperCPUMillicoresEnv      = "$.spec.template.spec.initContainers[0].env[0].value"
+	bootstrapperMemoryBytesEnv        = "$.spec.template.spec.initContainers[0].env[1].value"
+	initConfigCPU                     = "$.spec.template.spec.initContainers[1].resources.requests.cpu"
+	initConfigMemoryLimit             = "$.spec.template.spec.initContainers[1].resources.limits.memory"
+	containerImage                    = "$.spec.template.spec.containers[0].image"
+	containerCPULimit                 = "$.spec.template.spec.containers[0].resources.limits.cpu"
+	containerEphemeralStorage         = "$.spec.template.spec.containers[0].resources.requests[\"ephemeral-storage\"]"
+	containerEphemeralStorageLimit    = "$.spec.template.spec.containers[0].resources.limits[\"ephemeral-storage\"]"
 )
 
 func TestCluster(t *testing.T) {
@@ -107,7 +120,7 @@ var _ = Describe("cluster events", func() {
 	Context("pod spec change is detected", func() {
 		It("should produce a change with updated cpu when cpu specification has changed", func() {
 			newClusterSpec.Pod.CPU = resource.MustParse("110m")
-			changes, err := adjuster.ChangesForCluster(oldClusterSpec, newClusterSpec)
+			changes, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
 
 			Expect(err).ToNot(HaveOccurred())
 			Expect(changes).To(HaveLen(1))
@@ -116,19 +129,36 @@ var _ = Describe("cluster events", func() {
 
 		It("should produce a change with updated memory when memory specification has changed", func() {
 			newClusterSpec.Pod.Memory = resource.MustParse("1Gi")
-			changes, err := adjuster.ChangesForCluster(oldClusterSpec, newClusterSpec)
+			changes, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
 
 			Expect(err).ToNot(HaveOccurred())
 			Expect(changes).To(HaveLen(1))
 			Expect(changes).To(HaveClusterChange(newClusterSpec.Racks[0], UpdateRack, map[string]interface{}{containerMemoryRequest: "1Gi", containerMemoryLimit: "1Gi"}, 0))
 		})
 
+		It("should produce a change with updated resources for the init containers when cpu or memory specification has changed", func() {
+			newClusterSpec.Pod.CPU = resource.MustParse("110m")
+			newClusterSpec.Pod.Memory = resource.MustParse("1Gi")
+			changes, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changes).To(HaveLen(1))
+			Expect(changes).To(HaveClusterChange(newClusterSpec.Racks[0], UpdateRack, map[string]interface{}{
+				bootstrapperCPU:              "110m",
+				bootstrapperMemoryLimit:      "1Gi",
+				bootstrapperCPUMillicoresEnv: "110",
+				bootstrapperMemoryBytesEnv:   "1073741824",
+				initConfigCPU:                "110m",
+				initConfigMemoryLimit:        "1Gi",
+			}, 0))
+		})
+
 		It("should produce a change with updated timeout when liveness probe specification has changed", func() {
 			newClusterSpec.Pod.LivenessProbe.FailureThreshold = 5
 			newClusterSpec.Pod.LivenessProbe.InitialDelaySeconds = 99
 			newClusterSpec.Pod.LivenessProbe.PeriodSeconds = 20
 			newClusterSpec.Pod.LivenessProbe.TimeoutSeconds = 10
-			changes, err := adjuster.ChangesForCluster(oldClusterSpec, newClusterSpec)
+			changes, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
 
 			Expect(err).ToNot(HaveOccurred())
 			Expect(changes).To(HaveLen(1))
@@ -149,7 +179,7 @@ var _ = Describe("cluster events", func() {
 			newClusterSpec.Pod.ReadinessProbe.PeriodSeconds = 77
 			newClusterSpec.Pod.ReadinessProbe.SuccessThreshold = 80
 			newClusterSpec.Pod.ReadinessProbe.TimeoutSeconds = 4
-			changes, err := adjuster.ChangesForCluster(oldClusterSpec, newClusterSpec)
+			changes, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
 
 			Expect(err).ToNot(HaveOccurred())
 			Expect(changes).To(HaveLen(1))
@@ -163,24 +193,275 @@ var _ = Describe("cluster events", func() {
 				}, 0))
 		})
 
+		It("should produce a change with the cpu limit and ephemeral storage of the cassandra container when they are set", func() {
+			cpuLimit := resource.MustParse("2")
+			ephemeralStorage := resource.MustParse("1Gi")
+			newClusterSpec.Pod.CPULimit = &cpuLimit
+			newClusterSpec.Pod.EphemeralStorage = &ephemeralStorage
+			changes, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changes).To(HaveLen(1))
+			Expect(changes).To(HaveClusterChange(newClusterSpec.Racks[0], UpdateRack, map[string]interface{}{
+				containerCPULimit:              "2",
+				containerEphemeralStorage:      "1Gi",
+				containerEphemeralStorageLimit: nil,
+				bootstrapperCPU:                "100m",
+			}, 0))
+		})
+
+		It("should produce a change removing the cpu limit when it is no longer set", func() {
+			cpuLimit := resource.MustParse("2")
+			oldClusterSpec.Pod.CPULimit = &cpuLimit
+			changes, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changes).To(HaveLen(1))
+			Expect(changes).To(HaveClusterChange(newClusterSpec.Racks[0], UpdateRack, map[string]interface{}{containerCPULimit: nil}, 0))
+		})
+
+		It("should produce a change with the resources of the init containers when they are set separately", func() {
+			newClusterSpec.Pod.BootstrapperResources = &v1.ResourceRequirements{
+				Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("10m"), v1.ResourceMemory: resource.MustParse("64Mi")},
+			}
+			changes, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changes).To(HaveLen(1))
+			Expect(changes).To(HaveClusterChange(newClusterSpec.Racks[0], UpdateRack, map[string]interface{}{
+				bootstrapperCPU:            "10m",
+				bootstrapperMemoryLimit:    nil,
+				bootstrapperMemoryBytesEnv: "2147483648",
+				initConfigCPU:              "100m",
+				initConfigMemoryLimit:      "2Gi",
+				containerMemoryLimit:       "2Gi",
+			}, 0))
+		})
+
 		It("should produce a patch containing the updated image when the bootstrapper image has been updated", func() {
 			newClusterSpec.Pod.BootstrapperImage = "someotherimage"
-			changes, err := adjuster.ChangesForCluster(oldClusterSpec, newClusterSpec)
+			changes, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
 			Expect(err).ToNot(HaveOccurred())
 			Expect(changes).To(HaveLen(1))
 			Expect(changes).To(HaveClusterChange(newClusterSpec.Racks[0], UpdateRack, map[string]interface{}{bootstrapperImage: "someotherimage"}, 0))
 		})
+
+		It("should produce a patch adding and removing the pod labels and annotations which have changed", func() {
+			oldClusterSpec.Pod.Labels = map[string]string{"team": "data", "tier": "storage"}
+			oldClusterSpec.Pod.Annotations = map[string]string{"prometheus.io/scrape": "true"}
+			newClusterSpec.Pod.Labels = map[string]string{"team": "platform"}
+			newClusterSpec.Pod.Annotations = map[string]string{"prometheus.io/port": "7070"}
+			changes, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changes).To(HaveLen(1))
+			Expect(changes).To(HaveClusterChange(newClusterSpec.Racks[0], UpdateRack, map[string]interface{}{
+				"$.spec.template.metadata.labels.team":                           "platform",
+				"$.spec.template.metadata.labels.tier":                           nil,
+				"$.spec.template.metadata.annotations[\"prometheus.io/port\"]":   "7070",
+				"$.spec.template.metadata.annotations[\"prometheus.io/scrape\"]": nil,
+			}, 0))
+		})
+
+		It("should produce a patch adding and deleting the environment variables which have changed", func() {
+			oldClusterSpec.Pod.Env = []v1.EnvVar{{Name: "JVM_OPTS", Value: "-Xss256k"}}
+			newClusterSpec.Pod.Env = []v1.EnvVar{{Name: "MAX_HEAP_SIZE", Value: "1G"}}
+			changes, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changes).To(HaveLen(1))
+			Expect(changes).To(HaveClusterChange(newClusterSpec.Racks[0], UpdateRack, map[string]interface{}{
+				"$.spec.template.spec.containers[0].env[0].name":        "JVM_OPTS",
+				"$.spec.template.spec.containers[0].env[0][\"$patch\"]": "delete",
+				"$.spec.template.spec.containers[0].env[1].name":        "MAX_HEAP_SIZE",
+				"$.spec.template.spec.containers[0].env[1].value":       "1G",
+			}, 0))
+		})
+
+		It("should produce a patch adding and deleting the sidecars and init containers which have changed", func() {
+			oldClusterSpec.Pod.Sidecars = []v1.Container{{Name: "log-shipper", Image: "log-shipper:1"}}
+			newClusterSpec.Pod.Sidecars = []v1.Container{{Name: "backup-agent", Image: "backup-agent:1"}}
+			newClusterSpec.Pod.InitContainers = []v1.Container{{Name: "fetch-agent", Image: "agent-fetcher:1"}}
+			changes, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changes).To(HaveLen(1))
+			Expect(changes).To(HaveClusterChange(newClusterSpec.Racks[0], UpdateRack, map[string]interface{}{
+				"$.spec.template.spec.containers[0].name":        "cassandra",
+				"$.spec.template.spec.containers[1].name":        "log-shipper",
+				"$.spec.template.spec.containers[1][\"$patch\"]": "delete",
+				"$.spec.template.spec.containers[2].name":        "backup-agent",
+				"$.spec.template.spec.containers[2].image":       "backup-agent:1",
+				"$.spec.template.spec.initContainers[2].name":    "fetch-agent",
+				"$.spec.template.spec.initContainers[2].image":   "agent-fetcher:1",
+			}, 0))
+		})
+
+		It("should produce a patch adding and deleting the extra volumes and mounts which have changed", func() {
+			oldClusterSpec.Pod.ExtraVolumes = []v1.Volume{{Name: "logs"}}
+			oldClusterSpec.Pod.ExtraVolumeMounts = []v1.VolumeMount{{Name: "logs", MountPath: "/var/log/cassandra"}}
+			newClusterSpec.Pod.ExtraVolumes = []v1.Volume{{Name: "backups"}}
+			newClusterSpec.Pod.ExtraVolumeMounts = []v1.VolumeMount{{Name: "backups", MountPath: "/backups"}}
+			changes, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changes).To(HaveLen(1))
+			Expect(changes).To(HaveClusterChange(newClusterSpec.Racks[0], UpdateRack, map[string]interface{}{
+				"$.spec.template.spec.volumes[0].name":                           "logs",
+				"$.spec.template.spec.volumes[0][\"$patch\"]":                    "delete",
+				"$.spec.template.spec.volumes[1].name":                           "backups",
+				"$.spec.template.spec.containers[0].volumeMounts[0].mountPath":   "/var/log/cassandra",
+				"$.spec.template.spec.containers[0].volumeMounts[0][\"$patch\"]": "delete",
+				"$.spec.template.spec.containers[0].volumeMounts[1].mountPath":   "/backups",
+			}, 0))
+		})
+	})
+
+	Context("scheduling change is detected", func() {
+		BeforeEach(func() {
+			oldClusterSpec.Racks = []v1alpha1.Rack{{Name: "a", Replicas: 1, StorageClass: "some-storage", Zone: "some-zone"}, {Name: "b", Replicas: 2, StorageClass: "another-storage", Zone: "another-zone"}}
+			newClusterSpec.Racks = []v1alpha1.Rack{{Name: "a", Replicas: 1, StorageClass: "some-storage", Zone: "some-zone"}, {Name: "b", Replicas: 2, StorageClass: "another-storage", Zone: "another-zone"}}
+		})
+
+		It("should produce an update change for each rack with the tolerations and priority class of the cluster", func() {
+			newClusterSpec.Pod.Tolerations = []v1.Toleration{{Key: "dedicated", Operator: v1.TolerationOpEqual, Value: "cassandra", Effect: v1.TaintEffectNoSchedule}}
+			newClusterSpec.Pod.PriorityClassName = "high-priority"
+			changes, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changes).To(HaveLen(2))
+			for _, rack := range newClusterSpec.Racks {
+				Expect(changes).To(HaveClusterChange(rack, UpdateRack, map[string]interface{}{
+					"$.spec.template.spec.tolerations[0].key":   "dedicated",
+					"$.spec.template.spec.tolerations[0].value": "cassandra",
+					"$.spec.template.spec.priorityClassName":    "high-priority",
+				}, 0))
+				Expect(adjuster.RequiresRollingRestart(oldClusterSpec, newClusterSpec, rack.Name)).To(BeTrue())
+			}
+		})
+
+		It("should only update the rack whose node selector has changed, removing the labels no longer selected", func() {
+			oldClusterSpec.Racks[1].NodeSelector = map[string]string{"disk": "hdd"}
+			newClusterSpec.Racks[1].NodeSelector = map[string]string{"node-pool": "cassandra"}
+			changes, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changes).To(HaveLen(1))
+			Expect(changes).To(HaveClusterChange(newClusterSpec.Racks[1], UpdateRack, map[string]interface{}{
+				"$.spec.template.spec.nodeSelector.disk":           nil,
+				"$.spec.template.spec.nodeSelector[\"node-pool\"]": "cassandra",
+			}, 0))
+			Expect(adjuster.RequiresRollingRestart(oldClusterSpec, newClusterSpec, "a")).To(BeFalse())
+			Expect(adjuster.RequiresRollingRestart(oldClusterSpec, newClusterSpec, "b")).To(BeTrue())
+		})
+
+		It("should not update a rack whose own scheduling takes precedence over the change to the one of the cluster", func() {
+			oldClusterSpec.Pod.PriorityClassName = "low-priority"
+			oldClusterSpec.Racks[0].PriorityClassName = "high-priority"
+			newClusterSpec.Pod.PriorityClassName = "medium-priority"
+			newClusterSpec.Racks[0].PriorityClassName = "high-priority"
+			changes, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changes).To(HaveLen(1))
+			Expect(changes).To(HaveClusterChange(newClusterSpec.Racks[1], UpdateRack, map[string]interface{}{
+				"$.spec.template.spec.priorityClassName": "medium-priority",
+			}, 0))
+		})
+
+		It("should replace the required anti-affinity of the pods by a preferred one on the given topology", func() {
+			newClusterSpec.Pod.AntiAffinity = &v1alpha1.AntiAffinity{Mode: v1alpha1.AntiAffinityPreferred, TopologyKey: "kubernetes.io/rack"}
+			changes, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
+
+			antiAffinity := "$.spec.template.spec.affinity.podAntiAffinity"
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changes).To(HaveLen(2))
+			Expect(changes).To(HaveClusterChange(newClusterSpec.Racks[0], UpdateRack, map[string]interface{}{
+				antiAffinity + ".requiredDuringSchedulingIgnoredDuringExecution":                                                                              nil,
+				antiAffinity + ".preferredDuringSchedulingIgnoredDuringExecution[0].podAffinityTerm.topologyKey":                                              "kubernetes.io/rack",
+				antiAffinity + ".preferredDuringSchedulingIgnoredDuringExecution[0].podAffinityTerm.labelSelector.matchLabels[\"sky.uk/cassandra-operator\"]": clusterName,
+			}, 0))
+		})
+
+		It("should produce a patch keeping the pods of each rack in its zone with the new zone topology key", func() {
+			newClusterSpec.Racks[0].ZoneTopologyKey = "topology.kubernetes.io/zone"
+			changes, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changes).To(HaveLen(1))
+			Expect(changes).To(HaveClusterChange(newClusterSpec.Racks[0], UpdateRack, map[string]interface{}{
+				"$.spec.template.spec.affinity.nodeAffinity.requiredDuringSchedulingIgnoredDuringExecution.nodeSelectorTerms[0].matchExpressions[0].key":       "topology.kubernetes.io/zone",
+				"$.spec.template.spec.affinity.nodeAffinity.requiredDuringSchedulingIgnoredDuringExecution.nodeSelectorTerms[0].matchExpressions[0].values[0]": "some-zone",
+			}, 0))
+		})
+
+		It("should produce no change when the explicit scheduling matches the defaults", func() {
+			newClusterSpec.Pod.AntiAffinity = &v1alpha1.AntiAffinity{Mode: v1alpha1.AntiAffinityRequired, TopologyKey: "kubernetes.io/hostname"}
+			newClusterSpec.Pod.ZoneTopologyKey = "failure-domain.beta.kubernetes.io/zone"
+			changes, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changes).To(BeEmpty())
+		})
+	})
+
+	Context("image change is detected", func() {
+		BeforeEach(func() {
+			oldClusterSpec.Racks = []v1alpha1.Rack{{Name: "a", Replicas: 1, StorageClass: "some-storage", Zone: "some-zone"}, {Name: "b", Replicas: 2, StorageClass: "another-storage", Zone: "another-zone"}}
+			newClusterSpec.Racks = []v1alpha1.Rack{{Name: "a", Replicas: 1, StorageClass: "some-storage", Zone: "some-zone"}, {Name: "b", Replicas: 2, StorageClass: "another-storage", Zone: "another-zone"}}
+			oldClusterSpec.Pod.Image = "cassandra:3.11.2"
+		})
+
+		It("should produce an upgrade change for each rack patching the cassandra and init-config images", func() {
+			newClusterSpec.Pod.Image = "cassandra:3.11.3"
+			changes, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changes).To(HaveLen(2))
+			Expect(changes).To(HaveClusterChange(newClusterSpec.Racks[0], UpgradeRack, map[string]interface{}{containerImage: "cassandra:3.11.3", initConfigImage: "cassandra:3.11.3", rackReplicas: float64(1)}, 0))
+			Expect(changes).To(HaveClusterChange(newClusterSpec.Racks[1], UpgradeRack, map[string]interface{}{containerImage: "cassandra:3.11.3", initConfigImage: "cassandra:3.11.3", rackReplicas: float64(2)}, 0))
+		})
+
+		It("should allow an upgrade to the next major version", func() {
+			oldClusterSpec.Pod.Image = "cassandra:2.2.12"
+			newClusterSpec.Pod.Image = "my-registry:5000/cassandra:3.0.16-jessie"
+			changes, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changes).To(HaveLen(2))
+			Expect(changes).To(HaveClusterChange(newClusterSpec.Racks[0], UpgradeRack, map[string]interface{}{containerImage: "my-registry:5000/cassandra:3.0.16-jessie"}, 0))
+		})
+
+		It("should include other pod spec changes in the upgrade change", func() {
+			newClusterSpec.Pod.Image = "cassandra:3.11.3"
+			newClusterSpec.Pod.Memory = resource.MustParse("3Gi")
+			changes, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changes).To(HaveLen(2))
+			Expect(changes).To(HaveClusterChange(newClusterSpec.Racks[0], UpgradeRack, map[string]interface{}{containerImage: "cassandra:3.11.3", containerMemoryLimit: "3Gi"}, 0))
+		})
+
+		It("should allow the image to be pulled from another registry without changing version", func() {
+			newClusterSpec.Pod.Image = "my-registry/cassandra:3.11.2"
+			changes, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changes).To(HaveLen(2))
+			Expect(changes).To(HaveClusterChange(newClusterSpec.Racks[0], UpgradeRack, map[string]interface{}{containerImage: "my-registry/cassandra:3.11.2"}, 0))
+		})
 	})
 
 	Context("scale-up change is detected", func() {
 		Context("single-rack cluster", func() {
 			It("should produce a change with the updated number of replicas", func() {
 				newClusterSpec.Racks[0].Replicas = 2
-				changes, err := adjuster.ChangesForCluster(oldClusterSpec, newClusterSpec)
+				changes, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
 
 				Expect(err).ToNot(HaveOccurred())
 				Expect(changes).To(HaveLen(1))
 				Expect(changes).To(HaveClusterChange(newClusterSpec.Racks[0], UpdateRack, map[string]interface{}{rackReplicas: float64(2)}, 0))
+				Expect(changes[0].NodesToScaleUp).To(Equal(1))
 			})
 		})
 
@@ -188,12 +469,14 @@ var _ = Describe("cluster events", func() {
 			It("should produce a change for each changed rack with the updated number of replicas", func() {
 				oldClusterSpec.Racks = []v1alpha1.Rack{{Name: "a", Replicas: 1, StorageClass: "some-storage", Zone: "some-zone"}, {Name: "b", Replicas: 1, StorageClass: "another-storage", Zone: "another-zone"}, {Name: "c", Replicas: 1, StorageClass: "yet-another-storage", Zone: "yet-another-zone"}}
 				newClusterSpec.Racks = []v1alpha1.Rack{{Name: "a", Replicas: 2, StorageClass: "some-storage", Zone: "some-zone"}, {Name: "b", Replicas: 1, StorageClass: "another-storage", Zone: "another-zone"}, {Name: "c", Replicas: 3, StorageClass: "yet-another-storage", Zone: "yet-another-zone"}}
-				changes, err := adjuster.ChangesForCluster(oldClusterSpec, newClusterSpec)
+				changes, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
 
 				Expect(err).ToNot(HaveOccurred())
 				Expect(changes).To(HaveLen(2))
 				Expect(changes).To(HaveClusterChange(newClusterSpec.Racks[0], UpdateRack, map[string]interface{}{rackReplicas: float64(2)}, 0))
 				Expect(changes).To(HaveClusterChange(newClusterSpec.Racks[2], UpdateRack, map[string]interface{}{rackReplicas: float64(3)}, 0))
+				Expect(changes[0].NodesToScaleUp).To(Equal(1))
+				Expect(changes[1].NodesToScaleUp).To(Equal(2))
 			})
 		})
 	})
@@ -205,17 +488,62 @@ var _ = Describe("cluster events", func() {
 			newClusterSpec.Pod.CPU = resource.MustParse("1")
 			newClusterSpec.Pod.Memory = resource.MustParse("999Mi")
 
-			changes, err := adjuster.ChangesForCluster(oldClusterSpec, newClusterSpec)
+			changes, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
 			Expect(err).ToNot(HaveOccurred())
 			Expect(changes).To(HaveLen(2))
 			Expect(changes).To(HaveClusterChange(newClusterSpec.Racks[0], UpdateRack, map[string]interface{}{rackReplicas: float64(1), containerCPU: "1", containerMemoryRequest: "999Mi", containerMemoryLimit: "999Mi"}, 0))
 			Expect(changes).To(HaveClusterChange(newClusterSpec.Racks[1], UpdateRack, map[string]interface{}{rackReplicas: float64(3), containerCPU: "1", containerMemoryRequest: "999Mi", containerMemoryLimit: "999Mi"}, 0))
+			Expect(changes[0].NodesToScaleUp).To(Equal(0))
+			Expect(changes[1].NodesToScaleUp).To(Equal(2))
+		})
+	})
+
+	Context("storage size increase is detected", func() {
+		It("should produce a resize change for each rack", func() {
+			oldClusterSpec.Racks = []v1alpha1.Rack{{Name: "a", Replicas: 1, Zone: "zone-a", StorageClass: "storage-class-a"}, {Name: "b", Replicas: 1, Zone: "zone-b", StorageClass: "storage-class-b"}}
+			newClusterSpec.Racks = []v1alpha1.Rack{{Name: "a", Replicas: 1, Zone: "zone-a", StorageClass: "storage-class-a"}, {Name: "b", Replicas: 1, Zone: "zone-b", StorageClass: "storage-class-b"}}
+			newClusterSpec.Pod.StorageSize = resource.MustParse("2Gi")
+
+			changes, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changes).To(HaveLen(2))
+			Expect(changes).To(HaveClusterChange(newClusterSpec.Racks[0], ResizeRackStorage, nil, 0))
+			Expect(changes).To(HaveClusterChange(newClusterSpec.Racks[1], ResizeRackStorage, nil, 0))
+		})
+
+		It("should resize the storage after the other changes to the rack have been applied", func() {
+			newClusterSpec.Pod.StorageSize = resource.MustParse("2Gi")
+			newClusterSpec.Pod.Memory = resource.MustParse("3Gi")
+
+			changes, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changes).To(HaveLen(2))
+			Expect(changes[0].ChangeType).To(Equal(UpdateRack))
+			Expect(changes[1].ChangeType).To(Equal(ResizeRackStorage))
+		})
+
+		It("should not produce a resize change when the same size is expressed in different units", func() {
+			newClusterSpec.Pod.StorageSize = resource.MustParse("1024Mi")
+
+			changes, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changes).To(BeEmpty())
 		})
 	})
 
 	Context("nothing has changed in the definition", func() {
 		It("should not produce any changes", func() {
-			changes, err := adjuster.ChangesForCluster(oldClusterSpec, newClusterSpec)
+			changes, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changes).To(HaveLen(0))
+		})
+
+		It("should not produce any changes when the pod labels are changed from none to empty", func() {
+			newClusterSpec.Pod.Labels = map[string]string{}
+			changes, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
 			Expect(err).ToNot(HaveOccurred())
 			Expect(changes).To(HaveLen(0))
 		})
@@ -224,7 +552,7 @@ var _ = Describe("cluster events", func() {
 	Context("unsupported property change is detected", func() {
 		It("should reject the change with an error message when DC is changed", func() {
 			newClusterSpec.DC = "other-dc"
-			_, err := adjuster.ChangesForCluster(oldClusterSpec, newClusterSpec)
+			_, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
 
 			Expect(err).To(MatchError("changing dc is forbidden. The dc used will continue to be 'ADatacenter'"))
 		})
@@ -232,54 +560,89 @@ var _ = Describe("cluster events", func() {
 		It("should report that the default DC name will continue to be used when no DC was previously provided", func() {
 			oldClusterSpec.DC = ""
 			newClusterSpec.DC = "new-dc"
-			_, err := adjuster.ChangesForCluster(oldClusterSpec, newClusterSpec)
+			_, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
 
 			Expect(err).To(MatchError(fmt.Sprintf("changing dc is forbidden. The dc used will continue to be '%s'", cluster.DefaultDCName)))
 		})
 
-		It("should reject the change with an error message when Image is changed", func() {
+		It("should reject the change with an error message when the Cassandra version of the image is downgraded", func() {
+			oldClusterSpec.Pod.Image = "cassandra:3.11.2"
+			newClusterSpec.Pod.Image = "cassandra:3.0.16"
+			_, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
+
+			Expect(err).To(MatchError("changing image from 'cassandra:3.11.2' to 'cassandra:3.0.16' is forbidden: downgrading Cassandra from version 3.11.2 to 3.0.16 is not supported"))
+		})
+
+		It("should reject the change with an error message when the image upgrade skips a major Cassandra version", func() {
+			oldClusterSpec.Pod.Image = "cassandra:2.2.12"
+			newClusterSpec.Pod.Image = "cassandra:4.0"
+			_, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
+
+			Expect(err).To(MatchError("changing image from 'cassandra:2.2.12' to 'cassandra:4.0' is forbidden: upgrading Cassandra from version 2.2.12 to 4.0.0 skips a major version"))
+		})
+
+		It("should reject the change with an error message when the Cassandra version of the image cannot be determined", func() {
 			newClusterSpec.Pod.Image = "other-image"
-			_, err := adjuster.ChangesForCluster(oldClusterSpec, newClusterSpec)
+			_, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
 
-			Expect(err).To(MatchError("changing image is forbidden. The image used will continue to be 'anImage'"))
+			Expect(err).To(MatchError("changing image from 'anImage' to 'other-image' is forbidden: unable to determine the Cassandra version of image 'anImage' from its tag"))
 		})
 
-		It("should report that the default image will continue to be used if an image was not previously specified", func() {
+		It("should compare with the default image if an image was not previously specified", func() {
 			oldClusterSpec.Pod.Image = ""
-			newClusterSpec.Pod.Image = "other-image"
+			newClusterSpec.Pod.Image = "cassandra:3.0"
 
-			_, err := adjuster.ChangesForCluster(oldClusterSpec, newClusterSpec)
+			_, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
 
-			Expect(err).To(MatchError(fmt.Sprintf("changing image is forbidden. The image used will continue to be '%s'", cluster.DefaultCassandraImage)))
+			Expect(err).To(MatchError(fmt.Sprintf("changing image from '%s' to 'cassandra:3.0' is forbidden: downgrading Cassandra from version 3.11.0 to 3.0.0 is not supported", cluster.DefaultCassandraImage)))
 		})
 
 		It("should reject the change with an error message when UseEmptyDir is changed", func() {
 			newClusterSpec.UseEmptyDir = true
-			_, err := adjuster.ChangesForCluster(oldClusterSpec, newClusterSpec)
+			_, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
 
 			Expect(err).To(MatchError("changing useEmptyDir is forbidden. The useEmptyDir used will continue to be 'false'"))
 		})
 
+		It("should reject the change with an error message when the storageSize is reduced", func() {
+			newClusterSpec.Pod.StorageSize = resource.MustParse("512Mi")
+			_, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
+
+			Expect(err).To(MatchError("reducing storageSize is forbidden. The storageSize used will continue to be '1Gi'"))
+		})
+
 		It("should reject the change with an error message when a rack storageClass is changed", func() {
 			newClusterSpec.Racks[0].StorageClass = "another-storage-class"
-			_, err := adjuster.ChangesForCluster(oldClusterSpec, newClusterSpec)
+			_, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
 
 			Expect(err).To(MatchError("changing storageClass for rack 'a' is forbidden. The storageClass used will continue to be 'some-storage'"))
 		})
 
 		It("should reject the change with an error message when a rack zone is changed", func() {
 			newClusterSpec.Racks[0].Zone = "another-zone"
-			_, err := adjuster.ChangesForCluster(oldClusterSpec, newClusterSpec)
+			_, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
 
 			Expect(err).To(MatchError("changing zone for rack 'a' is forbidden. The zone used will continue to be 'some-zone'"))
 		})
+
+		It("should list all forbidden changes while only reporting the first one as an error", func() {
+			newClusterSpec.UseEmptyDir = true
+			newClusterSpec.Racks[0].Zone = "another-zone"
+			_, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
+
+			Expect(err).To(MatchError("changing useEmptyDir is forbidden. The useEmptyDir used will continue to be 'false'"))
+			Expect(adjuster.ForbiddenChanges(oldClusterSpec, newClusterSpec)).To(Equal([]string{
+				"changing useEmptyDir is forbidden. The useEmptyDir used will continue to be 'false'",
+				"changing zone for rack 'a' is forbidden. The zone used will continue to be 'some-zone'",
+			}))
+		})
 	})
 	Context("a new rack definition is added", func() {
 		It("should produce a change describing the new rack", func() {
 			newRack := v1alpha1.Rack{Name: "b", Replicas: 2, Zone: "zone-b", StorageClass: "storage-class-b"}
 			newClusterSpec.Racks = append(newClusterSpec.Racks, newRack)
 
-			changes, err := adjuster.ChangesForCluster(oldClusterSpec, newClusterSpec)
+			changes, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
 			Expect(err).ToNot(HaveOccurred())
 			Expect(changes).To(HaveLen(1))
 			Expect(changes).To(HaveClusterChange(newRack, AddRack, nil, 0))
@@ -297,24 +660,24 @@ var _ = Describe("cluster events", func() {
 		It("should produce a change describing the rack which was deleted", func() {
 			newClusterSpec.Racks = []v1alpha1.Rack{{Name: "b", Replicas: 1, Zone: "zone-b", StorageClass: "storage-class-b"}}
 
-			changes, err := adjuster.ChangesForCluster(oldClusterSpec, newClusterSpec)
+			changes, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
 
 			Expect(err).ToNot(HaveOccurred())
 			Expect(changes).To(HaveLen(1))
-			Expect(changes).To(HaveClusterChange(oldClusterSpec.Racks[0], deleteRack, nil, 0))
+			Expect(changes).To(HaveClusterChange(oldClusterSpec.Racks[0], DeleteRack, nil, 0))
 		})
 	})
 
 	Context("a rack is scaled down", func() {
 		It("should produce a change describing the rack which was scaled down, and how many pods should be removed", func() {
-			oldClusterSpec.Racks = []v1alpha1.Rack{{Name: "a", Replicas: 2, StorageClass: "some-storage", Zone: "some-zone"}}
+			oldClusterSpec.Racks = []v1alpha1.Rack{{Name: "a", Replicas: 3, StorageClass: "some-storage", Zone: "some-zone"}}
 			newClusterSpec.Racks = []v1alpha1.Rack{{Name: "a", Replicas: 1, StorageClass: "some-storage", Zone: "some-zone"}}
 
-			changes, err := adjuster.ChangesForCluster(oldClusterSpec, newClusterSpec)
+			changes, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
 
 			Expect(err).ToNot(HaveOccurred())
 			Expect(changes).To(HaveLen(1))
-			Expect(changes).To(HaveClusterChange(newClusterSpec.Racks[0], scaleDownRack, nil, 1))
+			Expect(changes).To(HaveClusterChange(newClusterSpec.Racks[0], ScaleDownRack, map[string]interface{}{rackReplicas: float64(1)}, 2))
 		})
 	})
 
@@ -326,7 +689,7 @@ var _ = Describe("cluster events", func() {
 				{Name: "b", Replicas: 1, Zone: "zone-b", StorageClass: "storage-class-b"},
 			}
 
-			changes, err := adjuster.ChangesForCluster(oldClusterSpec, newClusterSpec)
+			changes, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
 
 			Expect(err).ToNot(HaveOccurred())
 			Expect(changes).To(HaveLen(3))
@@ -336,7 +699,7 @@ var _ = Describe("cluster events", func() {
 			Expect(changes[1].ChangeType).To(Equal(AddRack))
 			Expect(changes[1].Rack.Name).To(Or(Equal("a"), Equal("b")))
 
-			Expect(changes[2].ChangeType).To(Equal(deleteRack))
+			Expect(changes[2].ChangeType).To(Equal(DeleteRack))
 			Expect(changes[2].Rack.Name).To(Equal("c"))
 		})
 
@@ -345,11 +708,11 @@ var _ = Describe("cluster events", func() {
 			newClusterSpec.Racks = []v1alpha1.Rack{{Name: "a", Replicas: 1, Zone: "zone-a", StorageClass: "storage-class-a"}}
 			newClusterSpec.Pod.Memory = resource.MustParse("3Gi")
 
-			changes, err := adjuster.ChangesForCluster(oldClusterSpec, newClusterSpec)
+			changes, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
 
 			Expect(err).ToNot(HaveOccurred())
 			Expect(changes).To(HaveLen(2))
-			Expect(changes[0].ChangeType).To(Equal(scaleDownRack))
+			Expect(changes[0].ChangeType).To(Equal(ScaleDownRack))
 			Expect(changes[1].ChangeType).To(Equal(UpdateRack))
 		})
 
@@ -361,14 +724,14 @@ var _ = Describe("cluster events", func() {
 			newClusterSpec.Racks = []v1alpha1.Rack{{Name: "a", Replicas: 1, Zone: "zone-a", StorageClass: "storage-class-a"}}
 			newClusterSpec.Pod.Memory = resource.MustParse("3Gi")
 
-			changes, err := adjuster.ChangesForCluster(oldClusterSpec, newClusterSpec)
+			changes, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
 
 			Expect(err).ToNot(HaveOccurred())
 			Expect(changes).To(HaveLen(3))
-			Expect(changes[0].ChangeType).To(Equal(deleteRack))
+			Expect(changes[0].ChangeType).To(Equal(DeleteRack))
 			Expect(changes[0].Rack.Name).To(Equal("b"))
 
-			Expect(changes[1].ChangeType).To(Equal(scaleDownRack))
+			Expect(changes[1].ChangeType).To(Equal(ScaleDownRack))
 			Expect(changes[1].Rack.Name).To(Equal("a"))
 
 			Expect(changes[2].ChangeType).To(Equal(UpdateRack))
@@ -384,17 +747,17 @@ var _ = Describe("cluster events", func() {
 			}
 			newClusterSpec.Pod.Memory = resource.MustParse("3Gi")
 
-			changes, err := adjuster.ChangesForCluster(oldClusterSpec, newClusterSpec)
+			changes, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
 
 			Expect(err).ToNot(HaveOccurred())
 			Expect(changes).To(HaveLen(5))
 			Expect(changes[0].ChangeType).To(Equal(AddRack))
 			Expect(changes[0].Rack.Name).To(Equal("c"))
 
-			Expect(changes[1].ChangeType).To(Equal(scaleDownRack))
+			Expect(changes[1].ChangeType).To(Equal(ScaleDownRack))
 			Expect(changes[1].Rack.Name).To(Or(Equal("a"), Equal("b")))
 
-			Expect(changes[2].ChangeType).To(Equal(scaleDownRack))
+			Expect(changes[2].ChangeType).To(Equal(ScaleDownRack))
 			Expect(changes[2].Rack.Name).To(Or(Equal("a"), Equal("b")))
 
 			Expect(changes[3].ChangeType).To(Equal(UpdateRack))
@@ -409,17 +772,17 @@ var _ = Describe("cluster events", func() {
 			newClusterSpec.Racks = []v1alpha1.Rack{{Name: "a", Replicas: 1, Zone: "zone-a", StorageClass: "storage-class-a"}, {Name: "c", Replicas: 1, Zone: "zone-c", StorageClass: "storage-class-c"}}
 			newClusterSpec.Pod.Memory = resource.MustParse("3Gi")
 
-			changes, err := adjuster.ChangesForCluster(oldClusterSpec, newClusterSpec)
+			changes, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
 
 			Expect(err).ToNot(HaveOccurred())
 			Expect(changes).To(HaveLen(4))
 			Expect(changes[0].ChangeType).To(Equal(AddRack))
 			Expect(changes[0].Rack.Name).To(Equal("c"))
 
-			Expect(changes[1].ChangeType).To(Equal(deleteRack))
+			Expect(changes[1].ChangeType).To(Equal(DeleteRack))
 			Expect(changes[1].Rack.Name).To(Equal("b"))
 
-			Expect(changes[2].ChangeType).To(Equal(scaleDownRack))
+			Expect(changes[2].ChangeType).To(Equal(ScaleDownRack))
 			Expect(changes[2].Rack.Name).To(Equal("a"))
 
 			Expect(changes[3].ChangeType).To(Equal(UpdateRack))
@@ -431,7 +794,7 @@ var _ = Describe("cluster events", func() {
 			newClusterSpec.Racks = []v1alpha1.Rack{{Name: "a", Replicas: 2, Zone: "zone-a", StorageClass: "storage-class-a"}}
 			newClusterSpec.Pod.Memory = resource.MustParse("3Gi")
 
-			changes, err := adjuster.ChangesForCluster(oldClusterSpec, newClusterSpec)
+			changes, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
 
 			Expect(err).ToNot(HaveOccurred())
 			Expect(changes).To(HaveLen(1))
@@ -444,7 +807,7 @@ var _ = Describe("cluster events", func() {
 			newClusterSpec.Racks = []v1alpha1.Rack{{Name: "c", Replicas: 1, Zone: "zone-c", StorageClass: "storage-class-c"}, {Name: "a", Replicas: 1, Zone: "zone-a", StorageClass: "storage-class-a"}, {Name: "b", Replicas: 1, Zone: "zone-b", StorageClass: "storage-class-b"}}
 			newClusterSpec.Pod.CPU = resource.MustParse("101m")
 
-			changes, err := adjuster.ChangesForCluster(oldClusterSpec, newClusterSpec)
+			changes, err := adjuster.ChangesForCluster(clusterName, oldClusterSpec, newClusterSpec)
 
 			Expect(err).ToNot(HaveOccurred())
 			Expect(changes).To(HaveLen(3))
@@ -472,7 +835,7 @@ type haveClusterChange struct {
 func (matcher *haveClusterChange) Match(actual interface{}) (success bool, err error) {
 	changes := actual.([]ClusterChange)
 	if change := matcher.findClusterChange(&matcher.rack, changes); change != nil {
-		if change.Rack != matcher.rack {
+		if !reflect.DeepEqual(change.Rack, matcher.rack) {
 			return false, fmt.Errorf("expected rack %v to match, but found %v", matcher.rack, change.Rack)
 		}
 
@@ -480,8 +843,8 @@ func (matcher *haveClusterChange) Match(actual interface{}) (success bool, err e
 			return false, fmt.Errorf("expected change type %s, but found %s", matcher.changeType, change.ChangeType)
 		}
 
-		if change.nodesToScaleDown != matcher.nodesToScaleDown {
-			return false, fmt.Errorf("expected to scale down %d nodes, but found %d", matcher.nodesToScaleDown, change.nodesToScaleDown)
+		if change.NodesToScaleDown != matcher.nodesToScaleDown {
+			return false, fmt.Errorf("expected to scale down %d nodes, but found %d", matcher.nodesToScaleDown, change.NodesToScaleDown)
 		}
 
 		for jsonPath, expectedValue := range matcher.patchExpectations {
@@ -0,0 +1,83 @@
+package adjuster
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var imageTagVersionPattern = regexp.MustCompile(`^v?(\d+)\.(\d+)(?:\.(\d+))?`)
+
+type cassandraVersion struct {
+	major int
+	minor int
+	patch int
+}
+
+func (v *cassandraVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.major, v.minor, v.patch)
+}
+
+func (v *cassandraVersion) isOlderThan(other *cassandraVersion) bool {
+	if v.major != other.major {
+		return v.major < other.major
+	}
+	if v.minor != other.minor {
+		return v.minor < other.minor
+	}
+	return v.patch < other.patch
+}
+
+// validateImageChange ensures the Cassandra version of newImage can be reached by a rolling upgrade from the version of oldImage.
+// Downgrades and upgrades skipping a major version are not supported by Cassandra.
+func validateImageChange(oldImage, newImage string) error {
+	oldVersion, err := versionOfImage(oldImage)
+	if err != nil {
+		return fmt.Errorf("changing image from '%s' to '%s' is forbidden: %v", oldImage, newImage, err)
+	}
+
+	newVersion, err := versionOfImage(newImage)
+	if err != nil {
+		return fmt.Errorf("changing image from '%s' to '%s' is forbidden: %v", oldImage, newImage, err)
+	}
+
+	if newVersion.isOlderThan(oldVersion) {
+		return fmt.Errorf("changing image from '%s' to '%s' is forbidden: downgrading Cassandra from version %s to %s is not supported", oldImage, newImage, oldVersion, newVersion)
+	}
+
+	if newVersion.major > oldVersion.major+1 {
+		return fmt.Errorf("changing image from '%s' to '%s' is forbidden: upgrading Cassandra from version %s to %s skips a major version", oldImage, newImage, oldVersion, newVersion)
+	}
+
+	return nil
+}
+
+// versionOfImage determines the Cassandra version from the tag of the image, e.g. 3.11.2 for cassandra:3.11.2-jessie
+func versionOfImage(image string) (*cassandraVersion, error) {
+	tag := imageTag(image)
+	matches := imageTagVersionPattern.FindStringSubmatch(tag)
+	if matches == nil {
+		return nil, fmt.Errorf("unable to determine the Cassandra version of image '%s' from its tag", image)
+	}
+
+	version := &cassandraVersion{}
+	version.major, _ = strconv.Atoi(matches[1])
+	version.minor, _ = strconv.Atoi(matches[2])
+	if matches[3] != "" {
+		version.patch, _ = strconv.Atoi(matches[3])
+	}
+	return version, nil
+}
+
+func imageTag(image string) string {
+	if strings.Contains(image, "@") {
+		return ""
+	}
+
+	tagSeparator := strings.LastIndex(image, ":")
+	if tagSeparator == -1 || tagSeparator < strings.LastIndex(image, "/") {
+		return ""
+	}
+	return image[tagSeparator+1:]
+}
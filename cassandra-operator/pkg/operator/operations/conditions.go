@@ -0,0 +1,79 @@
+package operations
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/apis/cassandra/v1alpha1"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/cluster"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"strings"
+)
+
+// reasons given for the conditions reported on the Cassandra resource
+const (
+	reasonAllReplicasReady         = "AllReplicasReady"
+	reasonReplicasNotReady         = "ReplicasNotReady"
+	reasonOperationInProgress      = "OperationInProgress"
+	reasonOperationsCompleted      = "OperationsCompleted"
+	reasonAllNodesUp               = "AllNodesUp"
+	reasonNodesDown                = "NodesDown"
+	reasonSnapshotJobScheduled     = "SnapshotJobScheduled"
+	reasonSnapshotJobFailed        = "SnapshotJobFailed"
+	reasonSnapshotNotConfigured    = "SnapshotNotConfigured"
+	reasonRepairJobScheduled       = "RepairJobScheduled"
+	reasonRepairJobFailed          = "RepairJobFailed"
+	reasonRepairNotConfigured      = "RepairNotConfigured"
+	reasonInvalidSpec              = "InvalidSpec"
+	reasonForbiddenChange          = "ForbiddenChange"
+	reasonUnsupportedChange        = "UnsupportedChange"
+	reasonSpecApplied              = "SpecApplied"
+	reasonClusterPaused            = "ClusterPaused"
+	reasonClusterResumed           = "ClusterResumed"
+	reasonNodeMaintenanceFailed    = "CommandFailed"
+	reasonNodeMaintenanceCompleted = "CommandCompleted"
+)
+
+// updateCondition sets the supplied condition on the status of the Cassandra resource.
+// Failures are logged rather than returned, as the condition is only a report of what the operator did.
+func updateCondition(clusterAccessor *cluster.Accessor, cassandra *v1alpha1.Cassandra, conditionType v1alpha1.CassandraConditionType, status v1.ConditionStatus, reason, message string) {
+	err := clusterAccessor.UpdateCassandraStatus(cassandra, func(cassandraStatus *v1alpha1.CassandraStatus) {
+		cassandraStatus.SetCondition(conditionType, status, reason, message)
+	})
+	if errors.IsNotFound(err) {
+		log.Debugf("Cluster %s no longer exists, condition %s not recorded", cassandra.QualifiedName(), conditionType)
+	} else if err != nil {
+		log.Warnf("Unable to record condition %s for cluster %s: %v", conditionType, cassandra.QualifiedName(), err)
+	}
+}
+
+func setAvailableCondition(status *v1alpha1.CassandraStatus, desiredReplicas int32) {
+	if status.ReadyReplicas == desiredReplicas && status.Replicas == desiredReplicas {
+		status.SetCondition(v1alpha1.ClusterAvailable, v1.ConditionTrue, reasonAllReplicasReady, fmt.Sprintf("%d of %d pods are ready", status.ReadyReplicas, desiredReplicas))
+	} else {
+		status.SetCondition(v1alpha1.ClusterAvailable, v1.ConditionFalse, reasonReplicasNotReady, fmt.Sprintf("%d of %d pods are ready", status.ReadyReplicas, desiredReplicas))
+	}
+}
+
+func setDegradedCondition(status *v1alpha1.CassandraStatus) {
+	var downNodes []string
+	for _, node := range status.Nodes {
+		if node.Liveness != "up" {
+			downNodes = append(downNodes, node.Name)
+		}
+	}
+
+	if len(downNodes) > 0 {
+		status.SetCondition(v1alpha1.ClusterDegraded, v1.ConditionTrue, reasonNodesDown, fmt.Sprintf("nodes down: %s", strings.Join(downNodes, ", ")))
+	} else {
+		status.SetCondition(v1alpha1.ClusterDegraded, v1.ConditionFalse, reasonAllNodesUp, "")
+	}
+}
+
+func setProgressingCondition(status *v1alpha1.CassandraStatus, currentOperation string) {
+	if currentOperation != "" {
+		status.SetCondition(v1alpha1.ClusterProgressing, v1.ConditionTrue, reasonOperationInProgress, currentOperation)
+	} else {
+		status.SetCondition(v1alpha1.ClusterProgressing, v1.ConditionFalse, reasonOperationsCompleted, "")
+	}
+}
@@ -0,0 +1,138 @@
+package operations
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/apis/cassandra/v1alpha1"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/cluster"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/operator/operations/adjuster"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// decommissionTimeout bounds the time taken by a node to stream its data to the remaining nodes
+	decommissionTimeout = 6 * time.Hour
+	// nodeLeftRingTimeout bounds the time taken by the remaining nodes to observe that a decommissioned node has left the ring
+	nodeLeftRingTimeout       = 10 * time.Minute
+	nodeLeftRingCheckInterval = 10 * time.Second
+)
+
+// scaleDownRack removes nodes from the rack one at a time, starting with the highest ordinal as the stateful set would.
+// Each node is decommissioned before its pod is removed, so that the data it owns is streamed to the remaining nodes.
+func (o *UpdateClusterOperation) scaleDownRack(clusterChange *adjuster.ClusterChange) error {
+	rack := clusterChange.Rack
+	statefulSet, err := o.clusterAccessor.GetStatefulSetForRack(o.cluster, &rack)
+	if err != nil {
+		return fmt.Errorf("unable to retrieve stateful set for rack %s in cluster %s: %v", rack.Name, o.cluster.QualifiedName(), err)
+	}
+
+	// the stateful set is scaled down after each node is removed, so its replicas show where a previous attempt stopped
+	currentReplicas := *statefulSet.Spec.Replicas
+	log.Infof("Scaling down rack %s in cluster %s from %d to %d replicas", rack.Name, o.cluster.QualifiedName(), currentReplicas, rack.Replicas)
+
+	for ordinal := currentReplicas - 1; ordinal >= rack.Replicas; ordinal-- {
+		if err := o.removeNode(&rack, ordinal); err != nil {
+			return fmt.Errorf("unable to scale down rack %s in cluster %s: %v", rack.Name, o.cluster.QualifiedName(), err)
+		}
+	}
+	return nil
+}
+
+// removeNode decommissions the node with the supplied ordinal, which must be the highest in the rack,
+// then removes its pod from the stateful set
+func (o *UpdateClusterOperation) removeNode(rack *v1alpha1.Rack, ordinal int32) error {
+	newCluster := o.update.NewCluster
+	pod, err := o.clusterAccessor.GetPodForRack(o.cluster, rack, ordinal)
+	if err != nil {
+		return fmt.Errorf("unable to retrieve pod %d of rack %s: %v", ordinal, rack.Name, err)
+	}
+
+	decommissioned, err := o.nodeHasLeftRing(rack, pod, ordinal)
+	if err != nil {
+		return err
+	}
+
+	if decommissioned {
+		log.Infof("Node on pod %s has already left the ring, its pod will be removed", pod.Name)
+	} else {
+		o.eventRecorder.Eventf(newCluster, v1.EventTypeNormal, cluster.NodeDecommissionStartedEvent, "decommissioning node on pod %s", pod.Name)
+		if err := o.nodetool.Decommission(pod, decommissionTimeout); err != nil {
+			o.eventRecorder.Eventf(newCluster, v1.EventTypeWarning, cluster.NodeDecommissionFailedEvent, "unable to decommission node on pod %s: %v", pod.Name, err)
+			return err
+		}
+
+		if err := o.waitUntilNodeHasLeftRing(pod); err != nil {
+			o.eventRecorder.Eventf(newCluster, v1.EventTypeWarning, cluster.NodeDecommissionFailedEvent, "node on pod %s has not left the ring: %v", pod.Name, err)
+			return err
+		}
+	}
+
+	scaledDownRack := *rack
+	scaledDownRack.Replicas = ordinal
+	scaleDownChange := &adjuster.ClusterChange{Rack: scaledDownRack, ChangeType: adjuster.ScaleDownRack, Patch: o.adjuster.ScaleDownPatch(ordinal)}
+	if err := o.statefulSetAccessor.patchStatefulSet(o.cluster, scaleDownChange); err != nil {
+		return err
+	}
+	o.eventRecorder.Eventf(newCluster, v1.EventTypeNormal, cluster.NodeDecommissionedEvent, "node on pod %s has been decommissioned and its pod removed", pod.Name)
+
+	o.reclaimStorage(rack, ordinal)
+	return nil
+}
+
+// nodeHasLeftRing reports whether the node on the pod with the supplied ordinal has already been decommissioned,
+// which happens when a previous attempt failed after the decommission but before the pod was removed.
+// The node is only considered gone when the ring holds no more nodes for the rack than the pods which remain once it is removed,
+// so that a node whose pod has been recreated with a new address, but which hasn't rejoined the ring yet, is still decommissioned.
+func (o *UpdateClusterOperation) nodeHasLeftRing(rack *v1alpha1.Rack, pod *v1.Pod, ordinal int32) (bool, error) {
+	if pod.Status.PodIP == "" {
+		return false, fmt.Errorf("pod %s has no address, so its node cannot be decommissioned", pod.Name)
+	}
+
+	nodes, err := o.metricsPoller.NodesInRack(o.cluster, rack.Name)
+	if err != nil {
+		return false, fmt.Errorf("unable to determine whether node on pod %s is part of the ring: %v", pod.Name, err)
+	}
+	for _, node := range nodes {
+		if node == pod.Status.PodIP {
+			return false, nil
+		}
+	}
+	return int32(len(nodes)) <= ordinal, nil
+}
+
+func (o *UpdateClusterOperation) waitUntilNodeHasLeftRing(pod *v1.Pod) error {
+	return wait.PollImmediate(nodeLeftRingCheckInterval, nodeLeftRingTimeout, func() (bool, error) {
+		leavingNodes, err := o.metricsPoller.LeavingNodes(o.cluster)
+		if err != nil {
+			log.Warnf("Unable to determine whether node on pod %s has left the ring, will retry: %v", pod.Name, err)
+			return false, nil
+		}
+
+		for _, leavingNode := range leavingNodes {
+			if leavingNode == pod.Status.PodIP {
+				log.Debugf("Node on pod %s is still leaving the ring", pod.Name)
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+}
+
+// reclaimStorage deletes the persistent volume claim left behind by the removed node when the reclaim policy requires it.
+// A failure is only reported, as the node has already been removed.
+func (o *UpdateClusterOperation) reclaimStorage(rack *v1alpha1.Rack, ordinal int32) {
+	newCluster := o.update.NewCluster
+	if newCluster.Spec.UseEmptyDir || newCluster.StorageReclaimPolicy() != v1alpha1.StorageReclaimDelete {
+		return
+	}
+
+	claimName := newCluster.StorageVolumeClaimName(rack, ordinal)
+	if err := o.clusterAccessor.DeletePersistentVolumeClaimForRack(o.cluster, rack, ordinal); err != nil {
+		log.Errorf("Unable to delete persistent volume claim %s for cluster %s: %v", claimName, o.cluster.QualifiedName(), err)
+		return
+	}
+	o.eventRecorder.Eventf(newCluster, v1.EventTypeNormal, cluster.PersistentVolumeClaimDeletedEvent, "deleted persistent volume claim %s", claimName)
+}
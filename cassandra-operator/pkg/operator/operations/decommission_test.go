@@ -0,0 +1,94 @@
+package operations
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/client/clientset/versioned/fake"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/cluster"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/operator/operations/adjuster"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("scale down of a rack", func() {
+	var (
+		c             *cluster.Cluster
+		kubeClientset *kubefake.Clientset
+		nodes         *stubNodeCommands
+		ring          *stubRingState
+		operation     *UpdateClusterOperation
+		scaleDown     *adjuster.ClusterChange
+	)
+
+	BeforeEach(func() {
+		c = aClusterWithRack("a", 3)
+		kubeClientset = fakeClusterResources(
+			aStatefulSet(c, "a", 3),
+			aRunningPod(c, "a", 0, "10.0.0.1"),
+			aRunningPod(c, "a", 1, "10.0.0.2"),
+			aRunningPod(c, "a", 2, "10.0.0.3"),
+		)
+		nodes = &stubNodeCommands{}
+		ring = &stubRingState{nodesInRack: map[string][]string{"a": {"10.0.0.1", "10.0.0.2", "10.0.0.3"}}}
+
+		adj, err := adjuster.New()
+		Expect(err).ToNot(HaveOccurred())
+		accessor := cluster.NewAccessor(kubeClientset, fake.NewSimpleClientset(), &stubEventRecorder{})
+		operation = &UpdateClusterOperation{
+			cluster:             c,
+			adjuster:            adj,
+			eventRecorder:       &stubEventRecorder{},
+			statefulSetAccessor: &statefulSetAccessor{clusterAccessor: accessor},
+			clusterAccessor:     accessor,
+			nodetool:            nodes,
+			metricsPoller:       ring,
+			update:              ClusterUpdate{OldCluster: c.Definition(), NewCluster: c.Definition()},
+		}
+
+		rack := c.Definition().Spec.Racks[0]
+		rack.Replicas = 2
+		scaleDown = &adjuster.ClusterChange{Rack: rack, ChangeType: adjuster.ScaleDownRack}
+	})
+
+	It("should decommission the node with the highest ordinal before removing its pod", func() {
+		Expect(operation.scaleDownRack(scaleDown)).To(Succeed())
+
+		Expect(nodes.decommissionedPods).To(Equal([]string{"mycluster-a-2"}))
+		Expect(patchedStatefulSets(kubeClientset)).To(Equal([]string{`mycluster-a: {"spec": {"replicas": 2}}`}))
+	})
+
+	It("should remove the pod without decommissioning its node again when the node has already left the ring", func() {
+		ring.nodesInRack["a"] = []string{"10.0.0.1", "10.0.0.2"}
+
+		Expect(operation.scaleDownRack(scaleDown)).To(Succeed())
+
+		Expect(nodes.decommissionedPods).To(BeEmpty())
+		Expect(patchedStatefulSets(kubeClientset)).To(Equal([]string{`mycluster-a: {"spec": {"replicas": 2}}`}))
+	})
+
+	It("should decommission a node whose pod has a new address while the ring still holds a node too many for the rack", func() {
+		ring.nodesInRack["a"] = []string{"10.0.0.1", "10.0.0.2", "10.0.0.99"}
+
+		Expect(operation.scaleDownRack(scaleDown)).To(Succeed())
+
+		Expect(nodes.decommissionedPods).To(Equal([]string{"mycluster-a-2"}))
+	})
+
+	It("should neither decommission the node nor remove its pod when the ring cannot be read", func() {
+		ring.err = errors.New("jolokia is unavailable")
+
+		Expect(operation.scaleDownRack(scaleDown)).ToNot(Succeed())
+
+		Expect(nodes.decommissionedPods).To(BeEmpty())
+		Expect(patchedStatefulSets(kubeClientset)).To(BeEmpty())
+	})
+
+	It("should not remove the pod when its node could not be decommissioned", func() {
+		nodes.failOn = map[string]bool{"mycluster-a-2": true}
+
+		Expect(operation.scaleDownRack(scaleDown)).ToNot(Succeed())
+
+		Expect(patchedStatefulSets(kubeClientset)).To(BeEmpty())
+	})
+})
@@ -6,6 +6,7 @@ import (
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/apis/cassandra/v1alpha1"
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/cluster"
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/metrics"
+	"k8s.io/apimachinery/pkg/api/errors"
 )
 
 // DeleteClusterOperation describes what the operator does when deleting a cluster
@@ -17,30 +18,63 @@ type DeleteClusterOperation struct {
 }
 
 // Execute performs the operation
-func (o *DeleteClusterOperation) Execute() {
+func (o *DeleteClusterOperation) Execute() error {
 	log.Infof("Cassandra cluster definition deleted for cluster: %s.%s", o.clusterDefinition.Namespace, o.clusterDefinition.Name)
 
 	var c *cluster.Cluster
 	var ok bool
 	if c, ok = o.clusters[fmt.Sprintf("%s.%s", o.clusterDefinition.Namespace, o.clusterDefinition.Name)]; !ok {
 		log.Warnf("No record found of deleted cluster %s.%s", o.clusterDefinition.Namespace, o.clusterDefinition.Name)
-		return
+		return nil
 	}
 
-	delete(o.clusters, c.QualifiedName())
 	c.Online = false
 	o.metricsPoller.DeleteMetrics(c)
 
+	// the cluster is only forgotten once all its resources are deleted, so a failed deletion can be retried
 	if err := o.clusterAccessor.DeleteStatefulSetsForCluster(c); err != nil {
-		log.Errorf("Error while deleting stateful sets for cluster %s: %v", c.QualifiedName(), err)
+		return fmt.Errorf("error while deleting stateful sets for cluster %s: %v", c.QualifiedName(), err)
 	}
 	log.Infof("Deleted stateful sets for cluster: %s", c.QualifiedName())
 
-	if err := o.clusterAccessor.DeleteServiceForCluster(c); err != nil {
-		log.Errorf("Error while deleting service for cluster %s: %v", c.QualifiedName(), err)
+	if err := o.clusterAccessor.DeleteServiceForCluster(c); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("error while deleting service for cluster %s: %v", c.QualifiedName(), err)
 	}
 	log.Infof("Deleted headless service for cluster: %s", c.QualifiedName())
+
+	if err := o.reclaimStorage(c); err != nil {
+		return err
+	}
+
+	if err := o.clusterAccessor.DeleteNodeReplacementsForCluster(c); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("error while deleting node replacements for cluster %s: %v", c.QualifiedName(), err)
+	}
+
+	delete(o.clusters, c.QualifiedName())
 	log.Infof("Existing Cassandra cluster removed: %s", c.QualifiedName())
+	return nil
+}
+
+// reclaimStorage deletes the persistent volume claims of the cluster when its reclaim policy requires it.
+// Kubernetes only removes a claim once the pod using it has terminated, so the claims can be deleted straight after the stateful sets.
+func (o *DeleteClusterOperation) reclaimStorage(c *cluster.Cluster) error {
+	if c.Definition().Spec.UseEmptyDir || c.Definition().StorageReclaimPolicy() != v1alpha1.StorageReclaimDelete {
+		log.Infof("Persistent volume claims of cluster %s are retained", c.QualifiedName())
+		return nil
+	}
+
+	claims, err := o.clusterAccessor.FindPersistentVolumeClaimsForCluster(c)
+	if err != nil {
+		return err
+	}
+
+	for i := range claims {
+		if err := o.clusterAccessor.DeletePersistentVolumeClaim(&claims[i]); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("error while deleting persistent volume claim %s for cluster %s: %v", claims[i].Name, c.QualifiedName(), err)
+		}
+		log.Infof("Deleted persistent volume claim %s for cluster: %s", claims[i].Name, c.QualifiedName())
+	}
+	return nil
 }
 
 func (o *DeleteClusterOperation) String() string {
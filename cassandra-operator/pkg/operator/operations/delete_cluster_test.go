@@ -0,0 +1,87 @@
+package operations
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/apis/cassandra/v1alpha1"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/client/clientset/versioned/fake"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/cluster"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("reclaim of the storage of a deleted cluster", func() {
+	var (
+		definition    *v1alpha1.Cassandra
+		kubeClientset *kubefake.Clientset
+		accessor      *cluster.Accessor
+	)
+
+	clusterFor := func(definition *v1alpha1.Cassandra) *cluster.Cluster {
+		c, err := cluster.New(definition)
+		Expect(err).ToNot(HaveOccurred())
+		return c
+	}
+
+	reclaimStorage := func(c *cluster.Cluster) error {
+		operation := &DeleteClusterOperation{clusterAccessor: accessor, clusterDefinition: c.Definition()}
+		return operation.reclaimStorage(c)
+	}
+
+	BeforeEach(func() {
+		c := aClusterWithRack("a", 2)
+		definition = c.Definition()
+		otherClustersClaim := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{
+			Name:      "cassandra-storage-othercluster-othercluster-a-0",
+			Namespace: c.Namespace(),
+			Labels:    map[string]string{cluster.OperatorLabel: "othercluster"},
+		}}
+		unlabelledClaim := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "some-claim", Namespace: c.Namespace()}}
+		kubeClientset = fakeClusterResources(aClaim(c, 0, "claim-0"), aClaim(c, 1, "claim-1"), otherClustersClaim, unlabelledClaim)
+		accessor = cluster.NewAccessor(kubeClientset, fake.NewSimpleClientset(), &stubEventRecorder{})
+	})
+
+	It("should retain the persistent volume claims by default", func() {
+		Expect(reclaimStorage(clusterFor(definition))).To(Succeed())
+
+		Expect(deletedObjects(kubeClientset, "persistentvolumeclaims")).To(BeEmpty())
+	})
+
+	It("should retain the persistent volume claims when the reclaim policy is Retain", func() {
+		definition.Spec.Storage = &v1alpha1.Storage{ReclaimPolicy: v1alpha1.StorageReclaimRetain}
+
+		Expect(reclaimStorage(clusterFor(definition))).To(Succeed())
+
+		Expect(deletedObjects(kubeClientset, "persistentvolumeclaims")).To(BeEmpty())
+	})
+
+	It("should only delete the persistent volume claims labelled for the cluster when the reclaim policy is Delete", func() {
+		definition.Spec.Storage = &v1alpha1.Storage{ReclaimPolicy: v1alpha1.StorageReclaimDelete}
+
+		Expect(reclaimStorage(clusterFor(definition))).To(Succeed())
+
+		Expect(deletedObjects(kubeClientset, "persistentvolumeclaims")).To(ConsistOf(
+			"cassandra-storage-mycluster-mycluster-a-0",
+			"cassandra-storage-mycluster-mycluster-a-1",
+		))
+	})
+
+	It("should not look for persistent volume claims when the cluster uses emptyDir storage", func() {
+		definition.Spec.Storage = &v1alpha1.Storage{ReclaimPolicy: v1alpha1.StorageReclaimDelete}
+		definition.Spec.UseEmptyDir = true
+		definition.Spec.Pod.StorageSize = resource.Quantity{}
+
+		Expect(reclaimStorage(clusterFor(definition))).To(Succeed())
+
+		Expect(kubeClientset.Actions()).To(BeEmpty())
+	})
+
+	It("should report the persistent volume claims left behind by a previous cluster of the same name", func() {
+		Expect(accessor.FindExistingResourcesFor(clusterFor(definition))).To(ConsistOf(
+			"persistentvolumeclaim:cassandra-storage-mycluster-mycluster-a-0",
+			"persistentvolumeclaim:cassandra-storage-mycluster-mycluster-a-1",
+		))
+	})
+})
@@ -2,7 +2,6 @@ package operations
 
 import (
 	"fmt"
-	log "github.com/sirupsen/logrus"
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/cluster"
 	"k8s.io/api/core/v1"
 	"k8s.io/client-go/tools/record"
@@ -17,15 +16,15 @@ type DeleteCustomConfigOperation struct {
 }
 
 // Execute performs the operation
-func (o *DeleteCustomConfigOperation) Execute() {
+func (o *DeleteCustomConfigOperation) Execute() error {
 	o.eventRecorder.Eventf(o.cluster.Definition(), v1.EventTypeNormal, cluster.ClusterUpdateEvent, "Custom config deleted for cluster %s", o.cluster.QualifiedName())
 	for _, rack := range o.cluster.Racks() {
 		err := o.statefulSetAccessor.updateStatefulSet(o.cluster, o.configMap, &rack, o.cluster.RemoveCustomConfigVolumeFromStatefulSet)
 		if err != nil {
-			log.Errorf("unable to remove custom configMap from statefulSet for rack %s in cluster %s: %v. Other racks will not be updated", rack.Name, o.cluster.QualifiedName(), err)
-			return
+			return fmt.Errorf("unable to remove custom configMap from statefulSet for rack %s in cluster %s: %v. Other racks will not be updated", rack.Name, o.cluster.QualifiedName(), err)
 		}
 	}
+	return nil
 }
 
 func (o *DeleteCustomConfigOperation) String() string {
@@ -0,0 +1,129 @@
+package operations
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/cluster"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/operator/operations/adjuster"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+// replicationFactorsTimeout bounds the time taken by cqlsh to read the keyspace definitions
+const replicationFactorsTimeout = 1 * time.Minute
+
+// Cassandra creates these keyspaces with a fixed replication factor regardless of the cluster size,
+// so they would prevent small clusters from removing any rack if taken into account.
+var keyspacesIgnoredForRackDeletion = map[string]bool{
+	"system_distributed": true,
+	"system_traces":      true,
+}
+
+// rackDeletionRefusal is returned when a rack is not deleted because doing so would leave too few racks.
+// The deletion is not retried, as it can only succeed once the cluster definition or the keyspaces are changed.
+type rackDeletionRefusal struct {
+	rack   string
+	reason string
+}
+
+func (r *rackDeletionRefusal) Error() string {
+	return fmt.Sprintf("rack %s cannot be deleted: %s", r.rack, r.reason)
+}
+
+// deleteRack decommissions every node of a rack removed from the cluster definition, one at a time from the highest ordinal,
+// then deletes its stateful set. The rack is only deleted when enough racks remain in the data centre for the replication
+// factor of each keyspace, so that each replica is still held in a separate rack.
+func (o *UpdateClusterOperation) deleteRack(clusterChange *adjuster.ClusterChange) error {
+	newCluster := o.update.NewCluster
+	rack := clusterChange.Rack
+
+	statefulSet, err := o.clusterAccessor.GetStatefulSetForRack(o.cluster, &rack)
+	if errors.IsNotFound(err) {
+		log.Infof("Rack %s has already been deleted from cluster %s", rack.Name, o.cluster.QualifiedName())
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to retrieve stateful set for rack %s in cluster %s: %v", rack.Name, o.cluster.QualifiedName(), err)
+	}
+
+	replicationFactors, err := o.replicationFactors()
+	if err != nil {
+		return fmt.Errorf("unable to check whether rack %s can be deleted from cluster %s: %v", rack.Name, o.cluster.QualifiedName(), err)
+	}
+
+	if reason := o.remainingRacksCannotHoldAllReplicas(replicationFactors); reason != "" {
+		o.eventRecorder.Eventf(newCluster, v1.EventTypeWarning, cluster.RackDeletionRefusedEvent, "refusing to delete rack %s: %s", rack.Name, reason)
+		return &rackDeletionRefusal{rack: rack.Name, reason: reason}
+	}
+
+	log.Infof("Deleting rack %s with %d replicas from cluster %s", rack.Name, *statefulSet.Spec.Replicas, o.cluster.QualifiedName())
+	for ordinal := *statefulSet.Spec.Replicas - 1; ordinal >= 0; ordinal-- {
+		if err := o.removeNode(&rack, ordinal); err != nil {
+			return fmt.Errorf("unable to delete rack %s in cluster %s: %v", rack.Name, o.cluster.QualifiedName(), err)
+		}
+	}
+
+	nodes, err := o.metricsPoller.NodesInRack(o.cluster, rack.Name)
+	if err != nil {
+		return fmt.Errorf("unable to verify that the nodes of rack %s in cluster %s have left the ring: %v", rack.Name, o.cluster.QualifiedName(), err)
+	}
+	if len(nodes) > 0 {
+		return fmt.Errorf("nodes %s of rack %s in cluster %s are still part of the ring", strings.Join(nodes, ", "), rack.Name, o.cluster.QualifiedName())
+	}
+
+	if err := o.clusterAccessor.DeleteStatefulSetForRack(o.cluster, &rack); err != nil {
+		return fmt.Errorf("unable to delete stateful set for rack %s in cluster %s: %v", rack.Name, o.cluster.QualifiedName(), err)
+	}
+	o.eventRecorder.Eventf(newCluster, v1.EventTypeNormal, cluster.RackDeletedEvent, "rack %s has been deleted", rack.Name)
+	return nil
+}
+
+// remainingRacksCannotHoldAllReplicas checks that the racks kept in the cluster definition will still provide a separate rack
+// for each replica in the data centre, as NetworkTopologyStrategy places the replicas of a range in distinct racks,
+// and describes the keyspaces whose replication factor is too high otherwise.
+func (o *UpdateClusterOperation) remainingRacksCannotHoldAllReplicas(replicationFactors map[string]int) string {
+	var remainingRacks int
+	for _, rack := range o.cluster.Racks() {
+		if rack.Replicas > 0 {
+			remainingRacks++
+		}
+	}
+
+	var keyspacesWithTooFewRacks []string
+	for keyspace, replicationFactor := range replicationFactors {
+		if !keyspacesIgnoredForRackDeletion[keyspace] && replicationFactor > remainingRacks {
+			keyspacesWithTooFewRacks = append(keyspacesWithTooFewRacks, fmt.Sprintf("%s (%d)", keyspace, replicationFactor))
+		}
+	}
+
+	if len(keyspacesWithTooFewRacks) == 0 {
+		return ""
+	}
+	sort.Strings(keyspacesWithTooFewRacks)
+	return fmt.Sprintf("the %d remaining racks of data centre %s are fewer than the replication factor of keyspaces %s", remainingRacks, o.cluster.Definition().Spec.DC, strings.Join(keyspacesWithTooFewRacks, ", "))
+}
+
+// replicationFactors reads the keyspace definitions from the first node of one of the racks kept in the cluster definition
+func (o *UpdateClusterOperation) replicationFactors() (map[string]int, error) {
+	definition := o.cluster.Definition()
+	var lastErr error
+	for i := range definition.Spec.Racks {
+		pod, err := o.clusterAccessor.GetPodForRack(o.cluster, &definition.Spec.Racks[i], 0)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		replicationFactors, err := o.nodetool.ReplicationFactors(pod, definition.Spec.DC, replicationFactorsTimeout)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return replicationFactors, nil
+	}
+	return nil, fmt.Errorf("unable to determine the replication factor of keyspaces: %v", lastErr)
+}
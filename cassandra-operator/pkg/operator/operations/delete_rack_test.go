@@ -0,0 +1,60 @@
+package operations
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/apis/cassandra/v1alpha1"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/client/clientset/versioned/fake"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/cluster"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/operator/operations/adjuster"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("deletion of a rack", func() {
+	var (
+		kubeClientset *kubefake.Clientset
+		nodes         *stubNodeCommands
+		operation     *UpdateClusterOperation
+		deletion      *adjuster.ClusterChange
+	)
+
+	BeforeEach(func() {
+		// racks a and b are kept with 3 nodes each, while rack c is removed from the cluster definition
+		definition := aClusterWithRack("a", 3).Definition()
+		definition.Spec.Racks = append(definition.Spec.Racks, v1alpha1.Rack{Name: "b", Replicas: 3, StorageClass: "some-storage", Zone: "some-zone"})
+		c, err := cluster.New(definition)
+		Expect(err).ToNot(HaveOccurred())
+
+		kubeClientset = fakeClusterResources(
+			aStatefulSet(c, "c", 1),
+			aRunningPod(c, "a", 0, "10.0.0.1"),
+			aRunningPod(c, "c", 0, "10.0.0.7"),
+		)
+		nodes = &stubNodeCommands{}
+		accessor := cluster.NewAccessor(kubeClientset, fake.NewSimpleClientset(), &stubEventRecorder{})
+		operation = &UpdateClusterOperation{
+			cluster:             c,
+			eventRecorder:       &stubEventRecorder{},
+			statefulSetAccessor: &statefulSetAccessor{clusterAccessor: accessor},
+			clusterAccessor:     accessor,
+			nodetool:            nodes,
+			metricsPoller:       &stubRingState{},
+			update:              ClusterUpdate{OldCluster: c.Definition(), NewCluster: c.Definition()},
+		}
+		deletion = &adjuster.ClusterChange{Rack: v1alpha1.Rack{Name: "c", Replicas: 1, StorageClass: "some-storage", Zone: "some-zone"}, ChangeType: adjuster.DeleteRack}
+	})
+
+	It("should be refused when fewer racks than the replication factor of a keyspace would remain, however many nodes they hold", func() {
+		nodes.replicationFactors = map[string]int{"my_keyspace": 3, "system_traces": 3}
+
+		err := operation.deleteRack(deletion)
+
+		Expect(err).To(BeAssignableToTypeOf(&rackDeletionRefusal{}))
+		Expect(err.Error()).To(Equal("rack c cannot be deleted: the 2 remaining racks of data centre dc1 are fewer than the replication factor of keyspaces my_keyspace (3)"))
+		Expect(nodes.decommissionedPods).To(BeEmpty())
+	})
+
+	It("should be allowed when each replica of every keyspace can still be held in a separate rack", func() {
+		Expect(operation.remainingRacksCannotHoldAllReplicas(map[string]int{"my_keyspace": 2, "system_traces": 3})).To(BeEmpty())
+	})
+})
@@ -0,0 +1,40 @@
+package operations
+
+import (
+	"fmt"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/apis/cassandra/v1alpha1"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/cluster"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/record"
+)
+
+// DeleteRepairOperation describes what the operator does when a repair schedule is removed for a cluster
+type DeleteRepairOperation struct {
+	cassandra       *v1alpha1.Cassandra
+	clusterAccessor *cluster.Accessor
+	eventRecorder   record.EventRecorder
+}
+
+// Execute performs the operation
+func (o *DeleteRepairOperation) Execute() error {
+	qualifiedName := o.cassandra.QualifiedName()
+	job, err := o.clusterAccessor.FindCronJobForCluster(o.cassandra, fmt.Sprintf("app=%s", o.cassandra.RepairJobName()))
+	if err != nil {
+		return fmt.Errorf("error while retrieving repair job list for cluster %s: %v", qualifiedName, err)
+	}
+
+	if job != nil {
+		err = o.clusterAccessor.DeleteCronJob(job)
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("error while deleting repair job %s for cluster %s: %v", job.Name, qualifiedName, err)
+		}
+		o.eventRecorder.Eventf(o.cassandra, v1.EventTypeNormal, cluster.ClusterRepairUnscheduleEvent, "Repair unscheduled for cluster %s", qualifiedName)
+	}
+	updateCondition(o.clusterAccessor, o.cassandra, v1alpha1.RepairScheduled, v1.ConditionFalse, reasonRepairNotConfigured, "")
+	return nil
+}
+
+func (o *DeleteRepairOperation) String() string {
+	return fmt.Sprintf("delete repair schedule for cluster %s", o.cassandra.QualifiedName())
+}
@@ -2,10 +2,10 @@ package operations
 
 import (
 	"fmt"
-	log "github.com/sirupsen/logrus"
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/apis/cassandra/v1alpha1"
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/cluster"
 	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/client-go/tools/record"
 )
 
@@ -17,20 +17,21 @@ type DeleteSnapshotCleanupOperation struct {
 }
 
 // Execute performs the operation
-func (o *DeleteSnapshotCleanupOperation) Execute() {
+func (o *DeleteSnapshotCleanupOperation) Execute() error {
 	qualifiedName := o.cassandra.QualifiedName()
 	job, err := o.clusterAccessor.FindCronJobForCluster(o.cassandra, fmt.Sprintf("app=%s", o.cassandra.SnapshotCleanupJobName()))
 	if err != nil {
-		log.Errorf("Error while retrieving snapshot cleanup job for cluster %s: %v", qualifiedName, err)
+		return fmt.Errorf("error while retrieving snapshot cleanup job for cluster %s: %v", qualifiedName, err)
 	}
 
 	if job != nil {
 		err = o.clusterAccessor.DeleteCronJob(job)
-		if err != nil {
-			log.Errorf("Error while deleting snapshot cleanup job %s for cluster %s: %v", job.Name, qualifiedName, err)
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("error while deleting snapshot cleanup job %s for cluster %s: %v", job.Name, qualifiedName, err)
 		}
 		o.eventRecorder.Eventf(o.cassandra, v1.EventTypeNormal, cluster.ClusterSnapshotCleanupUnscheduleEvent, "Snapshot cleanup unscheduled for cluster %s", qualifiedName)
 	}
+	return nil
 }
 
 func (o *DeleteSnapshotCleanupOperation) String() string {
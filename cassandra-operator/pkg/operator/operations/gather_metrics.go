@@ -3,20 +3,59 @@ package operations
 import (
 	"fmt"
 	log "github.com/sirupsen/logrus"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/apis/cassandra/v1alpha1"
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/cluster"
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/metrics"
 )
 
 // GatherMetricsOperation describes what the operator does when gathering metrics
 type GatherMetricsOperation struct {
-	metricsPoller *metrics.PrometheusMetrics
-	cluster       *cluster.Cluster
+	metricsPoller   *metrics.PrometheusMetrics
+	clusterAccessor *cluster.Accessor
+	cluster         *cluster.Cluster
 }
 
-// Execute performs the operation
-func (o *GatherMetricsOperation) Execute() {
+// Execute performs the operation.
+// Failures are only logged, as metrics are gathered again at the next polling interval.
+func (o *GatherMetricsOperation) Execute() error {
 	log.Debugf("Processing request to update metrics for %s", o.cluster.QualifiedName())
-	o.metricsPoller.UpdateMetrics(o.cluster)
+	nodeStatuses, err := o.metricsPoller.UpdateMetrics(o.cluster)
+	if err != nil {
+		log.Error(err)
+	}
+
+	rackStatuses, err := o.clusterAccessor.FindRackStatusesFor(o.cluster)
+	if err != nil {
+		log.Error(err)
+	}
+
+	err = o.clusterAccessor.UpdateCassandraStatus(o.cluster.Definition(), func(status *v1alpha1.CassandraStatus) {
+		if rackStatuses != nil {
+			status.Racks = rackStatuses
+			status.Replicas, status.ReadyReplicas = 0, 0
+			for _, rackStatus := range rackStatuses {
+				status.Replicas += rackStatus.Replicas
+				status.ReadyReplicas += rackStatus.ReadyReplicas
+			}
+			setAvailableCondition(status, o.desiredReplicas())
+		}
+		if nodeStatuses != nil {
+			status.Nodes = nodeStatuses
+			setDegradedCondition(status)
+		}
+	})
+	if err != nil {
+		log.Errorf("Unable to update status for cluster %s: %v", o.cluster.QualifiedName(), err)
+	}
+	return nil
+}
+
+func (o *GatherMetricsOperation) desiredReplicas() int32 {
+	var replicas int32
+	for _, rack := range o.cluster.Racks() {
+		replicas += rack.Replicas
+	}
+	return replicas
 }
 
 func (o *GatherMetricsOperation) String() string {
@@ -0,0 +1,135 @@
+package operations
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/apis/cassandra/v1alpha1"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/cluster"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/record"
+)
+
+// NodeMaintenanceOperation runs the nodetool commands which remain to be run on the nodes of a cluster, one node at a time,
+// as recorded in the status of the cluster. It is dispatched apart from the other operations of the cluster,
+// so that commands which take hours on nodes holding a lot of data don't hold up the changes made to the cluster.
+type NodeMaintenanceOperation struct {
+	clusterAccessor   *cluster.Accessor
+	clusters          map[string]*cluster.Cluster
+	clusterDefinition *v1alpha1.Cassandra
+	nodetool          nodeCommands
+	eventRecorder     record.EventRecorder
+}
+
+// Execute performs the operation
+func (o *NodeMaintenanceOperation) Execute() error {
+	for {
+		c, ok := o.clusters[o.clusterDefinition.QualifiedName()]
+		if !ok || !c.Online {
+			log.Debugf("Cluster %s is not managed, no node maintenance will be run", o.clusterDefinition.QualifiedName())
+			return nil
+		}
+
+		cassandra, err := o.clusterAccessor.GetCassandraForCluster(c)
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("unable to retrieve pending node maintenance for cluster %s: %v", c.QualifiedName(), err)
+		}
+		if cassandra.Spec.Paused {
+			log.Infof("Cluster %s is paused, its pending node maintenance is held until it is unpaused", c.QualifiedName())
+			return nil
+		}
+		if len(cassandra.Status.NodeMaintenance) == 0 {
+			return nil
+		}
+
+		// the outcome is recorded before moving on to the next node, so that a node isn't maintained twice should the operator restart
+		maintenance := cassandra.Status.NodeMaintenance[0]
+		var commandErr error
+		if len(maintenance.Pods) > 0 {
+			commandErr = o.runCommand(c, cassandra, maintenance.Command, maintenance.Pods[0])
+		}
+		if err := o.recordOutcome(cassandra, maintenance, commandErr); err != nil {
+			return fmt.Errorf("unable to record node maintenance for cluster %s: %v", c.QualifiedName(), err)
+		}
+	}
+}
+
+// runCommand runs the supplied command on the named pod. A pod which no longer exists, as its rack has been scaled down
+// or deleted since, has nothing left to maintain.
+func (o *NodeMaintenanceOperation) runCommand(c *cluster.Cluster, cassandra *v1alpha1.Cassandra, command v1alpha1.NodeMaintenanceCommand, podName string) error {
+	pod, err := o.clusterAccessor.GetPodForCluster(c, podName)
+	if errors.IsNotFound(err) {
+		log.Infof("Pod %s no longer exists in cluster %s, %s will not be run on it", podName, c.QualifiedName(), command)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to retrieve pod %s: %v", podName, err)
+	}
+
+	switch command {
+	case v1alpha1.UpgradeSSTablesCommand:
+		o.eventRecorder.Eventf(cassandra, v1.EventTypeNormal, cluster.UpgradeSSTablesStartedEvent, "upgrading SSTables on pod %s", pod.Name)
+		if err := o.nodetool.UpgradeSSTables(pod, upgradeSSTablesTimeout); err != nil {
+			log.Errorf("Unable to upgrade SSTables on pod %s in cluster %s: %v", pod.Name, c.QualifiedName(), err)
+			o.eventRecorder.Eventf(cassandra, v1.EventTypeWarning, cluster.UpgradeSSTablesFailedEvent, "unable to upgrade SSTables on pod %s: %v", pod.Name, err)
+			return err
+		}
+		o.eventRecorder.Eventf(cassandra, v1.EventTypeNormal, cluster.UpgradeSSTablesCompletedEvent, "upgraded SSTables on pod %s", pod.Name)
+		return nil
+	case v1alpha1.CleanupCommand:
+		var jobs *int32
+		if cassandra.Spec.AutoCleanup != nil {
+			jobs = cassandra.Spec.AutoCleanup.Jobs
+		}
+		o.eventRecorder.Eventf(cassandra, v1.EventTypeNormal, cluster.CleanupStartedEvent, "cleaning up pod %s after scale-up", pod.Name)
+		if err := o.nodetool.Cleanup(pod, jobs, cleanupTimeout); err != nil {
+			log.Errorf("Unable to clean up pod %s in cluster %s: %v", pod.Name, c.QualifiedName(), err)
+			o.eventRecorder.Eventf(cassandra, v1.EventTypeWarning, cluster.CleanupFailedEvent, "unable to clean up pod %s: %v", pod.Name, err)
+			return err
+		}
+		o.eventRecorder.Eventf(cassandra, v1.EventTypeNormal, cluster.CleanupCompletedEvent, "cleaned up pod %s", pod.Name)
+		return nil
+	default:
+		return fmt.Errorf("node maintenance command '%s' isn't supported", command)
+	}
+}
+
+// recordOutcome removes the first pod from the pending pods of the supplied maintenance, and reports in the NodeMaintenanceFailed
+// condition the pods on which the command has failed. A failure on one node doesn't prevent the other nodes from being maintained,
+// as the node keeps serving its data until the command is run again.
+func (o *NodeMaintenanceOperation) recordOutcome(cassandra *v1alpha1.Cassandra, maintenance v1alpha1.NodeMaintenance, commandErr error) error {
+	return o.clusterAccessor.UpdateCassandraStatus(cassandra, func(status *v1alpha1.CassandraStatus) {
+		for i := range status.NodeMaintenance {
+			current := &status.NodeMaintenance[i]
+			if current.Command != maintenance.Command {
+				continue
+			}
+
+			if len(maintenance.Pods) > 0 && len(current.Pods) > 0 && current.Pods[0] == maintenance.Pods[0] {
+				current.Pods = current.Pods[1:]
+				if commandErr != nil {
+					current.FailedPods = append(current.FailedPods, maintenance.Pods[0])
+				}
+			}
+
+			if len(current.FailedPods) > 0 {
+				status.SetCondition(v1alpha1.NodeMaintenanceFailed, v1.ConditionTrue, reasonNodeMaintenanceFailed, fmt.Sprintf("%s failed on pods: %s", current.Command, strings.Join(current.FailedPods, ", ")))
+			} else if len(current.Pods) == 0 {
+				status.SetCondition(v1alpha1.NodeMaintenanceFailed, v1.ConditionFalse, reasonNodeMaintenanceCompleted, fmt.Sprintf("%s completed on all pods", current.Command))
+			}
+			if len(current.Pods) == 0 {
+				status.NodeMaintenance = append(status.NodeMaintenance[:i], status.NodeMaintenance[i+1:]...)
+			}
+			return
+		}
+	})
+}
+
+func (o *NodeMaintenanceOperation) String() string {
+	return fmt.Sprintf("node maintenance for cluster %s", o.clusterDefinition.QualifiedName())
+}
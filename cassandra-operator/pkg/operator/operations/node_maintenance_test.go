@@ -0,0 +1,139 @@
+package operations
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/apis/cassandra/v1alpha1"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/client/clientset/versioned/fake"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/cluster"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("node maintenance", func() {
+	var (
+		c                  *cluster.Cluster
+		cassandra          *v1alpha1.Cassandra
+		cassandraClientset *fake.Clientset
+		nodes              *stubNodeCommands
+		operation          *NodeMaintenanceOperation
+	)
+
+	currentStatus := func() v1alpha1.CassandraStatus {
+		current, err := cassandraClientset.CoreV1alpha1().Cassandras(c.Namespace()).Get(c.Name(), metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		return current.Status
+	}
+
+	BeforeEach(func() {
+		c = aClusterWithRack("a", 3)
+		c.Online = true
+		cassandra = c.Definition()
+		cassandra.Status.NodeMaintenance = []v1alpha1.NodeMaintenance{
+			{Command: v1alpha1.UpgradeSSTablesCommand, Pods: []string{"mycluster-a-0", "mycluster-a-1", "mycluster-a-2"}},
+		}
+		nodes = &stubNodeCommands{}
+	})
+
+	JustBeforeEach(func() {
+		cassandraClientset = fake.NewSimpleClientset(cassandra)
+		kubeClientset := fakeClusterResources(
+			aRunningPod(c, "a", 0, "10.0.0.1"),
+			aRunningPod(c, "a", 1, "10.0.0.2"),
+			aRunningPod(c, "a", 2, "10.0.0.3"),
+		)
+		operation = &NodeMaintenanceOperation{
+			clusterAccessor:   cluster.NewAccessor(kubeClientset, cassandraClientset, &stubEventRecorder{}),
+			clusters:          map[string]*cluster.Cluster{c.QualifiedName(): c},
+			clusterDefinition: cassandra,
+			nodetool:          nodes,
+			eventRecorder:     &stubEventRecorder{},
+		}
+	})
+
+	It("should upgrade the SSTables of each pending pod in turn and then clear the pending maintenance", func() {
+		Expect(operation.Execute()).To(Succeed())
+
+		Expect(nodes.upgradedPods).To(Equal([]string{"mycluster-a-0", "mycluster-a-1", "mycluster-a-2"}))
+		status := currentStatus()
+		Expect(status.NodeMaintenance).To(BeEmpty())
+		Expect(status.GetCondition(v1alpha1.NodeMaintenanceFailed).Status).To(Equal(corev1.ConditionFalse))
+	})
+
+	It("should carry on with the other pods and report the pods on which the command failed", func() {
+		nodes.failOn = map[string]bool{"mycluster-a-1": true}
+
+		Expect(operation.Execute()).To(Succeed())
+
+		Expect(nodes.upgradedPods).To(Equal([]string{"mycluster-a-0", "mycluster-a-1", "mycluster-a-2"}))
+		status := currentStatus()
+		Expect(status.NodeMaintenance).To(BeEmpty())
+		condition := status.GetCondition(v1alpha1.NodeMaintenanceFailed)
+		Expect(condition.Status).To(Equal(corev1.ConditionTrue))
+		Expect(condition.Message).To(Equal("upgradesstables failed on pods: mycluster-a-1"))
+	})
+
+	Context("when the nodes are also to be cleaned up after a scale-up", func() {
+		BeforeEach(func() {
+			cassandra.Status.NodeMaintenance = append(cassandra.Status.NodeMaintenance,
+				v1alpha1.NodeMaintenance{Command: v1alpha1.CleanupCommand, Pods: []string{"mycluster-a-0", "mycluster-a-1"}})
+		})
+
+		It("should clean up the nodes once their SSTables have been upgraded", func() {
+			nodes.failOn = map[string]bool{"mycluster-a-0": true}
+
+			Expect(operation.Execute()).To(Succeed())
+
+			Expect(nodes.upgradedPods).To(Equal([]string{"mycluster-a-0", "mycluster-a-1", "mycluster-a-2"}))
+			Expect(nodes.cleanedUpPods).To(Equal([]string{"mycluster-a-0", "mycluster-a-1"}))
+			status := currentStatus()
+			Expect(status.NodeMaintenance).To(BeEmpty())
+			Expect(status.GetCondition(v1alpha1.NodeMaintenanceFailed).Message).To(Equal("cleanup failed on pods: mycluster-a-0"))
+		})
+	})
+
+	Context("when some of the pending pods no longer exist", func() {
+		BeforeEach(func() {
+			cassandra.Status.NodeMaintenance[0].Pods = []string{"mycluster-a-5", "mycluster-a-2"}
+		})
+
+		It("should skip them", func() {
+			Expect(operation.Execute()).To(Succeed())
+
+			Expect(nodes.upgradedPods).To(Equal([]string{"mycluster-a-2"}))
+			Expect(currentStatus().NodeMaintenance).To(BeEmpty())
+		})
+	})
+
+	Context("when the cluster is paused", func() {
+		BeforeEach(func() {
+			cassandra.Spec.Paused = true
+		})
+
+		It("should hold the pending maintenance until it is unpaused", func() {
+			Expect(operation.Execute()).To(Succeed())
+
+			Expect(nodes.upgradedPods).To(BeEmpty())
+			Expect(currentStatus().NodeMaintenance).To(HaveLen(1))
+		})
+	})
+
+	It("should do nothing once the cluster is no longer managed", func() {
+		c.Online = false
+
+		Expect(operation.Execute()).To(Succeed())
+
+		Expect(nodes.upgradedPods).To(BeEmpty())
+	})
+
+	It("should add the pods to the maintenance already pending for the same command", func() {
+		status := &v1alpha1.CassandraStatus{}
+		status.AddNodeMaintenance(v1alpha1.UpgradeSSTablesCommand, []string{"mycluster-a-0", "mycluster-a-1"})
+		status.AddNodeMaintenance(v1alpha1.UpgradeSSTablesCommand, []string{"mycluster-a-1", "mycluster-b-0"})
+		status.AddNodeMaintenance(v1alpha1.UpgradeSSTablesCommand, nil)
+
+		Expect(status.NodeMaintenance).To(Equal([]v1alpha1.NodeMaintenance{
+			{Command: v1alpha1.UpgradeSSTablesCommand, Pods: []string{"mycluster-a-0", "mycluster-a-1", "mycluster-b-0"}},
+		}))
+	})
+})
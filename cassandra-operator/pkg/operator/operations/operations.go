@@ -4,22 +4,52 @@ import (
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/apis/cassandra/v1alpha1"
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/cluster"
 	"k8s.io/api/core/v1"
+	"time"
 )
 
 // Operation describes a single unit of work
 type Operation interface {
-	// Execute actually performs the operation
-	Execute()
+	// Execute actually performs the operation.
+	// An error is returned when the operation could not be completed, in which case it is executed again later,
+	// so an operation must cope with the changes made by a previous partial execution.
+	Execute() error
 	// Human-readable description of the operation
 	String() string
 }
 
+// nodeCommands runs the commands which operations need on the Cassandra nodes, as provided by nodetool.Nodetool
+type nodeCommands interface {
+	UpgradeSSTables(pod *v1.Pod, timeout time.Duration) error
+	Cleanup(pod *v1.Pod, jobs *int32, timeout time.Duration) error
+	Decommission(pod *v1.Pod, timeout time.Duration) error
+	ReplicationFactors(pod *v1.Pod, dc string, timeout time.Duration) (map[string]int, error)
+}
+
+// ringState reports the state of the nodes in the ring of a cluster, as provided by metrics.PrometheusMetrics
+type ringState interface {
+	LeavingNodes(c *cluster.Cluster) ([]string, error)
+	LiveNodes(c *cluster.Cluster) ([]string, error)
+	JoiningNodes(c *cluster.Cluster) ([]string, error)
+	NodesInRack(c *cluster.Cluster, rackName string) ([]string, error)
+}
+
 func (r *Receiver) newAddCluster(cassandra *v1alpha1.Cassandra) Operation {
 	return &AddClusterOperation{
 		clusterAccessor:     r.clusterAccessor,
 		clusters:            r.clusters,
 		statefulSetAccessor: r.statefulSetAccessor,
 		clusterDefinition:   cassandra,
+		eventRecorder:       r.eventRecorder,
+	}
+}
+
+func (r *Receiver) newNodeMaintenance(cassandra *v1alpha1.Cassandra) Operation {
+	return &NodeMaintenanceOperation{
+		clusterAccessor:   r.clusterAccessor,
+		clusters:          r.clusters,
+		clusterDefinition: cassandra,
+		nodetool:          r.nodetool,
+		eventRecorder:     r.eventRecorder,
 	}
 }
 
@@ -32,6 +62,14 @@ func (r *Receiver) newDeleteCluster(cassandra *v1alpha1.Cassandra) Operation {
 	}
 }
 
+func (r *Receiver) newUnwatchCluster(cassandra *v1alpha1.Cassandra) Operation {
+	return &UnwatchClusterOperation{
+		clusters:          r.clusters,
+		clusterDefinition: cassandra,
+		metricsPoller:     r.metricsPoller,
+	}
+}
+
 func (r *Receiver) newDeleteSnapshot(cassandra *v1alpha1.Cassandra) Operation {
 	return &DeleteSnapshotOperation{
 		cassandra:       cassandra,
@@ -64,6 +102,22 @@ func (r *Receiver) newAddSnapshotCleanup(cassandra *v1alpha1.Cassandra) Operatio
 	}
 }
 
+func (r *Receiver) newAddRepair(cassandra *v1alpha1.Cassandra) Operation {
+	return &AddRepairOperation{
+		clusterDefinition: cassandra,
+		clusterAccessor:   r.clusterAccessor,
+		eventRecorder:     r.eventRecorder,
+	}
+}
+
+func (r *Receiver) newDeleteRepair(cassandra *v1alpha1.Cassandra) Operation {
+	return &DeleteRepairOperation{
+		cassandra:       cassandra,
+		clusterAccessor: r.clusterAccessor,
+		eventRecorder:   r.eventRecorder,
+	}
+}
+
 func (r *Receiver) newUpdateCluster(c *cluster.Cluster, update ClusterUpdate) Operation {
 	return &UpdateClusterOperation{
 		cluster:             c,
@@ -71,10 +125,60 @@ func (r *Receiver) newUpdateCluster(c *cluster.Cluster, update ClusterUpdate) Op
 		eventRecorder:       r.eventRecorder,
 		statefulSetAccessor: r.statefulSetAccessor,
 		clusterAccessor:     r.clusterAccessor,
+		nodetool:            r.nodetool,
+		metricsPoller:       r.metricsPoller,
 		update:              update,
 	}
 }
 
+func (r *Receiver) newReconcileCluster(cassandra *v1alpha1.Cassandra) Operation {
+	return &ReconcileClusterOperation{
+		clusters:            r.clusters,
+		clusterAccessor:     r.clusterAccessor,
+		statefulSetAccessor: r.statefulSetAccessor,
+		adjuster:            r.adjuster,
+		eventRecorder:       r.eventRecorder,
+		metricsPoller:       r.metricsPoller,
+		clusterDefinition:   cassandra,
+		updateCluster:       r.newUpdateCluster,
+	}
+}
+
+func (r *Receiver) newRestartCluster(c *cluster.Cluster, cassandra *v1alpha1.Cassandra) Operation {
+	return &RestartClusterOperation{
+		cluster:             c,
+		clusterDefinition:   cassandra,
+		clusterAccessor:     r.clusterAccessor,
+		statefulSetAccessor: r.statefulSetAccessor,
+		eventRecorder:       r.eventRecorder,
+	}
+}
+
+func (r *Receiver) newRunRepair(cassandra *v1alpha1.Cassandra) Operation {
+	return &RunRepairOperation{
+		clusterDefinition: cassandra,
+		clusterAccessor:   r.clusterAccessor,
+		eventRecorder:     r.eventRecorder,
+	}
+}
+
+func (r *Receiver) newPauseCluster(cassandra *v1alpha1.Cassandra) Operation {
+	return &PauseClusterOperation{
+		clusters:          r.clusters,
+		clusterAccessor:   r.clusterAccessor,
+		eventRecorder:     r.eventRecorder,
+		clusterDefinition: cassandra,
+	}
+}
+
+func (r *Receiver) newResumeCluster(cassandra *v1alpha1.Cassandra) Operation {
+	return &ResumeClusterOperation{
+		clusterAccessor:   r.clusterAccessor,
+		eventRecorder:     r.eventRecorder,
+		clusterDefinition: cassandra,
+	}
+}
+
 func (r *Receiver) newUpdateSnapshot(c *cluster.Cluster, newSnapshot *v1alpha1.Snapshot) Operation {
 	return &UpdateSnapshotOperation{
 		cluster:         c,
@@ -93,8 +197,17 @@ func (r *Receiver) newUpdateSnapshotCleanup(c *cluster.Cluster, newSnapshot *v1a
 	}
 }
 
+func (r *Receiver) newUpdateRepair(c *cluster.Cluster, newRepair *v1alpha1.Repair) Operation {
+	return &UpdateRepairOperation{
+		cluster:         c,
+		newRepair:       newRepair,
+		clusterAccessor: r.clusterAccessor,
+		eventRecorder:   r.eventRecorder,
+	}
+}
+
 func (r *Receiver) newGatherMetrics(c *cluster.Cluster) Operation {
-	return &GatherMetricsOperation{metricsPoller: r.metricsPoller, cluster: c}
+	return &GatherMetricsOperation{metricsPoller: r.metricsPoller, clusterAccessor: r.clusterAccessor, cluster: c}
 }
 
 func (r *Receiver) newUpdateCustomConfig(cluster *cluster.Cluster, configMap *v1.ConfigMap) Operation {
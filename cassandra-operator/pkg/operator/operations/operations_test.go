@@ -1,19 +1,28 @@
 package operations
 
 import (
+	"fmt"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/apis/cassandra/v1alpha1"
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/cluster"
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/dispatcher"
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/metrics"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/nodetool"
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/test"
+	appsv1beta1 "k8s.io/api/apps/v1beta1"
+	appsv1beta2 "k8s.io/api/apps/v1beta2"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	k8stesting "k8s.io/client-go/testing"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestOperations(t *testing.T) {
@@ -57,11 +66,11 @@ var _ = Describe("operations to execute based on event", func() {
 
 		c, _ := cluster.New(newClusterDef)
 		clusters[newClusterDef.QualifiedName()] = c
-		receiver = NewEventReceiver(clusters, &cluster.Accessor{}, &metrics.PrometheusMetrics{}, &stubEventRecorder{})
+		receiver = NewEventReceiver(clusters, &cluster.Accessor{}, &metrics.PrometheusMetrics{}, &nodetool.Nodetool{}, &stubEventRecorder{})
 	})
 
 	Context("when a cluster is added", func() {
-		It("should return an add cluster operation", func() {
+		It("should return add cluster and reconcile cluster operations", func() {
 			// given
 			newClusterDef.Spec.Snapshot = nil
 
@@ -69,8 +78,9 @@ var _ = Describe("operations to execute based on event", func() {
 			operations := receiver.operationsToExecute(&dispatcher.Event{Kind: AddCluster, Data: newClusterDef})
 
 			//then
-			Expect(operations).To(HaveLen(1))
+			Expect(operations).To(HaveLen(2))
 			Expect(reflect.TypeOf(operations[0])).To(Equal(reflect.TypeOf(&AddClusterOperation{})))
+			Expect(reflect.TypeOf(operations[1])).To(Equal(reflect.TypeOf(&ReconcileClusterOperation{})))
 		})
 
 		Context("a snapshot spec exists", func() {
@@ -83,9 +93,10 @@ var _ = Describe("operations to execute based on event", func() {
 				operations := receiver.operationsToExecute(&dispatcher.Event{Kind: AddCluster, Data: newClusterDef})
 
 				//then
-				Expect(operations).To(HaveLen(2))
+				Expect(operations).To(HaveLen(3))
 				Expect(reflect.TypeOf(operations[0])).To(Equal(reflect.TypeOf(&AddClusterOperation{})))
 				Expect(reflect.TypeOf(operations[1])).To(Equal(reflect.TypeOf(&AddSnapshotOperation{})))
+				Expect(reflect.TypeOf(operations[2])).To(Equal(reflect.TypeOf(&ReconcileClusterOperation{})))
 			})
 
 			It("should return add cluster and add snapshot operations when the retention policy is disabled", func() {
@@ -96,9 +107,10 @@ var _ = Describe("operations to execute based on event", func() {
 				operations := receiver.operationsToExecute(&dispatcher.Event{Kind: AddCluster, Data: newClusterDef})
 
 				//then
-				Expect(operations).To(HaveLen(2))
+				Expect(operations).To(HaveLen(3))
 				Expect(reflect.TypeOf(operations[0])).To(Equal(reflect.TypeOf(&AddClusterOperation{})))
 				Expect(reflect.TypeOf(operations[1])).To(Equal(reflect.TypeOf(&AddSnapshotOperation{})))
+				Expect(reflect.TypeOf(operations[2])).To(Equal(reflect.TypeOf(&ReconcileClusterOperation{})))
 			})
 
 			It("should return add cluster, add snapshot and add cleanup snapshot operations when a snapshot retention spec exists", func() {
@@ -106,13 +118,30 @@ var _ = Describe("operations to execute based on event", func() {
 				operations := receiver.operationsToExecute(&dispatcher.Event{Kind: AddCluster, Data: newClusterDef})
 
 				//then
-				Expect(operations).To(HaveLen(3))
+				Expect(operations).To(HaveLen(4))
 				Expect(reflect.TypeOf(operations[0])).To(Equal(reflect.TypeOf(&AddClusterOperation{})))
 				Expect(reflect.TypeOf(operations[1])).To(Equal(reflect.TypeOf(&AddSnapshotOperation{})))
 				Expect(reflect.TypeOf(operations[2])).To(Equal(reflect.TypeOf(&AddSnapshotCleanupOperation{})))
+				Expect(reflect.TypeOf(operations[3])).To(Equal(reflect.TypeOf(&ReconcileClusterOperation{})))
 			})
 		})
 
+		Context("a repair spec exists", func() {
+			It("should return add cluster, add repair and reconcile cluster operations", func() {
+				// given
+				newClusterDef.Spec.Snapshot = nil
+				newClusterDef.Spec.Repair = &v1alpha1.Repair{Schedule: "0 2 * * *"}
+
+				// when
+				operations := receiver.operationsToExecute(&dispatcher.Event{Kind: AddCluster, Data: newClusterDef})
+
+				//then
+				Expect(operations).To(HaveLen(3))
+				Expect(reflect.TypeOf(operations[0])).To(Equal(reflect.TypeOf(&AddClusterOperation{})))
+				Expect(reflect.TypeOf(operations[1])).To(Equal(reflect.TypeOf(&AddRepairOperation{})))
+				Expect(reflect.TypeOf(operations[2])).To(Equal(reflect.TypeOf(&ReconcileClusterOperation{})))
+			})
+		})
 	})
 
 	Context("when a cluster is deleted", func() {
@@ -165,6 +194,57 @@ var _ = Describe("operations to execute based on event", func() {
 			})
 
 		})
+		Context("a repair spec exists", func() {
+			It("should return a delete cluster and delete repair operations", func() {
+				// given
+				newClusterDef.Spec.Snapshot = nil
+				newClusterDef.Spec.Repair = &v1alpha1.Repair{Schedule: "0 2 * * *"}
+
+				// when
+				operations := receiver.operationsToExecute(&dispatcher.Event{Kind: DeleteCluster, Data: newClusterDef})
+
+				//then
+				Expect(operations).To(HaveLen(2))
+				Expect(reflect.TypeOf(operations[0])).To(Equal(reflect.TypeOf(&DeleteClusterOperation{})))
+				Expect(reflect.TypeOf(operations[1])).To(Equal(reflect.TypeOf(&DeleteRepairOperation{})))
+			})
+		})
+	})
+
+	Context("when the namespace of a cluster is no longer watched", func() {
+		It("should return an unwatch cluster operation", func() {
+			// when
+			operations := receiver.operationsToExecute(&dispatcher.Event{Kind: UnwatchCluster, Data: newClusterDef})
+
+			//then
+			Expect(operations).To(HaveLen(1))
+			Expect(reflect.TypeOf(operations[0])).To(Equal(reflect.TypeOf(&UnwatchClusterOperation{})))
+		})
+	})
+
+	Context("when a cluster is reconciled", func() {
+		It("should return a reconcile cluster operation", func() {
+			// when
+			operations := receiver.operationsToExecute(&dispatcher.Event{Kind: ReconcileCluster, Data: newClusterDef})
+
+			// then
+			Expect(operations).To(HaveLen(1))
+			Expect(reflect.TypeOf(operations[0])).To(Equal(reflect.TypeOf(&ReconcileClusterOperation{})))
+		})
+
+		It("should return add cluster and reconcile cluster operations when no record was found for the cluster", func() {
+			// given
+			delete(clusters, newClusterDef.QualifiedName())
+			newClusterDef.Spec.Snapshot = nil
+
+			// when
+			operations := receiver.operationsToExecute(&dispatcher.Event{Kind: ReconcileCluster, Data: newClusterDef})
+
+			// then
+			Expect(operations).To(HaveLen(2))
+			Expect(reflect.TypeOf(operations[0])).To(Equal(reflect.TypeOf(&AddClusterOperation{})))
+			Expect(reflect.TypeOf(operations[1])).To(Equal(reflect.TypeOf(&ReconcileClusterOperation{})))
+		})
 	})
 
 	Context("when a cluster spec is updated", func() {
@@ -173,7 +253,7 @@ var _ = Describe("operations to execute based on event", func() {
 				delete(clusters, newClusterDef.QualifiedName())
 			})
 
-			It("should return an add cluster operation", func() {
+			It("should return add cluster and reconcile cluster operations", func() {
 				// given
 				newClusterDef.Spec.Snapshot = nil
 
@@ -181,19 +261,21 @@ var _ = Describe("operations to execute based on event", func() {
 				operations := receiver.operationsToExecute(&dispatcher.Event{Kind: UpdateCluster, Data: ClusterUpdate{OldCluster: oldClusterDef, NewCluster: newClusterDef}})
 
 				// then
-				Expect(operations).To(HaveLen(1))
+				Expect(operations).To(HaveLen(2))
 				Expect(reflect.TypeOf(operations[0])).To(Equal(reflect.TypeOf(&AddClusterOperation{})))
+				Expect(reflect.TypeOf(operations[1])).To(Equal(reflect.TypeOf(&ReconcileClusterOperation{})))
 			})
 		})
 
 		Context("a record was found for the cluster", func() {
-			It("should return an update cluster operation", func() {
+			It("should return update cluster and reconcile cluster operations", func() {
 				// when
 				operations := receiver.operationsToExecute(&dispatcher.Event{Kind: UpdateCluster, Data: ClusterUpdate{OldCluster: oldClusterDef, NewCluster: newClusterDef}})
 
 				// then
-				Expect(operations).To(HaveLen(1))
+				Expect(operations).To(HaveLen(2))
 				Expect(reflect.TypeOf(operations[0])).To(Equal(reflect.TypeOf(&UpdateClusterOperation{})))
+				Expect(reflect.TypeOf(operations[1])).To(Equal(reflect.TypeOf(&ReconcileClusterOperation{})))
 			})
 
 			Context("a snapshot or snapshot cleanup spec is removed", func() {
@@ -205,10 +287,11 @@ var _ = Describe("operations to execute based on event", func() {
 					operations := receiver.operationsToExecute(&dispatcher.Event{Kind: UpdateCluster, Data: ClusterUpdate{OldCluster: oldClusterDef, NewCluster: newClusterDef}})
 
 					// then
-					Expect(operations).To(HaveLen(3))
+					Expect(operations).To(HaveLen(4))
 					Expect(reflect.TypeOf(operations[0])).To(Equal(reflect.TypeOf(&UpdateClusterOperation{})))
 					Expect(reflect.TypeOf(operations[1])).To(Equal(reflect.TypeOf(&DeleteSnapshotOperation{})))
 					Expect(reflect.TypeOf(operations[2])).To(Equal(reflect.TypeOf(&DeleteSnapshotCleanupOperation{})))
+					Expect(reflect.TypeOf(operations[3])).To(Equal(reflect.TypeOf(&ReconcileClusterOperation{})))
 				})
 				It("should return update cluster and delete snapshot cleanup when snapshot retention policy is removed", func() {
 					// given
@@ -218,9 +301,10 @@ var _ = Describe("operations to execute based on event", func() {
 					operations := receiver.operationsToExecute(&dispatcher.Event{Kind: UpdateCluster, Data: ClusterUpdate{OldCluster: oldClusterDef, NewCluster: newClusterDef}})
 
 					// then
-					Expect(operations).To(HaveLen(2))
+					Expect(operations).To(HaveLen(3))
 					Expect(reflect.TypeOf(operations[0])).To(Equal(reflect.TypeOf(&UpdateClusterOperation{})))
 					Expect(reflect.TypeOf(operations[1])).To(Equal(reflect.TypeOf(&DeleteSnapshotCleanupOperation{})))
+					Expect(reflect.TypeOf(operations[2])).To(Equal(reflect.TypeOf(&ReconcileClusterOperation{})))
 				})
 				It("should return update cluster and delete snapshot cleanup when snapshot retention policy is disabled", func() {
 					// given
@@ -230,9 +314,10 @@ var _ = Describe("operations to execute based on event", func() {
 					operations := receiver.operationsToExecute(&dispatcher.Event{Kind: UpdateCluster, Data: ClusterUpdate{OldCluster: oldClusterDef, NewCluster: newClusterDef}})
 
 					// then
-					Expect(operations).To(HaveLen(2))
+					Expect(operations).To(HaveLen(3))
 					Expect(reflect.TypeOf(operations[0])).To(Equal(reflect.TypeOf(&UpdateClusterOperation{})))
 					Expect(reflect.TypeOf(operations[1])).To(Equal(reflect.TypeOf(&DeleteSnapshotCleanupOperation{})))
+					Expect(reflect.TypeOf(operations[2])).To(Equal(reflect.TypeOf(&ReconcileClusterOperation{})))
 				})
 			})
 
@@ -245,10 +330,11 @@ var _ = Describe("operations to execute based on event", func() {
 					operations := receiver.operationsToExecute(&dispatcher.Event{Kind: UpdateCluster, Data: ClusterUpdate{OldCluster: oldClusterDef, NewCluster: newClusterDef}})
 
 					// then
-					Expect(operations).To(HaveLen(3))
+					Expect(operations).To(HaveLen(4))
 					Expect(reflect.TypeOf(operations[0])).To(Equal(reflect.TypeOf(&UpdateClusterOperation{})))
 					Expect(reflect.TypeOf(operations[1])).To(Equal(reflect.TypeOf(&AddSnapshotOperation{})))
 					Expect(reflect.TypeOf(operations[2])).To(Equal(reflect.TypeOf(&AddSnapshotCleanupOperation{})))
+					Expect(reflect.TypeOf(operations[3])).To(Equal(reflect.TypeOf(&ReconcileClusterOperation{})))
 				})
 				It("should return update cluster and add snapshot when snapshot without retention policy is added", func() {
 					// given
@@ -259,9 +345,10 @@ var _ = Describe("operations to execute based on event", func() {
 					operations := receiver.operationsToExecute(&dispatcher.Event{Kind: UpdateCluster, Data: ClusterUpdate{OldCluster: oldClusterDef, NewCluster: newClusterDef}})
 
 					// then
-					Expect(operations).To(HaveLen(2))
+					Expect(operations).To(HaveLen(3))
 					Expect(reflect.TypeOf(operations[0])).To(Equal(reflect.TypeOf(&UpdateClusterOperation{})))
 					Expect(reflect.TypeOf(operations[1])).To(Equal(reflect.TypeOf(&AddSnapshotOperation{})))
+					Expect(reflect.TypeOf(operations[2])).To(Equal(reflect.TypeOf(&ReconcileClusterOperation{})))
 				})
 				It("should return update cluster and add snapshot when snapshot with retention policy disabled", func() {
 					// given
@@ -272,9 +359,10 @@ var _ = Describe("operations to execute based on event", func() {
 					operations := receiver.operationsToExecute(&dispatcher.Event{Kind: UpdateCluster, Data: ClusterUpdate{OldCluster: oldClusterDef, NewCluster: newClusterDef}})
 
 					// then
-					Expect(operations).To(HaveLen(2))
+					Expect(operations).To(HaveLen(3))
 					Expect(reflect.TypeOf(operations[0])).To(Equal(reflect.TypeOf(&UpdateClusterOperation{})))
 					Expect(reflect.TypeOf(operations[1])).To(Equal(reflect.TypeOf(&AddSnapshotOperation{})))
+					Expect(reflect.TypeOf(operations[2])).To(Equal(reflect.TypeOf(&ReconcileClusterOperation{})))
 				})
 			})
 
@@ -287,9 +375,10 @@ var _ = Describe("operations to execute based on event", func() {
 					operations := receiver.operationsToExecute(&dispatcher.Event{Kind: UpdateCluster, Data: ClusterUpdate{OldCluster: oldClusterDef, NewCluster: newClusterDef}})
 
 					// then
-					Expect(operations).To(HaveLen(2))
+					Expect(operations).To(HaveLen(3))
 					Expect(reflect.TypeOf(operations[0])).To(Equal(reflect.TypeOf(&UpdateClusterOperation{})))
 					Expect(reflect.TypeOf(operations[1])).To(Equal(reflect.TypeOf(&UpdateSnapshotOperation{})))
+					Expect(reflect.TypeOf(operations[2])).To(Equal(reflect.TypeOf(&ReconcileClusterOperation{})))
 				})
 			})
 
@@ -302,9 +391,120 @@ var _ = Describe("operations to execute based on event", func() {
 					operations := receiver.operationsToExecute(&dispatcher.Event{Kind: UpdateCluster, Data: ClusterUpdate{OldCluster: oldClusterDef, NewCluster: newClusterDef}})
 
 					// then
-					Expect(operations).To(HaveLen(2))
+					Expect(operations).To(HaveLen(3))
 					Expect(reflect.TypeOf(operations[0])).To(Equal(reflect.TypeOf(&UpdateClusterOperation{})))
 					Expect(reflect.TypeOf(operations[1])).To(Equal(reflect.TypeOf(&UpdateSnapshotCleanupOperation{})))
+					Expect(reflect.TypeOf(operations[2])).To(Equal(reflect.TypeOf(&ReconcileClusterOperation{})))
+				})
+			})
+			Context("a repair spec is added, updated or removed", func() {
+				It("should return update cluster and add repair when a repair spec is added", func() {
+					// given
+					newClusterDef.Spec.Repair = &v1alpha1.Repair{Schedule: "0 2 * * *"}
+
+					// when
+					operations := receiver.operationsToExecute(&dispatcher.Event{Kind: UpdateCluster, Data: ClusterUpdate{OldCluster: oldClusterDef, NewCluster: newClusterDef}})
+
+					// then
+					Expect(operations).To(HaveLen(3))
+					Expect(reflect.TypeOf(operations[0])).To(Equal(reflect.TypeOf(&UpdateClusterOperation{})))
+					Expect(reflect.TypeOf(operations[1])).To(Equal(reflect.TypeOf(&AddRepairOperation{})))
+					Expect(reflect.TypeOf(operations[2])).To(Equal(reflect.TypeOf(&ReconcileClusterOperation{})))
+				})
+
+				It("should return update cluster and update repair when the repair spec is updated", func() {
+					// given
+					oldClusterDef.Spec.Repair = &v1alpha1.Repair{Schedule: "0 2 * * *"}
+					newClusterDef.Spec.Repair = &v1alpha1.Repair{Schedule: "0 2 * * *", Parallelism: v1alpha1.RepairSequential}
+
+					// when
+					operations := receiver.operationsToExecute(&dispatcher.Event{Kind: UpdateCluster, Data: ClusterUpdate{OldCluster: oldClusterDef, NewCluster: newClusterDef}})
+
+					// then
+					Expect(operations).To(HaveLen(3))
+					Expect(reflect.TypeOf(operations[0])).To(Equal(reflect.TypeOf(&UpdateClusterOperation{})))
+					Expect(reflect.TypeOf(operations[1])).To(Equal(reflect.TypeOf(&UpdateRepairOperation{})))
+					Expect(reflect.TypeOf(operations[2])).To(Equal(reflect.TypeOf(&ReconcileClusterOperation{})))
+				})
+
+				It("should return update cluster and delete repair when the repair spec is removed", func() {
+					// given
+					oldClusterDef.Spec.Repair = &v1alpha1.Repair{Schedule: "0 2 * * *"}
+
+					// when
+					operations := receiver.operationsToExecute(&dispatcher.Event{Kind: UpdateCluster, Data: ClusterUpdate{OldCluster: oldClusterDef, NewCluster: newClusterDef}})
+
+					// then
+					Expect(operations).To(HaveLen(3))
+					Expect(reflect.TypeOf(operations[0])).To(Equal(reflect.TypeOf(&UpdateClusterOperation{})))
+					Expect(reflect.TypeOf(operations[1])).To(Equal(reflect.TypeOf(&DeleteRepairOperation{})))
+					Expect(reflect.TypeOf(operations[2])).To(Equal(reflect.TypeOf(&ReconcileClusterOperation{})))
+				})
+			})
+
+			Context("a restart is requested", func() {
+				It("should return update cluster and restart cluster when the restartedAt annotation changes", func() {
+					// given
+					newClusterDef.Annotations = map[string]string{cluster.RestartedAtAnnotation: "2019-01-01T00:00:00Z"}
+
+					// when
+					operations := receiver.operationsToExecute(&dispatcher.Event{Kind: UpdateCluster, Data: ClusterUpdate{OldCluster: oldClusterDef, NewCluster: newClusterDef}})
+
+					// then
+					Expect(operations).To(HaveLen(3))
+					Expect(reflect.TypeOf(operations[0])).To(Equal(reflect.TypeOf(&UpdateClusterOperation{})))
+					Expect(reflect.TypeOf(operations[1])).To(Equal(reflect.TypeOf(&RestartClusterOperation{})))
+					Expect(reflect.TypeOf(operations[2])).To(Equal(reflect.TypeOf(&ReconcileClusterOperation{})))
+				})
+
+				It("should not restart the cluster when the restartedAt annotation is removed", func() {
+					// given
+					oldClusterDef.Annotations = map[string]string{cluster.RestartedAtAnnotation: "2019-01-01T00:00:00Z"}
+
+					// then
+					Expect(RestartRequested(oldClusterDef, newClusterDef)).To(BeFalse())
+				})
+
+				It("should return a restart cluster operation", func() {
+					// when
+					operations := receiver.operationsToExecute(&dispatcher.Event{Kind: RestartCluster, Data: newClusterDef})
+
+					// then
+					Expect(operations).To(HaveLen(1))
+					Expect(reflect.TypeOf(operations[0])).To(Equal(reflect.TypeOf(&RestartClusterOperation{})))
+				})
+			})
+
+			Context("a repair is requested", func() {
+				It("should return update cluster and run repair when the repairRequestedAt annotation changes", func() {
+					// given
+					newClusterDef.Annotations = map[string]string{cluster.RepairRequestedAtAnnotation: "2019-01-01T00:00:00Z"}
+
+					// when
+					operations := receiver.operationsToExecute(&dispatcher.Event{Kind: UpdateCluster, Data: ClusterUpdate{OldCluster: oldClusterDef, NewCluster: newClusterDef}})
+
+					// then
+					Expect(operations).To(HaveLen(3))
+					Expect(reflect.TypeOf(operations[0])).To(Equal(reflect.TypeOf(&UpdateClusterOperation{})))
+					Expect(reflect.TypeOf(operations[1])).To(Equal(reflect.TypeOf(&RunRepairOperation{})))
+					Expect(reflect.TypeOf(operations[2])).To(Equal(reflect.TypeOf(&ReconcileClusterOperation{})))
+				})
+
+				It("should not repair the cluster when the repairRequestedAt annotation is removed", func() {
+					// given
+					oldClusterDef.Annotations = map[string]string{cluster.RepairRequestedAtAnnotation: "2019-01-01T00:00:00Z"}
+
+					// then
+					Expect(RepairRequested(oldClusterDef, newClusterDef)).To(BeFalse())
+				})
+
+				It("should return a run repair operation", func() {
+					// when
+					operations := receiver.operationsToExecute(&dispatcher.Event{Kind: RunRepair, Data: newClusterDef})
+
+					// then
+					Expect(operations).To(HaveLen(1))
+					Expect(reflect.TypeOf(operations[0])).To(Equal(reflect.TypeOf(&RunRepairOperation{})))
 				})
 			})
 		})
@@ -320,6 +520,17 @@ var _ = Describe("operations to execute based on event", func() {
 			})
 		})
 
+		Context("when node maintenance is requested", func() {
+			It("should return a node maintenance operation", func() {
+				// when
+				operations := receiver.operationsToExecute(&dispatcher.Event{Kind: NodeMaintenance, Data: newClusterDef})
+
+				// then
+				Expect(operations).To(HaveLen(1))
+				Expect(reflect.TypeOf(operations[0])).To(Equal(reflect.TypeOf(&NodeMaintenanceOperation{})))
+			})
+		})
+
 		Context("when a custom configmap is updated", func() {
 			var configMap *corev1.ConfigMap
 
@@ -401,6 +612,393 @@ var _ = Describe("operations to execute based on event", func() {
 			})
 		})
 
+		Context("when a cluster is paused", func() {
+			BeforeEach(func() {
+				newClusterDef.Spec.Paused = true
+				c, _ := cluster.New(newClusterDef)
+				clusters[newClusterDef.QualifiedName()] = c
+			})
+
+			It("should only return a pause cluster operation when the cluster is added", func() {
+				// when
+				operations := receiver.operationsToExecute(&dispatcher.Event{Kind: AddCluster, Data: newClusterDef})
+
+				// then
+				Expect(operations).To(HaveLen(1))
+				Expect(reflect.TypeOf(operations[0])).To(Equal(reflect.TypeOf(&PauseClusterOperation{})))
+			})
+
+			It("should only return a pause cluster operation when the cluster spec is updated", func() {
+				// given
+				newClusterDef.Spec.Racks[0].Replicas = 2
+				newClusterDef.Spec.Snapshot = nil
+				newClusterDef.Annotations = map[string]string{cluster.RestartedAtAnnotation: "2019-01-01T00:00:00Z"}
+
+				// when
+				operations := receiver.operationsToExecute(&dispatcher.Event{Kind: UpdateCluster, Data: ClusterUpdate{OldCluster: oldClusterDef, NewCluster: newClusterDef}})
+
+				// then
+				Expect(operations).To(HaveLen(1))
+				Expect(reflect.TypeOf(operations[0])).To(Equal(reflect.TypeOf(&PauseClusterOperation{})))
+			})
+
+			It("should only return a pause cluster operation when the cluster is reconciled or restarted", func() {
+				for _, kind := range []string{ReconcileCluster, RestartCluster} {
+					// when
+					operations := receiver.operationsToExecute(&dispatcher.Event{Kind: kind, Data: newClusterDef})
+
+					// then
+					Expect(operations).To(HaveLen(1))
+					Expect(reflect.TypeOf(operations[0])).To(Equal(reflect.TypeOf(&PauseClusterOperation{})))
+				}
+			})
+
+			It("should return no operations when a repair is requested", func() {
+				// when
+				operations := receiver.operationsToExecute(&dispatcher.Event{Kind: RunRepair, Data: newClusterDef})
+
+				// then
+				Expect(operations).To(BeEmpty())
+			})
+
+			It("should return no operations when node maintenance is requested", func() {
+				// when
+				operations := receiver.operationsToExecute(&dispatcher.Event{Kind: NodeMaintenance, Data: newClusterDef})
+
+				// then
+				Expect(operations).To(BeEmpty())
+			})
+
+			It("should return no operations when its custom configmap changes", func() {
+				// given
+				configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "mycluster-config", Namespace: "mynamespace"}}
+
+				for _, kind := range []string{AddCustomConfig, UpdateCustomConfig, DeleteCustomConfig} {
+					// when
+					operations := receiver.operationsToExecute(&dispatcher.Event{Kind: kind, Data: configMap})
+
+					// then
+					Expect(operations).To(HaveLen(0))
+				}
+			})
+
+			It("should bring the cluster in line with its latest definition and record the resumption once it is unpaused", func() {
+				// given
+				oldClusterDef = newClusterDef.DeepCopy()
+				newClusterDef.Spec.Paused = false
+				newClusterDef.Status.SetCondition(v1alpha1.ClusterPaused, corev1.ConditionTrue, reasonClusterPaused, "")
+
+				// when
+				operations := receiver.operationsToExecute(&dispatcher.Event{Kind: UpdateCluster, Data: ClusterUpdate{OldCluster: oldClusterDef, NewCluster: newClusterDef}})
+
+				// then
+				Expect(operations).To(HaveLen(5))
+				Expect(reflect.TypeOf(operations[0])).To(Equal(reflect.TypeOf(&AddClusterOperation{})))
+				Expect(reflect.TypeOf(operations[1])).To(Equal(reflect.TypeOf(&AddSnapshotOperation{})))
+				Expect(reflect.TypeOf(operations[2])).To(Equal(reflect.TypeOf(&AddSnapshotCleanupOperation{})))
+				Expect(reflect.TypeOf(operations[3])).To(Equal(reflect.TypeOf(&ReconcileClusterOperation{})))
+				Expect(reflect.TypeOf(operations[4])).To(Equal(reflect.TypeOf(&ResumeClusterOperation{})))
+			})
+		})
+	})
+})
+
+var _ = Describe("cluster whose status reflects the operations triggered by an event", func() {
+	var (
+		receiver   *Receiver
+		clusterDef *v1alpha1.Cassandra
+		clusters   map[string]*cluster.Cluster
+	)
+
+	BeforeEach(func() {
+		clusterDef = &v1alpha1.Cassandra{
+			ObjectMeta: metav1.ObjectMeta{Name: "mycluster", Namespace: "mynamespace"},
+			Spec: v1alpha1.CassandraSpec{
+				Racks: []v1alpha1.Rack{{Name: "a", Replicas: 1, StorageClass: "some-storage", Zone: "some-zone"}},
+				Pod: v1alpha1.Pod{
+					Memory:      resource.MustParse("1Gi"),
+					CPU:         resource.MustParse("100m"),
+					StorageSize: resource.MustParse("1Gi"),
+				},
+			},
+		}
+		c, _ := cluster.New(clusterDef)
+		clusters = map[string]*cluster.Cluster{clusterDef.QualifiedName(): c}
+		receiver = NewEventReceiver(clusters, &cluster.Accessor{}, &metrics.PrometheusMetrics{}, &nodetool.Nodetool{}, &stubEventRecorder{})
+	})
+
+	It("should be the added cluster when a cluster is added", func() {
+		Expect(receiver.cassandraForEvent(&dispatcher.Event{Kind: AddCluster, Data: clusterDef})).To(Equal(clusterDef))
+	})
+
+	It("should be the new cluster definition when a cluster is updated", func() {
+		newClusterDef := clusterDef.DeepCopy()
+		update := ClusterUpdate{OldCluster: clusterDef, NewCluster: newClusterDef}
+		Expect(receiver.cassandraForEvent(&dispatcher.Event{Kind: UpdateCluster, Data: update})).To(BeIdenticalTo(newClusterDef))
+	})
+
+	It("should be the cluster associated with a custom config map", func() {
+		configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "mycluster-config", Namespace: "mynamespace"}}
+		Expect(receiver.cassandraForEvent(&dispatcher.Event{Kind: UpdateCustomConfig, Data: configMap})).To(Equal(clusters[clusterDef.QualifiedName()].Definition()))
+	})
+
+	It("should be none when a cluster is deleted", func() {
+		Expect(receiver.cassandraForEvent(&dispatcher.Event{Kind: DeleteCluster, Data: clusterDef})).To(BeNil())
+	})
+
+	It("should be none when metrics are gathered", func() {
+		Expect(receiver.cassandraForEvent(&dispatcher.Event{Kind: GatherMetrics, Data: clusters[clusterDef.QualifiedName()]})).To(BeNil())
+	})
+
+	It("should be none when a cluster is reconciled", func() {
+		Expect(receiver.cassandraForEvent(&dispatcher.Event{Kind: ReconcileCluster, Data: clusterDef})).To(BeNil())
+	})
+})
+
+var _ = Describe("retry of an event whose operation has failed", func() {
+	It("should resume with the failed operation rather than execute the previous operations again", func() {
+		// given
+		receiver := NewEventReceiver(map[string]*cluster.Cluster{}, &cluster.Accessor{}, &metrics.PrometheusMetrics{}, &nodetool.Nodetool{}, &stubEventRecorder{})
+		first, failing, last := &stubOperation{}, &stubOperation{failures: 1}, &stubOperation{}
+		event := &dispatcher.Event{Kind: ReconcileCluster, Progress: []Operation{first, failing, last}}
+
+		// when
+		firstAttempt := receiver.Receive(event)
+		secondAttempt := receiver.Receive(event)
+
+		// then
+		Expect(firstAttempt).To(HaveOccurred())
+		Expect(secondAttempt).ToNot(HaveOccurred())
+		Expect(first.executions).To(Equal(1))
+		Expect(failing.executions).To(Equal(2))
+		Expect(last.executions).To(Equal(1))
+		Expect(event.Progress).To(BeNil())
+	})
+})
+
+var _ = Describe("conditions reported on the Cassandra resource", func() {
+	var status *v1alpha1.CassandraStatus
+
+	BeforeEach(func() {
+		status = &v1alpha1.CassandraStatus{}
+	})
+
+	Context("availability", func() {
+		It("should be available when all desired pods are ready", func() {
+			status.Replicas, status.ReadyReplicas = 3, 3
+			setAvailableCondition(status, 3)
+			Expect(status.GetCondition(v1alpha1.ClusterAvailable).Status).To(Equal(corev1.ConditionTrue))
+			Expect(status.GetCondition(v1alpha1.ClusterAvailable).Reason).To(Equal(reasonAllReplicasReady))
+		})
+
+		It("should not be available when some pods are not ready", func() {
+			status.Replicas, status.ReadyReplicas = 3, 2
+			setAvailableCondition(status, 3)
+			Expect(status.GetCondition(v1alpha1.ClusterAvailable).Status).To(Equal(corev1.ConditionFalse))
+			Expect(status.GetCondition(v1alpha1.ClusterAvailable).Message).To(Equal("2 of 3 pods are ready"))
+		})
+
+		It("should not be available until all desired pods exist", func() {
+			status.Replicas, status.ReadyReplicas = 2, 2
+			setAvailableCondition(status, 3)
+			Expect(status.GetCondition(v1alpha1.ClusterAvailable).Status).To(Equal(corev1.ConditionFalse))
+		})
+	})
+
+	Context("degradation", func() {
+		It("should be degraded when a node is down", func() {
+			status.Nodes = []v1alpha1.NodeStatus{
+				{Name: "mycluster-a-0", Liveness: "up", State: "normal"},
+				{Name: "mycluster-a-1", Liveness: "down", State: "normal"},
+			}
+			setDegradedCondition(status)
+			Expect(status.GetCondition(v1alpha1.ClusterDegraded).Status).To(Equal(corev1.ConditionTrue))
+			Expect(status.GetCondition(v1alpha1.ClusterDegraded).Message).To(Equal("nodes down: mycluster-a-1"))
+		})
+
+		It("should not be degraded when all nodes are up", func() {
+			status.Nodes = []v1alpha1.NodeStatus{{Name: "mycluster-a-0", Liveness: "up", State: "joining"}}
+			setDegradedCondition(status)
+			Expect(status.GetCondition(v1alpha1.ClusterDegraded).Status).To(Equal(corev1.ConditionFalse))
+		})
+	})
+
+	Context("progress", func() {
+		It("should be progressing while an operation is executing", func() {
+			setProgressingCondition(status, "update cluster mynamespace.mycluster")
+			Expect(status.GetCondition(v1alpha1.ClusterProgressing).Status).To(Equal(corev1.ConditionTrue))
+			Expect(status.GetCondition(v1alpha1.ClusterProgressing).Message).To(Equal("update cluster mynamespace.mycluster"))
+		})
+
+		It("should not be progressing once operations are completed", func() {
+			setProgressingCondition(status, "update cluster mynamespace.mycluster")
+			setProgressingCondition(status, "")
+			Expect(status.Conditions).To(HaveLen(1))
+			Expect(status.GetCondition(v1alpha1.ClusterProgressing).Status).To(Equal(corev1.ConditionFalse))
+		})
+	})
+
+	Context("transition time", func() {
+		It("should only change when the condition status changes", func() {
+			status.SetCondition(v1alpha1.ConfigInvalid, corev1.ConditionTrue, reasonInvalidSpec, "first")
+			transitionTime := metav1.NewTime(status.GetCondition(v1alpha1.ConfigInvalid).LastTransitionTime.Add(-time.Hour))
+			status.GetCondition(v1alpha1.ConfigInvalid).LastTransitionTime = transitionTime
+
+			status.SetCondition(v1alpha1.ConfigInvalid, corev1.ConditionTrue, reasonForbiddenChange, "second")
+			Expect(status.GetCondition(v1alpha1.ConfigInvalid).LastTransitionTime).To(Equal(transitionTime))
+			Expect(status.GetCondition(v1alpha1.ConfigInvalid).Message).To(Equal("second"))
+
+			status.SetCondition(v1alpha1.ConfigInvalid, corev1.ConditionFalse, reasonSpecApplied, "")
+			Expect(status.GetCondition(v1alpha1.ConfigInvalid).LastTransitionTime).ToNot(Equal(transitionTime))
+		})
+	})
+})
+
+var _ = Describe("drift between the resources of a cluster and its definition", func() {
+	var c *cluster.Cluster
+	var rack v1alpha1.Rack
+
+	BeforeEach(func() {
+		timeout := int32(1)
+		rack = v1alpha1.Rack{Name: "a", Replicas: 1, StorageClass: "some-storage", Zone: "some-zone"}
+		c, _ = cluster.New(&v1alpha1.Cassandra{
+			ObjectMeta: metav1.ObjectMeta{Name: "mycluster", Namespace: "mynamespace"},
+			Spec: v1alpha1.CassandraSpec{
+				Racks: []v1alpha1.Rack{rack},
+				Pod: v1alpha1.Pod{
+					Memory:      resource.MustParse("1Gi"),
+					CPU:         resource.MustParse("100m"),
+					StorageSize: resource.MustParse("1Gi"),
+				},
+				Snapshot: &v1alpha1.Snapshot{Schedule: "2 * * * *", TimeoutSeconds: &timeout},
+			},
+		})
+	})
+
+	Context("pod template", func() {
+		It("should not have drifted when Kubernetes has defaulted some fields", func() {
+			live := c.CreateStatefulSetForRack(&rack, nil).Spec.Template
+			live.Spec.RestartPolicy = corev1.RestartPolicyAlways
+			live.Spec.Containers[0].ImagePullPolicy = corev1.PullIfNotPresent
+			live.Spec.Containers[0].Resources.Requests[corev1.ResourceMemory] = resource.MustParse("1024Mi")
+
+			Expect(podTemplateHasDrifted(&c.CreateStatefulSetForRack(&rack, nil).Spec.Template, &live)).To(BeFalse())
+		})
+
+		It("should not have drifted when the custom config volume is in a different position", func() {
+			configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "mycluster-config", Namespace: "mynamespace"}}
+			live := c.CreateStatefulSetForRack(&rack, nil)
+			Expect(c.AddCustomConfigVolumeToStatefulSet(live, configMap)).To(Succeed())
+
+			Expect(podTemplateHasDrifted(&c.CreateStatefulSetForRack(&rack, configMap).Spec.Template, &live.Spec.Template)).To(BeFalse())
+		})
+
+		It("should have drifted when a container has been changed", func() {
+			live := c.CreateStatefulSetForRack(&rack, nil).Spec.Template
+			live.Spec.Containers[0].Image = "cassandra:2.2"
+
+			Expect(podTemplateHasDrifted(&c.CreateStatefulSetForRack(&rack, nil).Spec.Template, &live)).To(BeTrue())
+		})
+
+		It("should have drifted when a container has been added", func() {
+			live := c.CreateStatefulSetForRack(&rack, nil).Spec.Template
+			live.Spec.Containers = append(live.Spec.Containers, corev1.Container{Name: "sidecar", Image: "busybox"})
+
+			Expect(podTemplateHasDrifted(&c.CreateStatefulSetForRack(&rack, nil).Spec.Template, &live)).To(BeTrue())
+		})
+
+		It("should have drifted when a toleration has been removed from the definition", func() {
+			live := c.CreateStatefulSetForRack(&rack, nil).Spec.Template
+			live.Spec.Tolerations = []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "cassandra", Effect: corev1.TaintEffectNoSchedule}}
+
+			Expect(podTemplateHasDrifted(&c.CreateStatefulSetForRack(&rack, nil).Spec.Template, &live)).To(BeTrue())
+		})
+
+		It("should have drifted when the anti-affinity is no longer required", func() {
+			live := c.CreateStatefulSetForRack(&rack, nil).Spec.Template
+			preferredRack := rack.DeepCopy()
+			preferredRack.AntiAffinity = &v1alpha1.AntiAffinity{Mode: v1alpha1.AntiAffinityPreferred}
+
+			Expect(podTemplateHasDrifted(&c.CreateStatefulSetForRack(preferredRack, nil).Spec.Template, &live)).To(BeTrue())
+		})
+
+		It("should have drifted when the custom config map hash differs", func() {
+			live := c.CreateStatefulSetForRack(&rack, nil).Spec.Template
+			live.Annotations = map[string]string{cluster.ConfigHashAnnotation: "some-hash"}
+
+			Expect(podTemplateHasDrifted(&c.CreateStatefulSetForRack(&rack, nil).Spec.Template, &live)).To(BeTrue())
+		})
+
+		It("should have drifted when a restart has been requested since the pod template was last updated", func() {
+			live := c.CreateStatefulSetForRack(&rack, nil).Spec.Template
+			live.Annotations = map[string]string{cluster.RestartedAtAnnotation: "2019-01-01T00:00:00Z"}
+			restarted := c.Definition().DeepCopy()
+			restarted.Annotations = map[string]string{cluster.RestartedAtAnnotation: "2019-02-01T00:00:00Z"}
+			restartedCluster, _ := cluster.New(restarted)
+
+			Expect(podTemplateHasDrifted(&restartedCluster.CreateStatefulSetForRack(&rack, nil).Spec.Template, &live)).To(BeTrue())
+		})
+
+		It("should not have drifted when the annotation of a previous restart is no longer requested", func() {
+			live := c.CreateStatefulSetForRack(&rack, nil).Spec.Template
+			live.Annotations = map[string]string{cluster.RestartedAtAnnotation: "2019-01-01T00:00:00Z"}
+
+			Expect(podTemplateHasDrifted(&c.CreateStatefulSetForRack(&rack, nil).Spec.Template, &live)).To(BeFalse())
+		})
+
+		It("should replace the pod template while keeping labels and annotations which are not managed", func() {
+			live := c.CreateStatefulSetForRack(&rack, nil)
+			live.Spec.Template.Labels["team"] = "some-team"
+			live.Spec.Template.Annotations = map[string]string{"some-annotation": "some-value", cluster.ConfigHashAnnotation: "some-hash"}
+			live.Spec.Template.Spec.Containers[0].Image = "cassandra:2.2"
+			desired := c.CreateStatefulSetForRack(&rack, nil).Spec.Template
+
+			replacePodTemplate(live, &desired)
+
+			Expect(podTemplateHasDrifted(&desired, &live.Spec.Template)).To(BeFalse())
+			Expect(live.Spec.Template.Labels).To(HaveKeyWithValue("team", "some-team"))
+			Expect(live.Spec.Template.Annotations).To(Equal(map[string]string{"some-annotation": "some-value"}))
+		})
+	})
+
+	Context("cron job", func() {
+		It("should not have drifted when it matches the definition", func() {
+			live := c.CreateSnapshotJob()
+			live.Spec.JobTemplate.Spec.Template.Spec.Containers[0].ImagePullPolicy = corev1.PullAlways
+
+			Expect(cronJobHasDrifted(c.CreateSnapshotJob(), live)).To(BeFalse())
+		})
+
+		It("should have drifted when its schedule has been changed", func() {
+			live := c.CreateSnapshotJob()
+			live.Spec.Schedule = "1 * * * *"
+
+			Expect(cronJobHasDrifted(c.CreateSnapshotJob(), live)).To(BeTrue())
+		})
+
+		It("should have drifted when its command has been changed", func() {
+			live := c.CreateSnapshotJob()
+			live.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Command = []string{"/cassandra-snapshot", "create"}
+
+			Expect(cronJobHasDrifted(c.CreateSnapshotJob(), live)).To(BeTrue())
+		})
+
+		It("should have drifted when it is still suspended after the cluster has been unpaused", func() {
+			suspend := true
+			live := c.CreateSnapshotJob()
+			live.Spec.Suspend = &suspend
+			live.Annotations = map[string]string{cluster.SuspendedByPauseAnnotation: "true"}
+
+			Expect(cronJobHasDrifted(c.CreateSnapshotJob(), live)).To(BeTrue())
+		})
+
+		It("should not have drifted when it has been suspended by hand", func() {
+			suspend := true
+			live := c.CreateSnapshotJob()
+			live.Spec.Suspend = &suspend
+
+			Expect(cronJobHasDrifted(c.CreateSnapshotJob(), live)).To(BeFalse())
+		})
 	})
 })
 
@@ -411,3 +1009,178 @@ func (r *stubEventRecorder) Eventf(object runtime.Object, eventtype, reason, mes
 }
 func (r *stubEventRecorder) PastEventf(object runtime.Object, timestamp metav1.Time, eventtype, reason, messageFmt string, args ...interface{}) {
 }
+
+// stubOperation counts its executions, and fails the number of times given in failures before succeeding
+type stubOperation struct {
+	executions int
+	failures   int
+}
+
+func (o *stubOperation) Execute() error {
+	o.executions++
+	if o.executions <= o.failures {
+		return fmt.Errorf("operation failed")
+	}
+	return nil
+}
+
+func (o *stubOperation) String() string {
+	return "stub operation"
+}
+
+// stubNodeCommands records the pods on which each command was run, and fails the commands run on the pods given in failOn
+type stubNodeCommands struct {
+	upgradedPods       []string
+	cleanedUpPods      []string
+	decommissionedPods []string
+	replicationFactors map[string]int
+	failOn             map[string]bool
+}
+
+func (n *stubNodeCommands) UpgradeSSTables(pod *corev1.Pod, _ time.Duration) error {
+	n.upgradedPods = append(n.upgradedPods, pod.Name)
+	return n.result(pod)
+}
+
+func (n *stubNodeCommands) Cleanup(pod *corev1.Pod, _ *int32, _ time.Duration) error {
+	n.cleanedUpPods = append(n.cleanedUpPods, pod.Name)
+	return n.result(pod)
+}
+
+func (n *stubNodeCommands) Decommission(pod *corev1.Pod, _ time.Duration) error {
+	n.decommissionedPods = append(n.decommissionedPods, pod.Name)
+	return n.result(pod)
+}
+
+func (n *stubNodeCommands) ReplicationFactors(pod *corev1.Pod, _ string, _ time.Duration) (map[string]int, error) {
+	return n.replicationFactors, n.result(pod)
+}
+
+func (n *stubNodeCommands) result(pod *corev1.Pod) error {
+	if n.failOn[pod.Name] {
+		return fmt.Errorf("command failed on pod %s", pod.Name)
+	}
+	return nil
+}
+
+// stubRingState reports the nodes it is given, or fails when err is set
+type stubRingState struct {
+	leavingNodes []string
+	liveNodes    []string
+	joiningNodes []string
+	nodesInRack  map[string][]string
+	err          error
+}
+
+func (s *stubRingState) LeavingNodes(_ *cluster.Cluster) ([]string, error) {
+	return s.leavingNodes, s.err
+}
+
+func (s *stubRingState) LiveNodes(_ *cluster.Cluster) ([]string, error) {
+	return s.liveNodes, s.err
+}
+
+func (s *stubRingState) JoiningNodes(_ *cluster.Cluster) ([]string, error) {
+	return s.joiningNodes, s.err
+}
+
+func (s *stubRingState) NodesInRack(_ *cluster.Cluster, rackName string) ([]string, error) {
+	return s.nodesInRack[rackName], s.err
+}
+
+// aClusterWithRack creates a cluster with a single rack
+func aClusterWithRack(rackName string, replicas int32) *cluster.Cluster {
+	c, err := cluster.New(&v1alpha1.Cassandra{
+		ObjectMeta: metav1.ObjectMeta{Name: "mycluster", Namespace: "mynamespace"},
+		Spec: v1alpha1.CassandraSpec{
+			Racks: []v1alpha1.Rack{{Name: rackName, Replicas: replicas, StorageClass: "some-storage", Zone: "some-zone"}},
+			Pod: v1alpha1.Pod{
+				Memory:      resource.MustParse("1Gi"),
+				CPU:         resource.MustParse("100m"),
+				StorageSize: resource.MustParse("1Gi"),
+			},
+		},
+	})
+	Expect(err).ToNot(HaveOccurred())
+	return c
+}
+
+// fakeClusterResources holds the Kubernetes resources of a cluster, and reports the changes made to its stateful sets as applied.
+// The stateful sets returned once changed report no replicas, so that the accessor doesn't wait for their pods to become ready.
+func fakeClusterResources(objects ...runtime.Object) *kubefake.Clientset {
+	return fakeClusterResourcesIn(anObjectTracker(objects...))
+}
+
+// anObjectTracker holds the supplied objects, which the reactors of tests can change without going through the clientset
+func anObjectTracker(objects ...runtime.Object) k8stesting.ObjectTracker {
+	tracker := k8stesting.NewObjectTracker(scheme.Scheme, scheme.Codecs.UniversalDecoder())
+	for _, object := range objects {
+		Expect(tracker.Add(object)).To(Succeed())
+	}
+	return tracker
+}
+
+// fakeClusterResourcesIn creates a clientset which serves the objects of the supplied tracker
+func fakeClusterResourcesIn(tracker k8stesting.ObjectTracker) *kubefake.Clientset {
+	kubeClientset := kubefake.NewSimpleClientset()
+	kubeClientset.PrependReactor("*", "*", k8stesting.ObjectReaction(tracker))
+	kubeClientset.PrependReactor("*", "statefulsets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		var replicas int32
+		switch action.GetVerb() {
+		case "create", "update":
+			_, object, err := k8stesting.ObjectReaction(tracker)(action)
+			if err != nil {
+				return true, nil, err
+			}
+			statefulSet := object.(*appsv1beta2.StatefulSet).DeepCopy()
+			statefulSet.Spec.Replicas = &replicas
+			return true, statefulSet, nil
+		case "patch":
+			objectMeta := metav1.ObjectMeta{Name: action.(k8stesting.PatchAction).GetName(), Namespace: action.GetNamespace()}
+			return true, &appsv1beta2.StatefulSet{ObjectMeta: objectMeta, Spec: appsv1beta2.StatefulSetSpec{Replicas: &replicas}}, nil
+		case "get":
+			// the accessor checks whether a change has been applied through apps/v1beta1
+			if action.GetResource().Version != "v1beta1" {
+				return false, nil, nil
+			}
+			var observedGeneration int64
+			objectMeta := metav1.ObjectMeta{Name: action.(k8stesting.GetAction).GetName(), Namespace: action.GetNamespace()}
+			return true, &appsv1beta1.StatefulSet{ObjectMeta: objectMeta, Status: appsv1beta1.StatefulSetStatus{ObservedGeneration: &observedGeneration}}, nil
+		}
+		return false, nil, nil
+	})
+	return kubeClientset
+}
+
+func aStatefulSet(c *cluster.Cluster, rackName string, replicas int32) *appsv1beta2.StatefulSet {
+	return &appsv1beta2.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s-%s", c.Name(), rackName), Namespace: c.Namespace(), Labels: map[string]string{cluster.OperatorLabel: c.Name()}},
+		Spec:       appsv1beta2.StatefulSetSpec{Replicas: &replicas},
+	}
+}
+
+func aRunningPod(c *cluster.Cluster, rackName string, ordinal int32, podIP string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s-%d", c.Name(), rackName, ordinal),
+			Namespace: c.Namespace(),
+			Labels:    map[string]string{cluster.OperatorLabel: c.Name(), cluster.RackLabel: rackName},
+			UID:       types.UID(fmt.Sprintf("%s-%s-%d-uid", c.Name(), rackName, ordinal)),
+		},
+		Status: corev1.PodStatus{
+			PodIP:      podIP,
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+		},
+	}
+}
+
+// patchedStatefulSets returns the patches applied to stateful sets, in order
+func patchedStatefulSets(kubeClientset *kubefake.Clientset) []string {
+	var patches []string
+	for _, action := range kubeClientset.Actions() {
+		if patch, ok := action.(k8stesting.PatchAction); ok && action.GetResource().Resource == "statefulsets" {
+			patches = append(patches, fmt.Sprintf("%s: %s", patch.GetName(), patch.GetPatch()))
+		}
+	}
+	return patches
+}
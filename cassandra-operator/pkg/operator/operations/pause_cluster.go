@@ -0,0 +1,111 @@
+package operations
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/apis/cassandra/v1alpha1"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/cluster"
+	"k8s.io/api/batch/v1beta1"
+	"k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+// PauseClusterOperation describes what the operator does when a cluster is paused.
+// The cluster definition is recorded so that it is known once the cluster is unpaused,
+// but the only change made to the cluster resources is to suspend its cron jobs.
+type PauseClusterOperation struct {
+	clusters          map[string]*cluster.Cluster
+	clusterAccessor   *cluster.Accessor
+	eventRecorder     record.EventRecorder
+	clusterDefinition *v1alpha1.Cassandra
+}
+
+// Execute performs the operation
+func (o *PauseClusterOperation) Execute() error {
+	c, ok := o.clusters[o.clusterDefinition.QualifiedName()]
+	if !ok {
+		var err error
+		if c, err = cluster.New(o.clusterDefinition); err != nil {
+			log.Errorf("Unable to record paused cluster %s: %v", o.clusterDefinition.QualifiedName(), err)
+			return nil
+		}
+		o.clusters[c.QualifiedName()] = c
+	} else if err := cluster.CopyInto(c, o.clusterDefinition); err != nil {
+		log.Errorf("Unable to record the definition of paused cluster %s: %v", o.clusterDefinition.QualifiedName(), err)
+		return nil
+	}
+
+	for _, jobName := range []string{c.Definition().SnapshotJobName(), c.Definition().SnapshotCleanupJobName(), c.Definition().RepairJobName()} {
+		if err := o.suspendCronJob(c, jobName); err != nil {
+			return err
+		}
+	}
+
+	if !pauseRecorded(o.clusterDefinition) {
+		log.Infof("Cluster %s is paused, changes to its resources will be held until it is unpaused", c.QualifiedName())
+		o.eventRecorder.Eventf(o.clusterDefinition, v1.EventTypeNormal, cluster.ClusterPausedEvent, "Changes to cluster %s are held until it is unpaused", c.QualifiedName())
+		updateCondition(o.clusterAccessor, o.clusterDefinition, v1alpha1.ClusterPaused, v1.ConditionTrue, reasonClusterPaused, "changes to the cluster resources are held until it is unpaused")
+	}
+	return nil
+}
+
+func (o *PauseClusterOperation) suspendCronJob(c *cluster.Cluster, jobName string) error {
+	job, err := o.clusterAccessor.FindCronJobForCluster(c.Definition(), fmt.Sprintf("app=%s", jobName))
+	if err != nil {
+		return fmt.Errorf("error while retrieving cronjob %s for cluster %s: %v", jobName, c.QualifiedName(), err)
+	}
+	if job == nil || cronJobIsSuspended(job.Spec.Suspend) {
+		return nil
+	}
+
+	suspend := true
+	job.Spec.Suspend = &suspend
+	if job.Annotations == nil {
+		job.Annotations = map[string]string{}
+	}
+	job.Annotations[cluster.SuspendedByPauseAnnotation] = "true"
+	if err := o.clusterAccessor.UpdateCronJob(job); err != nil {
+		return fmt.Errorf("error while suspending cronjob %s for cluster %s: %v", jobName, c.QualifiedName(), err)
+	}
+	log.Infof("Cronjob %s of cluster %s has been suspended", jobName, c.QualifiedName())
+	return nil
+}
+
+func (o *PauseClusterOperation) String() string {
+	return fmt.Sprintf("pause cluster %s", o.clusterDefinition.QualifiedName())
+}
+
+// ResumeClusterOperation describes what the operator does once the changes held while a cluster was paused have been applied
+type ResumeClusterOperation struct {
+	clusterAccessor   *cluster.Accessor
+	eventRecorder     record.EventRecorder
+	clusterDefinition *v1alpha1.Cassandra
+}
+
+// Execute performs the operation
+func (o *ResumeClusterOperation) Execute() error {
+	log.Infof("Cluster %s has been unpaused", o.clusterDefinition.QualifiedName())
+	o.eventRecorder.Eventf(o.clusterDefinition, v1.EventTypeNormal, cluster.ClusterResumedEvent, "Changes held while cluster %s was paused have been applied", o.clusterDefinition.QualifiedName())
+	updateCondition(o.clusterAccessor, o.clusterDefinition, v1alpha1.ClusterPaused, v1.ConditionFalse, reasonClusterResumed, "")
+	return nil
+}
+
+func (o *ResumeClusterOperation) String() string {
+	return fmt.Sprintf("resume cluster %s", o.clusterDefinition.QualifiedName())
+}
+
+// pauseRecorded reports whether the status of the cluster records that the changes to its resources are held
+func pauseRecorded(cassandra *v1alpha1.Cassandra) bool {
+	condition := cassandra.Status.GetCondition(v1alpha1.ClusterPaused)
+	return condition != nil && condition.Status == v1.ConditionTrue
+}
+
+func cronJobIsSuspended(suspend *bool) bool {
+	return suspend != nil && *suspend
+}
+
+// suspendedByPause reports whether the cron job was suspended by the operator when its cluster was paused, rather than by hand
+func suspendedByPause(job *v1beta1.CronJob) bool {
+	_, ok := job.Annotations[cluster.SuspendedByPauseAnnotation]
+	return ok
+}
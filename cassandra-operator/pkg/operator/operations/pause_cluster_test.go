@@ -0,0 +1,65 @@
+package operations
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/apis/cassandra/v1alpha1"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/client/clientset/versioned/fake"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/cluster"
+	"k8s.io/api/batch/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("pause of a cluster", func() {
+	var (
+		kubeClientset *kubefake.Clientset
+		definition    *v1alpha1.Cassandra
+		snapshotJob   *v1beta1.CronJob
+	)
+
+	BeforeEach(func() {
+		definition = aClusterWithRack("a", 3).Definition()
+		definition.Spec.Paused = true
+		definition.Spec.Snapshot = &v1alpha1.Snapshot{Schedule: "1 23 * * *"}
+		c, err := cluster.New(definition)
+		Expect(err).ToNot(HaveOccurred())
+		snapshotJob = c.CreateSnapshotJob()
+	})
+
+	pauseCluster := func() {
+		kubeClientset = fakeClusterResources(snapshotJob)
+		operation := &PauseClusterOperation{
+			clusters:          map[string]*cluster.Cluster{},
+			clusterAccessor:   cluster.NewAccessor(kubeClientset, fake.NewSimpleClientset(), &stubEventRecorder{}),
+			eventRecorder:     &stubEventRecorder{},
+			clusterDefinition: definition,
+		}
+		Expect(operation.Execute()).To(Succeed())
+	}
+
+	liveSnapshotJob := func() *v1beta1.CronJob {
+		job, err := kubeClientset.BatchV1beta1().CronJobs(definition.Namespace).Get(snapshotJob.Name, metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		return job
+	}
+
+	It("should suspend the cron jobs and mark them as suspended by the pause, so that they are resumed once it is unpaused", func() {
+		pauseCluster()
+
+		job := liveSnapshotJob()
+		Expect(cronJobIsSuspended(job.Spec.Suspend)).To(BeTrue())
+		Expect(suspendedByPause(job)).To(BeTrue())
+	})
+
+	It("should not mark the cron jobs which have been suspended by hand, so that they are left suspended once it is unpaused", func() {
+		suspend := true
+		snapshotJob.Spec.Suspend = &suspend
+
+		pauseCluster()
+
+		job := liveSnapshotJob()
+		Expect(cronJobIsSuspended(job.Spec.Suspend)).To(BeTrue())
+		Expect(suspendedByPause(job)).To(BeFalse())
+	})
+})
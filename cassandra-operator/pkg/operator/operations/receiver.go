@@ -1,11 +1,13 @@
 package operations
 
 import (
+	"fmt"
 	log "github.com/sirupsen/logrus"
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/apis/cassandra/v1alpha1"
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/cluster"
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/dispatcher"
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/metrics"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/nodetool"
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/operator/operations/adjuster"
 	"k8s.io/api/core/v1"
 	"k8s.io/client-go/tools/record"
@@ -26,8 +28,24 @@ const (
 	UpdateCustomConfig = "UPDATE_CUSTOM_CONFIG"
 	// DeleteCustomConfig is a kind of event which the receiver is able to handle
 	DeleteCustomConfig = "DELETE_CUSTOM_CONFIG"
+	// ReconcileCluster is a kind of event which the receiver is able to handle
+	ReconcileCluster = "RECONCILE_CLUSTER"
+	// UnwatchCluster is a kind of event which the receiver is able to handle
+	UnwatchCluster = "UNWATCH_CLUSTER"
+	// RestartCluster is a kind of event which the receiver is able to handle
+	RestartCluster = "RESTART_CLUSTER"
+	// RunRepair is a kind of event which the receiver is able to handle
+	RunRepair = "RUN_REPAIR"
+	// NodeMaintenance is a kind of event which the receiver is able to handle.
+	// It is dispatched with the key given by NodeMaintenanceKey, so that it doesn't hold up the other events of the cluster.
+	NodeMaintenance = "NODE_MAINTENANCE"
 )
 
+// NodeMaintenanceKey is the key of the node maintenance events of the cluster with the supplied qualified name
+func NodeMaintenanceKey(clusterID string) string {
+	return fmt.Sprintf("%s/node-maintenance", clusterID)
+}
+
 // ClusterUpdate encapsulates Cassandra specs before and after the change
 type ClusterUpdate struct {
 	OldCluster *v1alpha1.Cassandra
@@ -40,12 +58,13 @@ type Receiver struct {
 	clusterAccessor     *cluster.Accessor
 	statefulSetAccessor *statefulSetAccessor
 	metricsPoller       *metrics.PrometheusMetrics
+	nodetool            *nodetool.Nodetool
 	eventRecorder       record.EventRecorder
 	adjuster            *adjuster.Adjuster
 }
 
 // NewEventReceiver creates a new Receiver
-func NewEventReceiver(clusters map[string]*cluster.Cluster, clusterAccessor *cluster.Accessor, metricsPoller *metrics.PrometheusMetrics, eventRecorder record.EventRecorder) *Receiver {
+func NewEventReceiver(clusters map[string]*cluster.Cluster, clusterAccessor *cluster.Accessor, metricsPoller *metrics.PrometheusMetrics, nodetool *nodetool.Nodetool, eventRecorder record.EventRecorder) *Receiver {
 	adj, err := adjuster.New()
 	if err != nil {
 		log.Fatalf("unable to initialise Adjuster: %v", err)
@@ -59,17 +78,69 @@ func NewEventReceiver(clusters map[string]*cluster.Cluster, clusterAccessor *clu
 		eventRecorder:       eventRecorder,
 		adjuster:            adj,
 		metricsPoller:       metricsPoller,
+		nodetool:            nodetool,
 	}
 }
 
-// Receive receives operator events and delegates their processing to the appropriate handler
-func (r *Receiver) Receive(event *dispatcher.Event) {
-	operations := r.operationsToExecute(event)
-	log.Infof("Event type %s will trigger %d operations", event.Kind, len(operations))
+// Receive receives operator events and delegates their processing to the appropriate handler.
+// Operations are executed in order and an error is returned as soon as one of them fails, so that the event
+// can be received again later.
+func (r *Receiver) Receive(event *dispatcher.Event) error {
+	// the operations which remain after a failed operation are kept on the event, so that a retry doesn't execute again
+	// the operations which have already succeeded
+	operations, resumed := event.Progress.([]Operation)
+	if resumed {
+		log.Infof("Event type %s will resume with %d remaining operations", event.Kind, len(operations))
+	} else {
+		operations = r.operationsToExecute(event)
+		log.Infof("Event type %s will trigger %d operations", event.Kind, len(operations))
+	}
 
-	for _, operation := range operations {
+	cassandra := r.cassandraForEvent(event)
+	for i, operation := range operations {
 		log.Debugf("Executing operation %s", operation.String())
-		operation.Execute()
+		r.recordCurrentOperation(cassandra, operation.String())
+		if err := operation.Execute(); err != nil {
+			event.Progress = operations[i:]
+			r.recordCurrentOperation(cassandra, "")
+			return fmt.Errorf("operation %s failed: %v", operation.String(), err)
+		}
+	}
+	event.Progress = nil
+	if len(operations) > 0 {
+		r.recordCurrentOperation(cassandra, "")
+	}
+	return nil
+}
+
+// cassandraForEvent returns the Cassandra resource whose status should reflect the operations triggered by the event,
+// or nil when no status should be recorded. Periodic reconciliations are not recorded, as they rarely have anything to do
+// and the cluster update they may trigger records its own outcome.
+func (r *Receiver) cassandraForEvent(event *dispatcher.Event) *v1alpha1.Cassandra {
+	switch event.Kind {
+	case AddCluster, RestartCluster, RunRepair:
+		return event.Data.(*v1alpha1.Cassandra)
+	case UpdateCluster:
+		return event.Data.(ClusterUpdate).NewCluster
+	case AddCustomConfig, UpdateCustomConfig, DeleteCustomConfig:
+		if c := r.clusterForConfigMap(event.Data.(*v1.ConfigMap)); c != nil {
+			return c.Definition()
+		}
+	}
+	return nil
+}
+
+func (r *Receiver) recordCurrentOperation(cassandra *v1alpha1.Cassandra, operation string) {
+	if cassandra == nil {
+		return
+	}
+
+	err := r.clusterAccessor.UpdateCassandraStatus(cassandra, func(status *v1alpha1.CassandraStatus) {
+		status.CurrentOperation = operation
+		setProgressingCondition(status, operation)
+	})
+	if err != nil {
+		log.Warnf("Unable to record current operation for cluster %s: %v", cassandra.QualifiedName(), err)
 	}
 }
 
@@ -81,21 +152,34 @@ func (r *Receiver) operationsToExecute(event *dispatcher.Event) []Operation {
 		return r.operationsForDeleteCluster(event.Data.(*v1alpha1.Cassandra))
 	case UpdateCluster:
 		return r.operationsForUpdateCluster(event.Data.(ClusterUpdate))
+	case ReconcileCluster:
+		return r.operationsForReconcileCluster(event.Data.(*v1alpha1.Cassandra))
+	case RestartCluster:
+		return r.operationsForRestartCluster(event.Data.(*v1alpha1.Cassandra))
+	case RunRepair:
+		return r.operationsForRunRepair(event.Data.(*v1alpha1.Cassandra))
+	case UnwatchCluster:
+		return []Operation{r.newUnwatchCluster(event.Data.(*v1alpha1.Cassandra))}
 	case GatherMetrics:
 		return []Operation{r.newGatherMetrics(event.Data.(*cluster.Cluster))}
+	case NodeMaintenance:
+		cassandra := event.Data.(*v1alpha1.Cassandra)
+		if !r.heldWhilePaused(cassandra, event.Kind) {
+			return []Operation{r.newNodeMaintenance(cassandra)}
+		}
 	case UpdateCustomConfig:
 		configMap := event.Data.(*v1.ConfigMap)
-		if c := r.clusterForConfigMap(configMap); c != nil {
+		if c := r.clusterForConfigMap(configMap); c != nil && !r.heldWhilePaused(c.Definition(), event.Kind) {
 			return []Operation{r.newUpdateCustomConfig(c, configMap)}
 		}
 	case AddCustomConfig:
 		configMap := event.Data.(*v1.ConfigMap)
-		if c := r.clusterForConfigMap(configMap); c != nil {
+		if c := r.clusterForConfigMap(configMap); c != nil && !r.heldWhilePaused(c.Definition(), event.Kind) {
 			return []Operation{r.newAddCustomConfig(c, configMap)}
 		}
 	case DeleteCustomConfig:
 		configMap := event.Data.(*v1.ConfigMap)
-		if c := r.clusterForConfigMap(configMap); c != nil {
+		if c := r.clusterForConfigMap(configMap); c != nil && !r.heldWhilePaused(c.Definition(), event.Kind) {
 			return []Operation{r.newDeleteCustomConfig(c, configMap)}
 		}
 	default:
@@ -106,6 +190,10 @@ func (r *Receiver) operationsToExecute(event *dispatcher.Event) []Operation {
 }
 
 func (r *Receiver) operationsForAddCluster(cassandra *v1alpha1.Cassandra) []Operation {
+	if cassandra.Spec.Paused {
+		return []Operation{r.newPauseCluster(cassandra)}
+	}
+
 	operations := []Operation{r.newAddCluster(cassandra)}
 	if cassandra.Spec.Snapshot != nil {
 		operations = append(operations, r.newAddSnapshot(cassandra))
@@ -113,6 +201,13 @@ func (r *Receiver) operationsForAddCluster(cassandra *v1alpha1.Cassandra) []Oper
 			operations = append(operations, r.newAddSnapshotCleanup(cassandra))
 		}
 	}
+	if cassandra.Spec.Repair != nil {
+		operations = append(operations, r.newAddRepair(cassandra))
+	}
+	operations = append(operations, r.newReconcileCluster(cassandra))
+	if pauseRecorded(cassandra) {
+		operations = append(operations, r.newResumeCluster(cassandra))
+	}
 	return operations
 }
 
@@ -124,6 +219,9 @@ func (r *Receiver) operationsForDeleteCluster(cassandra *v1alpha1.Cassandra) []O
 			operations = append(operations, r.newDeleteSnapshotCleanup(cassandra))
 		}
 	}
+	if cassandra.Spec.Repair != nil {
+		operations = append(operations, r.newDeleteRepair(cassandra))
+	}
 	return operations
 }
 
@@ -132,6 +230,16 @@ func (r *Receiver) operationsForUpdateCluster(clusterUpdate ClusterUpdate) []Ope
 	oldCluster := clusterUpdate.OldCluster
 	newCluster := clusterUpdate.NewCluster
 
+	// while paused, the latest cluster definition is only recorded. Once unpaused, the resources of the cluster are
+	// brought in line with the definition as they would be on startup, which applies all the changes held in the meantime
+	if newCluster.Spec.Paused {
+		return []Operation{r.newPauseCluster(newCluster)}
+	}
+	if oldCluster.Spec.Paused {
+		log.Infof("Cluster %s.%s has been unpaused, changes held in the meantime will be applied", newCluster.Namespace, newCluster.Name)
+		return r.operationsForAddCluster(newCluster)
+	}
+
 	var c *cluster.Cluster
 	var ok bool
 	if c, ok = r.clusters[newCluster.QualifiedName()]; !ok {
@@ -161,7 +269,74 @@ func (r *Receiver) operationsForUpdateCluster(clusterUpdate ClusterUpdate) []Ope
 			operations = append(operations, r.newAddSnapshotCleanup(clusterUpdate.NewCluster))
 		}
 	}
-	return operations
+	if newCluster.Spec.Repair == nil && oldCluster.Spec.Repair != nil {
+		operations = append(operations, r.newDeleteRepair(clusterUpdate.NewCluster))
+	} else if newCluster.Spec.Repair != nil && oldCluster.Spec.Repair == nil {
+		operations = append(operations, r.newAddRepair(clusterUpdate.NewCluster))
+	} else if newCluster.Spec.Repair != nil && v1alpha1.RepairPropertiesUpdated(oldCluster.Spec.Repair, newCluster.Spec.Repair) {
+		operations = append(operations, r.newUpdateRepair(c, newCluster.Spec.Repair))
+	}
+	if RestartRequested(oldCluster, newCluster) {
+		operations = append(operations, r.newRestartCluster(c, newCluster))
+	}
+	if RepairRequested(oldCluster, newCluster) {
+		operations = append(operations, r.newRunRepair(newCluster))
+	}
+	return append(operations, r.newReconcileCluster(newCluster))
+}
+
+func (r *Receiver) operationsForReconcileCluster(cassandra *v1alpha1.Cassandra) []Operation {
+	if cassandra.Spec.Paused {
+		return []Operation{r.newPauseCluster(cassandra)}
+	}
+	if _, ok := r.clusters[cassandra.QualifiedName()]; !ok {
+		log.Warnf("No record found for cluster %s.%s. Will attempt to create it.", cassandra.Namespace, cassandra.Name)
+		return r.operationsForAddCluster(cassandra)
+	}
+	return []Operation{r.newReconcileCluster(cassandra)}
+}
+
+func (r *Receiver) operationsForRestartCluster(cassandra *v1alpha1.Cassandra) []Operation {
+	if r.heldWhilePaused(cassandra, RestartCluster) {
+		return []Operation{r.newPauseCluster(cassandra)}
+	}
+	c, ok := r.clusters[cassandra.QualifiedName()]
+	if !ok {
+		log.Warnf("No record found for cluster %s.%s. Will attempt to create it.", cassandra.Namespace, cassandra.Name)
+		return r.operationsForAddCluster(cassandra)
+	}
+	return []Operation{r.newRestartCluster(c, cassandra)}
+}
+
+// operationsForRunRepair runs the requested repair unless the cluster is paused, in which case the request is dropped
+// as the repair cron job is suspended too
+func (r *Receiver) operationsForRunRepair(cassandra *v1alpha1.Cassandra) []Operation {
+	if cassandra.Spec.Paused {
+		log.Warnf("Cluster %s is paused, the repair requested at %s will not be run", cassandra.QualifiedName(), cassandra.Annotations[cluster.RepairRequestedAtAnnotation])
+		return nil
+	}
+	return []Operation{r.newRunRepair(cassandra)}
+}
+
+// heldWhilePaused reports whether the event must not change the resources of the cluster because it is paused.
+// Such changes are not lost: they are applied along with the other changes held once the cluster is unpaused.
+func (r *Receiver) heldWhilePaused(cassandra *v1alpha1.Cassandra, eventKind string) bool {
+	if cassandra.Spec.Paused {
+		log.Infof("Cluster %s is paused, the changes requested by event %s are held until it is unpaused", cassandra.QualifiedName(), eventKind)
+	}
+	return cassandra.Spec.Paused
+}
+
+// RestartRequested reports whether the restartedAt annotation of the cluster has been set to a new value
+func RestartRequested(oldCluster, newCluster *v1alpha1.Cassandra) bool {
+	restartedAt := newCluster.Annotations[cluster.RestartedAtAnnotation]
+	return restartedAt != "" && restartedAt != oldCluster.Annotations[cluster.RestartedAtAnnotation]
+}
+
+// RepairRequested reports whether the repairRequestedAt annotation of the cluster has been set to a new value
+func RepairRequested(oldCluster, newCluster *v1alpha1.Cassandra) bool {
+	requestedAt := newCluster.Annotations[cluster.RepairRequestedAtAnnotation]
+	return requestedAt != "" && requestedAt != oldCluster.Annotations[cluster.RepairRequestedAtAnnotation]
 }
 
 func (r *Receiver) clusterForConfigMap(configMap *v1.ConfigMap) *cluster.Cluster {
@@ -176,5 +351,9 @@ func (r *Receiver) clusterForConfigMap(configMap *v1.ConfigMap) *cluster.Cluster
 		log.Warnf("Custom config %s.%s does not have a related cluster. Managed clusters %v", configMap.Namespace, configMap.Name, r.clusters)
 		return nil
 	}
+	if !c.IsCustomConfigMap(configMap) {
+		log.Warnf("Custom config %s.%s is not the one used by cluster %s, which is %s", configMap.Namespace, configMap.Name, c.QualifiedName(), c.Definition().CustomConfigMapName())
+		return nil
+	}
 	return c
 }
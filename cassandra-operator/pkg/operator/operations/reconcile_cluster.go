@@ -0,0 +1,263 @@
+package operations
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/apis/cassandra/v1alpha1"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/cluster"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/operator/operations/adjuster"
+	"k8s.io/api/apps/v1beta2"
+	"k8s.io/api/batch/v1beta1"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/record"
+	"reflect"
+	"sort"
+)
+
+// ReconcileClusterOperation describes what the operator does to bring the resources of a cluster back in line with
+// its cluster definition, whether they have been changed outside of the operator or a previous change was not fully applied
+type ReconcileClusterOperation struct {
+	clusters            map[string]*cluster.Cluster
+	clusterAccessor     *cluster.Accessor
+	statefulSetAccessor *statefulSetAccessor
+	adjuster            *adjuster.Adjuster
+	eventRecorder       record.EventRecorder
+	metricsPoller       ringState
+	clusterDefinition   *v1alpha1.Cassandra
+	updateCluster       func(*cluster.Cluster, ClusterUpdate) Operation
+}
+
+// Execute performs the operation
+func (o *ReconcileClusterOperation) Execute() error {
+	c, ok := o.clusters[o.clusterDefinition.QualifiedName()]
+	if !ok || !c.Online {
+		log.Debugf("Cluster %s has not been created, it will not be reconciled", o.clusterDefinition.QualifiedName())
+		return nil
+	}
+
+	if err := cluster.CopyInto(c, o.clusterDefinition); err != nil {
+		log.Warnf("Cluster definition %s is invalid, it will not be reconciled: %v", o.clusterDefinition.QualifiedName(), err)
+		return nil
+	}
+
+	if err := o.reconcileService(c); err != nil {
+		return err
+	}
+
+	statefulSets, err := o.clusterAccessor.FindStatefulSetsForCluster(c)
+	if err != nil {
+		return err
+	}
+
+	// the cluster definition may contain changes which cannot be applied, in which case the stateful sets are left
+	// untouched rather than being brought in line with changes which are forbidden
+	liveSpec := c.SpecForStatefulSets(statefulSets)
+	clusterChanges, err := o.adjuster.ChangesForCluster(c.Name(), liveSpec, &c.Definition().Spec)
+	if err != nil {
+		log.Warnf("Stateful sets of cluster %s will not be reconciled with its definition: %v", c.QualifiedName(), err)
+	} else {
+		if len(clusterChanges) > 0 {
+			log.Infof("Stateful sets of cluster %s do not match its definition, %d changes will be applied", c.QualifiedName(), len(clusterChanges))
+			liveCluster := c.Definition().DeepCopy()
+			liveCluster.Spec = *liveSpec
+			if err := o.updateCluster(c, ClusterUpdate{OldCluster: liveCluster, NewCluster: c.Definition()}).Execute(); err != nil {
+				return err
+			}
+		}
+
+		if err := o.reconcilePodTemplates(c); err != nil {
+			return err
+		}
+	}
+
+	if err := o.replaceNodes(c); err != nil {
+		return err
+	}
+
+	if err := o.reconcileCronJob(c, c.Definition().SnapshotJobName(), c.CreateSnapshotJob()); err != nil {
+		return err
+	}
+	if err := o.reconcileCronJob(c, c.Definition().SnapshotCleanupJobName(), c.CreateSnapshotCleanupJob()); err != nil {
+		return err
+	}
+	return o.reconcileCronJob(c, c.Definition().RepairJobName(), c.CreateRepairJob())
+}
+
+func (o *ReconcileClusterOperation) reconcileService(c *cluster.Cluster) error {
+	desiredService := c.CreateService()
+	service, err := o.clusterAccessor.GetServiceForCluster(c)
+	if errors.IsNotFound(err) {
+		if _, err := o.clusterAccessor.CreateServiceForCluster(c); err != nil && !errors.IsAlreadyExists(err) {
+			return fmt.Errorf("error while creating headless service for cluster %s: %v", c.QualifiedName(), err)
+		}
+		o.recordDriftCorrected(c, "headless service %s has been recreated", desiredService.Name)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to retrieve headless service for cluster %s: %v", c.QualifiedName(), err)
+	}
+
+	if len(desiredService.Spec.Ports) == len(service.Spec.Ports) &&
+		equality.Semantic.DeepDerivative(desiredService.Spec.Ports, service.Spec.Ports) &&
+		reflect.DeepEqual(desiredService.Spec.Selector, service.Spec.Selector) {
+		return nil
+	}
+
+	service.Spec.Ports = desiredService.Spec.Ports
+	service.Spec.Selector = desiredService.Spec.Selector
+	if err := o.clusterAccessor.UpdateService(service); err != nil {
+		return fmt.Errorf("error while updating headless service for cluster %s: %v", c.QualifiedName(), err)
+	}
+	o.recordDriftCorrected(c, "headless service %s has been updated", service.Name)
+	return nil
+}
+
+// reconcilePodTemplates replaces the pod template of each rack which no longer matches the one generated from the
+// cluster definition, one rack at a time
+func (o *ReconcileClusterOperation) reconcilePodTemplates(c *cluster.Cluster) error {
+	// without the custom config map, the pod templates generated from the cluster definition would roll the cluster back onto the default config
+	customConfigMap, err := o.clusterAccessor.FindCustomConfigMap(c.Definition())
+	if err != nil {
+		log.Warnf("Pod templates of cluster %s will not be reconciled with its definition: %v", c.QualifiedName(), err)
+		return nil
+	}
+	for _, rack := range c.Racks() {
+		statefulSet, err := o.clusterAccessor.GetStatefulSetForRack(c, &rack)
+		if err != nil {
+			return fmt.Errorf("unable to retrieve stateful set for rack %s in cluster %s: %v", rack.Name, c.QualifiedName(), err)
+		}
+
+		desiredStatefulSet := c.CreateStatefulSetForRack(&rack, customConfigMap)
+		if !podTemplateHasDrifted(&desiredStatefulSet.Spec.Template, &statefulSet.Spec.Template) {
+			continue
+		}
+
+		log.Infof("Pod template of rack %s in cluster %s does not match the cluster definition and will be replaced", rack.Name, c.QualifiedName())
+		err = o.statefulSetAccessor.updateStatefulSet(c, customConfigMap, &rack, func(statefulSet *v1beta2.StatefulSet, _ *v1.ConfigMap) error {
+			replacePodTemplate(statefulSet, &desiredStatefulSet.Spec.Template)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		o.recordDriftCorrected(c, "pod template of stateful set %s has been replaced", statefulSet.Name)
+	}
+	return nil
+}
+
+// podTemplateHasDrifted reports whether the live pod template no longer matches the one generated from the cluster definition.
+// Fields defaulted by Kubernetes are ignored, as is the order of volumes, which changes when a custom config map is added.
+// So are the node replacements missing from the pod templates created by previous versions of the operator,
+// which would otherwise restart every cluster once the operator is upgraded. They are added by the next change to the rack.
+func podTemplateHasDrifted(desired, live *v1.PodTemplateSpec) bool {
+	if desired.Annotations[cluster.ConfigHashAnnotation] != live.Annotations[cluster.ConfigHashAnnotation] {
+		return true
+	}
+
+	// a restart is only carried out when requested, never by removing the annotation of the previous restart
+	if restartedAt := desired.Annotations[cluster.RestartedAtAnnotation]; restartedAt != "" && restartedAt != live.Annotations[cluster.RestartedAtAnnotation] {
+		return true
+	}
+
+	desiredSpec := desired.Spec.DeepCopy()
+	liveSpec := live.Spec.DeepCopy()
+	sortVolumesByName(desiredSpec.Volumes)
+	sortVolumesByName(liveSpec.Volumes)
+	if !cluster.SupportsNodeReplacement(live) {
+		cluster.RemoveNodeReplacementEnv(desiredSpec)
+	}
+
+	return len(desiredSpec.InitContainers) != len(liveSpec.InitContainers) ||
+		len(desiredSpec.Containers) != len(liveSpec.Containers) ||
+		len(desiredSpec.Volumes) != len(liveSpec.Volumes) ||
+		len(desiredSpec.Tolerations) != len(liveSpec.Tolerations) ||
+		len(desiredSpec.NodeSelector) != len(liveSpec.NodeSelector) ||
+		desiredSpec.PriorityClassName != liveSpec.PriorityClassName ||
+		!equality.Semantic.DeepEqual(desiredSpec.Affinity, liveSpec.Affinity) ||
+		!equality.Semantic.DeepDerivative(desired.Labels, live.Labels) ||
+		!equality.Semantic.DeepDerivative(desiredSpec, liveSpec)
+}
+
+func replacePodTemplate(statefulSet *v1beta2.StatefulSet, desired *v1.PodTemplateSpec) {
+	template := &statefulSet.Spec.Template
+	if template.Labels == nil {
+		template.Labels = map[string]string{}
+	}
+	for name, value := range desired.Labels {
+		template.Labels[name] = value
+	}
+
+	if len(desired.Annotations) > 0 && template.Annotations == nil {
+		template.Annotations = map[string]string{}
+	}
+	for name, value := range desired.Annotations {
+		template.Annotations[name] = value
+	}
+	if _, ok := desired.Annotations[cluster.ConfigHashAnnotation]; !ok {
+		delete(template.Annotations, cluster.ConfigHashAnnotation)
+	}
+
+	template.Spec = *desired.Spec.DeepCopy()
+}
+
+func sortVolumesByName(volumes []v1.Volume) {
+	sort.Slice(volumes, func(i, j int) bool { return volumes[i].Name < volumes[j].Name })
+}
+
+// reconcileCronJob creates, updates or deletes the named cron job so that it matches the desired one,
+// which is nil when the cluster definition doesn't require the job
+func (o *ReconcileClusterOperation) reconcileCronJob(c *cluster.Cluster, jobName string, desiredJob *v1beta1.CronJob) error {
+	job, err := o.clusterAccessor.FindCronJobForCluster(c.Definition(), fmt.Sprintf("app=%s", jobName))
+	if err != nil {
+		return fmt.Errorf("error while retrieving cronjob %s for cluster %s: %v", jobName, c.QualifiedName(), err)
+	}
+
+	switch {
+	case desiredJob == nil && job == nil:
+		return nil
+	case desiredJob == nil:
+		if err := o.clusterAccessor.DeleteCronJob(job); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("error while deleting cronjob %s for cluster %s: %v", jobName, c.QualifiedName(), err)
+		}
+		o.recordDriftCorrected(c, "cronjob %s has been deleted as it is not part of the cluster definition", jobName)
+	case job == nil:
+		if _, err := o.clusterAccessor.CreateCronJobForCluster(c, desiredJob); err != nil && !errors.IsAlreadyExists(err) {
+			return fmt.Errorf("error while creating cronjob %s for cluster %s: %v", jobName, c.QualifiedName(), err)
+		}
+		o.recordDriftCorrected(c, "cronjob %s has been recreated", jobName)
+	case cronJobHasDrifted(desiredJob, job):
+		job.Spec.Schedule = desiredJob.Spec.Schedule
+		if suspendedByPause(job) {
+			job.Spec.Suspend = desiredJob.Spec.Suspend
+			delete(job.Annotations, cluster.SuspendedByPauseAnnotation)
+		}
+		job.Spec.JobTemplate.Spec.Template.Spec.Containers = desiredJob.Spec.JobTemplate.Spec.Template.Spec.Containers
+		if err := o.clusterAccessor.UpdateCronJob(job); err != nil {
+			return fmt.Errorf("error while updating cronjob %s for cluster %s: %v", jobName, c.QualifiedName(), err)
+		}
+		o.recordDriftCorrected(c, "cronjob %s has been updated", jobName)
+	}
+	return nil
+}
+
+// cronJobHasDrifted ignores whether the live cron job is suspended, unless it was suspended when its cluster was paused,
+// so that the cron jobs suspended by hand are left suspended
+func cronJobHasDrifted(desired, live *v1beta1.CronJob) bool {
+	desiredContainers := desired.Spec.JobTemplate.Spec.Template.Spec.Containers
+	liveContainers := live.Spec.JobTemplate.Spec.Template.Spec.Containers
+	return desired.Spec.Schedule != live.Spec.Schedule ||
+		suspendedByPause(live) ||
+		len(desiredContainers) != len(liveContainers) ||
+		!equality.Semantic.DeepDerivative(desiredContainers, liveContainers)
+}
+
+func (o *ReconcileClusterOperation) recordDriftCorrected(c *cluster.Cluster, messageFmt string, args ...interface{}) {
+	log.Infof("Cluster %s: %s", c.QualifiedName(), fmt.Sprintf(messageFmt, args...))
+	o.eventRecorder.Eventf(c.Definition(), v1.EventTypeNormal, cluster.DriftCorrectedEvent, messageFmt, args...)
+}
+
+func (o *ReconcileClusterOperation) String() string {
+	return fmt.Sprintf("reconcile cluster %s", o.clusterDefinition.QualifiedName())
+}
@@ -0,0 +1,115 @@
+package operations
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/client/clientset/versioned/fake"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/cluster"
+	"k8s.io/api/apps/v1beta2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var _ = Describe("reconciliation of the pod templates of a cluster", func() {
+	var (
+		c               *cluster.Cluster
+		customConfigMap *corev1.ConfigMap
+		kubeClientset   *kubefake.Clientset
+		operation       *ReconcileClusterOperation
+	)
+
+	newOperation := func(objects ...runtime.Object) {
+		kubeClientset = fakeClusterResources(objects...)
+		accessor := cluster.NewAccessor(kubeClientset, fake.NewSimpleClientset(), &stubEventRecorder{})
+		operation = &ReconcileClusterOperation{
+			clusterAccessor:     accessor,
+			statefulSetAccessor: &statefulSetAccessor{clusterAccessor: accessor},
+			eventRecorder:       &stubEventRecorder{},
+		}
+	}
+
+	BeforeEach(func() {
+		c = aClusterWithRack("a", 3)
+		customConfigMap = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      c.Definition().CustomConfigMapName(),
+				Namespace: c.Namespace(),
+				Labels:    map[string]string{cluster.OperatorLabel: c.Name()},
+			},
+			Data: map[string]string{"cassandra.yaml": "num_tokens: 32"},
+		}
+	})
+
+	It("should leave the pod templates untouched when they match the custom config map", func() {
+		newOperation(c.CreateStatefulSetForRack(&c.Definition().Spec.Racks[0], customConfigMap), customConfigMap)
+
+		Expect(operation.reconcilePodTemplates(c)).To(Succeed())
+
+		Expect(updatedStatefulSets(kubeClientset)).To(BeEmpty())
+	})
+
+	It("should replace the pod templates which still use a custom config map which no longer exists", func() {
+		newOperation(c.CreateStatefulSetForRack(&c.Definition().Spec.Racks[0], customConfigMap))
+
+		Expect(operation.reconcilePodTemplates(c)).To(Succeed())
+
+		Expect(updatedStatefulSets(kubeClientset)).To(Equal([]string{"mycluster-a"}))
+	})
+
+	It("should leave the pod templates untouched when they only lack the node replacements", func() {
+		statefulSet := c.CreateStatefulSetForRack(&c.Definition().Spec.Racks[0], customConfigMap)
+		cluster.RemoveNodeReplacementEnv(&statefulSet.Spec.Template.Spec)
+		newOperation(statefulSet, customConfigMap)
+
+		Expect(operation.reconcilePodTemplates(c)).To(Succeed())
+
+		Expect(updatedStatefulSets(kubeClientset)).To(BeEmpty())
+	})
+
+	It("should add the node replacements when the pod templates are replaced for another reason", func() {
+		statefulSet := c.CreateStatefulSetForRack(&c.Definition().Spec.Racks[0], customConfigMap)
+		cluster.RemoveNodeReplacementEnv(&statefulSet.Spec.Template.Spec)
+		statefulSet.Spec.Template.Spec.PriorityClassName = "changed-by-hand"
+		newOperation(statefulSet, customConfigMap)
+
+		Expect(operation.reconcilePodTemplates(c)).To(Succeed())
+
+		Expect(updatedStatefulSets(kubeClientset)).To(Equal([]string{"mycluster-a"}))
+		Expect(cluster.SupportsNodeReplacement(&updatedStatefulSet(kubeClientset).Spec.Template)).To(BeTrue())
+	})
+
+	It("should leave the pod templates untouched when the custom config map cannot be retrieved", func() {
+		newOperation(c.CreateStatefulSetForRack(&c.Definition().Spec.Racks[0], customConfigMap), customConfigMap)
+		kubeClientset.PrependReactor("get", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, errors.New("the server is currently unable to handle the request")
+		})
+
+		Expect(operation.reconcilePodTemplates(c)).To(Succeed())
+
+		Expect(updatedStatefulSets(kubeClientset)).To(BeEmpty())
+	})
+})
+
+func updatedStatefulSet(kubeClientset *kubefake.Clientset) *v1beta2.StatefulSet {
+	for _, action := range kubeClientset.Actions() {
+		if update, ok := action.(k8stesting.UpdateAction); ok && action.GetResource().Resource == "statefulsets" {
+			return update.GetObject().(*v1beta2.StatefulSet)
+		}
+	}
+	return nil
+}
+
+func updatedStatefulSets(kubeClientset *kubefake.Clientset) []string {
+	var names []string
+	for _, action := range kubeClientset.Actions() {
+		if update, ok := action.(k8stesting.UpdateAction); ok && action.GetResource().Resource == "statefulsets" {
+			names = append(names, update.GetObject().(metav1.Object).GetName())
+		}
+	}
+	return names
+}
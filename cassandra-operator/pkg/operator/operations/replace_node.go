@@ -0,0 +1,263 @@
+package operations
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/apis/cassandra/v1alpha1"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/cluster"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// nodeReplacementTimeout bounds the time taken by a replacement node to stream the data of the dead node from the other nodes
+	nodeReplacementTimeout = 6 * time.Hour
+	// claimDeletionTimeout bounds the time taken by the persistent volume claim of a replaced node to be removed once its pod has terminated
+	claimDeletionTimeout         = 5 * time.Minute
+	nodeReplacementCheckInterval = 10 * time.Second
+)
+
+// replaceNodes replaces each dead node whose address is given in the replace address annotation of a pod by a new node
+// on that pod, one node at a time. The replacements which were started by a previous reconciliation, and which are held
+// in the node replacements config map once the annotated pod has been recreated, are completed once their node has joined the ring.
+func (o *ReconcileClusterOperation) replaceNodes(c *cluster.Cluster) error {
+	replacements, err := o.clusterAccessor.FindNodeReplacements(c)
+	if err != nil {
+		return err
+	}
+
+	for _, rack := range c.Racks() {
+		pods, err := o.clusterAccessor.FindPodsForRack(c, &rack)
+		if err != nil {
+			return err
+		}
+
+		for i := range pods {
+			if address, ok := pods[i].Annotations[cluster.ReplaceAddressAnnotation]; ok {
+				if err := o.replaceNode(c, &rack, &pods[i], address); err != nil {
+					return fmt.Errorf("unable to replace node %s with pod %s in cluster %s: %v", address, pods[i].Name, c.QualifiedName(), err)
+				}
+			} else if address, ok := replacements[pods[i].Name]; ok {
+				if err := o.completeNodeReplacement(c, &pods[i], address); err != nil {
+					return fmt.Errorf("unable to complete the replacement of node %s with pod %s in cluster %s: %v", address, pods[i].Name, c.QualifiedName(), err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// replaceNode restarts the pod with an empty data directory, so that its node takes over the tokens of the dead node
+// by streaming the data of the dead node from the other nodes, rather than bootstrapping with the identity of the dead node
+func (o *ReconcileClusterOperation) replaceNode(c *cluster.Cluster, rack *v1alpha1.Rack, pod *v1.Pod, address string) error {
+	reason, err := o.nodeReplacementRefusal(c, rack, address)
+	if err != nil {
+		return err
+	}
+	if reason != "" {
+		o.eventRecorder.Eventf(c.Definition(), v1.EventTypeWarning, cluster.NodeReplacementRefusedEvent, "refusing to replace node %s with pod %s: %s", address, pod.Name, reason)
+		// the annotation is removed so that the replacement isn't refused again at every reconciliation
+		return o.clusterAccessor.RemovePodAnnotation(pod, cluster.ReplaceAddressAnnotation)
+	}
+
+	log.Infof("Replacing node %s with pod %s in cluster %s", address, pod.Name, c.QualifiedName())
+	o.eventRecorder.Eventf(c.Definition(), v1.EventTypeNormal, cluster.NodeReplacementStartedEvent, "replacing node %s with a new node on pod %s", address, pod.Name)
+	ordinal, err := podOrdinal(pod)
+	if err != nil {
+		return err
+	}
+
+	if err := o.clusterAccessor.SetNodeReplacement(c, pod.Name, address); err != nil {
+		return err
+	}
+
+	if err := o.restartWithEmptyStorage(c, rack, pod, ordinal); err != nil {
+		o.eventRecorder.Eventf(c.Definition(), v1.EventTypeWarning, cluster.NodeReplacementFailedEvent, "unable to restart pod %s without its data: %v", pod.Name, err)
+		return err
+	}
+
+	if err := o.waitUntilNodeHasJoinedRing(c, rack, pod, ordinal); err != nil {
+		// the replacement is kept, and is completed by a later reconciliation once the node has joined the ring
+		o.eventRecorder.Eventf(c.Definition(), v1.EventTypeWarning, cluster.NodeReplacementFailedEvent, "node on pod %s has not replaced node %s yet: %v", pod.Name, address, err)
+		return err
+	}
+	return o.removeNodeReplacement(c, pod, address)
+}
+
+// completeNodeReplacement removes the replacement of the dead node by the node on the supplied pod once that node
+// has joined the ring, and otherwise leaves it to be checked again at the next reconciliation
+func (o *ReconcileClusterOperation) completeNodeReplacement(c *cluster.Cluster, pod *v1.Pod, address string) error {
+	joined, err := o.nodeHasJoinedRing(c, pod)
+	if err != nil {
+		return err
+	}
+	if !joined {
+		log.Infof("Node on pod %s in cluster %s has not replaced node %s yet", pod.Name, c.QualifiedName(), address)
+		return nil
+	}
+	return o.removeNodeReplacement(c, pod, address)
+}
+
+// removeNodeReplacement removes the replacement once the node has joined, as it is only kept so that it isn't lost
+// should the pod restart while streaming
+func (o *ReconcileClusterOperation) removeNodeReplacement(c *cluster.Cluster, pod *v1.Pod, address string) error {
+	if err := o.clusterAccessor.SetNodeReplacement(c, pod.Name, ""); err != nil {
+		return err
+	}
+	o.eventRecorder.Eventf(c.Definition(), v1.EventTypeNormal, cluster.NodeReplacedEvent, "node %s has been replaced by the node on pod %s", address, pod.Name)
+	return nil
+}
+
+// nodeReplacementRefusal gives the reason why the node with the supplied address must not be replaced, if any
+func (o *ReconcileClusterOperation) nodeReplacementRefusal(c *cluster.Cluster, rack *v1alpha1.Rack, address string) (string, error) {
+	if net.ParseIP(address) == nil {
+		return fmt.Sprintf("'%s' is not the IP address of a node", address), nil
+	}
+
+	statefulSet, err := o.clusterAccessor.GetStatefulSetForRack(c, rack)
+	if err != nil {
+		return "", fmt.Errorf("unable to retrieve stateful set for rack %s: %v", rack.Name, err)
+	}
+	if !cluster.SupportsNodeReplacement(&statefulSet.Spec.Template) {
+		return fmt.Sprintf("the pods of rack %s were created by a previous version of the operator and must first be restarted, by setting the %s annotation on the cluster", rack.Name, cluster.RestartedAtAnnotation), nil
+	}
+
+	liveNodes, err := o.metricsPoller.LiveNodes(c)
+	if err != nil {
+		return "", fmt.Errorf("unable to determine whether node %s is live: %v", address, err)
+	}
+	for _, liveNode := range liveNodes {
+		if liveNode == address {
+			return "the node is still live", nil
+		}
+	}
+	return "", nil
+}
+
+// restartWithEmptyStorage deletes the persistent volume claim of the pod along with the pod itself,
+// so that the stateful set recreates the pod with a new claim
+func (o *ReconcileClusterOperation) restartWithEmptyStorage(c *cluster.Cluster, rack *v1alpha1.Rack, pod *v1.Pod, ordinal int32) error {
+	var claimUID types.UID
+	if !c.Definition().Spec.UseEmptyDir {
+		claim, err := o.clusterAccessor.GetPersistentVolumeClaimForRack(c, rack, ordinal)
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		if err == nil {
+			claimUID = claim.UID
+			if err := o.clusterAccessor.DeletePersistentVolumeClaim(claim); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+		}
+	}
+
+	if err := o.clusterAccessor.DeletePod(pod); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	if claimUID == "" {
+		return nil
+	}
+
+	// the claim is only removed once the pod using it has terminated, by which time the stateful set may already have
+	// recreated the pod with the claim being deleted. Such a pod cannot start until it is recreated along with a new claim.
+	err := wait.PollImmediate(nodeReplacementCheckInterval, claimDeletionTimeout, func() (bool, error) {
+		claim, err := o.clusterAccessor.GetPersistentVolumeClaimForRack(c, rack, ordinal)
+		if errors.IsNotFound(err) {
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		return claim.UID != claimUID, nil
+	})
+	if err != nil {
+		return fmt.Errorf("error while waiting for persistent volume claim of pod %s to be deleted: %v", pod.Name, err)
+	}
+
+	recreatedPod, err := o.clusterAccessor.GetPodForRack(c, rack, ordinal)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := o.clusterAccessor.GetPersistentVolumeClaimForRack(c, rack, ordinal); errors.IsNotFound(err) {
+		log.Infof("Pod %s was recreated before its persistent volume claim was deleted and will be recreated again", pod.Name)
+		if err := o.clusterAccessor.DeletePod(recreatedPod); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// waitUntilNodeHasJoinedRing waits until the node on the pod recreated in place of the supplied pod is ready
+// and no longer reported in the joining nodes of the ring
+func (o *ReconcileClusterOperation) waitUntilNodeHasJoinedRing(c *cluster.Cluster, rack *v1alpha1.Rack, pod *v1.Pod, ordinal int32) error {
+	return wait.PollImmediate(nodeReplacementCheckInterval, nodeReplacementTimeout, func() (bool, error) {
+		currentPod, err := o.clusterAccessor.GetPodForRack(c, rack, ordinal)
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		if err != nil {
+			log.Warnf("Unable to retrieve pod %s, will retry: %v", pod.Name, err)
+			return false, nil
+		}
+		if currentPod.UID == pod.UID {
+			log.Debugf("Pod %s has not been recreated yet", pod.Name)
+			return false, nil
+		}
+
+		joined, err := o.nodeHasJoinedRing(c, currentPod)
+		if err != nil {
+			log.Warnf("%v, will retry", err)
+			return false, nil
+		}
+		return joined, nil
+	})
+}
+
+// nodeHasJoinedRing reports whether the node on the supplied pod is ready and no longer reported in the joining nodes of the ring
+func (o *ReconcileClusterOperation) nodeHasJoinedRing(c *cluster.Cluster, pod *v1.Pod) (bool, error) {
+	if !podIsReady(pod) {
+		log.Debugf("Node on pod %s is not ready yet", pod.Name)
+		return false, nil
+	}
+
+	joiningNodes, err := o.metricsPoller.JoiningNodes(c)
+	if err != nil {
+		return false, fmt.Errorf("unable to determine whether node on pod %s has joined the ring: %v", pod.Name, err)
+	}
+	for _, joiningNode := range joiningNodes {
+		if joiningNode == pod.Status.PodIP {
+			log.Debugf("Node on pod %s is still joining the ring", pod.Name)
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// podOrdinal returns the ordinal of a pod in the stateful set of its rack, which ends its name
+func podOrdinal(pod *v1.Pod) (int32, error) {
+	ordinal, err := strconv.ParseInt(pod.Name[strings.LastIndex(pod.Name, "-")+1:], 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("unable to determine the ordinal of pod %s: %v", pod.Name, err)
+	}
+	return int32(ordinal), nil
+}
+
+func podIsReady(pod *v1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,198 @@
+package operations
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/client/clientset/versioned/fake"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/cluster"
+	appsv1beta2 "k8s.io/api/apps/v1beta2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+)
+
+var (
+	podsResource         = corev1.SchemeGroupVersion.WithResource("pods")
+	statefulSetsResource = appsv1beta2.SchemeGroupVersion.WithResource("statefulsets")
+	configMapsResource   = corev1.SchemeGroupVersion.WithResource("configmaps")
+)
+
+var _ = Describe("replacement of a dead node", func() {
+	var (
+		c             *cluster.Cluster
+		tracker       k8stesting.ObjectTracker
+		kubeClientset *kubefake.Clientset
+		ring          *stubRingState
+		eventRecorder *record.FakeRecorder
+		operation     *ReconcileClusterOperation
+		annotatedPod  *corev1.Pod
+	)
+
+	// recreatePodsOnDeletion stands in for the stateful set controller, which recreates a deleted pod along with
+	// a new persistent volume claim unless the previous claim is still there
+	recreatePodsOnDeletion := func(withNewClaim bool) {
+		recreations := 0
+		kubeClientset.PrependReactor("delete", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			name := action.(k8stesting.DeleteAction).GetName()
+			if err := tracker.Delete(podsResource, c.Namespace(), name); err != nil {
+				return true, nil, err
+			}
+
+			recreations++
+			pod := aRunningPod(c, "a", 1, "10.0.0.12")
+			pod.UID = types.UID(fmt.Sprintf("%s-recreated-%d", name, recreations))
+			if err := tracker.Add(pod); err != nil {
+				return true, nil, err
+			}
+			if withNewClaim {
+				return true, nil, tracker.Add(aClaim(c, 1, types.UID(fmt.Sprintf("new-claim-%d", recreations))))
+			}
+			return true, nil, nil
+		})
+	}
+
+	BeforeEach(func() {
+		c = aClusterWithRack("a", 3)
+		annotatedPod = aRunningPod(c, "a", 1, "10.0.0.2")
+		annotatedPod.Annotations = map[string]string{cluster.ReplaceAddressAnnotation: "10.0.0.9"}
+		tracker = anObjectTracker(
+			c.CreateStatefulSetForRack(&c.Definition().Spec.Racks[0], nil),
+			aRunningPod(c, "a", 0, "10.0.0.1"),
+			annotatedPod,
+			aRunningPod(c, "a", 2, "10.0.0.3"),
+			aClaim(c, 1, "old-claim"),
+		)
+		kubeClientset = fakeClusterResourcesIn(tracker)
+		ring = &stubRingState{liveNodes: []string{"10.0.0.1", "10.0.0.3"}}
+		eventRecorder = record.NewFakeRecorder(10)
+		operation = &ReconcileClusterOperation{
+			clusterAccessor: cluster.NewAccessor(kubeClientset, fake.NewSimpleClientset(), eventRecorder),
+			metricsPoller:   ring,
+			eventRecorder:   eventRecorder,
+		}
+	})
+
+	Context("requested through the annotation of a pod", func() {
+		It("should restart the pod with empty storage and remove the replacement once the node has joined the ring", func() {
+			recreatePodsOnDeletion(true)
+
+			Expect(operation.replaceNodes(c)).To(Succeed())
+
+			Expect(deletedObjects(kubeClientset, "persistentvolumeclaims")).To(Equal([]string{"cassandra-storage-mycluster-mycluster-a-1"}))
+			Expect(deletedObjects(kubeClientset, "pods")).To(Equal([]string{"mycluster-a-1"}))
+			Expect(createdNodeReplacements(kubeClientset)).To(Equal(map[string]string{"mycluster-a-1": "10.0.0.9"}))
+			Expect(nodeReplacementsIn(tracker, c)).To(BeEmpty())
+			Expect(eventRecorder.Events).To(Receive(HavePrefix("Normal %s", cluster.NodeReplacementStartedEvent)))
+			Expect(eventRecorder.Events).To(Receive(HavePrefix("Normal %s", cluster.NodeReplacedEvent)))
+		})
+
+		It("should restart the pod again when it was recreated before its persistent volume claim was deleted", func() {
+			recreatePodsOnDeletion(false)
+
+			Expect(operation.replaceNodes(c)).To(Succeed())
+
+			Expect(deletedObjects(kubeClientset, "pods")).To(Equal([]string{"mycluster-a-1", "mycluster-a-1"}))
+			Expect(nodeReplacementsIn(tracker, c)).To(BeEmpty())
+		})
+
+		It("should refuse to replace a node which is still live and remove the annotation", func() {
+			ring.liveNodes = append(ring.liveNodes, "10.0.0.9")
+
+			Expect(operation.replaceNodes(c)).To(Succeed())
+
+			Expect(deletedObjects(kubeClientset, "pods")).To(BeEmpty())
+			Expect(deletedObjects(kubeClientset, "persistentvolumeclaims")).To(BeEmpty())
+			Expect(patchedPods(kubeClientset)).To(Equal([]string{fmt.Sprintf(`mycluster-a-1: {"metadata": {"annotations": {"%s": null}}}`, cluster.ReplaceAddressAnnotation)}))
+			Expect(eventRecorder.Events).To(Receive(HavePrefix("Warning %s", cluster.NodeReplacementRefusedEvent)))
+		})
+
+		It("should refuse to replace a node on a pod whose bootstrapper isn't given the node replacements", func() {
+			statefulSet := c.CreateStatefulSetForRack(&c.Definition().Spec.Racks[0], nil)
+			cluster.RemoveNodeReplacementEnv(&statefulSet.Spec.Template.Spec)
+			Expect(tracker.Update(statefulSetsResource, statefulSet, c.Namespace())).To(Succeed())
+
+			Expect(operation.replaceNodes(c)).To(Succeed())
+
+			Expect(deletedObjects(kubeClientset, "pods")).To(BeEmpty())
+			Expect(eventRecorder.Events).To(Receive(And(HavePrefix("Warning %s", cluster.NodeReplacementRefusedEvent), ContainSubstring("previous version of the operator"))))
+		})
+	})
+
+	Context("started by a previous reconciliation", func() {
+		BeforeEach(func() {
+			delete(annotatedPod.Annotations, cluster.ReplaceAddressAnnotation)
+			Expect(tracker.Update(podsResource, annotatedPod, c.Namespace())).To(Succeed())
+			Expect(tracker.Add(c.CreateNodeReplacementsConfigMap(map[string]string{"mycluster-a-1": "10.0.0.9"}))).To(Succeed())
+		})
+
+		It("should remove the replacement once the node has joined the ring", func() {
+			Expect(operation.replaceNodes(c)).To(Succeed())
+
+			Expect(nodeReplacementsIn(tracker, c)).To(BeEmpty())
+			Expect(deletedObjects(kubeClientset, "pods")).To(BeEmpty())
+			Expect(eventRecorder.Events).To(Receive(HavePrefix("Normal %s", cluster.NodeReplacedEvent)))
+		})
+
+		It("should keep the replacement while the node is still joining the ring", func() {
+			ring.joiningNodes = []string{"10.0.0.2"}
+
+			Expect(operation.replaceNodes(c)).To(Succeed())
+
+			Expect(nodeReplacementsIn(tracker, c)).To(Equal(map[string]string{"mycluster-a-1": "10.0.0.9"}))
+			Expect(eventRecorder.Events).ToNot(Receive())
+		})
+	})
+})
+
+func aClaim(c *cluster.Cluster, ordinal int32, uid types.UID) *corev1.PersistentVolumeClaim {
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      c.Definition().StorageVolumeClaimName(&c.Definition().Spec.Racks[0], ordinal),
+			Namespace: c.Namespace(),
+			Labels:    map[string]string{cluster.OperatorLabel: c.Name()},
+			UID:       uid,
+		},
+	}
+}
+
+// deletedObjects returns the names of the deleted resources of the supplied kind, in order
+func deletedObjects(kubeClientset *kubefake.Clientset, resource string) []string {
+	var names []string
+	for _, action := range kubeClientset.Actions() {
+		if deletion, ok := action.(k8stesting.DeleteAction); ok && action.GetVerb() == "delete" && action.GetResource().Resource == resource {
+			names = append(names, deletion.GetName())
+		}
+	}
+	return names
+}
+
+func patchedPods(kubeClientset *kubefake.Clientset) []string {
+	var patches []string
+	for _, action := range kubeClientset.Actions() {
+		if patch, ok := action.(k8stesting.PatchAction); ok && action.GetResource().Resource == "pods" {
+			patches = append(patches, fmt.Sprintf("%s: %s", patch.GetName(), patch.GetPatch()))
+		}
+	}
+	return patches
+}
+
+func createdNodeReplacements(kubeClientset *kubefake.Clientset) map[string]string {
+	for _, action := range kubeClientset.Actions() {
+		if creation, ok := action.(k8stesting.CreateAction); ok && action.GetResource().Resource == "configmaps" {
+			return cluster.NodeReplacements(creation.GetObject().(*corev1.ConfigMap))
+		}
+	}
+	return nil
+}
+
+func nodeReplacementsIn(tracker k8stesting.ObjectTracker, c *cluster.Cluster) map[string]string {
+	configMap, err := tracker.Get(configMapsResource, c.Namespace(), c.Definition().NodeReplacementsConfigMapName())
+	Expect(err).ToNot(HaveOccurred())
+	return cluster.NodeReplacements(configMap.(*corev1.ConfigMap))
+}
@@ -0,0 +1,141 @@
+package operations
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/apis/cassandra/v1alpha1"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/cluster"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/operator/operations/adjuster"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// storageResizeTimeout bounds the time taken to expand the volume of a single node, which depends on the storage provider
+	storageResizeTimeout = 30 * time.Minute
+	// fileSystemResizeRestartTimeout bounds the time taken by a node restarted to resize its file system to be ready again
+	fileSystemResizeRestartTimeout = 30 * time.Minute
+	fileSystemResizeCheckInterval  = 10 * time.Second
+)
+
+// storageResizeRefusal is returned when the storage of a rack is not resized because its storage class doesn't allow it.
+// The resize is not retried, as it can only succeed once the storage class is changed.
+type storageResizeRefusal struct {
+	rack   string
+	reason string
+}
+
+func (r *storageResizeRefusal) Error() string {
+	return fmt.Sprintf("storage of rack %s cannot be resized: %s", r.rack, r.reason)
+}
+
+// resizeRackStorage expands the persistent volume claim of each node of a rack, one at a time, then recreates the stateful set
+// of the rack without deleting its pods so that the claims of nodes added later request the new storage size
+func (o *UpdateClusterOperation) resizeRackStorage(clusterChange *adjuster.ClusterChange) error {
+	newCluster := o.update.NewCluster
+	rack := clusterChange.Rack
+	storageSize := newCluster.Spec.Pod.StorageSize
+
+	storageClass, err := o.clusterAccessor.GetStorageClass(rack.StorageClass)
+	if err != nil {
+		return fmt.Errorf("unable to retrieve storage class %s for rack %s in cluster %s: %v", rack.StorageClass, rack.Name, o.cluster.QualifiedName(), err)
+	}
+	if storageClass.AllowVolumeExpansion == nil || !*storageClass.AllowVolumeExpansion {
+		reason := fmt.Sprintf("storage class %s does not allow volume expansion", storageClass.Name)
+		o.eventRecorder.Eventf(newCluster, v1.EventTypeWarning, cluster.StorageResizeRefusedEvent, "refusing to resize the storage of rack %s to %s: %s", rack.Name, storageSize.String(), reason)
+		return &storageResizeRefusal{rack: rack.Name, reason: reason}
+	}
+
+	statefulSet, err := o.clusterAccessor.GetStatefulSetForRack(o.cluster, &rack)
+	if err != nil {
+		return fmt.Errorf("unable to retrieve stateful set for rack %s in cluster %s: %v", rack.Name, o.cluster.QualifiedName(), err)
+	}
+
+	log.Infof("Resizing the storage of rack %s in cluster %s to %s", rack.Name, o.cluster.QualifiedName(), storageSize.String())
+	for ordinal := int32(0); ordinal < *statefulSet.Spec.Replicas; ordinal++ {
+		if err := o.resizePersistentVolumeClaim(&rack, ordinal, storageSize); err != nil {
+			return fmt.Errorf("unable to resize the storage of rack %s in cluster %s: %v", rack.Name, o.cluster.QualifiedName(), err)
+		}
+	}
+
+	customConfigMap, err := o.clusterAccessor.FindCustomConfigMap(o.cluster.Definition())
+	if err != nil {
+		return err
+	}
+	return o.statefulSetAccessor.recreateStatefulSet(o.cluster, &rack, customConfigMap)
+}
+
+// resizePersistentVolumeClaim expands the persistent volume claim of the node with the supplied ordinal and waits until
+// its volume has reached the new size. Claims which have already been expanded are only waited on.
+// Storage providers which can only resize the file system of a volume when it is mounted report FileSystemResizePending
+// on the claim once its volume has been expanded, in which case the pod of the node is restarted.
+func (o *UpdateClusterOperation) resizePersistentVolumeClaim(rack *v1alpha1.Rack, ordinal int32, storageSize resource.Quantity) error {
+	newCluster := o.update.NewCluster
+	claim, err := o.clusterAccessor.GetPersistentVolumeClaimForRack(o.cluster, rack, ordinal)
+	if errors.IsNotFound(err) {
+		log.Infof("No persistent volume claim found for pod %s in cluster %s, it will be created with the new size", o.cluster.Definition().PodName(rack, ordinal), o.cluster.QualifiedName())
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if requested := claim.Spec.Resources.Requests[v1.ResourceStorage]; requested.Cmp(storageSize) < 0 {
+		o.eventRecorder.Eventf(newCluster, v1.EventTypeNormal, cluster.PersistentVolumeClaimResizeStartedEvent, "resizing persistent volume claim %s from %s to %s", claim.Name, requested.String(), storageSize.String())
+		if _, err := o.clusterAccessor.ResizePersistentVolumeClaim(claim, storageSize); err != nil {
+			o.eventRecorder.Eventf(newCluster, v1.EventTypeWarning, cluster.PersistentVolumeClaimResizeFailedEvent, "unable to resize persistent volume claim %s: %v", claim.Name, err)
+			return err
+		}
+	}
+
+	resizedClaim, err := o.clusterAccessor.WaitUntilPersistentVolumeClaimResized(claim, storageSize, storageResizeTimeout)
+	if err == nil && cluster.FileSystemResizePending(resizedClaim) {
+		if err = o.restartForFileSystemResize(rack, ordinal, claim); err == nil {
+			_, err = o.clusterAccessor.WaitUntilPersistentVolumeClaimResized(claim, storageSize, storageResizeTimeout)
+		}
+	}
+	if err != nil {
+		o.eventRecorder.Eventf(newCluster, v1.EventTypeWarning, cluster.PersistentVolumeClaimResizeFailedEvent, "persistent volume claim %s has not been resized: %v", claim.Name, err)
+		return err
+	}
+	o.eventRecorder.Eventf(newCluster, v1.EventTypeNormal, cluster.PersistentVolumeClaimResizedEvent, "resized persistent volume claim %s to %s", claim.Name, storageSize.String())
+	return nil
+}
+
+// restartForFileSystemResize deletes the pod of the node with the supplied ordinal so that the stateful set recreates it,
+// mounting the expanded volume, and waits until the recreated pod is ready before the next node is resized
+func (o *UpdateClusterOperation) restartForFileSystemResize(rack *v1alpha1.Rack, ordinal int32, claim *v1.PersistentVolumeClaim) error {
+	pod, err := o.clusterAccessor.GetPodForRack(o.cluster, rack, ordinal)
+	if errors.IsNotFound(err) {
+		log.Infof("Pod %s of cluster %s doesn't exist, the file system of claim %s will be resized when it is created", o.cluster.Definition().PodName(rack, ordinal), o.cluster.QualifiedName(), claim.Name)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	o.eventRecorder.Eventf(o.update.NewCluster, v1.EventTypeNormal, cluster.PersistentVolumeClaimFileSystemResizeStartedEvent, "restarting pod %s to resize the file system of persistent volume claim %s", pod.Name, claim.Name)
+	if err := o.clusterAccessor.DeletePod(pod); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	err = wait.PollImmediate(fileSystemResizeCheckInterval, fileSystemResizeRestartTimeout, func() (bool, error) {
+		currentPod, err := o.clusterAccessor.GetPodForRack(o.cluster, rack, ordinal)
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		if err != nil {
+			log.Warnf("Unable to retrieve pod %s, will retry: %v", pod.Name, err)
+			return false, nil
+		}
+		return currentPod.UID != pod.UID && podIsReady(currentPod), nil
+	})
+	if err != nil {
+		return fmt.Errorf("error while waiting for pod %s to be ready after its restart: %v", pod.Name, err)
+	}
+	return nil
+}
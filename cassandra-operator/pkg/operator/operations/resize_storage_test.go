@@ -0,0 +1,177 @@
+package operations
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/client/clientset/versioned/fake"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/cluster"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+)
+
+var (
+	claimsResource                   = corev1.SchemeGroupVersion.WithResource("persistentvolumeclaims")
+	fileSystemResizePendingCondition = corev1.PersistentVolumeClaimCondition{Type: "FileSystemResizePending", Status: corev1.ConditionTrue}
+)
+
+var _ = Describe("resize of the storage of a rack", func() {
+	var (
+		c             *cluster.Cluster
+		tracker       k8stesting.ObjectTracker
+		kubeClientset *kubefake.Clientset
+		eventRecorder *record.FakeRecorder
+		operation     *UpdateClusterOperation
+		newSize       resource.Quantity
+	)
+
+	// setClaimStatus stands in for the storage provider, which reports the capacity of the volume and its resize conditions
+	setClaimStatus := func(name string, capacity resource.Quantity, conditions ...corev1.PersistentVolumeClaimCondition) error {
+		object, err := tracker.Get(claimsResource, c.Namespace(), name)
+		if err != nil {
+			return err
+		}
+		claim := object.(*corev1.PersistentVolumeClaim)
+		claim.Status.Capacity = corev1.ResourceList{corev1.ResourceStorage: capacity}
+		claim.Status.Conditions = conditions
+		return tracker.Update(claimsResource, claim, c.Namespace())
+	}
+
+	// expandVolumesOnResize stands in for a storage provider which expands the volume of a claim when it is resized,
+	// but only resizes its file system while the pod is running when onlineFileSystemResize is set
+	expandVolumesOnResize := func(onlineFileSystemResize bool) {
+		kubeClientset.PrependReactor("patch", "persistentvolumeclaims", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			if _, _, err := k8stesting.ObjectReaction(tracker)(action); err != nil {
+				return true, nil, err
+			}
+			name := action.(k8stesting.PatchAction).GetName()
+			if onlineFileSystemResize {
+				return true, nil, setClaimStatus(name, newSize)
+			}
+			return true, nil, setClaimStatus(name, resource.MustParse("1Gi"), fileSystemResizePendingCondition)
+		})
+	}
+
+	// recreatePodsOnDeletion stands in for the stateful set controller, which recreates a deleted pod,
+	// and for the kubelet, which resizes the file system of its volume when it is mounted
+	recreatePodsOnDeletion := func() {
+		kubeClientset.PrependReactor("delete", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			name := action.(k8stesting.DeleteAction).GetName()
+			if err := tracker.Delete(podsResource, c.Namespace(), name); err != nil {
+				return true, nil, err
+			}
+
+			var ordinal int32
+			if _, err := fmt.Sscanf(name, "mycluster-a-%d", &ordinal); err != nil {
+				return true, nil, err
+			}
+			pod := aRunningPod(c, "a", ordinal, fmt.Sprintf("10.0.0.%d", ordinal+11))
+			pod.UID = types.UID(fmt.Sprintf("%s-recreated", name))
+			if err := tracker.Add(pod); err != nil {
+				return true, nil, err
+			}
+			return true, nil, setClaimStatus(c.Definition().StorageVolumeClaimName(&c.Definition().Spec.Racks[0], ordinal), newSize)
+		})
+	}
+
+	aClaimOfSize := func(ordinal int32, size string, conditions ...corev1.PersistentVolumeClaimCondition) *corev1.PersistentVolumeClaim {
+		claim := aClaim(c, ordinal, types.UID(fmt.Sprintf("claim-%d", ordinal)))
+		claim.Spec.Resources.Requests = corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(size)}
+		claim.Status.Capacity = corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")}
+		claim.Status.Conditions = conditions
+		return claim
+	}
+
+	resizeClaims := func() error {
+		rack := c.Definition().Spec.Racks[0]
+		for ordinal := int32(0); ordinal < rack.Replicas; ordinal++ {
+			if err := operation.resizePersistentVolumeClaim(&rack, ordinal, newSize); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	BeforeEach(func() {
+		c = aClusterWithRack("a", 2)
+		newSize = resource.MustParse("2Gi")
+		tracker = anObjectTracker(
+			aRunningPod(c, "a", 0, "10.0.0.1"),
+			aRunningPod(c, "a", 1, "10.0.0.2"),
+		)
+		kubeClientset = fakeClusterResourcesIn(tracker)
+		eventRecorder = record.NewFakeRecorder(20)
+		operation = &UpdateClusterOperation{
+			cluster:         c,
+			eventRecorder:   eventRecorder,
+			clusterAccessor: cluster.NewAccessor(kubeClientset, fake.NewSimpleClientset(), eventRecorder),
+			update:          ClusterUpdate{OldCluster: c.Definition(), NewCluster: c.Definition()},
+		}
+	})
+
+	Context("when the storage provider resizes the file system of a mounted volume", func() {
+		BeforeEach(func() {
+			Expect(tracker.Add(aClaimOfSize(0, "1Gi"))).To(Succeed())
+			Expect(tracker.Add(aClaimOfSize(1, "1Gi"))).To(Succeed())
+			expandVolumesOnResize(true)
+		})
+
+		It("should resize each claim without restarting its pod", func() {
+			Expect(resizeClaims()).To(Succeed())
+
+			Expect(deletedObjects(kubeClientset, "pods")).To(BeEmpty())
+			Expect(eventRecorder.Events).To(Receive(HavePrefix("Normal %s", cluster.PersistentVolumeClaimResizeStartedEvent)))
+			Expect(eventRecorder.Events).To(Receive(HavePrefix("Normal %s", cluster.PersistentVolumeClaimResizedEvent)))
+		})
+	})
+
+	Context("when the file system of a volume is only resized once it is next mounted", func() {
+		BeforeEach(func() {
+			Expect(tracker.Add(aClaimOfSize(0, "1Gi"))).To(Succeed())
+			Expect(tracker.Add(aClaimOfSize(1, "1Gi"))).To(Succeed())
+			expandVolumesOnResize(false)
+			recreatePodsOnDeletion()
+		})
+
+		It("should restart the pod of each node in turn once its claim reports FileSystemResizePending", func() {
+			Expect(resizeClaims()).To(Succeed())
+
+			Expect(deletedObjects(kubeClientset, "pods")).To(Equal([]string{"mycluster-a-0", "mycluster-a-1"}))
+			Expect(eventRecorder.Events).To(Receive(HavePrefix("Normal %s", cluster.PersistentVolumeClaimResizeStartedEvent)))
+			Expect(eventRecorder.Events).To(Receive(HavePrefix("Normal %s", cluster.PersistentVolumeClaimFileSystemResizeStartedEvent)))
+			Expect(eventRecorder.Events).To(Receive(HavePrefix("Normal %s", cluster.PersistentVolumeClaimResizedEvent)))
+		})
+	})
+
+	Context("when a claim was expanded before the operator restarted", func() {
+		BeforeEach(func() {
+			Expect(tracker.Add(aClaimOfSize(0, "2Gi", fileSystemResizePendingCondition))).To(Succeed())
+			Expect(tracker.Add(aClaimOfSize(1, "1Gi"))).To(Succeed())
+			expandVolumesOnResize(false)
+			recreatePodsOnDeletion()
+		})
+
+		It("should restart its pod without resizing the claim again", func() {
+			Expect(resizeClaims()).To(Succeed())
+
+			Expect(patchedClaims(kubeClientset)).To(Equal([]string{"cassandra-storage-mycluster-mycluster-a-1"}))
+			Expect(deletedObjects(kubeClientset, "pods")).To(Equal([]string{"mycluster-a-0", "mycluster-a-1"}))
+		})
+	})
+})
+
+func patchedClaims(kubeClientset *kubefake.Clientset) []string {
+	var names []string
+	for _, action := range kubeClientset.Actions() {
+		if patch, ok := action.(k8stesting.PatchAction); ok && action.GetVerb() == "patch" && action.GetResource().Resource == "persistentvolumeclaims" {
+			names = append(names, patch.GetName())
+		}
+	}
+	return names
+}
@@ -0,0 +1,67 @@
+package operations
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/apis/cassandra/v1alpha1"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/cluster"
+	"k8s.io/api/apps/v1beta2"
+	"k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+// RestartClusterOperation describes what the operator does when a restart of a cluster is requested
+// through the restartedAt annotation
+type RestartClusterOperation struct {
+	cluster             *cluster.Cluster
+	clusterDefinition   *v1alpha1.Cassandra
+	clusterAccessor     *cluster.Accessor
+	statefulSetAccessor *statefulSetAccessor
+	eventRecorder       record.EventRecorder
+}
+
+// Execute performs the operation
+func (o *RestartClusterOperation) Execute() error {
+	if err := cluster.CopyInto(o.cluster, o.clusterDefinition); err != nil {
+		log.Errorf("Cluster definition %s is invalid, it will not be restarted: %v", o.clusterDefinition.QualifiedName(), err)
+		return nil
+	}
+
+	restartedAt := o.cluster.RestartedAt()
+	if restartedAt == "" {
+		return nil
+	}
+
+	customConfigMap, err := o.clusterAccessor.FindCustomConfigMap(o.cluster.Definition())
+	if err != nil {
+		return err
+	}
+	for _, rack := range o.cluster.Racks() {
+		statefulSet, err := o.clusterAccessor.GetStatefulSetForRack(o.cluster, &rack)
+		if err != nil {
+			return fmt.Errorf("unable to retrieve stateful set for rack %s in cluster %s: %v", rack.Name, o.cluster.QualifiedName(), err)
+		}
+		if statefulSet.Spec.Template.Annotations[cluster.RestartedAtAnnotation] == restartedAt {
+			continue
+		}
+
+		log.Infof("Restarting rack %s in cluster %s", rack.Name, o.cluster.QualifiedName())
+		o.eventRecorder.Eventf(o.clusterDefinition, v1.EventTypeNormal, cluster.RackRestartStartedEvent, "restarting rack %s as requested at %s", rack.Name, restartedAt)
+		err = o.statefulSetAccessor.updateStatefulSet(o.cluster, customConfigMap, &rack, func(statefulSet *v1beta2.StatefulSet, _ *v1.ConfigMap) error {
+			if statefulSet.Spec.Template.Annotations == nil {
+				statefulSet.Spec.Template.Annotations = map[string]string{}
+			}
+			statefulSet.Spec.Template.Annotations[cluster.RestartedAtAnnotation] = restartedAt
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		o.eventRecorder.Eventf(o.clusterDefinition, v1.EventTypeNormal, cluster.RackRestartedEvent, "restarted rack %s", rack.Name)
+	}
+	return nil
+}
+
+func (o *RestartClusterOperation) String() string {
+	return fmt.Sprintf("restart cluster %s", o.clusterDefinition.QualifiedName())
+}
@@ -0,0 +1,54 @@
+package operations
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/apis/cassandra/v1alpha1"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/cluster"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/record"
+)
+
+// RunRepairOperation describes what the operator does when a repair of a cluster is requested
+// through the repairRequestedAt annotation
+type RunRepairOperation struct {
+	clusterDefinition *v1alpha1.Cassandra
+	clusterAccessor   *cluster.Accessor
+	eventRecorder     record.EventRecorder
+}
+
+// Execute performs the operation
+func (o *RunRepairOperation) Execute() error {
+	requestedAt := o.clusterDefinition.Annotations[cluster.RepairRequestedAtAnnotation]
+	if requestedAt == "" {
+		return nil
+	}
+	if o.clusterDefinition.Spec.Repair == nil {
+		log.Warnf("Repair requested at %s for cluster %s, which has no repair configured", requestedAt, o.clusterDefinition.QualifiedName())
+		o.eventRecorder.Eventf(o.clusterDefinition, v1.EventTypeWarning, cluster.RepairRefusedEvent, "repair requested at %s not run as no repair is configured", requestedAt)
+		return nil
+	}
+
+	cronJob, err := o.clusterAccessor.FindCronJobForCluster(o.clusterDefinition, fmt.Sprintf("app=%s", o.clusterDefinition.RepairJobName()))
+	if err != nil {
+		return fmt.Errorf("error while retrieving repair job for cluster %s: %v", o.clusterDefinition.QualifiedName(), err)
+	}
+	if cronJob == nil {
+		return fmt.Errorf("repair job %s not found for cluster %s", o.clusterDefinition.RepairJobName(), o.clusterDefinition.QualifiedName())
+	}
+
+	job, err := o.clusterAccessor.CreateJob(cluster.CreateOnDemandJob(cronJob, requestedAt))
+	if errors.IsAlreadyExists(err) {
+		log.Infof("Repair requested at %s has already been started for cluster %s", requestedAt, o.clusterDefinition.QualifiedName())
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("error while starting repair job for cluster %s: %v", o.clusterDefinition.QualifiedName(), err)
+	}
+	o.eventRecorder.Eventf(o.clusterDefinition, v1.EventTypeNormal, cluster.RepairStartedEvent, "repair job %s started as requested at %s", job.Name, requestedAt)
+	return nil
+}
+
+func (o *RunRepairOperation) String() string {
+	return fmt.Sprintf("run repair for cluster %s", o.clusterDefinition.QualifiedName())
+}
@@ -0,0 +1,85 @@
+package operations
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/apis/cassandra/v1alpha1"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/client/clientset/versioned/fake"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/cluster"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("repair requested on demand", func() {
+	var (
+		kubeClientset *kubefake.Clientset
+		definition    *v1alpha1.Cassandra
+		operation     *RunRepairOperation
+	)
+
+	BeforeEach(func() {
+		definition = aClusterWithRack("a", 3).Definition()
+		definition.Spec.Repair = &v1alpha1.Repair{Schedule: "0 2 * * *", Keyspaces: []string{"keyspace1"}}
+		definition.Annotations = map[string]string{cluster.RepairRequestedAtAnnotation: "2019-01-01T00:00:00Z"}
+		c, err := cluster.New(definition)
+		Expect(err).ToNot(HaveOccurred())
+
+		cronJob := c.CreateRepairJob()
+		cronJob.UID = types.UID("repair-uid")
+		kubeClientset = fakeClusterResources(cronJob)
+		operation = &RunRepairOperation{
+			clusterDefinition: definition,
+			clusterAccessor:   cluster.NewAccessor(kubeClientset, fake.NewSimpleClientset(), &stubEventRecorder{}),
+			eventRecorder:     &stubEventRecorder{},
+		}
+	})
+
+	It("should run a job from the template of the repair cron job, owned by the cron job", func() {
+		Expect(operation.Execute()).To(Succeed())
+
+		jobs := repairJobs(kubeClientset)
+		Expect(jobs).To(HaveLen(1))
+		cronJob := definition.RepairJobName()
+		Expect(jobs[0].Name).To(HavePrefix(cronJob + "-"))
+		Expect(jobs[0].Labels).To(HaveKeyWithValue("app", cronJob))
+		Expect(jobs[0].Annotations).To(HaveKeyWithValue(cluster.RepairRequestedAtAnnotation, "2019-01-01T00:00:00Z"))
+		Expect(jobs[0].OwnerReferences).To(HaveLen(1))
+		Expect(jobs[0].OwnerReferences[0].Name).To(Equal(cronJob))
+		Expect(jobs[0].OwnerReferences[0].UID).To(Equal(types.UID("repair-uid")))
+		Expect(jobs[0].Spec.Template.Spec.Containers[0].Command).To(ContainElement("keyspace1"))
+	})
+
+	It("should run the job only once for each request", func() {
+		Expect(operation.Execute()).To(Succeed())
+		Expect(operation.Execute()).To(Succeed())
+		Expect(repairJobs(kubeClientset)).To(HaveLen(1))
+
+		operation.clusterDefinition.Annotations[cluster.RepairRequestedAtAnnotation] = "2019-02-01T00:00:00Z"
+		Expect(operation.Execute()).To(Succeed())
+		Expect(repairJobs(kubeClientset)).To(HaveLen(2))
+	})
+
+	It("should not run a job when the cluster has no repair configured", func() {
+		definition.Spec.Repair = nil
+
+		Expect(operation.Execute()).To(Succeed())
+
+		Expect(repairJobs(kubeClientset)).To(BeEmpty())
+	})
+
+	It("should fail when the repair cron job does not exist, so that the request is retried", func() {
+		kubeClientset = fakeClusterResources()
+		operation.clusterAccessor = cluster.NewAccessor(kubeClientset, fake.NewSimpleClientset(), &stubEventRecorder{})
+
+		Expect(operation.Execute()).To(MatchError("repair job mycluster-repair not found for cluster mynamespace.mycluster"))
+		Expect(repairJobs(kubeClientset)).To(BeEmpty())
+	})
+})
+
+func repairJobs(kubeClientset *kubefake.Clientset) []batchv1.Job {
+	jobs, err := kubeClientset.BatchV1().Jobs("mynamespace").List(metav1.ListOptions{})
+	Expect(err).ToNot(HaveOccurred())
+	return jobs.Items
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/operator/operations/adjuster"
 	"k8s.io/api/apps/v1beta2"
 	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 )
 
 type statefulSetAccessor struct {
@@ -26,6 +27,10 @@ func (h *statefulSetAccessor) registerStatefulSets(c *cluster.Cluster, configMap
 
 func (h *statefulSetAccessor) registerStatefulSet(c *cluster.Cluster, rack *v1alpha1.Rack, customConfigMap *v1.ConfigMap) error {
 	statefulSet, err := h.clusterAccessor.CreateStatefulSetForRack(c, rack, customConfigMap)
+	if errors.IsAlreadyExists(err) {
+		log.Infof("Stateful set already exists for cluster : %s in rack: %s", c.QualifiedName(), rack.Name)
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("error while creating stateful set rack %s for cluster %s.%s: %v", rack.Name, c.Namespace(), c.Name(), err)
 	}
@@ -71,3 +76,18 @@ func (h *statefulSetAccessor) patchStatefulSet(c *cluster.Cluster, clusterChange
 	}
 	return h.clusterAccessor.WaitUntilRackChangeApplied(c, updatedStatefulSet)
 }
+
+// recreateStatefulSet replaces the stateful set of a rack with the one generated from the cluster definition while its pods
+// keep running, as the volume claim templates of an existing stateful set cannot be changed
+func (h *statefulSetAccessor) recreateStatefulSet(c *cluster.Cluster, rack *v1alpha1.Rack, customConfigMap *v1.ConfigMap) error {
+	log.Infof("Recreating stateful set for rack %s in cluster %s", rack.Name, c.QualifiedName())
+	if err := h.clusterAccessor.OrphanPodsOfStatefulSetForRack(c, rack); err != nil {
+		return fmt.Errorf("unable to delete statefulSet for rack %s: %v. Other racks will not be updated", rack.Name, err)
+	}
+
+	statefulSet, err := h.clusterAccessor.CreateStatefulSetForRack(c, rack, customConfigMap)
+	if err != nil {
+		return fmt.Errorf("unable to recreate statefulSet for rack %s: %v. Other racks will not be updated", rack.Name, err)
+	}
+	return h.clusterAccessor.WaitUntilRackChangeApplied(c, statefulSet)
+}
@@ -0,0 +1,36 @@
+package operations
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/apis/cassandra/v1alpha1"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/cluster"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/metrics"
+)
+
+// UnwatchClusterOperation describes what the operator does when the namespace of a cluster is no longer watched.
+// The cluster is forgotten but, unlike when its definition is deleted, its resources are left in place.
+type UnwatchClusterOperation struct {
+	clusters          map[string]*cluster.Cluster
+	clusterDefinition *v1alpha1.Cassandra
+	metricsPoller     *metrics.PrometheusMetrics
+}
+
+// Execute performs the operation
+func (o *UnwatchClusterOperation) Execute() error {
+	c, ok := o.clusters[o.clusterDefinition.QualifiedName()]
+	if !ok {
+		log.Debugf("No record found of unwatched cluster %s", o.clusterDefinition.QualifiedName())
+		return nil
+	}
+
+	c.Online = false
+	o.metricsPoller.DeleteMetrics(c)
+	delete(o.clusters, c.QualifiedName())
+	log.Infof("Cluster %s is no longer managed by this operator, its resources have been left in place", c.QualifiedName())
+	return nil
+}
+
+func (o *UnwatchClusterOperation) String() string {
+	return fmt.Sprintf("unwatch cluster %s", o.clusterDefinition.QualifiedName())
+}
@@ -3,10 +3,20 @@ package operations
 import (
 	"fmt"
 	log "github.com/sirupsen/logrus"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/apis/cassandra/v1alpha1"
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/cluster"
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/operator/operations/adjuster"
 	"k8s.io/api/core/v1"
 	"k8s.io/client-go/tools/record"
+	"strings"
+	"time"
+)
+
+const (
+	// upgradeSSTablesTimeout bounds the time taken to rewrite the SSTables of a single node, which depends on its data volume
+	upgradeSSTablesTimeout = 6 * time.Hour
+	// cleanupTimeout bounds the time taken to remove the data a single node no longer owns, which depends on its data volume
+	cleanupTimeout = 6 * time.Hour
 )
 
 // UpdateClusterOperation describes what the operator does when the Cassandra spec is updated for a cluster
@@ -16,48 +26,165 @@ type UpdateClusterOperation struct {
 	eventRecorder       record.EventRecorder
 	statefulSetAccessor *statefulSetAccessor
 	clusterAccessor     *cluster.Accessor
+	nodetool            nodeCommands
+	metricsPoller       ringState
 	update              ClusterUpdate
 }
 
 // Execute performs the operation
-func (o *UpdateClusterOperation) Execute() {
+func (o *UpdateClusterOperation) Execute() error {
 	oldCluster := o.update.OldCluster
 	newCluster := o.update.NewCluster
 
 	log.Infof("Cluster definition has been updated for cluster %s.%s", oldCluster.Namespace, oldCluster.Name)
 	if err := cluster.CopyInto(o.cluster, newCluster); err != nil {
 		log.Errorf("Cluster definition %s.%s is invalid: %v", newCluster.Namespace, newCluster.Name, err)
-		return
+		updateCondition(o.clusterAccessor, newCluster, v1alpha1.ConfigInvalid, v1.ConditionTrue, reasonInvalidSpec, err.Error())
+		return nil
 	}
 
-	clusterChanges, err := o.adjuster.ChangesForCluster(&oldCluster.Spec, &newCluster.Spec)
+	clusterChanges, err := o.adjuster.ChangesForCluster(newCluster.Name, &oldCluster.Spec, &newCluster.Spec)
 	if err != nil {
 		o.eventRecorder.Eventf(oldCluster, v1.EventTypeWarning, cluster.InvalidChangeEvent, "unable to generate patch for cluster %s.%s: %v", newCluster.Namespace, newCluster.Name, err)
-		return
+		updateCondition(o.clusterAccessor, newCluster, v1alpha1.ConfigInvalid, v1.ConditionTrue, reasonForbiddenChange, err.Error())
+		return nil
+	}
+
+	// a config map referenced in place of the previous one is labelled, so that its changes are watched
+	if newCluster.CustomConfigMapName() != oldCluster.CustomConfigMapName() {
+		customConfigMap, err := o.clusterAccessor.FindCustomConfigMap(newCluster)
+		if err != nil {
+			return err
+		}
+		if customConfigMap != nil {
+			labelCustomConfigMap(o.clusterAccessor, o.eventRecorder, newCluster, customConfigMap)
+		}
 	}
 
+	var unsupportedChanges []string
+	var refusedChanges []string
+	var podsToUpgrade []string
 	for _, clusterChange := range clusterChanges {
 		switch clusterChange.ChangeType {
 		case adjuster.UpdateRack:
 			err := o.statefulSetAccessor.patchStatefulSet(o.cluster, &clusterChange)
 			if err != nil {
-				log.Error(err)
-				return
+				return err
+			}
+		case adjuster.UpgradeRack:
+			log.Infof("Upgrading rack %s in cluster %s to image %s", clusterChange.Rack.Name, o.cluster.QualifiedName(), newCluster.Spec.Pod.Image)
+			err := o.statefulSetAccessor.patchStatefulSet(o.cluster, &clusterChange)
+			if err != nil {
+				return err
+			}
+			pods, err := o.clusterAccessor.FindPodsForRack(o.cluster, &clusterChange.Rack)
+			if err != nil {
+				return err
+			}
+			for _, pod := range pods {
+				podsToUpgrade = append(podsToUpgrade, pod.Name)
+			}
+		case adjuster.ScaleDownRack:
+			if err := o.scaleDownRack(&clusterChange); err != nil {
+				return err
+			}
+		case adjuster.DeleteRack:
+			if err := o.deleteRack(&clusterChange); err != nil {
+				if refusal, ok := err.(*rackDeletionRefusal); ok {
+					log.Warn(refusal)
+					refusedChanges = append(refusedChanges, refusal.Error())
+					continue
+				}
+				return err
+			}
+		case adjuster.ResizeRackStorage:
+			if err := o.resizeRackStorage(&clusterChange); err != nil {
+				if refusal, ok := err.(*storageResizeRefusal); ok {
+					log.Warn(refusal)
+					refusedChanges = append(refusedChanges, refusal.Error())
+					continue
+				}
+				return err
 			}
 		case adjuster.AddRack:
 			log.Infof("Adding new rack %s to cluster %s", clusterChange.Rack.Name, o.cluster.QualifiedName())
 
-			customConfigMap := o.clusterAccessor.FindCustomConfigMap(o.cluster.Namespace(), o.cluster.Name())
+			customConfigMap, err := o.clusterAccessor.FindCustomConfigMap(o.cluster.Definition())
+			if err != nil {
+				return fmt.Errorf("error while creating stateful sets for added rack %s in cluster %s: %v", clusterChange.Rack.Name, o.cluster.QualifiedName(), err)
+			}
 			if err := o.statefulSetAccessor.registerStatefulSet(o.cluster, &clusterChange.Rack, customConfigMap); err != nil {
-				log.Errorf("Error while creating stateful sets for added rack %s in cluster %s: %v", clusterChange.Rack.Name, o.cluster.QualifiedName(), err)
-				return
+				return fmt.Errorf("error while creating stateful sets for added rack %s in cluster %s: %v", clusterChange.Rack.Name, o.cluster.QualifiedName(), err)
 			}
 		default:
 			message := fmt.Sprintf("Change type '%s' isn't supported for cluster %s", clusterChange.ChangeType, o.cluster.QualifiedName())
 			log.Error(message)
 			o.eventRecorder.Event(oldCluster, v1.EventTypeWarning, cluster.InvalidChangeEvent, message)
+			unsupportedChanges = append(unsupportedChanges, message)
+		}
+	}
+
+	podsToCleanUp, err := o.podsToCleanUpAfterScaleUp(clusterChanges)
+	if err != nil {
+		return err
+	}
+
+	// the SSTables are upgraded and the nodes cleaned up by a separate node maintenance operation, as it may take hours on each node
+	err = o.clusterAccessor.UpdateCassandraStatus(newCluster, func(status *v1alpha1.CassandraStatus) {
+		status.ObservedGeneration = newCluster.Generation
+		status.AddNodeMaintenance(v1alpha1.UpgradeSSTablesCommand, podsToUpgrade)
+		status.AddNodeMaintenance(v1alpha1.CleanupCommand, podsToCleanUp)
+		if len(unsupportedChanges) > 0 {
+			status.SetCondition(v1alpha1.ConfigInvalid, v1.ConditionTrue, reasonUnsupportedChange, strings.Join(unsupportedChanges, "; "))
+		} else if len(refusedChanges) > 0 {
+			status.SetCondition(v1alpha1.ConfigInvalid, v1.ConditionTrue, reasonForbiddenChange, strings.Join(refusedChanges, "; "))
+		} else {
+			status.SetCondition(v1alpha1.ConfigInvalid, v1.ConditionFalse, reasonSpecApplied, "")
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("unable to update status for cluster %s: %v", o.cluster.QualifiedName(), err)
+	}
+	return nil
+}
+
+// podsToCleanUpAfterScaleUp returns the pods which were running before racks were scaled up or added,
+// whose data that they no longer own is to be removed once the new nodes are ready
+func (o *UpdateClusterOperation) podsToCleanUpAfterScaleUp(clusterChanges []adjuster.ClusterChange) ([]string, error) {
+	autoCleanup := o.update.NewCluster.Spec.AutoCleanup
+	if autoCleanup == nil || !autoCleanup.Enabled {
+		return nil, nil
+	}
+
+	addedNodes := map[string]int32{}
+	for _, clusterChange := range clusterChanges {
+		if clusterChange.ChangeType == adjuster.AddRack {
+			addedNodes[clusterChange.Rack.Name] = clusterChange.Rack.Replicas
+		} else if clusterChange.NodesToScaleUp > 0 {
+			addedNodes[clusterChange.Rack.Name] = int32(clusterChange.NodesToScaleUp)
+		}
+	}
+	if len(addedNodes) == 0 {
+		return nil, nil
+	}
+
+	var podsToCleanUp []string
+	for _, rack := range o.cluster.Racks() {
+		pods, err := o.clusterAccessor.FindPodsForRack(o.cluster, &rack)
+		if err != nil {
+			return nil, err
+		}
+
+		existingReplicas := rack.Replicas - addedNodes[rack.Name]
+		for i := range pods {
+			ordinal, err := podOrdinal(&pods[i])
+			if err != nil || ordinal >= existingReplicas {
+				continue
+			}
+			podsToCleanUp = append(podsToCleanUp, pods[i].Name)
 		}
 	}
+	return podsToCleanUp, nil
 }
 
 func (o *UpdateClusterOperation) String() string {
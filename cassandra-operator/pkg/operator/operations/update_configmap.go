@@ -2,7 +2,6 @@ package operations
 
 import (
 	"fmt"
-	log "github.com/sirupsen/logrus"
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/cluster"
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/operator/operations/adjuster"
 	"k8s.io/api/core/v1"
@@ -19,15 +18,15 @@ type UpdateCustomConfigOperation struct {
 }
 
 // Execute performs the operation
-func (o *UpdateCustomConfigOperation) Execute() {
+func (o *UpdateCustomConfigOperation) Execute() error {
 	o.eventRecorder.Eventf(o.cluster.Definition(), v1.EventTypeNormal, cluster.ClusterUpdateEvent, "Custom config updated for cluster %s", o.cluster.QualifiedName())
 	for _, rack := range o.cluster.Racks() {
 		patchChange := o.adjuster.CreateConfigMapHashPatchForRack(&rack, o.configMap)
 		if err := o.statefulSetAccessor.patchStatefulSet(o.cluster, patchChange); err != nil {
-			log.Errorf("Error while attempting to update rack %s in cluster %s as a result of a custom config change. No further updates will be applied: %v", rack.Name, o.cluster.QualifiedName(), err)
-			return
+			return fmt.Errorf("error while attempting to update rack %s in cluster %s as a result of a custom config change. No further updates will be applied: %v", rack.Name, o.cluster.QualifiedName(), err)
 		}
 	}
+	return nil
 }
 
 func (o *UpdateCustomConfigOperation) String() string {
@@ -0,0 +1,48 @@
+package operations
+
+import (
+	"fmt"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/apis/cassandra/v1alpha1"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/cluster"
+	"k8s.io/api/batch/v1beta1"
+	"k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+// UpdateRepairOperation describes what the operator does when the Repair spec is updated for a cluster
+type UpdateRepairOperation struct {
+	cluster         *cluster.Cluster
+	clusterAccessor *cluster.Accessor
+	newRepair       *v1alpha1.Repair
+	eventRecorder   record.EventRecorder
+}
+
+// Execute performs the operation
+func (o *UpdateRepairOperation) Execute() error {
+	cassandra := o.cluster.Definition()
+	job, err := o.clusterAccessor.FindCronJobForCluster(cassandra, fmt.Sprintf("app=%s", cassandra.RepairJobName()))
+	if err != nil {
+		return fmt.Errorf("error while retrieving repair job for cluster %s: %v", cassandra.QualifiedName(), err)
+	}
+
+	if job != nil {
+		return o.updateRepairJob(job)
+	}
+	return nil
+}
+
+func (o *UpdateRepairOperation) updateRepairJob(repairJob *v1beta1.CronJob) error {
+	repairJob.Spec.Schedule = o.newRepair.Schedule
+	repairJob.Spec.JobTemplate.Spec.Template.Spec.Containers[0] = *o.cluster.CreateRepairContainer(o.newRepair)
+	err := o.clusterAccessor.UpdateCronJob(repairJob)
+	if err != nil {
+		return fmt.Errorf("error while updating repair job %s for cluster %s: %v", repairJob.Name, o.cluster.QualifiedName(), err)
+	}
+	o.eventRecorder.Eventf(o.cluster.Definition(), v1.EventTypeNormal, cluster.ClusterRepairModificationEvent, "Repair modified for cluster %s", o.cluster.QualifiedName())
+	updateCondition(o.clusterAccessor, o.cluster.Definition(), v1alpha1.RepairScheduled, v1.ConditionTrue, reasonRepairJobScheduled, fmt.Sprintf("repair job %s scheduled", repairJob.Name))
+	return nil
+}
+
+func (o *UpdateRepairOperation) String() string {
+	return fmt.Sprintf("update repair schedule for cluster %s", o.cluster.QualifiedName())
+}
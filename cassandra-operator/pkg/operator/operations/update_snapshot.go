@@ -2,7 +2,6 @@ package operations
 
 import (
 	"fmt"
-	log "github.com/sirupsen/logrus"
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/apis/cassandra/v1alpha1"
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/cluster"
 	"k8s.io/api/batch/v1beta1"
@@ -19,27 +18,29 @@ type UpdateSnapshotOperation struct {
 }
 
 // Execute performs the operation
-func (o *UpdateSnapshotOperation) Execute() {
+func (o *UpdateSnapshotOperation) Execute() error {
 	cassandra := o.cluster.Definition()
 	job, err := o.clusterAccessor.FindCronJobForCluster(cassandra, fmt.Sprintf("app=%s", cassandra.SnapshotJobName()))
 	if err != nil {
-		log.Errorf("Error while retrieving snapshot job for cluster %s: %v", cassandra.QualifiedName(), err)
+		return fmt.Errorf("error while retrieving snapshot job for cluster %s: %v", cassandra.QualifiedName(), err)
 	}
 
 	if job != nil {
-		o.updateSnapshotJob(job)
+		return o.updateSnapshotJob(job)
 	}
+	return nil
 }
 
-func (o *UpdateSnapshotOperation) updateSnapshotJob(snapshotJob *v1beta1.CronJob) {
+func (o *UpdateSnapshotOperation) updateSnapshotJob(snapshotJob *v1beta1.CronJob) error {
 	snapshotJob.Spec.Schedule = o.newSnapshot.Schedule
 	snapshotJob.Spec.JobTemplate.Spec.Template.Spec.Containers[0] = *o.cluster.CreateSnapshotContainer(o.newSnapshot)
 	err := o.clusterAccessor.UpdateCronJob(snapshotJob)
 	if err != nil {
-		log.Errorf("Error while updating snapshot snapshotJob %s for cluster %s: %v", snapshotJob.Name, o.cluster.QualifiedName(), err)
-		return
+		return fmt.Errorf("error while updating snapshot snapshotJob %s for cluster %s: %v", snapshotJob.Name, o.cluster.QualifiedName(), err)
 	}
 	o.eventRecorder.Eventf(o.cluster.Definition(), v1.EventTypeNormal, cluster.ClusterSnapshotCreationModificationEvent, "Snapshot creation modified for cluster %s", o.cluster.QualifiedName())
+	updateCondition(o.clusterAccessor, o.cluster.Definition(), v1alpha1.SnapshotScheduled, v1.ConditionTrue, reasonSnapshotJobScheduled, fmt.Sprintf("snapshot job %s scheduled", snapshotJob.Name))
+	return nil
 }
 
 func (o *UpdateSnapshotOperation) String() string {
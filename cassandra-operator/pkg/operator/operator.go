@@ -18,10 +18,16 @@ import (
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/cluster"
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/dispatcher"
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/metrics"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/nodetool"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/operator/plan"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/operator/webhook"
 	"k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/fields"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/record"
 	"reflect"
 )
 
@@ -33,6 +39,9 @@ type Operator struct {
 	metricsPoller      *metrics.PrometheusMetrics
 	config             *Config
 	eventDispatcher    dispatcher.Dispatcher
+	eventRecorder      record.EventRecorder
+	leaderElector      *leaderelection.LeaderElector
+	namespaceWatches   *namespaceWatches
 	stopCh             chan struct{}
 }
 
@@ -41,12 +50,26 @@ type Config struct {
 	MetricPollInterval    time.Duration
 	MetricRequestDuration time.Duration
 	AllowEmptyDir         bool
+	Webhook               webhook.Config
+	LeaderElection        LeaderElectionConfig
+	// Namespaces are the namespaces in which clusters are managed, all namespaces when empty
+	Namespaces []string
+	// NamespaceSelector selects the namespaces in which clusters are managed, in place of Namespaces
+	NamespaceSelector string
+	// PauseAllClusters holds the changes to the resources of all clusters, as though each of them was paused
+	PauseAllClusters bool
+	// ServePlans serves the plans of proposed cluster changes on the metrics port, which doesn't authenticate its requests
+	ServePlans bool
+	// PodNamespace and PodName identify the pod running this replica, which is labelled with LeaderLabel
+	// while it manages the clusters
+	PodNamespace string
+	PodName      string
 }
 
 const resourceResyncInterval = 5 * time.Minute
 
 // New creates a new Operator.
-func New(kubeClientset *kubernetes.Clientset, cassandraClientset *versioned.Clientset, operatorConfig *Config) *Operator {
+func New(kubeClientset *kubernetes.Clientset, cassandraClientset *versioned.Clientset, restConfig *rest.Config, operatorConfig *Config) *Operator {
 	clusters := make(map[string]*cluster.Cluster)
 	metricsPoller := metrics.NewMetrics(kubeClientset.CoreV1(), &metrics.Config{RequestTimeout: operatorConfig.MetricRequestDuration})
 
@@ -56,6 +79,7 @@ func New(kubeClientset *kubernetes.Clientset, cassandraClientset *versioned.Clie
 		clusters,
 		clusterAccessor,
 		metricsPoller,
+		nodetool.New(kubeClientset, restConfig),
 		eventRecorder,
 	)
 
@@ -66,32 +90,52 @@ func New(kubeClientset *kubernetes.Clientset, cassandraClientset *versioned.Clie
 		cassandraClientset: cassandraClientset,
 		config:             operatorConfig,
 		clusters:           clusters,
-		eventDispatcher:    dispatcher.New(receiver.Receive, stopCh),
+		eventDispatcher:    dispatcher.New(receiver.Receive, dispatcher.DefaultRetryPolicy, stopCh),
+		eventRecorder:      eventRecorder,
 		stopCh:             stopCh,
 		metricsPoller:      metricsPoller,
+		namespaceWatches:   &namespaceWatches{watches: map[string]*namespaceWatch{}},
 	}
 }
 
 // Run starts the Operator.
 func (o *Operator) Run() {
-	ns := os.Getenv("OPERATOR_NAMESPACE")
-	if ns == "" {
-		log.Info("Operator listening for changes in any namespace")
-	} else {
-		log.Infof("Operator listening for changes in namespace %s", ns)
-	}
+	if o.config.LeaderElection.Enabled {
+		leaderElector, err := o.newLeaderElector(o.manageClusters)
+		if err != nil {
+			log.Fatalf("Unable to set up leader election: %v", err)
+		}
+		o.leaderElector = leaderElector
 
-	cassandraInformer := registerCassandraInformer(o, ns)
-	configMapInformer := registerConfigMapInformer(o, ns)
+		// the pod may still be labelled as the leader by a previous run of this replica
+		if err := o.labelAsLeader(false); err != nil {
+			log.Error(err)
+		}
+	}
 
-	o.startServer(o.metricsPoller)
+	o.startWebhookServer()
+	o.startServer()
 	o.addSignalHandler(o.stopCh)
-	cassandraInformer.Start(o.stopCh)
-	configMapInformer.Run(o.stopCh)
+	if o.leaderElector != nil {
+		log.Infof("Operator %s waiting to be elected as leader using lock %s.%s", o.config.LeaderElection.Identity, o.config.LeaderElection.LockNamespace, o.config.LeaderElection.LockName)
+		go o.leaderElector.Run()
+	} else {
+		o.manageClusters()
+	}
 	<-o.stopCh
 	log.Info("Operator shutting down")
 }
 
+// manageClusters starts watching the Cassandra and config map resources in the configured namespaces
+// and polling the metrics of the managed clusters
+func (o *Operator) manageClusters() {
+	if err := o.labelAsLeader(true); err != nil {
+		log.Error(err)
+	}
+	o.startMetricPolling(o.metricsPoller)
+	o.watchNamespaces()
+}
+
 func registerCassandraInformer(o *Operator, ns string) informers.SharedInformerFactory {
 	cassandraInformerFactory := informers.NewSharedInformerFactoryWithOptions(o.cassandraClientset, resourceResyncInterval, informers.WithNamespace(ns))
 	cassandraInformer := cassandraInformerFactory.Core().V1alpha1().Cassandras()
@@ -103,8 +147,11 @@ func registerCassandraInformer(o *Operator, ns string) informers.SharedInformerF
 	return cassandraInformerFactory
 }
 
+// registerConfigMapInformer watches the config maps labelled with the name of a cluster only, so that unrelated config maps are not cached
 func registerConfigMapInformer(o *Operator, ns string) cache.Controller {
-	listWatch := cache.NewListWatchFromClient(o.kubeClientset.CoreV1().RESTClient(), "configmaps", ns, fields.Everything())
+	listWatch := cache.NewFilteredListWatchFromClient(o.kubeClientset.CoreV1().RESTClient(), "configmaps", ns, func(options *metav1.ListOptions) {
+		options.LabelSelector = cluster.OperatorLabel
+	})
 	_, informer := cache.NewInformer(listWatch, &v1.ConfigMap{}, resourceResyncInterval, cache.ResourceEventHandlerFuncs{
 		AddFunc:    o.configMapAdded,
 		UpdateFunc: o.configMapUpdated,
@@ -116,8 +163,7 @@ func registerConfigMapInformer(o *Operator, ns string) cache.Controller {
 func (o *Operator) configMapAdded(obj interface{}) {
 	cm := obj.(*v1.ConfigMap)
 
-	if cluster.LooksLikeACassandraConfigMap(cm) {
-		clusterID := fmt.Sprintf("%s.%s", cm.Namespace, cm.Name)
+	if clusterID, err := cluster.QualifiedClusterNameFor(cm); err == nil {
 		o.eventDispatcher.Dispatch(&dispatcher.Event{Kind: operations.AddCustomConfig, Key: clusterID, Data: cm})
 	}
 }
@@ -125,8 +171,7 @@ func (o *Operator) configMapAdded(obj interface{}) {
 func (o *Operator) configMapDeleted(obj interface{}) {
 	cm := obj.(*v1.ConfigMap)
 
-	if cluster.ConfigMapBelongsToAManagedCluster(o.clusters, cm) {
-		clusterID := fmt.Sprintf("%s.%s", cm.Namespace, cm.Name)
+	if clusterID, err := cluster.QualifiedClusterNameFor(cm); err == nil && cluster.ConfigMapBelongsToAManagedCluster(o.clusters, cm) {
 		o.eventDispatcher.Dispatch(&dispatcher.Event{Kind: operations.DeleteCustomConfig, Key: clusterID, Data: cm})
 	}
 }
@@ -140,8 +185,7 @@ func (o *Operator) configMapUpdated(old interface{}, new interface{}) {
 		return
 	}
 
-	if cluster.ConfigMapBelongsToAManagedCluster(o.clusters, oldConfigMap) {
-		clusterID := fmt.Sprintf("%s.%s", oldConfigMap.Namespace, oldConfigMap.Name)
+	if clusterID, err := cluster.QualifiedClusterNameFor(newConfigMap); err == nil && cluster.ConfigMapBelongsToAManagedCluster(o.clusters, oldConfigMap) {
 		o.eventDispatcher.Dispatch(&dispatcher.Event{Kind: operations.UpdateCustomConfig, Key: clusterID, Data: newConfigMap})
 	}
 }
@@ -149,9 +193,13 @@ func (o *Operator) configMapUpdated(old interface{}, new interface{}) {
 func (o *Operator) clusterAdded(obj interface{}) {
 	clusterDefinition := obj.(*v1alpha1.Cassandra)
 	o.adjustUseEmptyDir(clusterDefinition)
+	o.adjustPaused(clusterDefinition)
 
 	clusterID := fmt.Sprintf("%s.%s", clusterDefinition.Namespace, clusterDefinition.Name)
 	o.eventDispatcher.Dispatch(&dispatcher.Event{Kind: operations.AddCluster, Key: clusterID, Data: clusterDefinition})
+	if len(clusterDefinition.Status.NodeMaintenance) > 0 {
+		o.eventDispatcher.Dispatch(&dispatcher.Event{Kind: operations.NodeMaintenance, Key: operations.NodeMaintenanceKey(clusterID), Data: clusterDefinition})
+	}
 }
 
 func (o *Operator) clusterDeleted(obj interface{}) {
@@ -163,19 +211,52 @@ func (o *Operator) clusterDeleted(obj interface{}) {
 }
 
 func (o *Operator) clusterUpdated(old interface{}, new interface{}) {
-	oldCluster := old.(*v1alpha1.Cassandra)
-	newCluster := new.(*v1alpha1.Cassandra)
+	oldCluster := old.(*v1alpha1.Cassandra).DeepCopy()
+	newCluster := new.(*v1alpha1.Cassandra).DeepCopy()
 	log.Debugf("Cluster update detected for %s.%s, old: %v \nnew: %v", oldCluster.Namespace, oldCluster.Name, oldCluster.Spec, newCluster.Spec)
 
 	o.adjustUseEmptyDir(oldCluster)
 	o.adjustUseEmptyDir(newCluster)
+	o.adjustPaused(oldCluster)
+	o.adjustPaused(newCluster)
+
+	// defaults may or may not have been persisted, depending on whether the defaulting webhook is registered,
+	// so they are applied to both definitions in order to compare like with like
+	cluster.ApplyDefaults(oldCluster)
+	cluster.ApplyDefaults(newCluster)
+
+	clusterID := fmt.Sprintf("%s.%s", newCluster.Namespace, newCluster.Name)
+
+	// pending node maintenance is started as soon as it is recorded, and resumed at each periodic resync in case it was interrupted
+	if (oldCluster.ResourceVersion == newCluster.ResourceVersion && len(newCluster.Status.NodeMaintenance) > 0) || nodeMaintenanceAdded(oldCluster, newCluster) {
+		o.eventDispatcher.Dispatch(&dispatcher.Event{Kind: operations.NodeMaintenance, Key: operations.NodeMaintenanceKey(clusterID), Data: newCluster})
+	}
+
+	// the informer periodically notifies an update with the same version of the cluster definition, which is used to
+	// bring the cluster resources back in line with the definition in case they have been changed since
+	if oldCluster.ResourceVersion == newCluster.ResourceVersion {
+		log.Debugf("Periodic resync of cluster %s.%s, its resources will be reconciled", newCluster.Namespace, newCluster.Name)
+		o.eventDispatcher.Dispatch(&dispatcher.Event{Kind: operations.ReconcileCluster, Key: clusterID, Data: newCluster})
+		return
+	}
 
 	if reflect.DeepEqual(oldCluster.Spec, newCluster.Spec) {
-		log.Debugf("update event received for cluster %s.%s but no changes detected", newCluster.Namespace, newCluster.Name)
+		restartRequested := operations.RestartRequested(oldCluster, newCluster)
+		repairRequested := operations.RepairRequested(oldCluster, newCluster)
+		if restartRequested {
+			log.Infof("Restart requested for cluster %s.%s", newCluster.Namespace, newCluster.Name)
+			o.eventDispatcher.Dispatch(&dispatcher.Event{Kind: operations.RestartCluster, Key: clusterID, Data: newCluster})
+		}
+		if repairRequested {
+			log.Infof("Repair requested for cluster %s.%s", newCluster.Namespace, newCluster.Name)
+			o.eventDispatcher.Dispatch(&dispatcher.Event{Kind: operations.RunRepair, Key: clusterID, Data: newCluster})
+		}
+		if !restartRequested && !repairRequested {
+			log.Debugf("update event received for cluster %s.%s but no changes detected", newCluster.Namespace, newCluster.Name)
+		}
 		return
 	}
 
-	clusterID := fmt.Sprintf("%s.%s", newCluster.Namespace, newCluster.Name)
 	o.eventDispatcher.Dispatch(&dispatcher.Event{
 		Kind: operations.UpdateCluster,
 		Key:  clusterID,
@@ -183,6 +264,24 @@ func (o *Operator) clusterUpdated(old interface{}, new interface{}) {
 	})
 }
 
+// nodeMaintenanceAdded reports whether the status of the cluster records node maintenance which wasn't pending before
+func nodeMaintenanceAdded(oldCluster, newCluster *v1alpha1.Cassandra) bool {
+	pending := map[string]bool{}
+	for _, maintenance := range oldCluster.Status.NodeMaintenance {
+		for _, pod := range maintenance.Pods {
+			pending[fmt.Sprintf("%s/%s", maintenance.Command, pod)] = true
+		}
+	}
+	for _, maintenance := range newCluster.Status.NodeMaintenance {
+		for _, pod := range maintenance.Pods {
+			if !pending[fmt.Sprintf("%s/%s", maintenance.Command, pod)] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (o *Operator) adjustUseEmptyDir(cluster *v1alpha1.Cassandra) {
 	if cluster.Spec.UseEmptyDir && !o.config.AllowEmptyDir {
 		log.Warnf("Cluster %s.%s cannot be configured to use emptyDir, as the operator is configured not to allow the creation of clusters which use emptyDir storage.", cluster.Namespace, cluster.Name)
@@ -190,6 +289,14 @@ func (o *Operator) adjustUseEmptyDir(cluster *v1alpha1.Cassandra) {
 	}
 }
 
+// adjustPaused pauses the cluster when the operator is configured to pause all clusters.
+// The cluster definition is not persisted, so the clusters are unpaused once the operator is restarted without the setting.
+func (o *Operator) adjustPaused(cluster *v1alpha1.Cassandra) {
+	if o.config.PauseAllClusters {
+		cluster.Spec.Paused = true
+	}
+}
+
 func (o *Operator) addSignalHandler(stopCh chan struct{}) {
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
@@ -203,19 +310,40 @@ func (o *Operator) addSignalHandler(stopCh chan struct{}) {
 	}()
 }
 
-func (o *Operator) startServer(metricsPoller *metrics.PrometheusMetrics) {
-	o.startMetricPolling(metricsPoller)
+func (o *Operator) startServer() {
 	statusCheck := newStatusCheck()
 	http.Handle("/metrics", promhttp.Handler())
-	http.HandleFunc("/live", livenessAndReadinessCheck)
-	http.HandleFunc("/ready", livenessAndReadinessCheck)
+	http.HandleFunc("/live", livenessCheck)
+	http.HandleFunc("/ready", readinessCheck)
 	http.HandleFunc("/status", statusCheck.statusPage)
+	if o.config.ServePlans {
+		if planner, err := plan.New(); err != nil {
+			log.Errorf("Plans of cluster changes will not be served: %v", err)
+		} else {
+			http.Handle(plan.Path, planner.Handler(o.cassandraClientset))
+		}
+	}
 	go func() {
 		log.Error(http.ListenAndServe(":9090", nil))
 		os.Exit(0)
 	}()
 }
 
+func (o *Operator) startWebhookServer() {
+	if o.config.Webhook.BindAddress == "" {
+		log.Info("Admission webhooks are disabled")
+		return
+	}
+
+	webhookServer, err := webhook.New(&o.config.Webhook)
+	if err != nil {
+		log.Fatalf("Unable to create admission webhook server: %v", err)
+	}
+	if err := webhookServer.Start(); err != nil {
+		log.Fatalf("Unable to serve admission webhooks: %v", err)
+	}
+}
+
 func (o *Operator) startMetricPolling(metricsPoller *metrics.PrometheusMetrics) {
 	go func() {
 		for {
@@ -230,6 +358,13 @@ func (o *Operator) startMetricPolling(metricsPoller *metrics.PrometheusMetrics)
 	}()
 }
 
-func livenessAndReadinessCheck(resp http.ResponseWriter, _ *http.Request) {
+func livenessCheck(resp http.ResponseWriter, _ *http.Request) {
+	resp.WriteHeader(http.StatusNoContent)
+}
+
+// readinessCheck reports every replica as ready, whether or not it is the leader, as it is only served
+// once the admission webhooks are, and the webhooks are served by every replica.
+// Requests which only the leader can answer are routed to it by selecting its LeaderLabel instead.
+func readinessCheck(resp http.ResponseWriter, _ *http.Request) {
 	resp.WriteHeader(http.StatusNoContent)
 }
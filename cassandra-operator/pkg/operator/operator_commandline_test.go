@@ -1,7 +1,10 @@
 package operator
 
 import (
+	"fmt"
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/test"
+	"io/ioutil"
+	"os"
 	"os/exec"
 	"testing"
 
@@ -36,6 +39,86 @@ var _ = Describe("operator command line", func() {
 		})
 	})
 
+	Describe("--webhook-bind-address", func() {
+		It("should require the TLS certificate and key when serving admission webhooks", func() {
+			output, err := exec.Command("cassandra-operator", "--webhook-bind-address=:8443", "--webhook-tls-cert-file=/tls/tls.crt").CombinedOutput()
+			Expect(err).To(HaveOccurred())
+			Expect(string(output)).To(ContainSubstring("webhook-tls-cert-file and webhook-tls-key-file must be supplied when webhook-bind-address is set"))
+		})
+	})
+
+	Describe("--namespace-selector", func() {
+		It("should reject a namespace selector which is not a valid label selector", func() {
+			output, err := exec.Command("cassandra-operator", "--namespace-selector=cassandra in enabled").CombinedOutput()
+			Expect(err).To(HaveOccurred())
+			Expect(string(output)).To(ContainSubstring("invalid namespace-selector"))
+		})
+		It("should reject a namespace selector supplied together with namespaces", func() {
+			output, err := exec.Command("cassandra-operator", "--namespace-selector=cassandra=enabled", "--namespaces=ns1,ns2").CombinedOutput()
+			Expect(err).To(HaveOccurred())
+			Expect(string(output)).To(ContainSubstring("namespaces and namespace-selector cannot be supplied together"))
+		})
+	})
+
+	Describe("--leader-elect", func() {
+		It("should require the namespace of the lock when the operator namespace is unknown", func() {
+			cmd := exec.Command("cassandra-operator", "--leader-elect")
+			cmd.Env = []string{}
+			output, err := cmd.CombinedOutput()
+			Expect(err).To(HaveOccurred())
+			Expect(string(output)).To(ContainSubstring("leader-elect-namespace must be supplied"))
+		})
+		It("should reject a renew deadline which is not shorter than the lease duration", func() {
+			output, err := exec.Command("cassandra-operator", "--leader-elect", "--leader-elect-namespace=test", "--leader-elect-lease-duration=10s", "--leader-elect-renew-deadline=10s").CombinedOutput()
+			Expect(err).To(HaveOccurred())
+			Expect(string(output)).To(ContainSubstring("leader-elect-lease-duration must be greater than leader-elect-renew-deadline"))
+		})
+		It("should reject a retry period which is not shorter than the renew deadline", func() {
+			output, err := exec.Command("cassandra-operator", "--leader-elect", "--leader-elect-namespace=test", "--leader-elect-retry-period=10s").CombinedOutput()
+			Expect(err).To(HaveOccurred())
+			Expect(string(output)).To(ContainSubstring("leader-elect-renew-deadline must be greater than leader-elect-retry-period"))
+		})
+	})
+
+	Describe("--kubeconfig", func() {
+		It("should report a kubeconfig file which cannot be loaded", func() {
+			output, err := exec.Command("cassandra-operator", "--kubeconfig=/does/not/exist").CombinedOutput()
+			Expect(err).To(HaveOccurred())
+			Expect(string(output)).To(ContainSubstring("Unable to obtain Kubernetes config"))
+		})
+	})
+
+	Describe("plan", func() {
+		const clusterDefinition = `{"metadata": {"name": "mycluster"}, "spec": {"dc": "%s", "racks": [{"name": "a", "replicas": 1, "storageClass": "some-storage", "zone": "some-zone"}], "pod": {"memory": "1Gi", "cpu": "100m", "storageSize": "1Gi"}}}`
+		var proposedFile string
+
+		BeforeEach(func() {
+			proposedFile = writeTempFile(fmt.Sprintf(clusterDefinition, "dc1"))
+		})
+
+		AfterEach(func() {
+			os.Remove(proposedFile)
+		})
+
+		It("should print the changes needed to create a cluster which doesn't exist yet", func() {
+			output, err := exec.Command("cassandra-operator", "plan", "-f", proposedFile, "--current=/dev/null").Output()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(output)).To(ContainSubstring(`"cluster": "default.mycluster"`))
+			Expect(string(output)).To(ContainSubstring(`"changeType": "add rack"`))
+			Expect(string(output)).To(ContainSubstring(`"estimatedRestarts": 0`))
+		})
+
+		It("should fail when the proposed cluster definition contains forbidden changes", func() {
+			currentFile := writeTempFile(fmt.Sprintf(clusterDefinition, "other-dc"))
+			defer os.Remove(currentFile)
+
+			output, err := exec.Command("cassandra-operator", "plan", "-f", proposedFile, "--current", currentFile).CombinedOutput()
+			Expect(err).To(HaveOccurred())
+			Expect(string(output)).To(ContainSubstring("changing dc is forbidden. The dc used will continue to be 'other-dc'"))
+			Expect(string(output)).To(ContainSubstring("the proposed Cassandra resource would be rejected"))
+		})
+	})
+
 	Describe("--log-level", func() {
 		It("should reject unknown log level", func() {
 			output, err := exec.Command("cassandra-operator", "--log-level=debugwrong").CombinedOutput()
@@ -44,3 +127,12 @@ var _ = Describe("operator command line", func() {
 		})
 	})
 })
+
+func writeTempFile(content string) string {
+	file, err := ioutil.TempFile("", "cassandra")
+	Expect(err).ToNot(HaveOccurred())
+	defer file.Close()
+	_, err = file.WriteString(content)
+	Expect(err).ToNot(HaveOccurred())
+	return file.Name()
+}
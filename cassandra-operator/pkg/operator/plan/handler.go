@@ -0,0 +1,73 @@
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/apis/cassandra/v1alpha1"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/client/clientset/versioned"
+	"io/ioutil"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"net/http"
+)
+
+const (
+	// Path is the path on which the plans of proposed cluster definitions are served
+	Path = "/plan"
+	// MaxRequestBytes is the size above which a posted Cassandra resource is rejected
+	MaxRequestBytes = 1 << 20
+)
+
+// Handler serves the plan of the Cassandra resource posted as YAML or JSON,
+// which is compared with the definition of the cluster stored in Kubernetes
+func (p *Planner) Handler(cassandraClient versioned.Interface) http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			resp.Header().Set("Allow", http.MethodPost)
+			http.Error(resp, "the proposed Cassandra resource must be posted", http.StatusMethodNotAllowed)
+			return
+		}
+
+		body, err := ioutil.ReadAll(http.MaxBytesReader(resp, req.Body, MaxRequestBytes))
+		if err != nil {
+			http.Error(resp, fmt.Sprintf("unable to read request body: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		proposed, err := DecodeCassandra(body)
+		if err != nil {
+			http.Error(resp, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		current, err := CurrentDefinition(cassandraClient, proposed.Namespace, proposed.Name)
+		if err != nil {
+			log.Errorf("Unable to plan change to cluster %s: %v", proposed.QualifiedName(), err)
+			http.Error(resp, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		responseBody, err := json.MarshalIndent(p.PlanChange(current, proposed), "", "  ")
+		if err != nil {
+			log.Errorf("Unable to encode plan for cluster %s: %v", proposed.QualifiedName(), err)
+			http.Error(resp, "unable to encode plan", http.StatusInternalServerError)
+			return
+		}
+
+		resp.Header().Set("Content-Type", "application/json")
+		resp.Write(responseBody)
+	})
+}
+
+// CurrentDefinition retrieves the definition of the cluster stored in Kubernetes, which is nil when the cluster doesn't exist
+func CurrentDefinition(cassandraClient versioned.Interface, namespace, name string) (*v1alpha1.Cassandra, error) {
+	cassandra, err := cassandraClient.CoreV1alpha1().Cassandras(namespace).Get(name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve cluster %s.%s: %v", namespace, name, err)
+	}
+	return cassandra, nil
+}
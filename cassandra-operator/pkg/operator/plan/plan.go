@@ -0,0 +1,176 @@
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ghodss/yaml"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/apis/cassandra/v1alpha1"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/cluster"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/operator/operations"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/operator/operations/adjuster"
+)
+
+// RestartRack means that the pods of an existing rack are restarted one at a time, as requested by the restartedAt annotation.
+// The stateful set changes themselves are the ones described by the adjuster.ClusterChangeType.
+const RestartRack adjuster.ClusterChangeType = "restart rack"
+
+// Plan describes what the operator would do to a cluster in order to apply a proposed cluster definition
+type Plan struct {
+	// Cluster is the qualified name of the cluster
+	Cluster string `json:"cluster"`
+	// Exists is false when the cluster doesn't exist yet, in which case all its racks are added
+	Exists bool `json:"exists"`
+	// InvalidDefinition is the reason why the proposed cluster definition would be rejected, in which case nothing is changed
+	InvalidDefinition string `json:"invalidDefinition,omitempty"`
+	// ForbiddenChanges are the changes the operator refuses to make, in which case none of the rack changes are applied
+	ForbiddenChanges []string `json:"forbiddenChanges,omitempty"`
+	// Changes are the changes to the racks of the cluster, in the order in which they are applied
+	Changes []Change `json:"changes"`
+	// EstimatedRestarts is the number of times an existing pod is restarted while the changes are applied,
+	// not counting the restarts which depend on the storage provider, given by PossibleRestarts
+	EstimatedRestarts int `json:"estimatedRestarts"`
+	// PossibleRestarts is the number of times a pod may also be restarted so that the file system of its expanded volume
+	// is resized, which only happens when the storage provider cannot resize it while the volume is mounted
+	PossibleRestarts int `json:"possibleRestarts,omitempty"`
+}
+
+// Change describes a single change to a rack of the cluster
+type Change struct {
+	Rack                string                     `json:"rack"`
+	ChangeType          adjuster.ClusterChangeType `json:"changeType"`
+	Patch               json.RawMessage            `json:"patch,omitempty"`
+	NodesToScaleUp      int                        `json:"nodesToScaleUp,omitempty"`
+	NodesToScaleDown    int                        `json:"nodesToScaleDown,omitempty"`
+	PodRestarts         int                        `json:"podRestarts"`
+	PossiblePodRestarts int                        `json:"possiblePodRestarts,omitempty"`
+}
+
+// Planner works out the plan of a proposed change to a cluster, without applying anything
+type Planner struct {
+	adjuster *adjuster.Adjuster
+}
+
+// New creates a new Planner
+func New() (*Planner, error) {
+	adj, err := adjuster.New()
+	if err != nil {
+		return nil, fmt.Errorf("unable to initialise Adjuster: %v", err)
+	}
+	return &Planner{adjuster: adj}, nil
+}
+
+// PlanChange works out what the operator would do to change the current cluster definition into the proposed one.
+// The current cluster definition is nil when the cluster doesn't exist yet.
+func (p *Planner) PlanChange(current, proposed *v1alpha1.Cassandra) *Plan {
+	proposed = proposed.DeepCopy()
+	cluster.ApplyDefaults(proposed)
+
+	plan := &Plan{Cluster: proposed.QualifiedName(), Exists: current != nil, Changes: []Change{}}
+	if err := cluster.Validate(proposed); err != nil {
+		plan.InvalidDefinition = err.Error()
+		return plan
+	}
+
+	if current == nil {
+		// a new cluster is compared with an empty one, so that all its racks are added
+		current = proposed.DeepCopy()
+		current.Spec.Racks = nil
+	} else {
+		current = current.DeepCopy()
+		cluster.ApplyDefaults(current)
+	}
+
+	plan.ForbiddenChanges = p.adjuster.ForbiddenChanges(&current.Spec, &proposed.Spec)
+	if len(plan.ForbiddenChanges) == 0 {
+		// the change is allowed, so the adjuster cannot fail
+		clusterChanges, _ := p.adjuster.ChangesForCluster(proposed.Name, &current.Spec, &proposed.Spec)
+		for _, clusterChange := range clusterChanges {
+			rollingRestart := p.adjuster.RequiresRollingRestart(&current.Spec, &proposed.Spec, clusterChange.Rack.Name)
+			plan.addChange(Change{
+				Rack:                clusterChange.Rack.Name,
+				ChangeType:          clusterChange.ChangeType,
+				Patch:               patchContent(clusterChange.Patch),
+				NodesToScaleUp:      clusterChange.NodesToScaleUp,
+				NodesToScaleDown:    clusterChange.NodesToScaleDown,
+				PodRestarts:         podRestarts(&clusterChange, rollingRestart),
+				PossiblePodRestarts: possiblePodRestarts(&clusterChange),
+			})
+		}
+	}
+
+	// a restart is carried out once the rack changes have been applied, and restarts the pods of all existing racks
+	// whether or not they were just restarted
+	if plan.Exists && operations.RestartRequested(current, proposed) {
+		for _, rack := range proposed.Spec.Racks {
+			if hasRack(current.Spec.Racks, rack.Name) {
+				plan.addChange(Change{Rack: rack.Name, ChangeType: RestartRack, PodRestarts: int(rack.Replicas)})
+			}
+		}
+	}
+	return plan
+}
+
+func (p *Plan) addChange(change Change) {
+	p.Changes = append(p.Changes, change)
+	p.EstimatedRestarts += change.PodRestarts
+	p.PossibleRestarts += change.PossiblePodRestarts
+}
+
+// podRestarts is the number of existing pods of the rack restarted by the change.
+// Pods added by a scale-up start with the new pod template, so they are not restarted.
+func podRestarts(clusterChange *adjuster.ClusterChange, rollingRestart bool) int {
+	switch clusterChange.ChangeType {
+	case adjuster.UpgradeRack:
+		return int(clusterChange.Rack.Replicas) - clusterChange.NodesToScaleUp
+	case adjuster.UpdateRack:
+		if rollingRestart {
+			return int(clusterChange.Rack.Replicas) - clusterChange.NodesToScaleUp
+		}
+	}
+	return 0
+}
+
+// possiblePodRestarts is the number of pods of the rack which are restarted by the change when the storage provider reports
+// FileSystemResizePending on their expanded claims. Claims of the pods added by a scale-up are expanded too.
+func possiblePodRestarts(clusterChange *adjuster.ClusterChange) int {
+	if clusterChange.ChangeType == adjuster.ResizeRackStorage {
+		return int(clusterChange.Rack.Replicas)
+	}
+	return 0
+}
+
+// patchContent embeds the patch as JSON, so that it is readable in the plan
+func patchContent(patch string) json.RawMessage {
+	if patch == "" {
+		return nil
+	}
+	if !json.Valid([]byte(patch)) {
+		content, _ := json.Marshal(patch)
+		return content
+	}
+	return json.RawMessage(patch)
+}
+
+func hasRack(racks []v1alpha1.Rack, name string) bool {
+	for _, rack := range racks {
+		if rack.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// DecodeCassandra decodes a Cassandra resource given either as YAML or as JSON
+func DecodeCassandra(content []byte) (*v1alpha1.Cassandra, error) {
+	cassandra := &v1alpha1.Cassandra{}
+	if err := yaml.Unmarshal(content, cassandra); err != nil {
+		return nil, fmt.Errorf("unable to decode Cassandra resource: %v", err)
+	}
+	if cassandra.Name == "" {
+		return nil, fmt.Errorf("the Cassandra resource has no name")
+	}
+	if cassandra.Namespace == "" {
+		cassandra.Namespace = "default"
+	}
+	return cassandra, nil
+}
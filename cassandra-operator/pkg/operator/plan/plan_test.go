@@ -0,0 +1,237 @@
+package plan
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/apis/cassandra/v1alpha1"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/client/clientset/versioned/fake"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/cluster"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/operator/operations/adjuster"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/test"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPlan(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecsWithDefaultAndCustomReporters(t, "Plan Suite", test.CreateParallelReporters("plan"))
+}
+
+var _ = Describe("plan of a change to a cluster", func() {
+	var (
+		planner  *Planner
+		current  *v1alpha1.Cassandra
+		proposed *v1alpha1.Cassandra
+	)
+
+	BeforeEach(func() {
+		var err error
+		planner, err = New()
+		Expect(err).ToNot(HaveOccurred())
+
+		current = &v1alpha1.Cassandra{
+			ObjectMeta: metav1.ObjectMeta{Name: "mycluster", Namespace: "mynamespace"},
+			Spec: v1alpha1.CassandraSpec{
+				Racks: []v1alpha1.Rack{
+					{Name: "a", Replicas: 3, StorageClass: "some-storage", Zone: "some-zone"},
+					{Name: "b", Replicas: 3, StorageClass: "some-storage", Zone: "some-zone"},
+				},
+				Pod: v1alpha1.Pod{
+					Memory:      resource.MustParse("1Gi"),
+					CPU:         resource.MustParse("100m"),
+					StorageSize: resource.MustParse("1Gi"),
+				},
+			},
+		}
+		proposed = current.DeepCopy()
+	})
+
+	It("should contain no changes when the cluster definition is unchanged", func() {
+		plan := planner.PlanChange(current, proposed)
+
+		Expect(plan.Exists).To(BeTrue())
+		Expect(plan.Changes).To(BeEmpty())
+		Expect(plan.EstimatedRestarts).To(Equal(0))
+	})
+
+	It("should add all racks of a cluster which doesn't exist yet", func() {
+		plan := planner.PlanChange(nil, proposed)
+
+		Expect(plan.Exists).To(BeFalse())
+		Expect(plan.Changes).To(Equal([]Change{{Rack: "a", ChangeType: adjuster.AddRack}, {Rack: "b", ChangeType: adjuster.AddRack}}))
+		Expect(plan.EstimatedRestarts).To(Equal(0))
+	})
+
+	It("should restart the existing pods of each rack when the pod spec changes", func() {
+		proposed.Spec.Pod.Memory = resource.MustParse("2Gi")
+		proposed.Spec.Racks[0].Replicas = 4
+
+		plan := planner.PlanChange(current, proposed)
+
+		Expect(plan.Changes).To(HaveLen(2))
+		Expect(plan.Changes[0].Rack).To(Equal("a"))
+		Expect(plan.Changes[0].ChangeType).To(Equal(adjuster.UpdateRack))
+		Expect(plan.Changes[0].NodesToScaleUp).To(Equal(1))
+		Expect(plan.Changes[0].PodRestarts).To(Equal(3))
+		Expect(plan.Changes[1].PodRestarts).To(Equal(3))
+		Expect(plan.EstimatedRestarts).To(Equal(6))
+
+		var patch map[string]interface{}
+		Expect(json.Unmarshal(plan.Changes[0].Patch, &patch)).To(Succeed())
+		Expect(patch).To(HaveKey("spec"))
+	})
+
+	It("should not restart any pod when a rack is only scaled", func() {
+		proposed.Spec.Racks[0].Replicas = 5
+		proposed.Spec.Racks[1].Replicas = 2
+
+		plan := planner.PlanChange(current, proposed)
+
+		Expect(plan.Changes).To(HaveLen(2))
+		Expect(plan.Changes[0]).To(Equal(Change{Rack: "b", ChangeType: adjuster.ScaleDownRack, Patch: []byte(`{"spec": {"replicas": 2}}`), NodesToScaleDown: 1}))
+		Expect(plan.Changes[1].ChangeType).To(Equal(adjuster.UpdateRack))
+		Expect(plan.Changes[1].NodesToScaleUp).To(Equal(2))
+		Expect(plan.EstimatedRestarts).To(Equal(0))
+	})
+
+	It("should only restart the pods of the rack whose scheduling changes", func() {
+		proposed.Spec.Racks[1].Tolerations = []v1.Toleration{{Key: "dedicated", Operator: v1.TolerationOpExists}}
+
+		plan := planner.PlanChange(current, proposed)
+
+		Expect(plan.Changes).To(HaveLen(1))
+		Expect(plan.Changes[0].Rack).To(Equal("b"))
+		Expect(plan.Changes[0].ChangeType).To(Equal(adjuster.UpdateRack))
+		Expect(plan.Changes[0].PodRestarts).To(Equal(3))
+		Expect(plan.EstimatedRestarts).To(Equal(3))
+	})
+
+	It("should report the restarts of a storage resize as possible, as they depend on the storage provider", func() {
+		proposed.Spec.Pod.StorageSize = resource.MustParse("2Gi")
+		proposed.Spec.Racks[0].Replicas = 4
+
+		plan := planner.PlanChange(current, proposed)
+
+		Expect(plan.Changes).To(HaveLen(3))
+		Expect(plan.Changes[0].ChangeType).To(Equal(adjuster.UpdateRack))
+		Expect(plan.Changes[0].PodRestarts).To(Equal(0))
+		Expect(plan.Changes[1]).To(Equal(Change{Rack: "a", ChangeType: adjuster.ResizeRackStorage, PossiblePodRestarts: 4}))
+		Expect(plan.Changes[2]).To(Equal(Change{Rack: "b", ChangeType: adjuster.ResizeRackStorage, PossiblePodRestarts: 3}))
+		Expect(plan.EstimatedRestarts).To(Equal(0))
+		Expect(plan.PossibleRestarts).To(Equal(7))
+	})
+
+	It("should report all forbidden changes, in which case no rack is changed", func() {
+		proposed.Spec.DC = "other-dc"
+		proposed.Spec.Racks[0].Zone = "other-zone"
+		proposed.Spec.Pod.Memory = resource.MustParse("2Gi")
+
+		plan := planner.PlanChange(current, proposed)
+
+		Expect(plan.ForbiddenChanges).To(Equal([]string{
+			"changing dc is forbidden. The dc used will continue to be 'dc1'",
+			"changing zone for rack 'a' is forbidden. The zone used will continue to be 'some-zone'",
+		}))
+		Expect(plan.Changes).To(BeEmpty())
+		Expect(plan.EstimatedRestarts).To(Equal(0))
+	})
+
+	It("should report an invalid cluster definition", func() {
+		proposed.Spec.Racks = nil
+
+		plan := planner.PlanChange(current, proposed)
+
+		Expect(plan.InvalidDefinition).To(Equal("no racks specified for cluster: mynamespace.mycluster"))
+		Expect(plan.Changes).To(BeEmpty())
+	})
+
+	It("should restart all pods of the existing racks when a restart is requested", func() {
+		proposed.Annotations = map[string]string{cluster.RestartedAtAnnotation: "2019-01-01T00:00:00Z"}
+		proposed.Spec.Racks = append(proposed.Spec.Racks, v1alpha1.Rack{Name: "c", Replicas: 2, StorageClass: "some-storage", Zone: "some-zone"})
+
+		plan := planner.PlanChange(current, proposed)
+
+		Expect(plan.Changes).To(Equal([]Change{
+			{Rack: "c", ChangeType: adjuster.AddRack},
+			{Rack: "a", ChangeType: RestartRack, PodRestarts: 3},
+			{Rack: "b", ChangeType: RestartRack, PodRestarts: 3},
+		}))
+		Expect(plan.EstimatedRestarts).To(Equal(6))
+	})
+
+	Context("served over HTTP", func() {
+		var server *httptest.Server
+
+		BeforeEach(func() {
+			server = httptest.NewServer(planner.Handler(fake.NewSimpleClientset(current)))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("should compare the posted Cassandra resource with the stored one", func() {
+			resp, err := http.Post(server.URL+Path, "application/yaml", strings.NewReader(`
+apiVersion: core.sky.uk/v1alpha1
+kind: Cassandra
+metadata:
+  name: mycluster
+  namespace: mynamespace
+spec:
+  racks:
+  - name: a
+    replicas: 3
+    storageClass: some-storage
+    zone: some-zone
+  pod:
+    memory: 1Gi
+    cpu: 100m
+    storageSize: 1Gi
+`))
+			Expect(err).ToNot(HaveOccurred())
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+			plan := &Plan{}
+			Expect(json.NewDecoder(resp.Body).Decode(plan)).To(Succeed())
+			Expect(plan.Exists).To(BeTrue())
+			Expect(plan.Changes).To(HaveLen(1))
+			Expect(plan.Changes[0].Rack).To(Equal("b"))
+			Expect(plan.Changes[0].ChangeType).To(Equal(adjuster.DeleteRack))
+		})
+
+		It("should plan the creation of a cluster which doesn't exist", func() {
+			resp, err := http.Post(server.URL+Path, "application/json", strings.NewReader(`{"metadata": {"name": "newcluster"}, "spec": {"racks": [{"name": "a", "replicas": 1, "storageClass": "some-storage", "zone": "some-zone"}], "pod": {"memory": "1Gi", "cpu": "100m", "storageSize": "1Gi"}}}`))
+			Expect(err).ToNot(HaveOccurred())
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+			plan := &Plan{}
+			Expect(json.NewDecoder(resp.Body).Decode(plan)).To(Succeed())
+			Expect(plan.Cluster).To(Equal("default.newcluster"))
+			Expect(plan.Exists).To(BeFalse())
+			Expect(plan.Changes).To(Equal([]Change{{Rack: "a", ChangeType: adjuster.AddRack}}))
+		})
+
+		It("should reject a request whose body is too large", func() {
+			resp, err := http.Post(server.URL+Path, "application/yaml", strings.NewReader(strings.Repeat("#", MaxRequestBytes+1)))
+			Expect(err).ToNot(HaveOccurred())
+			resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+		})
+
+		It("should reject a request which isn't a post", func() {
+			resp, err := http.Get(server.URL + Path)
+			Expect(err).ToNot(HaveOccurred())
+			resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusMethodNotAllowed))
+		})
+	})
+})
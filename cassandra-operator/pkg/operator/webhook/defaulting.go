@@ -0,0 +1,69 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/apis/cassandra/v1alpha1"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/cluster"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+)
+
+const (
+	// DefaultPath is the path on which defaults are set on Cassandra resources
+	DefaultPath = "/default"
+)
+
+// patchOperation is a single JSON patch operation, see http://jsonpatch.com
+type patchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+}
+
+func (s *Server) setDefaults(request *admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionResponse {
+	cassandra, err := decodeCassandra(request.Object.Raw)
+	if err != nil {
+		return denied(err)
+	}
+
+	patch := defaultingPatch(cassandra)
+	if len(patch) == 0 {
+		return allowed()
+	}
+
+	patchBytes, err := json.Marshal(patch)
+	if err != nil {
+		return denied(fmt.Errorf("unable to encode defaulting patch: %v", err))
+	}
+
+	patchType := admissionv1beta1.PatchTypeJSONPatch
+	return &admissionv1beta1.AdmissionResponse{Allowed: true, Patch: patchBytes, PatchType: &patchType}
+}
+
+// defaultingPatch produces the JSON patch which writes the defaults of all unset optional properties into the Cassandra resource
+func defaultingPatch(cassandra *v1alpha1.Cassandra) []patchOperation {
+	defaulted := cassandra.DeepCopy()
+	cluster.ApplyDefaults(defaulted)
+
+	var patch []patchOperation
+	addIfDefaulted := func(path string, value, defaultedValue interface{}) {
+		if !reflect.DeepEqual(value, defaultedValue) {
+			patch = append(patch, patchOperation{Op: "add", Path: path, Value: defaultedValue})
+		}
+	}
+
+	addIfDefaulted("/spec/dc", cassandra.Spec.DC, defaulted.Spec.DC)
+	addIfDefaulted("/spec/pod/image", cassandra.Spec.Pod.Image, defaulted.Spec.Pod.Image)
+	addIfDefaulted("/spec/pod/bootstrapperImage", cassandra.Spec.Pod.BootstrapperImage, defaulted.Spec.Pod.BootstrapperImage)
+	addIfDefaulted("/spec/pod/livenessProbe", cassandra.Spec.Pod.LivenessProbe, defaulted.Spec.Pod.LivenessProbe)
+	addIfDefaulted("/spec/pod/readinessProbe", cassandra.Spec.Pod.ReadinessProbe, defaulted.Spec.Pod.ReadinessProbe)
+	if cassandra.Spec.Snapshot != nil {
+		addIfDefaulted("/spec/snapshot/image", cassandra.Spec.Snapshot.Image, defaulted.Spec.Snapshot.Image)
+	}
+	if cassandra.Spec.Repair != nil {
+		addIfDefaulted("/spec/repair/image", cassandra.Spec.Repair.Image, defaulted.Spec.Repair.Image)
+	}
+	return patch
+}
@@ -0,0 +1,175 @@
+package webhook
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"reflect"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/apis/cassandra/v1alpha1"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/cluster"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/operator/operations/adjuster"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ValidatePath is the path on which Cassandra resources are validated
+	ValidatePath = "/validate"
+)
+
+// Config contains options controlling how the admission webhooks are served
+type Config struct {
+	BindAddress string
+	CertFile    string
+	KeyFile     string
+}
+
+// Server serves the admission webhooks for Cassandra resources
+type Server struct {
+	config   *Config
+	adjuster *adjuster.Adjuster
+}
+
+type admitFunc func(*admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionResponse
+
+// New creates a new admission webhook Server
+func New(config *Config) (*Server, error) {
+	adj, err := adjuster.New()
+	if err != nil {
+		return nil, fmt.Errorf("unable to initialise Adjuster: %v", err)
+	}
+	return &Server{config: config, adjuster: adj}, nil
+}
+
+// Start serves the admission webhooks over HTTPS in the background,
+// once their certificate has been loaded and their address is listened on
+func (s *Server) Start() error {
+	certificate, err := tls.LoadX509KeyPair(s.config.CertFile, s.config.KeyFile)
+	if err != nil {
+		return fmt.Errorf("unable to load certificate: %v", err)
+	}
+	listener, err := net.Listen("tcp", s.config.BindAddress)
+	if err != nil {
+		return fmt.Errorf("unable to listen on %s: %v", s.config.BindAddress, err)
+	}
+
+	server := &http.Server{Handler: s.Handler(), TLSConfig: &tls.Config{Certificates: []tls.Certificate{certificate}}}
+	go func() {
+		log.Infof("Serving admission webhooks on %s", s.config.BindAddress)
+		log.Fatal(server.ServeTLS(listener, "", ""))
+	}()
+	return nil
+}
+
+// Handler returns the http.Handler serving all the admission webhooks
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(ValidatePath, func(resp http.ResponseWriter, req *http.Request) {
+		serveAdmissionReview(resp, req, s.validate)
+	})
+	mux.HandleFunc(DefaultPath, func(resp http.ResponseWriter, req *http.Request) {
+		serveAdmissionReview(resp, req, s.setDefaults)
+	})
+	return mux
+}
+
+func (s *Server) validate(request *admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionResponse {
+	cassandra, err := decodeCassandra(request.Object.Raw)
+	if err != nil {
+		return denied(err)
+	}
+
+	if request.Operation == admissionv1beta1.Update {
+		oldCassandra, err := decodeCassandra(request.OldObject.Raw)
+		if err != nil {
+			return denied(err)
+		}
+
+		// the stored object may predate the defaulting webhook, so defaults are applied to both
+		// definitions in order to compare like with like
+		oldCassandra = withDefaults(oldCassandra)
+		cassandra = withDefaults(cassandra)
+
+		if reflect.DeepEqual(oldCassandra.Spec, cassandra.Spec) {
+			return allowed()
+		}
+
+		if err := s.adjuster.ValidateChange(&oldCassandra.Spec, &cassandra.Spec); err != nil {
+			log.Infof("Rejecting change to cluster %s: %v", cassandra.QualifiedName(), err)
+			return denied(err)
+		}
+	}
+
+	if err := cluster.Validate(cassandra); err != nil {
+		log.Infof("Rejecting invalid cluster definition %s: %v", cassandra.QualifiedName(), err)
+		return denied(err)
+	}
+
+	return allowed()
+}
+
+func serveAdmissionReview(resp http.ResponseWriter, req *http.Request, admit admitFunc) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		http.Error(resp, fmt.Sprintf("unable to read request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	review := &admissionv1beta1.AdmissionReview{}
+	if err := json.Unmarshal(body, review); err != nil {
+		http.Error(resp, fmt.Sprintf("unable to decode admission review: %v", err), http.StatusBadRequest)
+		return
+	}
+	if review.Request == nil {
+		http.Error(resp, "admission review contains no request", http.StatusBadRequest)
+		return
+	}
+
+	review.Response = admit(review.Request)
+	review.Response.UID = review.Request.UID
+	review.Request = nil
+
+	responseBody, err := json.Marshal(review)
+	if err != nil {
+		log.Errorf("Unable to encode admission review response: %v", err)
+		http.Error(resp, "unable to encode admission review response", http.StatusInternalServerError)
+		return
+	}
+
+	resp.Header().Set("Content-Type", "application/json")
+	resp.Write(responseBody)
+}
+
+func decodeCassandra(raw []byte) (*v1alpha1.Cassandra, error) {
+	cassandra := &v1alpha1.Cassandra{}
+	if err := json.Unmarshal(raw, cassandra); err != nil {
+		return nil, fmt.Errorf("unable to decode Cassandra resource: %v", err)
+	}
+	return cassandra, nil
+}
+
+func withDefaults(cassandra *v1alpha1.Cassandra) *v1alpha1.Cassandra {
+	defaulted := cassandra.DeepCopy()
+	cluster.ApplyDefaults(defaulted)
+	return defaulted
+}
+
+func allowed() *admissionv1beta1.AdmissionResponse {
+	return &admissionv1beta1.AdmissionResponse{Allowed: true}
+}
+
+func denied(err error) *admissionv1beta1.AdmissionResponse {
+	return &admissionv1beta1.AdmissionResponse{
+		Allowed: false,
+		Result: &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Reason:  metav1.StatusReasonInvalid,
+			Message: err.Error(),
+		},
+	}
+}
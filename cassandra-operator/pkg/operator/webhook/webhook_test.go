@@ -0,0 +1,275 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/apis/cassandra/v1alpha1"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/cluster"
+	"github.com/sky-uk/cassandra-operator/cassandra-operator/test"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestWebhook(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecsWithDefaultAndCustomReporters(t, "Webhook Suite", test.CreateParallelReporters("webhook"))
+}
+
+var _ = Describe("validating admission webhook", func() {
+	var (
+		server     *Server
+		clusterDef *v1alpha1.Cassandra
+	)
+
+	BeforeEach(func() {
+		var err error
+		server, err = New(&Config{})
+		Expect(err).ToNot(HaveOccurred())
+
+		clusterDef = &v1alpha1.Cassandra{
+			ObjectMeta: metav1.ObjectMeta{Name: "mycluster", Namespace: "mynamespace"},
+			Spec: v1alpha1.CassandraSpec{
+				Racks: []v1alpha1.Rack{{Name: "a", Replicas: 1, StorageClass: "some-storage", Zone: "some-zone"}},
+				Pod: v1alpha1.Pod{
+					Memory:      resource.MustParse("1Gi"),
+					CPU:         resource.MustParse("100m"),
+					StorageSize: resource.MustParse("1Gi"),
+				},
+			},
+		}
+	})
+
+	Context("a cluster is created", func() {
+		It("should allow a valid cluster definition", func() {
+			response := reviewAdmission(server, ValidatePath, admissionv1beta1.Create, clusterDef, nil)
+			Expect(response.Allowed).To(BeTrue())
+			Expect(response.UID).To(Equal(types.UID("some-uid")))
+		})
+
+		It("should reject a cluster definition without racks", func() {
+			clusterDef.Spec.Racks = nil
+
+			response := reviewAdmission(server, ValidatePath, admissionv1beta1.Create, clusterDef, nil)
+			Expect(response.Allowed).To(BeFalse())
+			Expect(response.Result.Message).To(Equal("no racks specified for cluster: mynamespace.mycluster"))
+		})
+
+		It("should reject a cluster definition with an invalid probe", func() {
+			clusterDef.Spec.Pod.LivenessProbe = &v1alpha1.Probe{FailureThreshold: -1}
+
+			response := reviewAdmission(server, ValidatePath, admissionv1beta1.Create, clusterDef, nil)
+			Expect(response.Allowed).To(BeFalse())
+			Expect(response.Result.Message).To(Equal("invalid failure threshold for liveness probe, must be 1 or greater, got -1 for Cassandra cluster definition: mynamespace.mycluster"))
+		})
+	})
+
+	Context("a cluster is updated", func() {
+		var newClusterDef *v1alpha1.Cassandra
+
+		BeforeEach(func() {
+			newClusterDef = clusterDef.DeepCopy()
+		})
+
+		It("should allow an allowed change", func() {
+			newClusterDef.Spec.Racks[0].Replicas = 3
+
+			response := reviewAdmission(server, ValidatePath, admissionv1beta1.Update, newClusterDef, clusterDef)
+			Expect(response.Allowed).To(BeTrue())
+		})
+
+		It("should reject a change of dc", func() {
+			newClusterDef.Spec.DC = "other-dc"
+
+			response := reviewAdmission(server, ValidatePath, admissionv1beta1.Update, newClusterDef, clusterDef)
+			Expect(response.Allowed).To(BeFalse())
+			Expect(response.Result.Message).To(Equal("changing dc is forbidden. The dc used will continue to be 'dc1'"))
+		})
+
+		It("should allow an upgrade of the Cassandra image", func() {
+			newClusterDef.Spec.Pod.Image = "cassandra:3.11.3"
+
+			response := reviewAdmission(server, ValidatePath, admissionv1beta1.Update, newClusterDef, clusterDef)
+			Expect(response.Allowed).To(BeTrue())
+		})
+
+		It("should reject a downgrade of the Cassandra image", func() {
+			newClusterDef.Spec.Pod.Image = "cassandra:3.0"
+
+			response := reviewAdmission(server, ValidatePath, admissionv1beta1.Update, newClusterDef, clusterDef)
+			Expect(response.Allowed).To(BeFalse())
+			Expect(response.Result.Message).To(Equal("changing image from 'cassandra:3.11' to 'cassandra:3.0' is forbidden: downgrading Cassandra from version 3.11.0 to 3.0.0 is not supported"))
+		})
+
+		It("should reject a change of zone", func() {
+			newClusterDef.Spec.Racks[0].Zone = "other-zone"
+
+			response := reviewAdmission(server, ValidatePath, admissionv1beta1.Update, newClusterDef, clusterDef)
+			Expect(response.Allowed).To(BeFalse())
+			Expect(response.Result.Message).To(Equal("changing zone for rack 'a' is forbidden. The zone used will continue to be 'some-zone'"))
+		})
+
+		It("should reject a change of storageClass", func() {
+			newClusterDef.Spec.Racks[0].StorageClass = "other-storage"
+
+			response := reviewAdmission(server, ValidatePath, admissionv1beta1.Update, newClusterDef, clusterDef)
+			Expect(response.Allowed).To(BeFalse())
+			Expect(response.Result.Message).To(Equal("changing storageClass for rack 'a' is forbidden. The storageClass used will continue to be 'some-storage'"))
+		})
+
+		It("should reject a change of useEmptyDir", func() {
+			newClusterDef.Spec.UseEmptyDir = true
+
+			response := reviewAdmission(server, ValidatePath, admissionv1beta1.Update, newClusterDef, clusterDef)
+			Expect(response.Allowed).To(BeFalse())
+			Expect(response.Result.Message).To(Equal("changing useEmptyDir is forbidden. The useEmptyDir used will continue to be 'false'"))
+		})
+
+		It("should reject an invalid new cluster definition", func() {
+			newClusterDef.Spec.Racks = append(newClusterDef.Spec.Racks, v1alpha1.Rack{Name: "b", Replicas: -1, StorageClass: "some-storage", Zone: "some-zone"})
+
+			response := reviewAdmission(server, ValidatePath, admissionv1beta1.Update, newClusterDef, clusterDef)
+			Expect(response.Allowed).To(BeFalse())
+		})
+
+		It("should allow an update which leaves the spec unchanged", func() {
+			clusterDef.Spec.Racks = nil
+			newClusterDef = clusterDef.DeepCopy()
+			newClusterDef.Labels = map[string]string{"some": "label"}
+
+			response := reviewAdmission(server, ValidatePath, admissionv1beta1.Update, newClusterDef, clusterDef)
+			Expect(response.Allowed).To(BeTrue())
+		})
+	})
+
+	Context("defaults have been persisted for the stored cluster only", func() {
+		It("should allow an update of a cluster whose stored definition predates the defaults", func() {
+			newClusterDef := clusterDef.DeepCopy()
+			newClusterDef.Spec.DC = "dc1"
+			newClusterDef.Spec.Pod.Image = "cassandra:3.11"
+
+			response := reviewAdmission(server, ValidatePath, admissionv1beta1.Update, newClusterDef, clusterDef)
+			Expect(response.Allowed).To(BeTrue())
+		})
+	})
+
+	It("should reject a request which is not an admission review", func() {
+		recorder := httptest.NewRecorder()
+		server.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, ValidatePath, bytes.NewBufferString("{}")))
+		Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+	})
+})
+
+var _ = Describe("defaulting admission webhook", func() {
+	var (
+		server     *Server
+		clusterDef *v1alpha1.Cassandra
+	)
+
+	BeforeEach(func() {
+		var err error
+		server, err = New(&Config{})
+		Expect(err).ToNot(HaveOccurred())
+
+		clusterDef = &v1alpha1.Cassandra{
+			ObjectMeta: metav1.ObjectMeta{Name: "mycluster", Namespace: "mynamespace"},
+			Spec: v1alpha1.CassandraSpec{
+				Racks: []v1alpha1.Rack{{Name: "a", Replicas: 1, StorageClass: "some-storage", Zone: "some-zone"}},
+				Pod: v1alpha1.Pod{
+					Memory:      resource.MustParse("1Gi"),
+					CPU:         resource.MustParse("100m"),
+					StorageSize: resource.MustParse("1Gi"),
+				},
+				Snapshot: &v1alpha1.Snapshot{Schedule: "1 23 * * *"},
+			},
+		}
+	})
+
+	It("should patch the defaults of all unset properties into the cluster definition", func() {
+		response := reviewAdmission(server, DefaultPath, admissionv1beta1.Create, clusterDef, nil)
+		Expect(response.Allowed).To(BeTrue())
+		Expect(*response.PatchType).To(Equal(admissionv1beta1.PatchTypeJSONPatch))
+
+		var patch []map[string]interface{}
+		Expect(json.Unmarshal(response.Patch, &patch)).To(Succeed())
+		Expect(patch).To(ConsistOf(
+			map[string]interface{}{"op": "add", "path": "/spec/dc", "value": "dc1"},
+			map[string]interface{}{"op": "add", "path": "/spec/pod/image", "value": "cassandra:3.11"},
+			map[string]interface{}{"op": "add", "path": "/spec/pod/bootstrapperImage", "value": "skyuk/cassandra-bootstrapper:latest"},
+			map[string]interface{}{"op": "add", "path": "/spec/snapshot/image", "value": "skyuk/cassandra-snapshot:latest"},
+			map[string]interface{}{"op": "add", "path": "/spec/pod/livenessProbe", "value": map[string]interface{}{
+				"failureThreshold": float64(3), "initialDelaySeconds": float64(30), "periodSeconds": float64(30), "successThreshold": float64(1), "timeoutSeconds": float64(5),
+			}},
+			map[string]interface{}{"op": "add", "path": "/spec/pod/readinessProbe", "value": map[string]interface{}{
+				"failureThreshold": float64(3), "initialDelaySeconds": float64(30), "periodSeconds": float64(15), "successThreshold": float64(1), "timeoutSeconds": float64(5),
+			}},
+		))
+	})
+
+	It("should merge the probe defaults with the properties already set", func() {
+		clusterDef.Spec.Pod.LivenessProbe = &v1alpha1.Probe{FailureThreshold: 10}
+
+		response := reviewAdmission(server, DefaultPath, admissionv1beta1.Create, clusterDef, nil)
+
+		var patch []map[string]interface{}
+		Expect(json.Unmarshal(response.Patch, &patch)).To(Succeed())
+		Expect(patch).To(ContainElement(map[string]interface{}{"op": "add", "path": "/spec/pod/livenessProbe", "value": map[string]interface{}{
+			"failureThreshold": float64(10), "initialDelaySeconds": float64(30), "periodSeconds": float64(30), "successThreshold": float64(1), "timeoutSeconds": float64(5),
+		}}))
+	})
+
+	It("should patch the default image of the repair job into the cluster definition", func() {
+		clusterDef.Spec.Repair = &v1alpha1.Repair{Schedule: "1 2 * * *"}
+
+		response := reviewAdmission(server, DefaultPath, admissionv1beta1.Create, clusterDef, nil)
+
+		var patch []map[string]interface{}
+		Expect(json.Unmarshal(response.Patch, &patch)).To(Succeed())
+		Expect(patch).To(ContainElement(map[string]interface{}{"op": "add", "path": "/spec/repair/image", "value": "skyuk/cassandra-snapshot:latest"}))
+	})
+
+	It("should not patch a cluster definition which already has all defaults set", func() {
+		cluster.ApplyDefaults(clusterDef)
+
+		response := reviewAdmission(server, DefaultPath, admissionv1beta1.Update, clusterDef, clusterDef)
+		Expect(response.Allowed).To(BeTrue())
+		Expect(response.Patch).To(BeEmpty())
+		Expect(response.PatchType).To(BeNil())
+	})
+})
+
+func reviewAdmission(server *Server, path string, operation admissionv1beta1.Operation, object, oldObject *v1alpha1.Cassandra) *admissionv1beta1.AdmissionResponse {
+	request := &admissionv1beta1.AdmissionRequest{
+		UID:       types.UID("some-uid"),
+		Operation: operation,
+		Object:    rawExtension(object),
+	}
+	if oldObject != nil {
+		request.OldObject = rawExtension(oldObject)
+	}
+
+	body, err := json.Marshal(&admissionv1beta1.AdmissionReview{Request: request})
+	Expect(err).ToNot(HaveOccurred())
+
+	recorder := httptest.NewRecorder()
+	server.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, path, bytes.NewBuffer(body)))
+	Expect(recorder.Code).To(Equal(http.StatusOK))
+
+	review := &admissionv1beta1.AdmissionReview{}
+	Expect(json.Unmarshal(recorder.Body.Bytes(), review)).To(Succeed())
+	return review.Response
+}
+
+func rawExtension(cassandra *v1alpha1.Cassandra) runtime.RawExtension {
+	raw, err := json.Marshal(cassandra)
+	Expect(err).ToNot(HaveOccurred())
+	return runtime.RawExtension{Raw: raw}
+}
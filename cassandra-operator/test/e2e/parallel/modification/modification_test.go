@@ -150,6 +150,108 @@ var _ = Context("Allowable cluster modifications", func() {
 		Expect(podEvents.PodsStartedEventCount(PodName(clusterName, "b", 0))).To(Equal(1))
 	})
 
+	It("should decommission the nodes with the highest ordinals when a rack is scaled down", func() {
+		// given
+		registerResourcesUsed(3)
+		AClusterWithName(clusterName).
+			AndRacks([]v1alpha1.Rack{Rack("a", 2), Rack("b", 1)}).
+			UsingEmptyDir().Exists()
+
+		// when
+		modificationTime := time.Now()
+		TheRackReplicationIsChangedTo(Namespace, clusterName, "a", 1)
+
+		// then
+		By("decommissioning the node with the highest ordinal")
+		Eventually(CassandraEventsFor(Namespace, clusterName), NodeTerminationDuration, CheckInterval).Should(HaveEvent(EventExpectation{
+			Type:                 coreV1.EventTypeNormal,
+			Reason:               cluster.NodeDecommissionedEvent,
+			Message:              fmt.Sprintf("node on pod %s has been decommissioned", PodName(clusterName, "a", 1)),
+			LastTimestampCloseTo: modificationTime,
+		}))
+
+		By("removing its pod from the rack")
+		Eventually(RacksForCluster(Namespace, clusterName), NodeTerminationDuration, CheckInterval).Should(And(
+			HaveLen(2),
+			HaveKeyWithValue("a", []string{PodName(clusterName, "a", 0)}),
+			HaveKeyWithValue("b", []string{PodName(clusterName, "b", 0)}),
+		))
+
+		By("not restarting the other pods within the cluster")
+		Expect(podEvents.PodsStartedEventCount(PodName(clusterName, "a", 0))).To(Equal(1))
+		Expect(podEvents.PodsStartedEventCount(PodName(clusterName, "b", 0))).To(Equal(1))
+	})
+
+	It("should decommission all nodes of a rack and remove its stateful set when the rack is deleted", func() {
+		// given
+		registerResourcesUsed(3)
+		AClusterWithName(clusterName).
+			AndRacks([]v1alpha1.Rack{Rack("a", 1), Rack("b", 2)}).
+			UsingEmptyDir().Exists()
+
+		// when
+		modificationTime := time.Now()
+		ARackIsRemovedFromCluster(Namespace, clusterName, "b")
+
+		// then
+		By("decommissioning each node of the rack")
+		Eventually(CassandraEventsFor(Namespace, clusterName), 2*NodeTerminationDuration, CheckInterval).Should(And(
+			HaveEvent(EventExpectation{
+				Type:                 coreV1.EventTypeNormal,
+				Reason:               cluster.NodeDecommissionedEvent,
+				Message:              fmt.Sprintf("node on pod %s has been decommissioned", PodName(clusterName, "b", 1)),
+				LastTimestampCloseTo: modificationTime,
+			}),
+			HaveEvent(EventExpectation{
+				Type:                 coreV1.EventTypeNormal,
+				Reason:               cluster.NodeDecommissionedEvent,
+				Message:              fmt.Sprintf("node on pod %s has been decommissioned", PodName(clusterName, "b", 0)),
+				LastTimestampCloseTo: modificationTime,
+			}),
+		))
+
+		By("removing the rack from the cluster")
+		Eventually(CassandraEventsFor(Namespace, clusterName), NodeTerminationDuration, CheckInterval).Should(HaveEvent(EventExpectation{
+			Type:                 coreV1.EventTypeNormal,
+			Reason:               cluster.RackDeletedEvent,
+			Message:              "rack b has been deleted",
+			LastTimestampCloseTo: modificationTime,
+		}))
+		Eventually(RacksForCluster(Namespace, clusterName), NodeTerminationDuration, CheckInterval).Should(And(
+			HaveLen(1),
+			HaveKeyWithValue("a", []string{PodName(clusterName, "a", 0)}),
+		))
+
+		By("not restarting the pods of the remaining rack")
+		Expect(podEvents.PodsStartedEventCount(PodName(clusterName, "a", 0))).To(Equal(1))
+	})
+
+	It("should recreate the headless service when it is deleted outside of the operator", func() {
+		// given
+		registerResourcesUsed(1)
+		AClusterWithName(clusterName).
+			AndRacks([]v1alpha1.Rack{Rack("a", 1)}).
+			UsingEmptyDir().Exists()
+
+		// when
+		modificationTime := time.Now()
+		_, output, err := Kubectl(Namespace, "delete", "service", clusterName)
+		Expect(err).ToNot(HaveOccurred(), string(output))
+
+		// then
+		By("recreating the service on the next resync of the cluster definition")
+		Eventually(HeadlessServiceForCluster(Namespace, clusterName), 6*time.Minute, CheckInterval).ShouldNot(BeNil())
+		Eventually(CassandraEventsFor(Namespace, clusterName), NodeStartDuration, CheckInterval).Should(HaveEvent(EventExpectation{
+			Type:                 coreV1.EventTypeNormal,
+			Reason:               cluster.DriftCorrectedEvent,
+			Message:              fmt.Sprintf("headless service %s has been recreated", clusterName),
+			LastTimestampCloseTo: modificationTime,
+		}))
+
+		By("not restarting the pods of the cluster")
+		Expect(podEvents.PodsStartedEventCount(PodName(clusterName, "a", 0))).To(Equal(1))
+	})
+
 	It("should create a new stateful set when a new rack is added to the cluster definition", func() {
 		// given
 		registerResourcesUsed(2)
@@ -1,7 +1,6 @@
 package modification
 
 import (
-	"fmt"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/sky-uk/cassandra-operator/cassandra-operator/pkg/apis/cassandra/v1alpha1"
@@ -61,29 +60,7 @@ var _ = Context("forbidden cluster modifications", func() {
 		podWatcher.Stop()
 	})
 
-	It("should not allow the number of pods per rack to be scaled down as this is unsupported", func() {
-		// when
-		modificationTime := time.Now()
-		TheRackReplicationIsChangedTo(Namespace, multipleNodeCluster.Name, "a", 1)
-
-		// then
-		By("recording a warning that scale-down operations are not supported")
-		Eventually(CassandraEventsFor(Namespace, multipleNodeCluster.Name), 30*time.Second, CheckInterval).Should(HaveEvent(EventExpectation{
-			Type:                 coreV1.EventTypeWarning,
-			Reason:               cluster.InvalidChangeEvent,
-			Message:              fmt.Sprintf("Change type 'scale down rack' isn't supported for cluster %s.%s", Namespace, multipleNodeCluster.Name),
-			LastTimestampCloseTo: modificationTime,
-		}))
-
-		By("not changing the number of pods in the rack")
-		Expect(RacksForCluster(Namespace, multipleNodeCluster.Name)()).Should(And(
-			HaveLen(2),
-			HaveKeyWithValue("a", []string{PodName(multipleNodeCluster.Name, "a", 0), PodName(multipleNodeCluster.Name, "a", 1)}),
-			HaveKeyWithValue("b", []string{PodName(multipleNodeCluster.Name, "b", 0)}),
-		))
-	})
-
-	It("should reject any change where any other property is modified", func() {
+	It("should reject an image change when the Cassandra version of the new image cannot be determined", func() {
 		// when
 		modificationTime := time.Now()
 		TheImageImmutablePropertyIsChangedTo(Namespace, multipleNodeCluster.Name, "another-image")
@@ -93,26 +70,11 @@ var _ = Context("forbidden cluster modifications", func() {
 		Eventually(CassandraEventsFor(Namespace, multipleNodeCluster.Name), 30*time.Second, CheckInterval).Should(HaveEvent(EventExpectation{
 			Type:                 coreV1.EventTypeWarning,
 			Reason:               cluster.InvalidChangeEvent,
-			Message:              "changing image is forbidden",
+			Message:              "to 'another-image' is forbidden: unable to determine the Cassandra version",
 			LastTimestampCloseTo: modificationTime,
 		}))
 		By("not restarting any pods")
 		Expect(podEvents.PodsStartedEventCount(PodName(multipleNodeCluster.Name, "a", 0))).To(Equal(1))
 	})
 
-	It("should reject deletion of any racks as unsupported", func() {
-		// when
-		modificationTime := time.Now()
-		ARackIsRemovedFromCluster(Namespace, multipleNodeCluster.Name, "b")
-
-		// then
-		Eventually(CassandraEventsFor(Namespace, multipleNodeCluster.Name), 30*time.Second, CheckInterval).Should(HaveEvent(EventExpectation{
-			Type:                 coreV1.EventTypeWarning,
-			Reason:               cluster.InvalidChangeEvent,
-			Message:              fmt.Sprintf("Change type 'delete rack' isn't supported for cluster %s.%s", Namespace, multipleNodeCluster.Name),
-			LastTimestampCloseTo: modificationTime,
-		}))
-
-	})
-
 })
@@ -26,7 +26,7 @@ func init() {
 }
 
 func cleanupSnapshot(_ *cobra.Command, _ []string) {
-	err := snapshot.New().DoCleanup(&snapshot.CleanupConfig{
+	err := newManipulator().DoCleanup(&snapshot.CleanupConfig{
 		Namespace:       namespace,
 		RetentionPeriod: retentionPeriod,
 		Keyspaces:       keyspaces,
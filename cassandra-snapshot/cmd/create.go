@@ -22,7 +22,7 @@ func init() {
 }
 
 func createSnapshot(_ *cobra.Command, _ []string) {
-	err := snapshot.New().DoCreate(&snapshot.CreateConfig{
+	err := newManipulator().DoCreate(&snapshot.CreateConfig{
 		Keyspaces:       keyspaces,
 		PodLabel:        podLabel,
 		Namespace:       namespace,
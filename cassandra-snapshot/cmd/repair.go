@@ -0,0 +1,56 @@
+package main
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/sky-uk/cassandra-operator/cassandra-snapshot/pkg/repair"
+	"github.com/spf13/cobra"
+	"os"
+	"time"
+)
+
+var repairCmd = &cobra.Command{
+	Use:   "repair",
+	Short: "Repairs one or more keyspaces of a cassandra cluster, one node at a time",
+	Run:   repairCluster,
+}
+
+var (
+	repairTimeout     time.Duration
+	repairSubranges   int
+	repairParallelism string
+)
+
+func init() {
+	rootCmd.AddCommand(repairCmd)
+	repairCmd.Flags().DurationVarP(&repairTimeout, "repair-timeout", "t", 3*time.Hour, "Max wait time for the repair of a single node, or a single subrange")
+	repairCmd.Flags().IntVarP(&repairSubranges, "subranges", "s", 0, "Number of subranges into which each primary token range of a node is split, each repaired separately. Requires --keyspace and the Murmur3Partitioner. The primary range of each node is repaired at once when 0")
+	repairCmd.Flags().StringVarP(&repairParallelism, "parallelism", "p", "parallel", "should be one of: sequential, parallel, dc-parallel")
+}
+
+func repairCluster(_ *cobra.Command, _ []string) {
+	switch repairParallelism {
+	case "sequential", "parallel", "dc-parallel":
+	default:
+		logAndExit("invalid parallelism")
+	}
+	if repairSubranges < 0 {
+		logAndExit("invalid subranges, must not be negative")
+	}
+	if repairSubranges > 0 && len(keyspaces) == 0 {
+		logAndExit("subrange repair requires at least one keyspace, as the token ranges of each node depend on the replication of the keyspace")
+	}
+
+	err := newRepairer().DoRepair(&repair.Config{
+		Keyspaces:     keyspaces,
+		Namespace:     namespace,
+		PodLabel:      podLabel,
+		Parallelism:   repairParallelism,
+		Subranges:     repairSubranges,
+		RepairTimeout: repairTimeout,
+	})
+
+	if err != nil {
+		log.Errorf("Error while repairing pods with labels %s: %v ", podLabel, err)
+		os.Exit(1)
+	}
+}
@@ -2,21 +2,27 @@ package main
 
 import (
 	log "github.com/sirupsen/logrus"
+	"github.com/sky-uk/cassandra-operator/cassandra-snapshot/pkg/repair"
+	"github.com/sky-uk/cassandra-operator/cassandra-snapshot/pkg/snapshot"
 	"github.com/spf13/cobra"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
 	"os"
 )
 
 var rootCmd = &cobra.Command{
 	Use:   "cassandra-snapshot",
-	Short: "Creates or cleans up snapshots of a cassandra cluster for one or more keyspaces",
+	Short: "Creates or cleans up snapshots of a cassandra cluster for one or more keyspaces, or repairs them",
 	Args:  cobra.MinimumNArgs(1),
 }
 
 var (
-	keyspaces []string
-	logLevel  string
-	podLabel  string
-	namespace string
+	keyspaces   []string
+	logLevel    string
+	podLabel    string
+	namespace   string
+	kubeconfig  string
+	kubeContext string
 )
 
 func main() {
@@ -26,9 +32,11 @@ func main() {
 }
 
 func init() {
-	rootCmd.PersistentFlags().StringSliceVarP(&keyspaces, "keyspace", "k", []string{}, "Keyspace to snapshot. Repeat this flag to specify multiple values.")
+	rootCmd.PersistentFlags().StringSliceVarP(&keyspaces, "keyspace", "k", []string{}, "Keyspace to snapshot or repair. Repeat this flag to specify multiple values.")
 	rootCmd.PersistentFlags().StringVarP(&podLabel, "pod-label", "l", "", "Kubernetes labels attached to cassandra pods that are targeted. Comma-separated list")
 	rootCmd.PersistentFlags().StringVarP(&namespace, "namespace", "n", "", "Namespace where the cassandra pods are deployed")
+	rootCmd.PersistentFlags().StringVar(&kubeconfig, "kubeconfig", "", "Path to the kubeconfig file used outside of a Kubernetes cluster. Defaults to the standard loading rules, then the in-cluster config")
+	rootCmd.PersistentFlags().StringVar(&kubeContext, "context", "", "Name of the kubeconfig context to use. Defaults to the current context")
 	rootCmd.PersistentFlags().StringVarP(&logLevel, "log-level", "L", log.InfoLevel.String(), "should be one of: debug, info, warn, error, fatal, panic")
 	rootCmd.MarkFlagRequired("pod-label")
 	rootCmd.MarkFlagRequired("namespace")
@@ -47,6 +55,37 @@ func setLogLevel(logLevel string) {
 	log.SetLevel(level)
 }
 
+func newManipulator() *snapshot.Manipulator {
+	manipulator, err := snapshot.New(kubernetesConfig())
+	if err != nil {
+		logAndExit("Unable to access Kubernetes: %v", err)
+	}
+	return manipulator
+}
+
+func newRepairer() *repair.Repairer {
+	repairer, err := repair.New(kubernetesConfig())
+	if err != nil {
+		logAndExit("Unable to access Kubernetes: %v", err)
+	}
+	return repairer
+}
+
+// kubernetesConfig follows the standard kubeconfig loading rules, falling back to the in-cluster config
+// when no kubeconfig can be found, as is the case when run by a cronjob
+func kubernetesConfig() *rest.Config {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = kubeconfig
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		loadingRules,
+		&clientcmd.ConfigOverrides{CurrentContext: kubeContext},
+	).ClientConfig()
+	if err != nil {
+		logAndExit("Unable to obtain Kubernetes config: %v", err)
+	}
+	return config
+}
+
 func logAndExit(message string, args ...interface{}) {
 	log.Errorf(message, args...)
 	os.Exit(1)
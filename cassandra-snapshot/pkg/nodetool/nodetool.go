@@ -28,6 +28,23 @@ type Snapshot struct {
 	ColumnFamily string
 }
 
+// RepairOptions describes how a repair is run. The primary range of the node is repaired unless a token range is supplied.
+type RepairOptions struct {
+	Keyspace    string
+	Parallelism string
+	TokenRange  *TokenRange
+}
+
+// TokenRange is the range of tokens (Start, End] of the ring, along with the addresses of the nodes holding its replicas.
+// The first endpoint is the primary replica of the range.
+type TokenRange struct {
+	Start     string
+	End       string
+	Endpoints []string
+}
+
+var tokenRangePattern = regexp.MustCompile(`TokenRange\(start_token:(-?\d+), end_token:(-?\d+), endpoints:\[([^\]]*)\]`)
+
 // SnapshotFilter is an interface describing a function which allows Snapshots to be filtered based on particular
 // properties.
 type SnapshotFilter func([]Snapshot) []Snapshot
@@ -79,6 +96,59 @@ func (n *Nodetool) DeleteSnapshot(pod *v1.Pod, snapshot *Snapshot, timeout time.
 	return err
 }
 
+// Repair runs a full repair on a given Pod, of all keyspaces unless a keyspace is supplied.
+func (n *Nodetool) Repair(pod *v1.Pod, options *RepairOptions, timeout time.Duration) error {
+	_, err := n.runCommand(pod, timeout, RepairArgs(options))
+	return err
+}
+
+// TokenRanges returns the token ranges of the ring along with the replicas of each range for the given keyspace,
+// as reported by `nodetool describering` on the given Pod.
+func (n *Nodetool) TokenRanges(pod *v1.Pod, keyspace string, timeout time.Duration) ([]TokenRange, error) {
+	output, err := n.runCommand(pod, timeout, []string{"nodetool", "describering", "--", keyspace})
+	if err != nil {
+		return nil, fmt.Errorf("error while describing the ring of keyspace %s on pod %s: %v", keyspace, pod.Name, err)
+	}
+	return ParseTokenRanges(output), nil
+}
+
+// ParseTokenRanges reads the token ranges listed in the output of `nodetool describering`
+func ParseTokenRanges(describeRingOutput string) []TokenRange {
+	var tokenRanges []TokenRange
+	for _, match := range tokenRangePattern.FindAllStringSubmatch(describeRingOutput, -1) {
+		var endpoints []string
+		for _, endpoint := range strings.Split(match[3], ",") {
+			if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+				endpoints = append(endpoints, endpoint)
+			}
+		}
+		tokenRanges = append(tokenRanges, TokenRange{Start: match[1], End: match[2], Endpoints: endpoints})
+	}
+	return tokenRanges
+}
+
+// RepairArgs returns the nodetool command which runs the repair described by the supplied options.
+func RepairArgs(options *RepairOptions) []string {
+	args := []string{"nodetool", "repair", "-full"}
+	if options.TokenRange != nil {
+		args = append(args, "-st", options.TokenRange.Start, "-et", options.TokenRange.End)
+	} else {
+		args = append(args, "-pr")
+	}
+
+	switch options.Parallelism {
+	case "sequential":
+		args = append(args, "-seq")
+	case "dc-parallel":
+		args = append(args, "-dcpar")
+	}
+
+	if options.Keyspace != "" {
+		args = append(args, "--", options.Keyspace)
+	}
+	return args
+}
+
 func (n *Nodetool) runCommand(pod *v1.Pod, timeout time.Duration, args []string) (string, error) {
 	execRequest := n.kubeClientset.CoreV1().RESTClient().Post().
 		Timeout(timeout).
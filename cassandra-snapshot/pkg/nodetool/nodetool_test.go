@@ -0,0 +1,52 @@
+package nodetool
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sky-uk/cassandra-operator/cassandra-snapshot/test"
+	"testing"
+)
+
+func TestNodetool(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecsWithDefaultAndCustomReporters(t, "Nodetool Unit Tests", test.CreateReporters("nodetool"))
+}
+
+var _ = Describe("repair command", func() {
+	It("should repair the primary range of all keyspaces by default", func() {
+		Expect(RepairArgs(&RepairOptions{})).To(Equal([]string{"nodetool", "repair", "-full", "-pr"}))
+	})
+
+	It("should repair the primary range of a keyspace sequentially", func() {
+		args := RepairArgs(&RepairOptions{Keyspace: "keyspace1", Parallelism: "sequential"})
+		Expect(args).To(Equal([]string{"nodetool", "repair", "-full", "-pr", "-seq", "--", "keyspace1"}))
+	})
+
+	It("should repair each data centre in parallel", func() {
+		args := RepairArgs(&RepairOptions{Parallelism: "dc-parallel"})
+		Expect(args).To(Equal([]string{"nodetool", "repair", "-full", "-pr", "-dcpar"}))
+	})
+
+	It("should repair a token range instead of the primary range when one is given", func() {
+		args := RepairArgs(&RepairOptions{Keyspace: "keyspace1", TokenRange: &TokenRange{Start: "-100", End: "200"}})
+		Expect(args).To(Equal([]string{"nodetool", "repair", "-full", "-st", "-100", "-et", "200", "--", "keyspace1"}))
+	})
+})
+
+var _ = Describe("describering output", func() {
+	It("should read the token ranges and their endpoints", func() {
+		output := `Schema Version:2f7ec6cd-2c0c-3e3c-9c41-5a1c44f2a9d4
+TokenRange:
+	TokenRange(start_token:-9223372036854775808, end_token:-3074457345618258603, endpoints:[10.0.0.1, 10.0.0.2], rpc_endpoints:[10.0.0.1, 10.0.0.2], endpoint_details:[EndpointDetails(host:10.0.0.1, datacenter:dc1, rack:a), EndpointDetails(host:10.0.0.2, datacenter:dc1, rack:b)])
+	TokenRange(start_token:-3074457345618258603, end_token:3074457345618258602, endpoints:[10.0.0.2], rpc_endpoints:[10.0.0.2], endpoint_details:[EndpointDetails(host:10.0.0.2, datacenter:dc1, rack:b)])
+`
+		Expect(ParseTokenRanges(output)).To(Equal([]TokenRange{
+			{Start: "-9223372036854775808", End: "-3074457345618258603", Endpoints: []string{"10.0.0.1", "10.0.0.2"}},
+			{Start: "-3074457345618258603", End: "3074457345618258602", Endpoints: []string{"10.0.0.2"}},
+		}))
+	})
+
+	It("should find no token ranges in unexpected output", func() {
+		Expect(ParseTokenRanges("error: keyspace not found")).To(BeEmpty())
+	})
+})
@@ -0,0 +1,200 @@
+package repair
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/sky-uk/cassandra-operator/cassandra-snapshot/pkg/nodetool"
+	"k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"math/big"
+	"sort"
+	"time"
+)
+
+// Config is the configuration for repair operations.
+// When Subranges is set, each primary token range of a node is split into that many subranges, each repaired on its own.
+type Config struct {
+	Keyspaces     []string
+	Namespace     string
+	PodLabel      string
+	Parallelism   string
+	Subranges     int
+	RepairTimeout time.Duration
+}
+
+// Repairer is responsible for repairing the data of a cluster, one node at a time
+type Repairer struct {
+	kubeClient     kubernetes.Interface
+	nodetoolClient repairCommands
+}
+
+// repairCommands are the nodetool commands run on the nodes of a cluster to repair them
+type repairCommands interface {
+	Repair(pod *v1.Pod, options *nodetool.RepairOptions, timeout time.Duration) error
+	TokenRanges(pod *v1.Pod, keyspace string, timeout time.Duration) ([]nodetool.TokenRange, error)
+}
+
+// tokenRingSize is the number of tokens of the ring of the Murmur3Partitioner, whose tokens range from -2^63 to 2^63-1
+var (
+	tokenRingSize = new(big.Int).Lsh(big.NewInt(1), 64)
+	maxToken      = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 63), big.NewInt(1))
+)
+
+// New creates a new Repairer which accesses the Kubernetes API described by the given config
+func New(kubeconfig *rest.Config) (*Repairer, error) {
+	kubeClient, err := kubernetes.NewForConfig(kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("unable to obtain clientset: %v", err)
+	}
+	return &Repairer{
+		kubeClient:     kubeClient,
+		nodetoolClient: nodetool.New(kubeClient, kubeconfig),
+	}, nil
+}
+
+// DoRepair repairs one or more keyspaces of a cluster, by repairing the primary range of each node in turn,
+// either at once or in subranges.
+// The outcome for each node is logged, and an error is returned when the repair failed on any node.
+func (r *Repairer) DoRepair(config *Config) error {
+	podList, err := r.kubeClient.CoreV1().Pods(config.Namespace).List(metaV1.ListOptions{LabelSelector: config.PodLabel})
+	if err != nil {
+		return fmt.Errorf("unable to find cassandra pods with label %s: %v", config.PodLabel, err)
+	}
+
+	if len(podList.Items) == 0 {
+		return fmt.Errorf("no cassandra pods found with label %s in namespace %s", config.PodLabel, config.Namespace)
+	}
+
+	pods := podList.Items
+	sort.Slice(pods, func(i, j int) bool { return pods[i].Name < pods[j].Name })
+
+	failures := map[string]int{}
+	for i := range pods {
+		failures[pods[i].Name] += r.repairKeyspaces(&pods[i], config)
+	}
+
+	var failedPods []string
+	for _, pod := range pods {
+		if failures[pod.Name] > 0 {
+			log.Errorf("Repair failed for pod %s.%s: %d repairs did not complete", pod.Namespace, pod.Name, failures[pod.Name])
+			failedPods = append(failedPods, pod.Name)
+		} else {
+			log.Infof("Repair succeeded for pod %s.%s", pod.Namespace, pod.Name)
+		}
+	}
+
+	if len(failedPods) > 0 {
+		return fmt.Errorf("repair failed for pods: %v", failedPods)
+	}
+	return nil
+}
+
+// repairKeyspaces repairs each configured keyspace in turn, or all keyspaces when none is configured,
+// and returns the number of repairs which failed
+func (r *Repairer) repairKeyspaces(pod *v1.Pod, config *Config) int {
+	keyspaces := config.Keyspaces
+	if len(keyspaces) == 0 {
+		keyspaces = []string{""}
+	}
+
+	failed := 0
+	for _, keyspace := range keyspaces {
+		repairs := []*nodetool.RepairOptions{{Keyspace: keyspace, Parallelism: config.Parallelism}}
+		if config.Subranges > 0 {
+			var err error
+			if repairs, err = r.subrangeRepairs(pod, keyspace, config); err != nil {
+				log.Errorf("Unable to determine the token ranges of pod %s.%s: %v", pod.Namespace, pod.Name, err)
+				failed++
+				continue
+			}
+		}
+
+		for _, options := range repairs {
+			log.Infof("Repairing %s on pod %s.%s", describe(options), pod.Namespace, pod.Name)
+			if err := r.nodetoolClient.Repair(pod, options, config.RepairTimeout); err != nil {
+				log.Errorf("Error while repairing %s on pod %s.%s: %v", describe(options), pod.Namespace, pod.Name, err)
+				failed++
+			}
+		}
+	}
+	return failed
+}
+
+// subrangeRepairs splits each token range of the keyspace of which the node is the primary replica into subranges.
+// As each range is only repaired by its primary replica, each range of the ring is repaired once across all nodes,
+// as with the repair of the primary range of each node.
+func (r *Repairer) subrangeRepairs(pod *v1.Pod, keyspace string, config *Config) ([]*nodetool.RepairOptions, error) {
+	tokenRanges, err := r.nodetoolClient.TokenRanges(pod, keyspace, config.RepairTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	var repairs []*nodetool.RepairOptions
+	for _, tokenRange := range tokenRanges {
+		if len(tokenRange.Endpoints) == 0 || tokenRange.Endpoints[0] != pod.Status.PodIP {
+			continue
+		}
+		subranges, err := splitTokenRange(tokenRange, config.Subranges)
+		if err != nil {
+			return nil, err
+		}
+		for i := range subranges {
+			repairs = append(repairs, &nodetool.RepairOptions{Keyspace: keyspace, Parallelism: config.Parallelism, TokenRange: &subranges[i]})
+		}
+	}
+	return repairs, nil
+}
+
+// splitTokenRange splits the token range (Start, End] into at most the given number of contiguous subranges of even size,
+// wrapping around the end of the ring when the range does
+func splitTokenRange(tokenRange nodetool.TokenRange, subranges int) ([]nodetool.TokenRange, error) {
+	start, ok := new(big.Int).SetString(tokenRange.Start, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid start token %s", tokenRange.Start)
+	}
+	end, ok := new(big.Int).SetString(tokenRange.End, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid end token %s", tokenRange.End)
+	}
+
+	width := new(big.Int).Sub(end, start)
+	if width.Sign() <= 0 {
+		width.Add(width, tokenRingSize)
+	}
+	count := big.NewInt(int64(subranges))
+	if width.Cmp(count) < 0 {
+		count.Set(width)
+	}
+
+	var split []nodetool.TokenRange
+	boundary := tokenRange.Start
+	for i := int64(1); i <= count.Int64(); i++ {
+		next := tokenRange.End
+		if i < count.Int64() {
+			offset := new(big.Int).Div(new(big.Int).Mul(width, big.NewInt(i)), count)
+			token := new(big.Int).Add(start, offset)
+			if token.Cmp(maxToken) > 0 {
+				token.Sub(token, tokenRingSize)
+			}
+			next = token.String()
+		}
+		split = append(split, nodetool.TokenRange{Start: boundary, End: next, Endpoints: tokenRange.Endpoints})
+		boundary = next
+	}
+	return split, nil
+}
+
+func describe(options *nodetool.RepairOptions) string {
+	var what string
+	if options.TokenRange != nil {
+		what = fmt.Sprintf("token range (%s, %s]", options.TokenRange.Start, options.TokenRange.End)
+	} else {
+		what = "primary range"
+	}
+	if options.Keyspace == "" {
+		return fmt.Sprintf("%s of all keyspaces", what)
+	}
+	return fmt.Sprintf("%s of keyspace %s", what, options.Keyspace)
+}
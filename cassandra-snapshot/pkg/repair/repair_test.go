@@ -0,0 +1,179 @@
+package repair
+
+import (
+	"fmt"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sky-uk/cassandra-operator/cassandra-snapshot/pkg/nodetool"
+	"github.com/sky-uk/cassandra-operator/cassandra-snapshot/test"
+	"k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"testing"
+	"time"
+)
+
+func TestRepair(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecsWithDefaultAndCustomReporters(t, "Repair Unit Tests", test.CreateReporters("repair"))
+}
+
+var _ = Describe("repair of a cluster", func() {
+	var (
+		nodes    *stubNodetool
+		repairer *Repairer
+		config   *Config
+	)
+
+	BeforeEach(func() {
+		nodes = &stubNodetool{failOn: map[string]bool{}}
+		repairer = &Repairer{
+			kubeClient:     fake.NewSimpleClientset(aPod("mycluster-b-0"), aPod("mycluster-a-1"), aPod("mycluster-a-0"), aPodOf("othercluster-a-0", "othercluster")),
+			nodetoolClient: nodes,
+		}
+		config = &Config{Namespace: "mynamespace", PodLabel: "app=mycluster", Parallelism: "parallel", RepairTimeout: time.Hour}
+	})
+
+	It("should repair the primary range of all keyspaces on each node of the cluster in turn", func() {
+		Expect(repairer.DoRepair(config)).To(Succeed())
+
+		Expect(nodes.repairs).To(Equal([]string{
+			"mycluster-a-0: all keyspaces",
+			"mycluster-a-1: all keyspaces",
+			"mycluster-b-0: all keyspaces",
+		}))
+	})
+
+	It("should repair each of the configured keyspaces", func() {
+		config.Keyspaces = []string{"keyspace1", "keyspace2"}
+
+		Expect(repairer.DoRepair(config)).To(Succeed())
+
+		Expect(nodes.repairs).To(Equal([]string{
+			"mycluster-a-0: keyspace1", "mycluster-a-0: keyspace2",
+			"mycluster-a-1: keyspace1", "mycluster-a-1: keyspace2",
+			"mycluster-b-0: keyspace1", "mycluster-b-0: keyspace2",
+		}))
+	})
+
+	It("should carry on with the other nodes when the repair of a node fails, and report the pods on which it failed", func() {
+		nodes.failOn["mycluster-a-0"] = true
+		nodes.failOn["mycluster-b-0"] = true
+
+		err := repairer.DoRepair(config)
+
+		Expect(err).To(MatchError("repair failed for pods: [mycluster-a-0 mycluster-b-0]"))
+		Expect(nodes.repairs).To(HaveLen(3))
+	})
+
+	Context("in subranges", func() {
+		BeforeEach(func() {
+			config.Keyspaces = []string{"keyspace1"}
+			config.Subranges = 2
+			nodes.tokenRanges = []nodetool.TokenRange{
+				{Start: "-9223372036854775808", End: "-100", Endpoints: []string{"10.0.0.0", "10.0.0.1"}},
+				{Start: "-100", End: "100", Endpoints: []string{"10.0.0.1", "10.0.0.2"}},
+				{Start: "100", End: "-9223372036854775808", Endpoints: []string{"10.0.0.2", "10.0.0.0"}},
+			}
+		})
+
+		It("should split each token range of which the node is the primary replica", func() {
+			Expect(repairer.DoRepair(config)).To(Succeed())
+
+			Expect(nodes.repairs).To(Equal([]string{
+				"mycluster-a-0: keyspace1 (-9223372036854775808, -4611686018427387954]",
+				"mycluster-a-0: keyspace1 (-4611686018427387954, -100]",
+				"mycluster-a-1: keyspace1 (-100, 0]",
+				"mycluster-a-1: keyspace1 (0, 100]",
+				"mycluster-b-0: keyspace1 (100, 4611686018427387954]",
+				"mycluster-b-0: keyspace1 (4611686018427387954, -9223372036854775808]",
+			}))
+		})
+
+		It("should split a token range which wraps around the end of the ring", func() {
+			nodes.tokenRanges = []nodetool.TokenRange{
+				{Start: "9223372036854775800", End: "-9223372036854775800", Endpoints: []string{"10.0.0.0"}},
+			}
+
+			Expect(repairer.DoRepair(config)).To(Succeed())
+
+			Expect(nodes.repairs).To(Equal([]string{
+				"mycluster-a-0: keyspace1 (9223372036854775800, -9223372036854775808]",
+				"mycluster-a-0: keyspace1 (-9223372036854775808, -9223372036854775800]",
+			}))
+		})
+
+		It("should not split a token range into more subranges than it has tokens", func() {
+			config.Subranges = 5
+			nodes.tokenRanges = []nodetool.TokenRange{{Start: "0", End: "2", Endpoints: []string{"10.0.0.0"}}}
+
+			Expect(repairer.DoRepair(config)).To(Succeed())
+
+			Expect(nodes.repairs).To(Equal([]string{
+				"mycluster-a-0: keyspace1 (0, 1]",
+				"mycluster-a-0: keyspace1 (1, 2]",
+			}))
+		})
+
+		It("should carry on with the other nodes when the token ranges of a node cannot be read", func() {
+			nodes.failOn["mycluster-a-1"] = true
+
+			err := repairer.DoRepair(config)
+
+			Expect(err).To(MatchError("repair failed for pods: [mycluster-a-1]"))
+			Expect(nodes.repairs).To(HaveLen(4))
+		})
+	})
+
+	It("should fail when the cluster has no pods", func() {
+		config.PodLabel = "app=missingcluster"
+
+		Expect(repairer.DoRepair(config)).To(MatchError("no cassandra pods found with label app=missingcluster in namespace mynamespace"))
+		Expect(nodes.repairs).To(BeEmpty())
+	})
+})
+
+func aPod(name string) runtime.Object {
+	return aPodOf(name, "mycluster")
+}
+
+// aPodOf creates a pod of the given app, with the IP address given in podIPs
+func aPodOf(name, app string) runtime.Object {
+	return &v1.Pod{
+		ObjectMeta: metaV1.ObjectMeta{Name: name, Namespace: "mynamespace", Labels: map[string]string{"app": app}},
+		Status:     v1.PodStatus{PodIP: podIPs[name]},
+	}
+}
+
+var podIPs = map[string]string{"mycluster-a-0": "10.0.0.0", "mycluster-a-1": "10.0.0.1", "mycluster-b-0": "10.0.0.2"}
+
+// stubNodetool records the repairs run on each pod, and fails the commands run on the pods given in failOn.
+// Every pod reports the same token ranges, as the ring is the same whichever node describes it.
+type stubNodetool struct {
+	repairs     []string
+	failOn      map[string]bool
+	tokenRanges []nodetool.TokenRange
+}
+
+func (n *stubNodetool) TokenRanges(pod *v1.Pod, _ string, _ time.Duration) ([]nodetool.TokenRange, error) {
+	if n.failOn[pod.Name] {
+		return nil, fmt.Errorf("describering failed on pod %s", pod.Name)
+	}
+	return n.tokenRanges, nil
+}
+
+func (n *stubNodetool) Repair(pod *v1.Pod, options *nodetool.RepairOptions, _ time.Duration) error {
+	keyspace := options.Keyspace
+	if keyspace == "" {
+		keyspace = "all keyspaces"
+	}
+	if options.TokenRange != nil {
+		keyspace = fmt.Sprintf("%s (%s, %s]", keyspace, options.TokenRange.Start, options.TokenRange.End)
+	}
+	n.repairs = append(n.repairs, fmt.Sprintf("%s: %s", pod.Name, keyspace))
+	if n.failOn[pod.Name] {
+		return fmt.Errorf("repair failed on pod %s", pod.Name)
+	}
+	return nil
+}
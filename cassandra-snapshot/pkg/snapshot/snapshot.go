@@ -36,15 +36,17 @@ type Manipulator struct {
 	nodetoolClient *nodetool.Nodetool
 }
 
-// New creates a new Manipulator
-func New() *Manipulator {
-	kubeconfig := kubernetesConfig()
-	kubeClient := kubernetesClient(kubeconfig)
+// New creates a new Manipulator which accesses the Kubernetes API described by the given config
+func New(kubeconfig *rest.Config) (*Manipulator, error) {
+	kubeClient, err := kubernetes.NewForConfig(kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("unable to obtain clientset: %v", err)
+	}
 	return &Manipulator{
 		kubeconfig:     kubeconfig,
 		kubeClient:     kubeClient,
 		nodetoolClient: nodetool.New(kubeClient, kubeconfig),
-	}
+	}, nil
 }
 
 // DoCreate creates snapshots for one or more keyspaces of a cluster
@@ -117,19 +119,3 @@ func (m *Manipulator) findSnapshotsToDelete(pod *v1.Pod, config *CleanupConfig,
 
 	return snapshots, nil
 }
-
-func kubernetesConfig() *rest.Config {
-	config, err := rest.InClusterConfig()
-	if err != nil {
-		log.Fatalf("Unable to obtain in-cluster config: %v", err)
-	}
-	return config
-}
-
-func kubernetesClient(config *rest.Config) *kubernetes.Clientset {
-	clientset, err := kubernetes.NewForConfig(config)
-	if err != nil {
-		log.Fatalf("Unable to obtain clientset: %v", err)
-	}
-	return clientset
-}